@@ -17,6 +17,7 @@ import (
 	"fmt"
 	"log/slog"
 	"os"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
@@ -30,14 +31,17 @@ import (
 	"github.com/uglyswap/push/internal/csync"
 	"github.com/uglyswap/push/internal/message"
 	"github.com/uglyswap/push/internal/permission"
+	"github.com/uglyswap/push/internal/redact"
 	"github.com/uglyswap/push/internal/session"
 	"github.com/uglyswap/push/internal/stringext"
+	"github.com/uglyswap/push/internal/tracing"
 	"github.com/uglyswap/push/pkg/fantasy"
 	"github.com/uglyswap/push/pkg/fantasy/providers/anthropic"
 	"github.com/uglyswap/push/pkg/fantasy/providers/bedrock"
 	"github.com/uglyswap/push/pkg/fantasy/providers/google"
 	"github.com/uglyswap/push/pkg/fantasy/providers/openai"
 	"github.com/uglyswap/push/pkg/fantasy/providers/openrouter"
+	"go.opentelemetry.io/otel/trace"
 )
 
 //go:embed templates/title.md
@@ -57,6 +61,9 @@ type SessionAgentCall struct {
 	TopK             *int64
 	FrequencyPenalty *float64
 	PresencePenalty  *float64
+	// WorkingDir is the directory the session works in, when it differs
+	// from the project's, such as the session's worktree.
+	WorkingDir string
 }
 
 type SessionAgent interface {
@@ -70,6 +77,12 @@ type SessionAgent interface {
 	QueuedPrompts(sessionID string) int
 	QueuedPromptsList(sessionID string) []string
 	ClearQueue(sessionID string)
+	// EditQueuedPrompt, MoveQueuedPrompt and TakeQueuedPrompt act on the
+	// queued prompt at index, as listed by QueuedPromptsList.
+	EditQueuedPrompt(sessionID string, index int, prompt string) error
+	MoveQueuedPrompt(sessionID string, index, to int) error
+	TakeQueuedPrompt(sessionID string, index int) (SessionAgentCall, error)
+	Interrupt(sessionID string, call SessionAgentCall) bool
 	Summarize(context.Context, string, fantasy.ProviderOptions) error
 	Model() Model
 }
@@ -78,6 +91,10 @@ type Model struct {
 	Model      fantasy.LanguageModel
 	CatwalkCfg catwalk.Model
 	ModelCfg   config.SelectedModel
+
+	// Fallbacks are tried in order when the model fails with a retryable
+	// provider error.
+	Fallbacks []Model
 }
 
 type sessionAgent struct {
@@ -90,9 +107,13 @@ type sessionAgent struct {
 	sessions             session.Service
 	messages             message.Service
 	disableAutoSummarize bool
+	compaction           *config.Compaction
 	isYolo               bool
+	redactor             *redact.Redactor
 
 	messageQueue   *csync.Map[string, []SessionAgentCall]
+	queueMu        sync.Mutex
+	interrupts     *csync.Map[string, SessionAgentCall]
 	activeRequests *csync.Map[string, context.CancelFunc]
 }
 
@@ -103,10 +124,12 @@ type SessionAgentOptions struct {
 	SystemPrompt         string
 	IsSubAgent           bool
 	DisableAutoSummarize bool
+	Compaction           *config.Compaction
 	IsYolo               bool
 	Sessions             session.Service
 	Messages             message.Service
 	Tools                []fantasy.AgentTool
+	Redactor             *redact.Redactor
 }
 
 func NewSessionAgent(
@@ -121,9 +144,12 @@ func NewSessionAgent(
 		sessions:             opts.Sessions,
 		messages:             opts.Messages,
 		disableAutoSummarize: opts.DisableAutoSummarize,
+		compaction:           opts.Compaction,
 		tools:                opts.Tools,
 		isYolo:               opts.IsYolo,
+		redactor:             opts.Redactor,
 		messageQueue:         csync.NewMap[string, []SessionAgentCall](),
+		interrupts:           csync.NewMap[string, SessionAgentCall](),
 		activeRequests:       csync.NewMap[string, context.CancelFunc](),
 	}
 }
@@ -135,29 +161,37 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (*fantasy
 	if call.SessionID == "" {
 		return nil, ErrSessionMissing
 	}
+	call.Attachments = redactAttachments(a.redactor, call.Attachments)
 
-	// Queue the message if busy
+	// Queue the message if busy. The running turn keeps its span.
 	if a.IsSessionBusy(call.SessionID) {
-		existing, ok := a.messageQueue.Get(call.SessionID)
-		if !ok {
-			existing = []SessionAgentCall{}
-		}
-		existing = append(existing, call)
-		a.messageQueue.Set(call.SessionID, existing)
+		a.enqueue(call)
 		return nil, nil
 	}
 
+	// Prompts still queued while idle, such as the ones restored after a
+	// restart, were sent before this one.
+	if err := a.addQueuedMessages(ctx, call.SessionID); err != nil {
+		return nil, err
+	}
+	return a.turn(ctx, call)
+}
+
+// turn runs call as a turn of its own, ahead of the queued prompts.
+func (a *sessionAgent) turn(ctx context.Context, call SessionAgentCall) (*fantasy.AgentResult, error) {
+	ctx, span := tracing.StartTurn(ctx, call.SessionID)
+	result, err := a.run(ctx, call)
+	tracing.EndTurn(call.SessionID, span, err)
+	return result, err
+}
+
+func (a *sessionAgent) run(ctx context.Context, call SessionAgentCall) (*fantasy.AgentResult, error) {
+
 	if len(a.tools) > 0 {
 		// Add Anthropic caching to the last tool.
 		a.tools[len(a.tools)-1].SetProviderOptions(a.getCacheControlOptions())
 	}
 
-	agent := fantasy.NewAgent(
-		a.largeModel.Model,
-		fantasy.WithSystemPrompt(a.systemPrompt),
-		fantasy.WithTools(a.tools...),
-	)
-
 	sessionLock := sync.Mutex{}
 	currentSession, err := a.sessions.Get(ctx, call.SessionID)
 	if err != nil {
@@ -188,6 +222,12 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (*fantasy
 
 	// Add the session to the context.
 	ctx = context.WithValue(ctx, tools.SessionIDContextKey, call.SessionID)
+	if call.WorkingDir != "" {
+		ctx = context.WithValue(ctx, tools.WorkingDirContextKey, call.WorkingDir)
+	}
+	if a.redactor != nil {
+		ctx = context.WithValue(ctx, tools.RedactorContextKey, a.redactor)
+	}
 
 	genCtx, cancel := context.WithCancel(ctx)
 	a.activeRequests.Set(call.SessionID, cancel)
@@ -195,6 +235,7 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (*fantasy
 	defer cancel()
 	defer a.activeRequests.Del(call.SessionID)
 
+	msgs = a.compactHistory(a.largeModel, currentSession.PromptTokens+currentSession.CompletionTokens, msgs)
 	history, files := a.preparePrompt(msgs, call.Attachments...)
 
 	startTime := time.Now()
@@ -202,188 +243,267 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (*fantasy
 
 	var currentAssistant *message.Message
 	var shouldSummarize bool
-	result, err := agent.Stream(genCtx, fantasy.AgentStreamCall{
-		Prompt:           message.PromptWithTextAttachments(call.Prompt, call.Attachments),
-		Files:            files,
-		Messages:         history,
-		ProviderOptions:  call.ProviderOptions,
-		MaxOutputTokens:  &call.MaxOutputTokens,
-		TopP:             call.TopP,
-		Temperature:      call.Temperature,
-		PresencePenalty:  call.PresencePenalty,
-		TopK:             call.TopK,
-		FrequencyPenalty: call.FrequencyPenalty,
-		PrepareStep: func(callContext context.Context, options fantasy.PrepareStepFunctionOptions) (_ context.Context, prepared fantasy.PrepareStepResult, err error) {
-			prepared.Messages = options.Messages
-			for i := range prepared.Messages {
-				prepared.Messages[i].ProviderOptions = nil
-			}
-
-			queuedCalls, _ := a.messageQueue.Get(call.SessionID)
-			a.messageQueue.Del(call.SessionID)
-			for _, queued := range queuedCalls {
-				userMessage, createErr := a.createUserMessage(callContext, queued)
-				if createErr != nil {
-					return callContext, prepared, createErr
+	stream := func(model Model, call SessionAgentCall, prompt string, history []fantasy.Message, files []fantasy.FilePart) (*fantasy.AgentResult, error) {
+		// The turn is attributed to the model of its last attempt.
+		trace.SpanFromContext(ctx).SetAttributes(
+			tracing.AttrModel.String(model.ModelCfg.Model),
+			tracing.AttrProvider.String(model.ModelCfg.Provider),
+		)
+		systemPrompt := a.systemPrompt
+		if call.WorkingDir != "" {
+			systemPrompt += worktreeNote(call.WorkingDir)
+		}
+		agent := fantasy.NewAgent(
+			model.Model,
+			fantasy.WithSystemPrompt(systemPrompt),
+			fantasy.WithTools(a.tools...),
+		)
+		// stepSpan covers a model call and the tool calls it asks for.
+		var stepSpan trace.Span
+		result, err := agent.Stream(genCtx, fantasy.AgentStreamCall{
+			Prompt:           prompt,
+			Files:            files,
+			Messages:         history,
+			ProviderOptions:  call.ProviderOptions,
+			MaxOutputTokens:  &call.MaxOutputTokens,
+			TopP:             call.TopP,
+			Temperature:      call.Temperature,
+			PresencePenalty:  call.PresencePenalty,
+			TopK:             call.TopK,
+			FrequencyPenalty: call.FrequencyPenalty,
+			PrepareStep: func(callContext context.Context, options fantasy.PrepareStepFunctionOptions) (_ context.Context, prepared fantasy.PrepareStepResult, err error) {
+				prepared.Messages = options.Messages
+				for i := range prepared.Messages {
+					prepared.Messages[i].ProviderOptions = nil
 				}
-				prepared.Messages = append(prepared.Messages, userMessage.ToAIMessage()...)
-			}
 
-			prepared.Messages = a.workaroundProviderMediaLimitations(prepared.Messages)
-
-			lastSystemRoleInx := 0
-			systemMessageUpdated := false
-			for i, msg := range prepared.Messages {
-				// Only add cache control to the last message.
-				if msg.Role == fantasy.MessageRoleSystem {
-					lastSystemRoleInx = i
-				} else if !systemMessageUpdated {
-					prepared.Messages[lastSystemRoleInx].ProviderOptions = a.getCacheControlOptions()
-					systemMessageUpdated = true
+				for _, queued := range a.takeQueue(call.SessionID) {
+					userMessage, createErr := a.createUserMessage(callContext, queued)
+					if createErr != nil {
+						return callContext, prepared, createErr
+					}
+					prepared.Messages = append(prepared.Messages, userMessage.ToAIMessage()...)
 				}
-				// Than add cache control to the last 2 messages.
-				if i > len(prepared.Messages)-3 {
-					prepared.Messages[i].ProviderOptions = a.getCacheControlOptions()
+
+				prepared.Messages = a.workaroundProviderMediaLimitations(model, prepared.Messages)
+
+				lastSystemRoleInx := 0
+				systemMessageUpdated := false
+				for i, msg := range prepared.Messages {
+					// Only add cache control to the last message.
+					if msg.Role == fantasy.MessageRoleSystem {
+						lastSystemRoleInx = i
+					} else if !systemMessageUpdated {
+						prepared.Messages[lastSystemRoleInx].ProviderOptions = a.getCacheControlOptions()
+						systemMessageUpdated = true
+					}
+					// Than add cache control to the last 2 messages.
+					if i > len(prepared.Messages)-3 {
+						prepared.Messages[i].ProviderOptions = a.getCacheControlOptions()
+					}
 				}
-			}
 
-			if promptPrefix := a.promptPrefix(); promptPrefix != "" {
-				prepared.Messages = append([]fantasy.Message{fantasy.NewSystemMessage(promptPrefix)}, prepared.Messages...)
-			}
+				if promptPrefix := a.promptPrefix(); promptPrefix != "" {
+					prepared.Messages = append([]fantasy.Message{fantasy.NewSystemMessage(promptPrefix)}, prepared.Messages...)
+				}
 
-			var assistantMsg message.Message
-			assistantMsg, err = a.messages.Create(callContext, call.SessionID, message.CreateMessageParams{
-				Role:     message.Assistant,
-				Parts:    []message.ContentPart{},
-				Model:    a.largeModel.ModelCfg.Model,
-				Provider: a.largeModel.ModelCfg.Provider,
-			})
-			if err != nil {
+				var assistantMsg message.Message
+				assistantMsg, err = a.messages.Create(callContext, call.SessionID, message.CreateMessageParams{
+					Role:     message.Assistant,
+					Parts:    []message.ContentPart{},
+					Model:    model.ModelCfg.Model,
+					Provider: model.ModelCfg.Provider,
+				})
+				if err != nil {
+					return callContext, prepared, err
+				}
+				callContext = context.WithValue(callContext, tools.MessageIDContextKey, assistantMsg.ID)
+				callContext = context.WithValue(callContext, tools.SupportsImagesContextKey, model.CatwalkCfg.SupportsImages)
+				callContext = context.WithValue(callContext, tools.ModelNameContextKey, model.CatwalkCfg.Name)
+				callContext, stepSpan = tracing.Start(callContext, "agent.step",
+					tracing.AttrSessionID.String(call.SessionID),
+					tracing.AttrModel.String(model.ModelCfg.Model),
+					tracing.AttrProvider.String(model.ModelCfg.Provider),
+				)
+				currentAssistant = &assistantMsg
 				return callContext, prepared, err
-			}
-			callContext = context.WithValue(callContext, tools.MessageIDContextKey, assistantMsg.ID)
-			callContext = context.WithValue(callContext, tools.SupportsImagesContextKey, a.largeModel.CatwalkCfg.SupportsImages)
-			callContext = context.WithValue(callContext, tools.ModelNameContextKey, a.largeModel.CatwalkCfg.Name)
-			currentAssistant = &assistantMsg
-			return callContext, prepared, err
-		},
-		OnReasoningStart: func(id string, reasoning fantasy.ReasoningContent) error {
-			currentAssistant.AppendReasoningContent(reasoning.Text)
-			return a.messages.Update(genCtx, *currentAssistant)
-		},
-		OnReasoningDelta: func(id string, text string) error {
-			currentAssistant.AppendReasoningContent(text)
-			return a.messages.Update(genCtx, *currentAssistant)
-		},
-		OnReasoningEnd: func(id string, reasoning fantasy.ReasoningContent) error {
-			// handle anthropic signature
-			if anthropicData, ok := reasoning.ProviderMetadata[anthropic.Name]; ok {
-				if reasoning, ok := anthropicData.(*anthropic.ReasoningOptionMetadata); ok {
-					currentAssistant.AppendReasoningSignature(reasoning.Signature)
+			},
+			OnReasoningStart: func(id string, reasoning fantasy.ReasoningContent) error {
+				currentAssistant.AppendReasoningContent(reasoning.Text)
+				return a.messages.Update(genCtx, *currentAssistant)
+			},
+			OnReasoningDelta: func(id string, text string) error {
+				currentAssistant.AppendReasoningContent(text)
+				return a.messages.Update(genCtx, *currentAssistant)
+			},
+			OnReasoningEnd: func(id string, reasoning fantasy.ReasoningContent) error {
+				// handle anthropic signature
+				if anthropicData, ok := reasoning.ProviderMetadata[anthropic.Name]; ok {
+					if reasoning, ok := anthropicData.(*anthropic.ReasoningOptionMetadata); ok {
+						currentAssistant.AppendReasoningSignature(reasoning.Signature)
+					}
 				}
-			}
-			if googleData, ok := reasoning.ProviderMetadata[google.Name]; ok {
-				if reasoning, ok := googleData.(*google.ReasoningMetadata); ok {
-					currentAssistant.AppendThoughtSignature(reasoning.Signature, reasoning.ToolID)
+				if googleData, ok := reasoning.ProviderMetadata[google.Name]; ok {
+					if reasoning, ok := googleData.(*google.ReasoningMetadata); ok {
+						currentAssistant.AppendThoughtSignature(reasoning.Signature, reasoning.ToolID)
+					}
 				}
-			}
-			if openaiData, ok := reasoning.ProviderMetadata[openai.Name]; ok {
-				if reasoning, ok := openaiData.(*openai.ResponsesReasoningMetadata); ok {
-					currentAssistant.SetReasoningResponsesData(reasoning)
+				if openaiData, ok := reasoning.ProviderMetadata[openai.Name]; ok {
+					if reasoning, ok := openaiData.(*openai.ResponsesReasoningMetadata); ok {
+						currentAssistant.SetReasoningResponsesData(reasoning)
+					}
+				}
+				currentAssistant.FinishThinking()
+				return a.messages.Update(genCtx, *currentAssistant)
+			},
+			OnTextDelta: func(id string, text string) error {
+				// Strip leading newline from initial text content. This is is
+				// particularly important in non-interactive mode where leading
+				// newlines are very visible.
+				if len(currentAssistant.Parts) == 0 {
+					text = strings.TrimPrefix(text, "\n")
 				}
-			}
-			currentAssistant.FinishThinking()
-			return a.messages.Update(genCtx, *currentAssistant)
-		},
-		OnTextDelta: func(id string, text string) error {
-			// Strip leading newline from initial text content. This is is
-			// particularly important in non-interactive mode where leading
-			// newlines are very visible.
-			if len(currentAssistant.Parts) == 0 {
-				text = strings.TrimPrefix(text, "\n")
-			}
 
-			currentAssistant.AppendContent(text)
-			return a.messages.Update(genCtx, *currentAssistant)
-		},
-		OnToolInputStart: func(id string, toolName string) error {
-			toolCall := message.ToolCall{
-				ID:               id,
-				Name:             toolName,
-				ProviderExecuted: false,
-				Finished:         false,
-			}
-			currentAssistant.AddToolCall(toolCall)
-			return a.messages.Update(genCtx, *currentAssistant)
-		},
-		OnRetry: func(err *fantasy.ProviderError, delay time.Duration) {
-			// TODO: implement
-		},
-		OnToolCall: func(tc fantasy.ToolCallContent) error {
-			toolCall := message.ToolCall{
-				ID:               tc.ToolCallID,
-				Name:             tc.ToolName,
-				Input:            tc.Input,
-				ProviderExecuted: false,
-				Finished:         true,
-			}
-			currentAssistant.AddToolCall(toolCall)
-			return a.messages.Update(genCtx, *currentAssistant)
-		},
-		OnToolResult: func(result fantasy.ToolResultContent) error {
-			toolResult := a.convertToToolResult(result)
-			_, createMsgErr := a.messages.Create(genCtx, currentAssistant.SessionID, message.CreateMessageParams{
-				Role: message.Tool,
-				Parts: []message.ContentPart{
-					toolResult,
-				},
-			})
-			return createMsgErr
-		},
-		OnStepFinish: func(stepResult fantasy.StepResult) error {
-			finishReason := message.FinishReasonUnknown
-			switch stepResult.FinishReason {
-			case fantasy.FinishReasonLength:
-				finishReason = message.FinishReasonMaxTokens
-			case fantasy.FinishReasonStop:
-				finishReason = message.FinishReasonEndTurn
-			case fantasy.FinishReasonToolCalls:
-				finishReason = message.FinishReasonToolUse
-			}
-			currentAssistant.AddFinish(finishReason, "", "")
-			sessionLock.Lock()
-			updatedSession, getSessionErr := a.sessions.Get(genCtx, call.SessionID)
-			if getSessionErr != nil {
-				sessionLock.Unlock()
-				return getSessionErr
-			}
-			a.updateSessionUsage(a.largeModel, &updatedSession, stepResult.Usage, a.openrouterCost(stepResult.ProviderMetadata))
-			_, sessionErr := a.sessions.Save(genCtx, updatedSession)
-			sessionLock.Unlock()
-			if sessionErr != nil {
-				return sessionErr
-			}
-			return a.messages.Update(genCtx, *currentAssistant)
-		},
-		StopWhen: []fantasy.StopCondition{
-			func(_ []fantasy.StepResult) bool {
-				cw := int64(a.largeModel.CatwalkCfg.ContextWindow)
-				tokens := currentSession.CompletionTokens + currentSession.PromptTokens
-				remaining := cw - tokens
-				var threshold int64
-				if cw > 200_000 {
-					threshold = 20_000
-				} else {
-					threshold = int64(float64(cw) * 0.2)
+				currentAssistant.AppendContent(text)
+				return a.messages.Update(genCtx, *currentAssistant)
+			},
+			OnToolInputStart: func(id string, toolName string) error {
+				toolCall := message.ToolCall{
+					ID:               id,
+					Name:             toolName,
+					ProviderExecuted: false,
+					Finished:         false,
 				}
-				if (remaining <= threshold) && !a.disableAutoSummarize {
-					shouldSummarize = true
-					return true
+				currentAssistant.AddToolCall(toolCall)
+				return a.messages.Update(genCtx, *currentAssistant)
+			},
+			OnRetry: func(err *fantasy.ProviderError, delay time.Duration) {
+				// TODO: implement
+			},
+			OnToolCall: func(tc fantasy.ToolCallContent) error {
+				toolCall := message.ToolCall{
+					ID:               tc.ToolCallID,
+					Name:             tc.ToolName,
+					Input:            tc.Input,
+					ProviderExecuted: false,
+					Finished:         true,
 				}
-				return false
+				currentAssistant.AddToolCall(toolCall)
+				return a.messages.Update(genCtx, *currentAssistant)
 			},
-		},
-	})
+			OnToolResult: func(result fantasy.ToolResultContent) error {
+				toolResult := a.convertToToolResult(result)
+				_, createMsgErr := a.messages.Create(genCtx, currentAssistant.SessionID, message.CreateMessageParams{
+					Role: message.Tool,
+					Parts: []message.ContentPart{
+						toolResult,
+					},
+				})
+				return createMsgErr
+			},
+			OnStepFinish: func(stepResult fantasy.StepResult) error {
+				finishReason := message.FinishReasonUnknown
+				switch stepResult.FinishReason {
+				case fantasy.FinishReasonLength:
+					finishReason = message.FinishReasonMaxTokens
+				case fantasy.FinishReasonStop:
+					finishReason = message.FinishReasonEndTurn
+				case fantasy.FinishReasonToolCalls:
+					finishReason = message.FinishReasonToolUse
+				}
+				currentAssistant.AddFinish(finishReason, "", "")
+				if stepSpan != nil {
+					stepSpan.SetAttributes(
+						tracing.AttrInputTokens.Int64(stepResult.Usage.InputTokens),
+						tracing.AttrOutputTokens.Int64(stepResult.Usage.OutputTokens),
+						tracing.AttrCacheRead.Int64(stepResult.Usage.CacheReadTokens),
+						tracing.AttrCacheWrite.Int64(stepResult.Usage.CacheCreationTokens),
+						tracing.AttrFinishReason.String(string(stepResult.FinishReason)),
+					)
+					tracing.End(stepSpan, nil)
+					stepSpan = nil
+				}
+				sessionLock.Lock()
+				updatedSession, getSessionErr := a.sessions.Get(genCtx, call.SessionID)
+				if getSessionErr != nil {
+					sessionLock.Unlock()
+					return getSessionErr
+				}
+				a.updateSessionUsage(model, &updatedSession, stepResult.Usage, a.openrouterCost(stepResult.ProviderMetadata))
+				_, sessionErr := a.sessions.Save(genCtx, updatedSession)
+				currentSession.PromptTokens = updatedSession.PromptTokens
+				currentSession.CompletionTokens = updatedSession.CompletionTokens
+				sessionLock.Unlock()
+				if sessionErr != nil {
+					return sessionErr
+				}
+				return a.messages.Update(genCtx, *currentAssistant)
+			},
+			StopWhen: []fantasy.StopCondition{
+				func(_ []fantasy.StepResult) bool {
+					sessionLock.Lock()
+					tokens := currentSession.CompletionTokens + currentSession.PromptTokens
+					sessionLock.Unlock()
+					if a.shouldCompact(model, tokens) {
+						shouldSummarize = true
+						return true
+					}
+					return false
+				},
+			},
+		})
+		if stepSpan != nil {
+			// The step did not finish, because of an error or cancellation.
+			tracing.End(stepSpan, cmp.Or(err, context.Cause(genCtx)))
+		}
+		return result, err
+	}
+
+	model := a.largeModel
+	fallbacks := model.Fallbacks
+	retries := 0
+	result, err := stream(model, call, message.PromptWithTextAttachments(call.Prompt, call.Attachments), history, files)
+	for err != nil && canFailover(err, currentAssistant) {
+		if isTransientProviderError(err) && retries < maxProviderRetries {
+			retries++
+			delay := providerRetryDelay(retries)
+			slog.Warn("Provider error, retrying", "provider", model.ModelCfg.Provider, "model", model.ModelCfg.Model, "attempt", retries, "delay", delay, "error", err)
+			select {
+			case <-genCtx.Done():
+			case <-time.After(delay):
+			}
+			if genCtx.Err() != nil {
+				err = genCtx.Err()
+				break
+			}
+		} else if len(fallbacks) > 0 {
+			slog.Warn("Provider error, switching to fallback model",
+				"from_provider", model.ModelCfg.Provider, "from_model", model.ModelCfg.Model,
+				"to_provider", fallbacks[0].ModelCfg.Provider, "to_model", fallbacks[0].ModelCfg.Model,
+				"error", err,
+			)
+			model, fallbacks = fallbacks[0], fallbacks[1:]
+			retries = 0
+			call = a.callForModel(call, model)
+		} else {
+			break
+		}
+
+		if discardErr := a.discardFailedAttempt(ctx, currentAssistant, err); discardErr != nil {
+			return nil, discardErr
+		}
+		currentAssistant = nil
+
+		// The user message is already part of the session, so the next
+		// attempt replays it from history instead of sending it again.
+		msgs, err = a.getSessionMessages(ctx, currentSession)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get session messages: %w", err)
+		}
+		msgs = a.compactHistory(model, currentSession.PromptTokens+currentSession.CompletionTokens, msgs)
+		history, files = a.preparePrompt(msgs)
+		result, err = stream(model, call, "", history, files)
+	}
 
 	a.eventPromptResponded(call.SessionID, time.Since(startTime).Truncate(time.Second))
 
@@ -469,7 +589,7 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (*fantasy
 				currentAssistant.AddFinish(
 					message.FinishReasonError,
 					"Copilot model not enabled",
-					fmt.Sprintf("%q is not enabled in Copilot. Go to the following page to enable it. Then, wait a minute before trying again. %s", a.largeModel.CatwalkCfg.Name, link),
+					fmt.Sprintf("%q is not enabled in Copilot. Go to the following page to enable it. Then, wait a minute before trying again. %s", model.CatwalkCfg.Name, link),
 				)
 			} else {
 				currentAssistant.AddFinish(message.FinishReasonError, cmp.Or(stringext.Capitalize(providerErr.Title), defaultTitle), providerErr.Message)
@@ -485,24 +605,28 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (*fantasy
 		if updateErr != nil {
 			return nil, updateErr
 		}
+		// An interrupt canceled the turn to run a queued prompt.
+		if next, ok := a.interrupts.Take(call.SessionID); ok {
+			a.activeRequests.Del(call.SessionID)
+			cancel()
+			call.Prompt = next.Prompt
+			call.Attachments = next.Attachments
+			return a.turn(ctx, call)
+		}
 		return nil, err
 	}
 	wg.Wait()
 
 	if shouldSummarize {
 		a.activeRequests.Del(call.SessionID)
-		if summarizeErr := a.Summarize(genCtx, call.SessionID, call.ProviderOptions); summarizeErr != nil {
+		if summarizeErr := a.compact(genCtx, model, call.SessionID, call.ProviderOptions); summarizeErr != nil {
 			return nil, summarizeErr
 		}
 		// If the agent wasn't done...
 		if len(currentAssistant.ToolCalls()) > 0 {
-			existing, ok := a.messageQueue.Get(call.SessionID)
-			if !ok {
-				existing = []SessionAgentCall{}
-			}
-			call.Prompt = fmt.Sprintf("The previous session was interrupted because it got too long, the initial user request was: `%s`", call.Prompt)
-			existing = append(existing, call)
-			a.messageQueue.Set(call.SessionID, existing)
+			resumed := call
+			resumed.Prompt = fmt.Sprintf("The previous session was interrupted because it got too long, the initial user request was: `%s`", call.Prompt)
+			a.enqueue(resumed)
 		}
 	}
 
@@ -510,17 +634,22 @@ func (a *sessionAgent) Run(ctx context.Context, call SessionAgentCall) (*fantasy
 	a.activeRequests.Del(call.SessionID)
 	cancel()
 
-	queuedMessages, ok := a.messageQueue.Get(call.SessionID)
-	if !ok || len(queuedMessages) == 0 {
+	next, ok := a.nextCall(call)
+	if !ok {
 		return result, err
 	}
 	// There are queued messages restart the loop.
-	firstQueuedMessage := queuedMessages[0]
-	a.messageQueue.Set(call.SessionID, queuedMessages[1:])
-	return a.Run(ctx, firstQueuedMessage)
+	return a.turn(ctx, next)
 }
 
 func (a *sessionAgent) Summarize(ctx context.Context, sessionID string, opts fantasy.ProviderOptions) error {
+	return a.summarize(ctx, sessionID, opts, "")
+}
+
+// summarize replaces the conversation up to and including untilMessageID
+// with a summary. Messages after it are kept verbatim. An empty
+// untilMessageID summarizes the whole conversation.
+func (a *sessionAgent) summarize(ctx context.Context, sessionID string, opts fantasy.ProviderOptions, untilMessageID string) error {
 	if a.IsSessionBusy(sessionID) {
 		return ErrSessionBusy
 	}
@@ -538,6 +667,17 @@ func (a *sessionAgent) Summarize(ctx context.Context, sessionID string, opts fan
 		return nil
 	}
 
+	var kept []message.Message
+	if untilMessageID != "" {
+		until := slices.IndexFunc(msgs, func(msg message.Message) bool {
+			return msg.ID == untilMessageID
+		})
+		if until == -1 {
+			return fmt.Errorf("message %s not found in session", untilMessageID)
+		}
+		msgs, kept = msgs[:until+1], msgs[until+1:]
+	}
+
 	aiMsgs, _ := a.preparePrompt(msgs)
 
 	genCtx, cancel := context.WithCancel(ctx)
@@ -559,6 +699,9 @@ func (a *sessionAgent) Summarize(ctx context.Context, sessionID string, opts fan
 	}
 
 	summaryPromptText := "Provide a detailed summary of our conversation above."
+	if len(kept) > 0 {
+		summaryPromptText += " The most recent messages will be kept verbatim after your summary, so focus on the context they build upon."
+	}
 	if len(currentSession.Todos) > 0 {
 		summaryPromptText += "\n\n## Current Todo List\n\n"
 		for _, t := range currentSession.Todos {
@@ -631,8 +774,9 @@ func (a *sessionAgent) Summarize(ctx context.Context, sessionID string, opts fan
 	// Just in case, get just the last usage info.
 	usage := resp.Response.Usage
 	currentSession.SummaryMessageID = summaryMessage.ID
+	currentSession.SummaryUntilMessageID = untilMessageID
 	currentSession.CompletionTokens = usage.OutputTokens
-	currentSession.PromptTokens = 0
+	currentSession.PromptTokens = estimateTokens(kept)
 	_, err = a.sessions.Save(genCtx, currentSession)
 	return err
 }
@@ -721,8 +865,29 @@ func (a *sessionAgent) getSessionMessages(ctx context.Context, session session.S
 			}
 		}
 		if summaryMsgInex != -1 {
-			msgs = msgs[summaryMsgInex:]
-			msgs[0].Role = message.User
+			summary := msgs[summaryMsgInex]
+			summary.Role = message.User
+			untilIndex := -1
+			if session.SummaryUntilMessageID != "" {
+				untilIndex = slices.IndexFunc(msgs, func(msg message.Message) bool {
+					return msg.ID == session.SummaryUntilMessageID
+				})
+			}
+			if untilIndex == -1 {
+				msgs = msgs[summaryMsgInex:]
+				msgs[0] = summary
+			} else {
+				// The summary was created after the turns it keeps, put it
+				// back in front of them and drop the older summaries it
+				// already covers.
+				kept := []message.Message{summary}
+				for _, msg := range msgs[untilIndex+1:] {
+					if !msg.IsSummaryMessage {
+						kept = append(kept, msg)
+					}
+				}
+				msgs = kept
+			}
 		}
 	}
 	return msgs, nil
@@ -733,26 +898,39 @@ func (a *sessionAgent) generateTitle(ctx context.Context, sessionID string, prom
 		return
 	}
 
-	var maxOutput int64 = 40
-	if a.smallModel.CatwalkCfg.CanReason {
-		maxOutput = a.smallModel.CatwalkCfg.DefaultMaxTokens
-	}
+	stream := func(model Model) (*fantasy.AgentResult, error) {
+		var maxOutput int64 = 40
+		if model.CatwalkCfg.CanReason {
+			maxOutput = model.CatwalkCfg.DefaultMaxTokens
+		}
 
-	agent := fantasy.NewAgent(a.smallModel.Model,
-		fantasy.WithSystemPrompt(string(titlePrompt)+"\n /no_think"),
-		fantasy.WithMaxOutputTokens(maxOutput),
-	)
+		agent := fantasy.NewAgent(model.Model,
+			fantasy.WithSystemPrompt(string(titlePrompt)+"\n /no_think"),
+			fantasy.WithMaxOutputTokens(maxOutput),
+		)
+
+		return agent.Stream(ctx, fantasy.AgentStreamCall{
+			Prompt: fmt.Sprintf("Generate a concise title for the following content:\n\n%s\n <think>\n\n</think>", prompt),
+			PrepareStep: func(callContext context.Context, options fantasy.PrepareStepFunctionOptions) (_ context.Context, prepared fantasy.PrepareStepResult, err error) {
+				prepared.Messages = options.Messages
+				if a.systemPromptPrefix != "" {
+					prepared.Messages = append([]fantasy.Message{fantasy.NewSystemMessage(a.systemPromptPrefix)}, prepared.Messages...)
+				}
+				return callContext, prepared, nil
+			},
+		})
+	}
 
-	resp, err := agent.Stream(ctx, fantasy.AgentStreamCall{
-		Prompt: fmt.Sprintf("Generate a concise title for the following content:\n\n%s\n <think>\n\n</think>", prompt),
-		PrepareStep: func(callContext context.Context, options fantasy.PrepareStepFunctionOptions) (_ context.Context, prepared fantasy.PrepareStepResult, err error) {
-			prepared.Messages = options.Messages
-			if a.systemPromptPrefix != "" {
-				prepared.Messages = append([]fantasy.Message{fantasy.NewSystemMessage(a.systemPromptPrefix)}, prepared.Messages...)
-			}
-			return callContext, prepared, nil
-		},
-	})
+	model := a.smallModel
+	resp, err := stream(model)
+	for _, fallback := range a.smallModel.Fallbacks {
+		if err == nil || !canFailover(err, nil) {
+			break
+		}
+		slog.Warn("Title generation failed, switching to fallback model", "provider", fallback.ModelCfg.Provider, "model", fallback.ModelCfg.Model, "error", err)
+		model = fallback
+		resp, err = stream(model)
+	}
 	if err != nil {
 		slog.Error("error generating title", "err", err)
 		return
@@ -786,7 +964,7 @@ func (a *sessionAgent) generateTitle(ctx context.Context, sessionID string, prom
 		}
 	}
 
-	modelConfig := a.smallModel.CatwalkCfg
+	modelConfig := model.CatwalkCfg
 	cost := modelConfig.CostPer1MInCached/1e6*float64(resp.TotalUsage.CacheCreationTokens) +
 		modelConfig.CostPer1MOutCached/1e6*float64(resp.TotalUsage.CacheReadTokens) +
 		modelConfig.CostPer1MIn/1e6*float64(resp.TotalUsage.InputTokens) +
@@ -862,16 +1040,13 @@ func (a *sessionAgent) Cancel(sessionID string) {
 		cancel()
 	}
 
-	if a.QueuedPrompts(sessionID) > 0 {
-		slog.Info("Clearing queued prompts", "session_id", sessionID)
-		a.messageQueue.Del(sessionID)
-	}
+	a.interrupts.Del(sessionID)
+	a.ClearQueue(sessionID)
 }
 
 func (a *sessionAgent) ClearQueue(sessionID string) {
-	if a.QueuedPrompts(sessionID) > 0 {
+	if len(a.takeQueue(sessionID)) > 0 {
 		slog.Info("Clearing queued prompts", "session_id", sessionID)
-		a.messageQueue.Del(sessionID)
 	}
 }
 
@@ -911,16 +1086,16 @@ func (a *sessionAgent) IsSessionBusy(sessionID string) bool {
 }
 
 func (a *sessionAgent) QueuedPrompts(sessionID string) int {
-	l, ok := a.messageQueue.Get(sessionID)
-	if !ok {
-		return 0
-	}
-	return len(l)
+	a.queueMu.Lock()
+	defer a.queueMu.Unlock()
+	return len(a.queue(sessionID))
 }
 
 func (a *sessionAgent) QueuedPromptsList(sessionID string) []string {
-	l, ok := a.messageQueue.Get(sessionID)
-	if !ok {
+	a.queueMu.Lock()
+	l := a.queue(sessionID)
+	a.queueMu.Unlock()
+	if len(l) == 0 {
 		return nil
 	}
 	prompts := make([]string, len(l))
@@ -950,6 +1125,12 @@ func (a *sessionAgent) promptPrefix() string {
 	return a.systemPromptPrefix
 }
 
+// worktreeNote tells the model to work in the session's worktree rather than
+// in the working directory of the system prompt.
+func worktreeNote(workingDir string) string {
+	return fmt.Sprintf("\n\n<worktree>\nThis session is isolated in its own git worktree. Your working directory is %s: read, edit and run commands there, never in the project directory mentioned above. The user reviews and merges your changes.\n</worktree>\n", workingDir)
+}
+
 func (a *sessionAgent) isClaudeCode() bool {
 	cfg := config.Get()
 	pc, ok := cfg.Providers.Get(a.largeModel.ModelCfg.Provider)
@@ -965,10 +1146,12 @@ func (a *sessionAgent) convertToToolResult(result fantasy.ToolResultContent) mes
 			metadataStr = string(data)
 		}
 	}
+	// The result itself was redacted by redactedTool, the metadata does not
+	// go through it.
 	baseResult := message.ToolResult{
 		ToolCallID: result.ToolCallID,
 		Name:       result.ToolName,
-		Metadata:   metadataStr,
+		Metadata:   redactMetadata(a.redactor, metadataStr),
 	}
 
 	switch result.Result.GetType() {
@@ -1017,9 +1200,9 @@ func (a *sessionAgent) convertToToolResult(result fantasy.ToolResultContent) mes
 //
 //	BEFORE: [tool result: image data]
 //	AFTER:  [tool result: "Image loaded - see attached"], [user: image attachment]
-func (a *sessionAgent) workaroundProviderMediaLimitations(messages []fantasy.Message) []fantasy.Message {
-	providerSupportsMedia := a.largeModel.ModelCfg.Provider == string(catwalk.InferenceProviderAnthropic) ||
-		a.largeModel.ModelCfg.Provider == string(catwalk.InferenceProviderBedrock)
+func (a *sessionAgent) workaroundProviderMediaLimitations(model Model, messages []fantasy.Message) []fantasy.Message {
+	providerSupportsMedia := model.ModelCfg.Provider == string(catwalk.InferenceProviderAnthropic) ||
+		model.ModelCfg.Provider == string(catwalk.InferenceProviderBedrock)
 
 	if providerSupportsMedia {
 		return messages
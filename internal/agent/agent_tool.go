@@ -81,6 +81,7 @@ func (c *coordinator) agentTool(ctx context.Context) (fantasy.AgentTool, error)
 				TopK:             model.ModelCfg.TopK,
 				FrequencyPenalty: model.ModelCfg.FrequencyPenalty,
 				PresencePenalty:  model.ModelCfg.PresencePenalty,
+				WorkingDir:       tools.GetWorkingDirFromContext(ctx, ""),
 			})
 			if err != nil {
 				return fantasy.NewTextErrorResponse("error generating response"), nil
@@ -51,15 +51,11 @@ func validateAgenticFetchParams(ctx context.Context, params tools.AgenticFetchPa
 var agenticFetchPromptTmpl []byte
 
 func (c *coordinator) agenticFetchTool(_ context.Context, client *http.Client) (fantasy.AgentTool, error) {
+	webFetchClient, sourcegraphClient := client, client
 	if client == nil {
-		client = &http.Client{
-			Timeout: 30 * time.Second,
-			Transport: &http.Transport{
-				MaxIdleConns:        100,
-				MaxIdleConnsPerHost: 10,
-				IdleConnTimeout:     90 * time.Second,
-			},
-		}
+		client = c.webClient(tools.AgenticFetchToolName, 30*time.Second)
+		webFetchClient = c.webClient(tools.WebFetchToolName, 30*time.Second)
+		sourcegraphClient = c.webClient(tools.SourcegraphToolName, 30*time.Second)
 	}
 
 	return fantasy.NewParallelAgentTool(
@@ -158,14 +154,14 @@ func (c *coordinator) agenticFetchTool(_ context.Context, client *http.Client) (
 				return fantasy.ToolResponse{}, errors.New("small model provider not configured")
 			}
 
-			webFetchTool := tools.NewWebFetchToolWithClient(tmpDir, client)
+			webFetchTool := tools.NewWebFetchToolWithClient(tmpDir, webFetchClient)
 			webSearchTool := tools.NewWebSearchToolWithHTTPClient(client)
 			fetchTools := []fantasy.AgentTool{
 				tools.NewWebFetchToolAdapter(webFetchTool),
 				tools.NewWebSearchToolAdapter(webSearchTool),
 				tools.NewGlobTool(tmpDir),
 				tools.NewGrepTool(tmpDir),
-				tools.NewSourcegraphTool(client),
+				tools.NewSourcegraphTool(sourcegraphClient),
 				tools.NewViewTool(c.lspClients, c.permissions, tmpDir),
 			}
 
@@ -175,6 +171,7 @@ func (c *coordinator) agenticFetchTool(_ context.Context, client *http.Client) (
 				SystemPromptPrefix:   smallProviderCfg.SystemPromptPrefix,
 				SystemPrompt:         systemPrompt,
 				DisableAutoSummarize: c.cfg.Options.DisableAutoSummarize,
+				Compaction:           c.cfg.Options.Compaction,
 				IsYolo:               c.permissions.SkipRequests(),
 				Sessions:             c.sessions,
 				Messages:             c.messages,
@@ -5,14 +5,15 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/uglyswap/push/pkg/fantasy"
-	"charm.land/fantasy/providers/anthropic"
-	"charm.land/fantasy/providers/openai"
-	"charm.land/fantasy/providers/openaicompat"
-	"charm.land/fantasy/providers/openrouter"
+	"github.com/uglyswap/push/pkg/fantasy/providers/anthropic"
+	"github.com/uglyswap/push/pkg/fantasy/providers/openai"
+	"github.com/uglyswap/push/pkg/fantasy/providers/openaicompat"
+	"github.com/uglyswap/push/pkg/fantasy/providers/openrouter"
 	"charm.land/x/vcr"
 	"github.com/uglyswap/push/internal/catwalk"
 	"github.com/uglyswap/push/internal/agent/prompt"
@@ -149,7 +150,7 @@ func testSessionAgent(env fakeEnv, large, small fantasy.LanguageModel, systemPro
 			DefaultMaxTokens: 10000,
 		},
 	}
-	agent := NewSessionAgent(SessionAgentOptions{largeModel, smallModel, "", systemPrompt, false, false, true, env.sessions, env.messages, tools})
+	agent := NewSessionAgent(SessionAgentOptions{largeModel, smallModel, "", systemPrompt, false, false, nil, true, env.sessions, env.messages, tools, nil})
 	return agent
 }
 
@@ -206,6 +207,72 @@ func coderAgent(r *vcr.Recorder, env fakeEnv, large, small fantasy.LanguageModel
 	return testSessionAgent(env, large, small, systemPrompt, allTools...), nil
 }
 
+// stubModel is a model answering "done", after failing with the given
+// errors, one per call.
+type stubModel struct {
+	name string
+	errs []error
+
+	mu    sync.Mutex
+	calls int
+}
+
+func (m *stubModel) Model() string    { return m.name }
+func (m *stubModel) Provider() string { return "stub" }
+
+func (m *stubModel) Generate(ctx context.Context, messages []fantasy.Message, opts fantasy.GenerateOptions) (*fantasy.Response, error) {
+	return m.Stream(ctx, messages, opts, fantasy.StreamCallbacks{})
+}
+
+func (m *stubModel) Stream(_ context.Context, _ []fantasy.Message, _ fantasy.GenerateOptions, callbacks fantasy.StreamCallbacks) (*fantasy.Response, error) {
+	m.mu.Lock()
+	call := m.calls
+	m.calls++
+	m.mu.Unlock()
+	if call < len(m.errs) {
+		return nil, m.errs[call]
+	}
+	if callbacks.OnTextDelta != nil {
+		if err := callbacks.OnTextDelta("0", "done"); err != nil {
+			return nil, err
+		}
+	}
+	return &fantasy.Response{FinishReason: fantasy.FinishReasonStop}, nil
+}
+
+func (m *stubModel) Calls() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.calls
+}
+
+// stubAgent returns a session agent running on stub models, with the
+// configuration of an empty project loaded.
+func stubAgent(t *testing.T, env fakeEnv, large *stubModel, fallbacks ...*stubModel) *sessionAgent {
+	t.Helper()
+	_, err := config.Init(env.workingDir, t.TempDir(), false)
+	require.NoError(t, err)
+
+	model := func(m *stubModel) Model {
+		return Model{
+			Model:      m,
+			CatwalkCfg: catwalk.Model{ID: m.name, ContextWindow: 200000, DefaultMaxTokens: 10000},
+			ModelCfg:   config.SelectedModel{Provider: "stub", Model: m.name},
+		}
+	}
+	largeModel := model(large)
+	for _, fallback := range fallbacks {
+		largeModel.Fallbacks = append(largeModel.Fallbacks, model(fallback))
+	}
+	return NewSessionAgent(SessionAgentOptions{
+		LargeModel: largeModel,
+		SmallModel: model(&stubModel{name: "small"}),
+		IsYolo:     true,
+		Sessions:   env.sessions,
+		Messages:   env.messages,
+	}).(*sessionAgent)
+}
+
 // createSimpleGoProject creates a simple Go project structure in the given directory.
 // It creates a go.mod file and a main.go file with a basic hello world program.
 func createSimpleGoProject(t *testing.T, dir string) {
@@ -227,3 +294,9 @@ func main() {
 	err = os.WriteFile(dir+"/main.go", []byte(mainGo), 0o644)
 	require.NoError(t, err)
 }
+
+// contextOverflow returns the error of a provider rejecting a prompt longer
+// than the context window of the model.
+func contextOverflow() error {
+	return &fantasy.ProviderError{StatusCode: 400, Message: "prompt is too long"}
+}
@@ -0,0 +1,167 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/uglyswap/push/internal/message"
+	"github.com/uglyswap/push/pkg/fantasy"
+)
+
+// maxElidedInputChars caps how much of the original tool input is repeated
+// in the stub that replaces a pruned tool output.
+const maxElidedInputChars = 200
+
+// compactHistory applies the cheapest compaction tier, eliding large outputs
+// of old tool calls, once the session uses more of the context window than
+// the configured pruning threshold.
+func (a *sessionAgent) compactHistory(model Model, usedTokens int64, msgs []message.Message) []message.Message {
+	if !a.compaction.PruningEnabled() {
+		return msgs
+	}
+	prune, _ := a.compaction.Thresholds(model.ModelCfg.Model, int64(model.CatwalkCfg.ContextWindow))
+	if usedTokens < prune {
+		return msgs
+	}
+	keepTurns, maxChars := a.compaction.Limits()
+	return pruneToolOutputs(msgs, keepTurns, maxChars)
+}
+
+// shouldCompact reports whether the session got close enough to the context
+// window that older turns need to be summarized.
+func (a *sessionAgent) shouldCompact(model Model, usedTokens int64) bool {
+	if a.disableAutoSummarize {
+		return false
+	}
+	_, summarize := a.compaction.Thresholds(model.ModelCfg.Model, int64(model.CatwalkCfg.ContextWindow))
+	return usedTokens >= summarize
+}
+
+// compact summarizes the older turns of the session while keeping the most
+// recent ones verbatim. The whole conversation is only summarized when there
+// are not enough turns to keep or the recent turns alone are too large for
+// the context window of model, the one the turn ran with.
+func (a *sessionAgent) compact(ctx context.Context, model Model, sessionID string, opts fantasy.ProviderOptions) error {
+	currentSession, err := a.sessions.Get(ctx, sessionID)
+	if err != nil {
+		return fmt.Errorf("failed to get session: %w", err)
+	}
+	msgs, err := a.getSessionMessages(ctx, currentSession)
+	if err != nil {
+		return err
+	}
+
+	keepTurns, _ := a.compaction.Limits()
+	until := turnBoundary(msgs, keepTurns)
+	if until < 0 {
+		return a.Summarize(ctx, sessionID, opts)
+	}
+
+	_, summarize := a.compaction.Thresholds(model.ModelCfg.Model, int64(model.CatwalkCfg.ContextWindow))
+	if estimateTokens(msgs[until+1:]) > summarize/2 {
+		return a.Summarize(ctx, sessionID, opts)
+	}
+	return a.summarize(ctx, sessionID, opts, msgs[until].ID)
+}
+
+// turnBoundary returns the index of the last message that comes before the
+// keepTurns most recent user turns, or -1 if there is nothing worth folding
+// into a summary.
+func turnBoundary(msgs []message.Message, keepTurns int) int {
+	start := recentTurnsStart(msgs, keepTurns)
+	until := start - 1
+	if until < 0 || (until == 0 && msgs[0].IsSummaryMessage) {
+		return -1
+	}
+	return until
+}
+
+// recentTurnsStart returns the index of the user message that starts the
+// keepTurns most recent turns, or 0 if there are not that many turns.
+func recentTurnsStart(msgs []message.Message, keepTurns int) int {
+	if keepTurns <= 0 {
+		return len(msgs)
+	}
+	turns := 0
+	for i := len(msgs) - 1; i >= 0; i-- {
+		if msgs[i].Role != message.User || msgs[i].IsSummaryMessage {
+			continue
+		}
+		turns++
+		if turns == keepTurns {
+			return i
+		}
+	}
+	return 0
+}
+
+// pruneToolOutputs replaces tool outputs larger than maxChars that are older
+// than the keepTurns most recent turns with a stub that tells the model how
+// to get them back. The stored messages are left untouched.
+func pruneToolOutputs(msgs []message.Message, keepTurns, maxChars int) []message.Message {
+	cutoff := recentTurnsStart(msgs, keepTurns)
+	if cutoff == 0 {
+		return msgs
+	}
+
+	inputs := make(map[string]string)
+	for _, msg := range msgs[:cutoff] {
+		for _, tc := range msg.ToolCalls() {
+			inputs[tc.ID] = tc.Input
+		}
+	}
+
+	pruned := slices.Clone(msgs)
+	for i, msg := range pruned[:cutoff] {
+		if msg.Role != message.Tool {
+			continue
+		}
+		parts := slices.Clone(msg.Parts)
+		changed := false
+		for j, part := range parts {
+			result, ok := part.(message.ToolResult)
+			if !ok || result.Data != "" || len(result.Content) <= maxChars {
+				continue
+			}
+			result.Content = elidedToolOutput(result, inputs[result.ToolCallID])
+			parts[j] = result
+			changed = true
+		}
+		if changed {
+			msg.Parts = parts
+			pruned[i] = msg
+		}
+	}
+	return pruned
+}
+
+func elidedToolOutput(result message.ToolResult, input string) string {
+	if len(input) > maxElidedInputChars {
+		input = input[:maxElidedInputChars] + "..."
+	}
+	if input == "" {
+		input = "{}"
+	}
+	return fmt.Sprintf(
+		"[Output elided to save context: %d characters from the %s tool. If you still need it, call %s again with the same input: %s]",
+		len(result.Content), result.Name, result.Name, input,
+	)
+}
+
+// estimateTokens gives a rough token count for the given messages, good
+// enough to decide whether they fit in the remaining context.
+func estimateTokens(msgs []message.Message) int64 {
+	var chars int
+	for _, msg := range msgs {
+		chars += len(msg.Content().Text)
+		chars += len(msg.ReasoningContent().Thinking)
+		for _, tc := range msg.ToolCalls() {
+			chars += len(tc.Input)
+		}
+		for _, tr := range msg.ToolResults() {
+			chars += len(tr.Content)
+		}
+	}
+	return int64(chars / 4)
+}
@@ -0,0 +1,127 @@
+package agent
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/uglyswap/push/internal/message"
+)
+
+func toolTurn(prompt, callID, input, output string) []message.Message {
+	return []message.Message{
+		{Role: message.User, Parts: []message.ContentPart{message.TextContent{Text: prompt}}},
+		{Role: message.Assistant, Parts: []message.ContentPart{message.ToolCall{ID: callID, Name: "view", Input: input, Finished: true}}},
+		{Role: message.Tool, Parts: []message.ContentPart{message.ToolResult{ToolCallID: callID, Name: "view", Content: output}}},
+	}
+}
+
+func TestPruneToolOutputs(t *testing.T) {
+	t.Parallel()
+
+	long := strings.Repeat("x", 100)
+	var msgs []message.Message
+	msgs = append(msgs, toolTurn("first", "old", `{"file_path":"a.go"}`, long)...)
+	msgs = append(msgs, toolTurn("second", "short", `{"file_path":"b.go"}`, "short")...)
+	msgs = append(msgs, toolTurn("third", "recent", `{"file_path":"c.go"}`, long)...)
+
+	pruned := pruneToolOutputs(msgs, 1, 50)
+	require.Len(t, pruned, len(msgs))
+	require.Equal(t,
+		`[Output elided to save context: 100 characters from the view tool. If you still need it, call view again with the same input: {"file_path":"a.go"}]`,
+		pruned[2].ToolResults()[0].Content,
+	)
+	require.Equal(t, "short", pruned[5].ToolResults()[0].Content, "small outputs are kept")
+	require.Equal(t, long, pruned[8].ToolResults()[0].Content, "recent turns are kept")
+	require.Equal(t, long, msgs[2].ToolResults()[0].Content, "the messages given are left untouched")
+
+	require.Equal(t, msgs, pruneToolOutputs(msgs, 3, 50), "nothing is older than the turns kept")
+}
+
+func TestGetSessionMessagesSummaryUntil(t *testing.T) {
+	env := testEnv(t)
+	agent := stubAgent(t, env, &stubModel{name: "large"})
+	sess, err := env.sessions.Create(t.Context(), "New Session")
+	require.NoError(t, err)
+
+	create := func(role message.MessageRole, text string, summary bool) message.Message {
+		msg, err := env.messages.Create(t.Context(), sess.ID, message.CreateMessageParams{
+			Role:             role,
+			Parts:            []message.ContentPart{message.TextContent{Text: text}},
+			IsSummaryMessage: summary,
+		})
+		require.NoError(t, err)
+		return msg
+	}
+	texts := func(msgs []message.Message) []string {
+		var texts []string
+		for _, msg := range msgs {
+			texts = append(texts, msg.Content().Text)
+		}
+		return texts
+	}
+
+	create(message.User, "old prompt", false)
+	create(message.Assistant, "old answer", false)
+	create(message.Assistant, "older summary", true)
+	until := create(message.Assistant, "folded answer", false)
+	create(message.User, "kept prompt", false)
+	create(message.Assistant, "kept answer", false)
+	summary := create(message.Assistant, "summary", true)
+
+	sess.SummaryMessageID = summary.ID
+	sess.SummaryUntilMessageID = until.ID
+	msgs, err := agent.getSessionMessages(t.Context(), sess)
+	require.NoError(t, err)
+	require.Equal(t, []string{"summary", "kept prompt", "kept answer"}, texts(msgs))
+	require.Equal(t, message.User, msgs[0].Role, "the summary is sent as a user message")
+
+	sess.SummaryUntilMessageID = ""
+	msgs, err = agent.getSessionMessages(t.Context(), sess)
+	require.NoError(t, err)
+	require.Equal(t, []string{"summary"}, texts(msgs))
+}
+
+func TestCompactUsesActiveModel(t *testing.T) {
+	env := testEnv(t)
+
+	// Five turns of 800 characters each, the three kept are about 600
+	// tokens.
+	newSession := func(t *testing.T) string {
+		sess, err := env.sessions.Create(t.Context(), "New Session")
+		require.NoError(t, err)
+		for range 5 {
+			for _, role := range []message.MessageRole{message.User, message.Assistant} {
+				_, err := env.messages.Create(t.Context(), sess.ID, message.CreateMessageParams{
+					Role:  role,
+					Parts: []message.ContentPart{message.TextContent{Text: strings.Repeat("x", 400)}},
+				})
+				require.NoError(t, err)
+			}
+		}
+		return sess.ID
+	}
+
+	for _, tt := range []struct {
+		name          string
+		contextWindow int64
+		partial       bool
+	}{
+		{name: "recent turns fit", contextWindow: 200_000, partial: true},
+		{name: "recent turns too large", contextWindow: 1_000, partial: false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			agent := stubAgent(t, env, &stubModel{name: "large"})
+			sessionID := newSession(t)
+			model := agent.largeModel
+			model.CatwalkCfg.ContextWindow = tt.contextWindow
+
+			require.NoError(t, agent.compact(t.Context(), model, sessionID, nil))
+
+			sess, err := env.sessions.Get(t.Context(), sessionID)
+			require.NoError(t, err)
+			require.NotEmpty(t, sess.SummaryMessageID)
+			require.Equal(t, tt.partial, sess.SummaryUntilMessageID != "")
+		})
+	}
+}
@@ -2,6 +2,7 @@ package agent
 
 import (
 	"bytes"
+	"cmp"
 	"context"
 	"encoding/json"
 	"errors"
@@ -13,20 +14,26 @@ import (
 	"os"
 	"slices"
 	"strings"
+	"time"
 
 	"github.com/uglyswap/push/pkg/fantasy"
 	"github.com/uglyswap/push/internal/catwalk"
 	"github.com/uglyswap/push/internal/agent/hyper"
 	"github.com/uglyswap/push/internal/agent/prompt"
 	"github.com/uglyswap/push/internal/agent/tools"
+	"github.com/uglyswap/push/internal/checkpoint"
 	"github.com/uglyswap/push/internal/config"
 	"github.com/uglyswap/push/internal/csync"
 	"github.com/uglyswap/push/internal/history"
+	"github.com/uglyswap/push/internal/httpcache"
 	"github.com/uglyswap/push/internal/log"
 	"github.com/uglyswap/push/internal/lsp"
 	"github.com/uglyswap/push/internal/message"
 	"github.com/uglyswap/push/internal/permission"
+	"github.com/uglyswap/push/internal/recording"
+	"github.com/uglyswap/push/internal/redact"
 	"github.com/uglyswap/push/internal/session"
+	"github.com/uglyswap/push/internal/worktree"
 	"golang.org/x/sync/errgroup"
 
 	"github.com/uglyswap/push/pkg/fantasy/providers/anthropic"
@@ -50,6 +57,13 @@ type Coordinator interface {
 	QueuedPrompts(sessionID string) int
 	QueuedPromptsList(sessionID string) []string
 	ClearQueue(sessionID string)
+	// EditQueuedPrompt, MoveQueuedPrompt, RemoveQueuedPrompt and
+	// SendQueuedPromptNow act on the queued prompt at index, as listed by
+	// QueuedPromptsList.
+	EditQueuedPrompt(sessionID string, index int, prompt string) error
+	MoveQueuedPrompt(sessionID string, index, to int) error
+	RemoveQueuedPrompt(sessionID string, index int) error
+	SendQueuedPromptNow(ctx context.Context, sessionID string, index int) (*fantasy.AgentResult, error)
 	Summarize(context.Context, string) error
 	Model() Model
 	UpdateModels(ctx context.Context) error
@@ -62,6 +76,10 @@ type coordinator struct {
 	permissions permission.Service
 	history     history.Service
 	lspClients  *csync.Map[string, *lsp.Client]
+	httpCache   *httpcache.Store
+	worktrees   *worktree.Manager
+	redactor    *redact.Redactor
+	recording   *recording.Recording
 
 	currentAgent SessionAgent
 	agents       map[string]SessionAgent
@@ -77,6 +95,9 @@ func NewCoordinator(
 	permissions permission.Service,
 	history history.Service,
 	lspClients *csync.Map[string, *lsp.Client],
+	worktrees *worktree.Manager,
+	redactor *redact.Redactor,
+	rec *recording.Recording,
 ) (Coordinator, error) {
 	c := &coordinator{
 		cfg:         cfg,
@@ -85,8 +106,14 @@ func NewCoordinator(
 		permissions: permissions,
 		history:     history,
 		lspClients:  lspClients,
+		worktrees:   worktrees,
+		redactor:    redactor,
+		recording:   rec,
 		agents:      make(map[string]SessionAgent),
 	}
+	if cfg.Options.HTTPCache.Enabled() {
+		c.httpCache = httpcache.Open(cfg.Options.DataDirectory, cfg.Options.HTTPCache.Limit(), cfg.Options.HTTPCache.SizeLimit())
+	}
 
 	agentCfg, ok := cfg.Agents[config.AgentCoder]
 	if !ok {
@@ -114,6 +141,11 @@ func (c *coordinator) Run(ctx context.Context, sessionID string, prompt string,
 		return nil, err
 	}
 
+	// Recordings end up in bug reports, so they are kept free of secrets.
+	if err := c.recording.AddPrompt(sessionID, c.redactor.Redact(prompt), redactAttachments(c.redactor, attachments)); err != nil {
+		slog.Warn("Failed to record prompt", "session", sessionID, "error", err)
+	}
+
 	model := c.currentAgent.Model()
 	maxTokens := model.CatwalkCfg.DefaultMaxTokens
 	if model.ModelCfg.MaxTokens != 0 {
@@ -138,6 +170,11 @@ func (c *coordinator) Run(ctx context.Context, sessionID string, prompt string,
 
 	mergedOptions, temp, topP, topK, freqPenalty, presPenalty := mergeCallOptions(model, providerCfg)
 
+	workingDir, err := c.sessionWorkingDir(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
 	if providerCfg.OAuthToken != nil && providerCfg.OAuthToken.IsExpired() {
 		slog.Info("Token needs to be refreshed", "provider", providerCfg.ID)
 		if err := c.refreshOAuth2Token(ctx, providerCfg); err != nil {
@@ -157,6 +194,7 @@ func (c *coordinator) Run(ctx context.Context, sessionID string, prompt string,
 			TopK:             topK,
 			FrequencyPenalty: freqPenalty,
 			PresencePenalty:  presPenalty,
+			WorkingDir:       workingDir,
 		})
 	}
 	result, originalErr := run()
@@ -169,20 +207,60 @@ func (c *coordinator) Run(ctx context.Context, sessionID string, prompt string,
 				return nil, originalErr
 			}
 			slog.Info("Retrying request with refreshed OAuth token", "provider", providerCfg.ID)
-			return run()
+			result, originalErr = run()
 		case strings.Contains(providerCfg.APIKeyTemplate, "$"):
 			slog.Info("Received 401. Refreshing API Key template and retrying", "provider", providerCfg.ID)
 			if err := c.refreshApiKeyTemplate(ctx, providerCfg); err != nil {
 				return nil, originalErr
 			}
 			slog.Info("Retrying request with refreshed API key", "provider", providerCfg.ID)
-			return run()
+			result, originalErr = run()
 		}
 	}
 
+	// A nil result means the prompt was queued behind a running turn, which
+	// records the checkpoint.
+	if originalErr == nil && result != nil {
+		c.checkpoint(ctx, sessionID, cmp.Or(workingDir, c.cfg.WorkingDir()), prompt, model.CatwalkCfg.Name)
+	}
 	return result, originalErr
 }
 
+// checkpoint records the working tree at the end of a turn when checkpoints
+// are enabled. Failures are logged, they must not fail the turn.
+func (c *coordinator) checkpoint(ctx context.Context, sessionID, workingDir, prompt, modelName string) {
+	if !c.cfg.Options.Checkpoints {
+		return
+	}
+	cp, created, err := checkpoint.New(workingDir, c.cfg.Options.Attribution).Create(ctx, sessionID, checkpoint.Subject(prompt), modelName)
+	switch {
+	case errors.Is(err, checkpoint.ErrNotGitRepo):
+	case err != nil:
+		slog.Warn("Failed to record checkpoint", "session", sessionID, "error", err)
+	case created:
+		slog.Debug("Recorded checkpoint", "session", sessionID, "hash", cp.Hash)
+	}
+}
+
+// sessionWorkingDir returns the worktree of the session when sessions are
+// isolated in worktrees, creating it on the first run, and registers it with
+// the language servers. It returns an empty string otherwise.
+func (c *coordinator) sessionWorkingDir(ctx context.Context, sessionID string) (string, error) {
+	if c.worktrees == nil {
+		return "", nil
+	}
+	wt, err := c.worktrees.Ensure(ctx, sessionID)
+	if err != nil {
+		return "", fmt.Errorf("failed to prepare session worktree: %w", err)
+	}
+	for client := range c.lspClients.Seq() {
+		if err := client.AddWorkspaceFolder(ctx, wt.Path); err != nil {
+			slog.Warn("Failed to add worktree to LSP workspace", "lsp", client.GetName(), "error", err)
+		}
+	}
+	return wt.Path, nil
+}
+
 func getProviderOptions(model Model, providerCfg config.ProviderConfig) fantasy.ProviderOptions {
 	options := fantasy.ProviderOptions{}
 
@@ -332,10 +410,12 @@ func (c *coordinator) buildAgent(ctx context.Context, prompt *prompt.Prompt, age
 		systemPrompt,
 		isSubAgent,
 		c.cfg.Options.DisableAutoSummarize,
+		c.cfg.Options.Compaction,
 		c.permissions.SkipRequests(),
 		c.sessions,
 		c.messages,
 		nil,
+		c.redactor,
 	})
 	c.readyWg.Go(func() error {
 		tools, err := c.buildTools(ctx, agent)
@@ -379,21 +459,31 @@ func (c *coordinator) buildTools(ctx context.Context, agent config.Agent) ([]fan
 		tools.NewBashTool(c.permissions, c.cfg.WorkingDir(), c.cfg.Options.Attribution, modelName),
 		tools.NewJobOutputTool(),
 		tools.NewJobKillTool(),
-		tools.NewDownloadTool(c.permissions, c.cfg.WorkingDir(), nil),
+		tools.NewDownloadTool(c.permissions, c.cfg.WorkingDir(), c.webClient(tools.DownloadToolName, 5*time.Minute)),
 		tools.NewEditTool(c.lspClients, c.permissions, c.history, c.cfg.WorkingDir()),
 		tools.NewMultiEditTool(c.lspClients, c.permissions, c.history, c.cfg.WorkingDir()),
-		tools.NewFetchTool(c.permissions, c.cfg.WorkingDir(), nil),
+		tools.NewApplyPatchTool(c.lspClients, c.permissions, c.history, c.cfg.WorkingDir()),
+		tools.NewFetchTool(c.permissions, c.cfg.WorkingDir(), c.webClient(tools.FetchToolName, 30*time.Second)),
 		tools.NewGlobTool(c.cfg.WorkingDir()),
 		tools.NewGrepTool(c.cfg.WorkingDir()),
 		tools.NewLsTool(c.permissions, c.cfg.WorkingDir(), c.cfg.Tools.Ls),
-		tools.NewSourcegraphTool(nil),
+		tools.NewSourcegraphTool(c.webClient(tools.SourcegraphToolName, 30*time.Second)),
 		tools.NewTodosTool(c.sessions),
 		tools.NewViewTool(c.lspClients, c.permissions, c.cfg.WorkingDir()),
 		tools.NewWriteTool(c.lspClients, c.permissions, c.history, c.cfg.WorkingDir()),
 	)
 
 	if len(c.cfg.LSP) > 0 {
-		allTools = append(allTools, tools.NewDiagnosticsTool(c.lspClients), tools.NewReferencesTool(c.lspClients))
+		allTools = append(allTools,
+			tools.NewDiagnosticsTool(c.lspClients),
+			tools.NewReferencesTool(c.lspClients),
+			tools.NewRenameTool(c.lspClients, c.permissions, c.history, c.cfg.WorkingDir()),
+			tools.NewCodeActionsTool(c.lspClients, c.permissions, c.history, c.cfg.WorkingDir()),
+		)
+	}
+
+	if len(c.cfg.MCP) > 0 {
+		allTools = append(allTools, tools.NewReadMCPResourceTool())
 	}
 
 	var filteredTools []fantasy.AgentTool
@@ -434,6 +524,20 @@ func (c *coordinator) buildTools(ctx context.Context, agent config.Agent) ([]fan
 	slices.SortFunc(filteredTools, func(a, b fantasy.AgentTool) int {
 		return strings.Compare(a.Name(), b.Name())
 	})
+	if c.redactor != nil {
+		for i, tool := range filteredTools {
+			filteredTools[i] = redactedTool{tool, c.redactor}
+		}
+	}
+	if c.recording != nil {
+		// Record what the model sees, after redaction.
+		for i, tool := range filteredTools {
+			filteredTools[i] = recordedTool{tool, c.recording}
+		}
+	}
+	for i, tool := range filteredTools {
+		filteredTools[i] = tracedTool{tool}
+	}
 	return filteredTools, nil
 }
 
@@ -448,77 +552,72 @@ func (c *coordinator) buildAgentModels(ctx context.Context) (Model, Model, error
 		return Model{}, Model{}, errors.New("small model not selected")
 	}
 
-	largeProviderCfg, ok := c.cfg.Providers.Get(largeModelCfg.Provider)
-	if !ok {
-		return Model{}, Model{}, errors.New("large model provider not configured")
-	}
-
-	largeProvider, err := c.buildProvider(largeProviderCfg, largeModelCfg)
+	large, err := c.buildModel(ctx, config.SelectedModelTypeLarge, largeModelCfg)
 	if err != nil {
 		return Model{}, Model{}, err
 	}
-
-	smallProviderCfg, ok := c.cfg.Providers.Get(smallModelCfg.Provider)
-	if !ok {
-		return Model{}, Model{}, errors.New("large model provider not configured")
-	}
-
-	smallProvider, err := c.buildProvider(smallProviderCfg, largeModelCfg)
+	small, err := c.buildModel(ctx, config.SelectedModelTypeSmall, smallModelCfg)
 	if err != nil {
 		return Model{}, Model{}, err
 	}
+	return large, small, nil
+}
 
-	var largeCatwalkModel *catwalk.Model
-	var smallCatwalkModel *catwalk.Model
-
-	for _, m := range largeProviderCfg.Models {
-		if m.ID == largeModelCfg.Model {
-			largeCatwalkModel = &m
-		}
+// buildModel builds the language model for the given selection along with
+// its fallback chain. Fallbacks that can't be built are skipped so a broken
+// fallback never prevents the primary model from being used.
+func (c *coordinator) buildModel(ctx context.Context, modelType config.SelectedModelType, modelCfg config.SelectedModel) (Model, error) {
+	model, err := c.buildSingleModel(ctx, modelType, modelCfg)
+	if err != nil {
+		return Model{}, err
 	}
-	for _, m := range smallProviderCfg.Models {
-		if m.ID == smallModelCfg.Model {
-			smallCatwalkModel = &m
+	for _, fallbackCfg := range modelCfg.Fallbacks {
+		fallback, err := c.buildSingleModel(ctx, modelType, fallbackCfg)
+		if err != nil {
+			slog.Warn("Skipping fallback model", "type", modelType, "provider", fallbackCfg.Provider, "model", fallbackCfg.Model, "error", err)
+			continue
 		}
+		model.Fallbacks = append(model.Fallbacks, fallback)
 	}
+	return model, nil
+}
 
-	if largeCatwalkModel == nil {
-		return Model{}, Model{}, errors.New("large model not found in provider config")
+func (c *coordinator) buildSingleModel(ctx context.Context, modelType config.SelectedModelType, modelCfg config.SelectedModel) (Model, error) {
+	providerCfg, ok := c.cfg.Providers.Get(modelCfg.Provider)
+	if !ok {
+		return Model{}, fmt.Errorf("%s model provider not configured", modelType)
 	}
 
-	if smallCatwalkModel == nil {
-		return Model{}, Model{}, errors.New("snall model not found in provider config")
+	provider, err := c.buildProvider(providerCfg, modelCfg)
+	if err != nil {
+		return Model{}, err
 	}
 
-	largeModelID := largeModelCfg.Model
-	smallModelID := smallModelCfg.Model
-
-	if largeModelCfg.Provider == openrouter.Name && isExactoSupported(largeModelID) {
-		largeModelID += ":exacto"
+	var catwalkModel *catwalk.Model
+	for _, m := range providerCfg.Models {
+		if m.ID == modelCfg.Model {
+			catwalkModel = &m
+		}
 	}
-
-	if smallModelCfg.Provider == openrouter.Name && isExactoSupported(smallModelID) {
-		smallModelID += ":exacto"
+	if catwalkModel == nil {
+		return Model{}, fmt.Errorf("%s model not found in provider config", modelType)
 	}
 
-	largeModel, err := largeProvider.LanguageModel(ctx, largeModelID)
-	if err != nil {
-		return Model{}, Model{}, err
+	modelID := modelCfg.Model
+	if modelCfg.Provider == openrouter.Name && isExactoSupported(modelID) {
+		modelID += ":exacto"
 	}
-	smallModel, err := smallProvider.LanguageModel(ctx, smallModelID)
+
+	languageModel, err := provider.LanguageModel(ctx, modelID)
 	if err != nil {
-		return Model{}, Model{}, err
+		return Model{}, err
 	}
 
 	return Model{
-			Model:      largeModel,
-			CatwalkCfg: *largeCatwalkModel,
-			ModelCfg:   largeModelCfg,
-		}, Model{
-			Model:      smallModel,
-			CatwalkCfg: *smallCatwalkModel,
-			ModelCfg:   smallModelCfg,
-		}, nil
+		Model:      languageModel,
+		CatwalkCfg: *catwalkModel,
+		ModelCfg:   modelCfg,
+	}, nil
 }
 
 func (c *coordinator) buildAnthropicProvider(baseURL, apiKey string, headers map[string]string, isOauth bool) (fantasy.Provider, error) {
@@ -541,8 +640,7 @@ func (c *coordinator) buildAnthropicProvider(baseURL, apiKey string, headers map
 		opts = append(opts, anthropic.WithBaseURL(baseURL))
 	}
 
-	if c.cfg.Options.Debug {
-		httpClient := log.NewHTTPClient()
+	if httpClient := c.providerHTTPClient(); httpClient != nil {
 		opts = append(opts, anthropic.WithHTTPClient(httpClient))
 	}
 	return anthropic.New(opts...)
@@ -553,8 +651,7 @@ func (c *coordinator) buildOpenaiProvider(baseURL, apiKey string, headers map[st
 		openai.WithAPIKey(apiKey),
 		openai.WithUseResponsesAPI(),
 	}
-	if c.cfg.Options.Debug {
-		httpClient := log.NewHTTPClient()
+	if httpClient := c.providerHTTPClient(); httpClient != nil {
 		opts = append(opts, openai.WithHTTPClient(httpClient))
 	}
 	if len(headers) > 0 {
@@ -570,8 +667,7 @@ func (c *coordinator) buildOpenrouterProvider(_, apiKey string, headers map[stri
 	opts := []openrouter.Option{
 		openrouter.WithAPIKey(apiKey),
 	}
-	if c.cfg.Options.Debug {
-		httpClient := log.NewHTTPClient()
+	if httpClient := c.providerHTTPClient(); httpClient != nil {
 		opts = append(opts, openrouter.WithHTTPClient(httpClient))
 	}
 	if len(headers) > 0 {
@@ -585,8 +681,7 @@ func (c *coordinator) buildOpenaiCompatProvider(baseURL, apiKey string, headers
 		openaicompat.WithBaseURL(baseURL),
 		openaicompat.WithAPIKey(apiKey),
 	}
-	if c.cfg.Options.Debug {
-		httpClient := log.NewHTTPClient()
+	if httpClient := c.providerHTTPClient(); httpClient != nil {
 		opts = append(opts, openaicompat.WithHTTPClient(httpClient))
 	}
 	if len(headers) > 0 {
@@ -606,8 +701,7 @@ func (c *coordinator) buildAzureProvider(baseURL, apiKey string, headers map[str
 		azure.WithAPIKey(apiKey),
 		azure.WithUseResponsesAPI(),
 	}
-	if c.cfg.Options.Debug {
-		httpClient := log.NewHTTPClient()
+	if httpClient := c.providerHTTPClient(); httpClient != nil {
 		opts = append(opts, azure.WithHTTPClient(httpClient))
 	}
 	if options == nil {
@@ -625,8 +719,7 @@ func (c *coordinator) buildAzureProvider(baseURL, apiKey string, headers map[str
 
 func (c *coordinator) buildBedrockProvider(headers map[string]string) (fantasy.Provider, error) {
 	var opts []bedrock.Option
-	if c.cfg.Options.Debug {
-		httpClient := log.NewHTTPClient()
+	if httpClient := c.providerHTTPClient(); httpClient != nil {
 		opts = append(opts, bedrock.WithHTTPClient(httpClient))
 	}
 	if len(headers) > 0 {
@@ -644,8 +737,7 @@ func (c *coordinator) buildGoogleProvider(baseURL, apiKey string, headers map[st
 		google.WithBaseURL(baseURL),
 		google.WithGeminiAPIKey(apiKey),
 	}
-	if c.cfg.Options.Debug {
-		httpClient := log.NewHTTPClient()
+	if httpClient := c.providerHTTPClient(); httpClient != nil {
 		opts = append(opts, google.WithHTTPClient(httpClient))
 	}
 	if len(headers) > 0 {
@@ -656,8 +748,7 @@ func (c *coordinator) buildGoogleProvider(baseURL, apiKey string, headers map[st
 
 func (c *coordinator) buildGoogleVertexProvider(headers map[string]string, options map[string]string) (fantasy.Provider, error) {
 	opts := []google.Option{}
-	if c.cfg.Options.Debug {
-		httpClient := log.NewHTTPClient()
+	if httpClient := c.providerHTTPClient(); httpClient != nil {
 		opts = append(opts, google.WithHTTPClient(httpClient))
 	}
 	if len(headers) > 0 {
@@ -772,6 +863,34 @@ func (c *coordinator) ClearQueue(sessionID string) {
 	c.currentAgent.ClearQueue(sessionID)
 }
 
+func (c *coordinator) EditQueuedPrompt(sessionID string, index int, prompt string) error {
+	return c.currentAgent.EditQueuedPrompt(sessionID, index, prompt)
+}
+
+func (c *coordinator) MoveQueuedPrompt(sessionID string, index, to int) error {
+	return c.currentAgent.MoveQueuedPrompt(sessionID, index, to)
+}
+
+func (c *coordinator) RemoveQueuedPrompt(sessionID string, index int) error {
+	_, err := c.currentAgent.TakeQueuedPrompt(sessionID, index)
+	return err
+}
+
+// SendQueuedPromptNow cancels the current turn of a session to send its
+// queued prompt at index next. When the session is idle, which it is when
+// the prompt was queued before a restart, it is sent right away, after the
+// rest of the queue.
+func (c *coordinator) SendQueuedPromptNow(ctx context.Context, sessionID string, index int) (*fantasy.AgentResult, error) {
+	call, err := c.currentAgent.TakeQueuedPrompt(sessionID, index)
+	if err != nil {
+		return nil, err
+	}
+	if c.currentAgent.Interrupt(sessionID, call) {
+		return nil, nil
+	}
+	return c.Run(ctx, sessionID, call.Prompt, call.Attachments...)
+}
+
 func (c *coordinator) IsBusy() bool {
 	return c.currentAgent.IsBusy()
 }
@@ -852,3 +971,26 @@ func (c *coordinator) refreshApiKeyTemplate(ctx context.Context, providerCfg con
 	}
 	return nil
 }
+
+// providerHTTPClient returns the HTTP client of the providers, logging the
+// requests in debug mode and going through the recording when there is one.
+// It returns nil when the providers can use their default client.
+func (c *coordinator) providerHTTPClient() *http.Client {
+	if c.recording == nil && !c.cfg.Options.Debug {
+		return nil
+	}
+	transport := http.DefaultTransport
+	if c.recording != nil {
+		transport = c.recording.Transport()
+	}
+	if c.cfg.Options.Debug {
+		transport = &log.HTTPRoundTripLogger{Transport: transport}
+	}
+	return &http.Client{Transport: transport}
+}
+
+// webClient returns the HTTP client for a web tool. Requests go through the
+// shared response cache unless caching is disabled for that tool.
+func (c *coordinator) webClient(tool string, timeout time.Duration) *http.Client {
+	return c.httpCache.Client(tool, c.cfg.Options.HTTPCache.TTLFor(tool), timeout)
+}
@@ -3,8 +3,9 @@ package agent
 import "errors"
 
 var (
-	ErrRequestCancelled = errors.New("request canceled by user")
-	ErrSessionBusy      = errors.New("session is currently processing another request")
-	ErrEmptyPrompt      = errors.New("prompt is empty")
-	ErrSessionMissing   = errors.New("session id is missing")
+	ErrRequestCancelled     = errors.New("request canceled by user")
+	ErrSessionBusy          = errors.New("session is currently processing another request")
+	ErrEmptyPrompt          = errors.New("prompt is empty")
+	ErrSessionMissing       = errors.New("session id is missing")
+	ErrQueuedPromptNotFound = errors.New("queued prompt not found")
 )
@@ -0,0 +1,119 @@
+package agent
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/uglyswap/push/internal/config"
+	"github.com/uglyswap/push/internal/message"
+	"github.com/uglyswap/push/pkg/fantasy"
+)
+
+// maxProviderRetries is how many times a rate limited or failing model is
+// retried before switching to the next fallback model.
+const maxProviderRetries = 2
+
+// contextOverflowMessages are fragments providers use in their error
+// messages when the prompt does not fit the model's context window.
+var contextOverflowMessages = []string{
+	"context length",
+	"context window",
+	"context_length_exceeded",
+	"maximum context",
+	"prompt is too long",
+	"too many tokens",
+	"input is too long",
+}
+
+// canFailover reports whether a failed attempt may be repeated, either
+// against the same model or a fallback. Attempts that already produced tool
+// calls are never repeated since those may have had side effects.
+func canFailover(err error, assistant *message.Message) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	if assistant != nil && len(assistant.ToolCalls()) > 0 {
+		return false
+	}
+	return isTransientProviderError(err) || isContextOverflowError(err)
+}
+
+// isTransientProviderError reports whether the error is a rate limit, server
+// error or connection failure that may go away on its own.
+func isTransientProviderError(err error) bool {
+	var providerErr *fantasy.ProviderError
+	if !errors.As(err, &providerErr) {
+		return false
+	}
+	switch {
+	case providerErr.StatusCode == http.StatusTooManyRequests:
+		return true
+	case providerErr.StatusCode >= http.StatusInternalServerError:
+		return true
+	case providerErr.StatusCode == 0:
+		// No status means the request never got a response.
+		return true
+	}
+	return false
+}
+
+// isContextOverflowError reports whether the provider rejected the request
+// because it exceeds the model's context window.
+func isContextOverflowError(err error) bool {
+	var providerErr *fantasy.ProviderError
+	if !errors.As(err, &providerErr) {
+		return false
+	}
+	if providerErr.StatusCode == http.StatusRequestEntityTooLarge {
+		return true
+	}
+	msg := strings.ToLower(providerErr.Title + " " + providerErr.Message)
+	for _, fragment := range contextOverflowMessages {
+		if strings.Contains(msg, fragment) {
+			return true
+		}
+	}
+	return false
+}
+
+func providerRetryDelay(attempt int) time.Duration {
+	return time.Duration(1<<(attempt-1)) * time.Second
+}
+
+// callForModel returns a copy of call with the generation settings of the
+// given model, used when switching to a fallback model mid turn.
+func (a *sessionAgent) callForModel(call SessionAgentCall, model Model) SessionAgentCall {
+	call.MaxOutputTokens = cmp.Or(model.ModelCfg.MaxTokens, model.CatwalkCfg.DefaultMaxTokens)
+	providerCfg, ok := config.Get().Providers.Get(model.ModelCfg.Provider)
+	if !ok {
+		call.ProviderOptions = fantasy.ProviderOptions{}
+		call.Temperature = model.ModelCfg.Temperature
+		call.TopP = model.ModelCfg.TopP
+		call.TopK = model.ModelCfg.TopK
+		call.FrequencyPenalty = model.ModelCfg.FrequencyPenalty
+		call.PresencePenalty = model.ModelCfg.PresencePenalty
+		return call
+	}
+	call.ProviderOptions, call.Temperature, call.TopP, call.TopK, call.FrequencyPenalty, call.PresencePenalty = mergeCallOptions(model, providerCfg)
+	return call
+}
+
+// discardFailedAttempt cleans up the assistant message of a failed attempt.
+// Empty messages are removed, partial ones are closed with an error finish so
+// they stay visible but don't look like they are still streaming.
+func (a *sessionAgent) discardFailedAttempt(ctx context.Context, assistant *message.Message, cause error) error {
+	if assistant == nil {
+		return nil
+	}
+	if assistant.Content().Text == "" && assistant.ReasoningContent().Thinking == "" {
+		return a.messages.Delete(ctx, assistant.ID)
+	}
+	assistant.FinishThinking()
+	assistant.AddFinish(message.FinishReasonError, "Provider error", fmt.Sprintf("Retrying after error: %s", cause))
+	return a.messages.Update(ctx, *assistant)
+}
@@ -0,0 +1,115 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/uglyswap/push/internal/message"
+	"github.com/uglyswap/push/pkg/fantasy"
+)
+
+func TestCanFailover(t *testing.T) {
+	t.Parallel()
+
+	withToolCall := &message.Message{
+		Role:  message.Assistant,
+		Parts: []message.ContentPart{message.ToolCall{ID: "call", Name: "bash"}},
+	}
+	for _, tt := range []struct {
+		name      string
+		err       error
+		assistant *message.Message
+		want      bool
+	}{
+		{name: "rate limited", err: &fantasy.ProviderError{StatusCode: http.StatusTooManyRequests}, want: true},
+		{name: "server error", err: &fantasy.ProviderError{StatusCode: http.StatusBadGateway}, want: true},
+		{name: "no response", err: &fantasy.ProviderError{Message: "connection reset"}, want: true},
+		{name: "wrapped", err: fmt.Errorf("stream: %w", &fantasy.ProviderError{StatusCode: http.StatusServiceUnavailable}), want: true},
+		{name: "context overflow", err: contextOverflow(), want: true},
+		{name: "bad request", err: &fantasy.ProviderError{StatusCode: http.StatusBadRequest, Message: "invalid tool schema"}, want: false},
+		{name: "unauthorized", err: &fantasy.ProviderError{StatusCode: http.StatusUnauthorized}, want: false},
+		{name: "not a provider error", err: errors.New("boom"), want: false},
+		{name: "canceled", err: context.Canceled, want: false},
+		{name: "deadline exceeded", err: fmt.Errorf("stream: %w", context.DeadlineExceeded), want: false},
+		{name: "after tool calls", err: &fantasy.ProviderError{StatusCode: http.StatusTooManyRequests}, assistant: withToolCall, want: false},
+		{name: "after text", err: &fantasy.ProviderError{StatusCode: http.StatusTooManyRequests}, assistant: &message.Message{Role: message.Assistant}, want: true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			require.Equal(t, tt.want, canFailover(tt.err, tt.assistant))
+		})
+	}
+}
+
+func TestIsContextOverflowError(t *testing.T) {
+	t.Parallel()
+
+	for _, tt := range []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "entity too large", err: &fantasy.ProviderError{StatusCode: http.StatusRequestEntityTooLarge}, want: true},
+		{name: "anthropic", err: &fantasy.ProviderError{StatusCode: http.StatusBadRequest, Message: "prompt is too long: 210000 tokens > 200000 maximum"}, want: true},
+		{name: "openai", err: &fantasy.ProviderError{StatusCode: http.StatusBadRequest, Message: "This model's maximum context length is 128000 tokens."}, want: true},
+		{name: "in the title", err: &fantasy.ProviderError{StatusCode: http.StatusBadRequest, Title: "Context Window Exceeded"}, want: true},
+		{name: "wrapped", err: fmt.Errorf("stream: %w", &fantasy.ProviderError{Message: "context_length_exceeded"}), want: true},
+		{name: "other bad request", err: &fantasy.ProviderError{StatusCode: http.StatusBadRequest, Message: "invalid tool schema"}, want: false},
+		{name: "not a provider error", err: errors.New("prompt is too long"), want: false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			require.Equal(t, tt.want, isContextOverflowError(tt.err))
+		})
+	}
+}
+
+func TestRunFailover(t *testing.T) {
+	env := testEnv(t)
+
+	run := func(t *testing.T, agent *sessionAgent) ([]message.Message, error) {
+		sess, err := env.sessions.Create(t.Context(), "New Session")
+		require.NoError(t, err)
+		_, runErr := agent.Run(t.Context(), SessionAgentCall{SessionID: sess.ID, Prompt: "hello"})
+		msgs, err := env.messages.List(t.Context(), sess.ID)
+		require.NoError(t, err)
+		return msgs, runErr
+	}
+
+	t.Run("switches to the fallback model", func(t *testing.T) {
+		large := &stubModel{name: "large", errs: []error{contextOverflow()}}
+		fallback := &stubModel{name: "fallback"}
+		msgs, err := run(t, stubAgent(t, env, large, fallback))
+		require.NoError(t, err)
+		require.Equal(t, 1, large.Calls())
+		require.Equal(t, 1, fallback.Calls())
+
+		require.Len(t, msgs, 2, "the prompt is sent once and the failed attempt discarded")
+		require.Equal(t, message.User, msgs[0].Role)
+		require.Equal(t, "hello", msgs[0].Content().Text)
+		require.Equal(t, message.Assistant, msgs[1].Role)
+		require.Equal(t, "done", msgs[1].Content().Text)
+		require.Equal(t, "fallback", msgs[1].Model)
+	})
+
+	t.Run("retries transient errors first", func(t *testing.T) {
+		large := &stubModel{name: "large", errs: []error{&fantasy.ProviderError{StatusCode: http.StatusServiceUnavailable}}}
+		fallback := &stubModel{name: "fallback"}
+		msgs, err := run(t, stubAgent(t, env, large, fallback))
+		require.NoError(t, err)
+		require.Equal(t, 2, large.Calls())
+		require.Zero(t, fallback.Calls())
+		require.Equal(t, "large", msgs[len(msgs)-1].Model)
+	})
+
+	t.Run("fails without fallback", func(t *testing.T) {
+		large := &stubModel{name: "large", errs: []error{contextOverflow()}}
+		_, err := run(t, stubAgent(t, env, large))
+		require.True(t, isContextOverflowError(err))
+		require.Equal(t, 1, large.Calls())
+	})
+}
@@ -0,0 +1,146 @@
+package agent
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+
+	"github.com/uglyswap/push/internal/session"
+)
+
+// queue returns the calls queued for a session, loading the prompts stored
+// with it the first time. Callers hold queueMu.
+func (a *sessionAgent) queue(sessionID string) []SessionAgentCall {
+	if calls, ok := a.messageQueue.Get(sessionID); ok {
+		return calls
+	}
+	var calls []SessionAgentCall
+	if sess, err := a.sessions.Get(context.Background(), sessionID); err == nil {
+		for _, p := range sess.QueuedPrompts {
+			calls = append(calls, SessionAgentCall{
+				SessionID:   sessionID,
+				Prompt:      p.Prompt,
+				Attachments: p.Attachments,
+			})
+		}
+	}
+	a.messageQueue.Set(sessionID, calls)
+	return calls
+}
+
+// setQueue replaces the calls queued for a session and stores their prompts
+// with it, so they survive a restart. Callers hold queueMu.
+func (a *sessionAgent) setQueue(sessionID string, calls []SessionAgentCall) {
+	a.messageQueue.Set(sessionID, calls)
+	prompts := make([]session.QueuedPrompt, len(calls))
+	for i, call := range calls {
+		prompts[i] = session.QueuedPrompt{Prompt: call.Prompt, Attachments: call.Attachments}
+	}
+	if err := a.sessions.SetQueuedPrompts(context.Background(), sessionID, prompts); err != nil {
+		slog.Error("Failed to store queued prompts", "session_id", sessionID, "error", err)
+	}
+}
+
+func (a *sessionAgent) enqueue(call SessionAgentCall) {
+	a.queueMu.Lock()
+	defer a.queueMu.Unlock()
+	a.setQueue(call.SessionID, append(slices.Clone(a.queue(call.SessionID)), call))
+}
+
+// takeQueue empties the queue of a session and returns the calls it held.
+func (a *sessionAgent) takeQueue(sessionID string) []SessionAgentCall {
+	a.queueMu.Lock()
+	defer a.queueMu.Unlock()
+	calls := a.queue(sessionID)
+	if len(calls) > 0 {
+		a.setQueue(sessionID, nil)
+	}
+	return calls
+}
+
+// addQueuedMessages adds the prompts queued for a session to it as user
+// messages, emptying the queue.
+func (a *sessionAgent) addQueuedMessages(ctx context.Context, sessionID string) error {
+	for _, queued := range a.takeQueue(sessionID) {
+		if _, err := a.createUserMessage(ctx, queued); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// nextCall takes the call to run once call is done: the one an interrupt
+// sends, or else the first queued one. Restored calls do not have the
+// options of the model, so the next call keeps the ones of call.
+func (a *sessionAgent) nextCall(call SessionAgentCall) (SessionAgentCall, bool) {
+	next, ok := a.interrupts.Take(call.SessionID)
+	if !ok {
+		a.queueMu.Lock()
+		defer a.queueMu.Unlock()
+		calls := a.queue(call.SessionID)
+		if len(calls) == 0 {
+			return SessionAgentCall{}, false
+		}
+		next = calls[0]
+		a.setQueue(call.SessionID, calls[1:])
+	}
+	call.Prompt = next.Prompt
+	call.Attachments = next.Attachments
+	return call, true
+}
+
+func (a *sessionAgent) EditQueuedPrompt(sessionID string, index int, prompt string) error {
+	if prompt == "" {
+		return ErrEmptyPrompt
+	}
+	a.queueMu.Lock()
+	defer a.queueMu.Unlock()
+	calls := a.queue(sessionID)
+	if index < 0 || index >= len(calls) {
+		return ErrQueuedPromptNotFound
+	}
+	calls = slices.Clone(calls)
+	calls[index].Prompt = prompt
+	a.setQueue(sessionID, calls)
+	return nil
+}
+
+func (a *sessionAgent) MoveQueuedPrompt(sessionID string, index, to int) error {
+	a.queueMu.Lock()
+	defer a.queueMu.Unlock()
+	calls := a.queue(sessionID)
+	if index < 0 || index >= len(calls) {
+		return ErrQueuedPromptNotFound
+	}
+	to = max(0, min(to, len(calls)-1))
+	call := calls[index]
+	calls = slices.Delete(slices.Clone(calls), index, index+1)
+	a.setQueue(sessionID, slices.Insert(calls, to, call))
+	return nil
+}
+
+func (a *sessionAgent) TakeQueuedPrompt(sessionID string, index int) (SessionAgentCall, error) {
+	a.queueMu.Lock()
+	defer a.queueMu.Unlock()
+	calls := a.queue(sessionID)
+	if index < 0 || index >= len(calls) {
+		return SessionAgentCall{}, ErrQueuedPromptNotFound
+	}
+	call := calls[index]
+	a.setQueue(sessionID, slices.Delete(slices.Clone(calls), index, index+1))
+	return call, nil
+}
+
+// Interrupt cancels the current turn of a session and runs call once it
+// stopped, keeping the queue. It reports false when the session is idle.
+func (a *sessionAgent) Interrupt(sessionID string, call SessionAgentCall) bool {
+	a.interrupts.Set(sessionID, call)
+	cancel, ok := a.activeRequests.Take(sessionID)
+	if !ok || cancel == nil {
+		a.interrupts.Del(sessionID)
+		return false
+	}
+	slog.Info("Request interrupted by a queued prompt", "session_id", sessionID)
+	cancel()
+	return true
+}
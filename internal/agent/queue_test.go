@@ -0,0 +1,100 @@
+package agent
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/uglyswap/push/internal/message"
+)
+
+func TestQueuedPrompts(t *testing.T) {
+	env := testEnv(t)
+
+	t.Run("kept across restarts and sent first", func(t *testing.T) {
+		sess, err := env.sessions.Create(t.Context(), "New Session")
+		require.NoError(t, err)
+
+		agent := stubAgent(t, env, &stubModel{name: "large"})
+		agent.activeRequests.Set(sess.ID, func() {})
+		for _, prompt := range []string{"first", "second"} {
+			_, err := agent.Run(t.Context(), SessionAgentCall{SessionID: sess.ID, Prompt: prompt})
+			require.NoError(t, err)
+		}
+		stored, err := env.sessions.Get(t.Context(), sess.ID)
+		require.NoError(t, err)
+		require.Len(t, stored.QueuedPrompts, 2)
+
+		restarted := stubAgent(t, env, &stubModel{name: "large"})
+		require.Equal(t, []string{"first", "second"}, restarted.QueuedPromptsList(sess.ID))
+
+		_, err = restarted.Run(t.Context(), SessionAgentCall{SessionID: sess.ID, Prompt: "third"})
+		require.NoError(t, err)
+		require.Zero(t, restarted.QueuedPrompts(sess.ID))
+
+		msgs, err := env.messages.List(t.Context(), sess.ID)
+		require.NoError(t, err)
+		var prompts []string
+		for _, msg := range msgs {
+			if msg.Role == message.User {
+				prompts = append(prompts, msg.Content().Text)
+			}
+		}
+		require.Equal(t, []string{"first", "second", "third"}, prompts)
+
+		stored, err = env.sessions.Get(t.Context(), sess.ID)
+		require.NoError(t, err)
+		require.Empty(t, stored.QueuedPrompts)
+	})
+
+	t.Run("interrupt runs next, ahead of the queue", func(t *testing.T) {
+		sess, err := env.sessions.Create(t.Context(), "New Session")
+		require.NoError(t, err)
+		agent := stubAgent(t, env, &stubModel{name: "large"})
+		agent.enqueue(SessionAgentCall{SessionID: sess.ID, Prompt: "queued"})
+
+		require.False(t, agent.Interrupt(sess.ID, SessionAgentCall{SessionID: sess.ID, Prompt: "now"}))
+		_, pending := agent.interrupts.Get(sess.ID)
+		require.False(t, pending, "an idle session keeps no interrupt")
+
+		var canceled bool
+		agent.activeRequests.Set(sess.ID, func() { canceled = true })
+		require.True(t, agent.Interrupt(sess.ID, SessionAgentCall{SessionID: sess.ID, Prompt: "now"}))
+		require.True(t, canceled)
+
+		running := SessionAgentCall{SessionID: sess.ID, Prompt: "running", MaxOutputTokens: 42}
+		next, ok := agent.nextCall(running)
+		require.True(t, ok)
+		require.Equal(t, "now", next.Prompt)
+		require.Equal(t, int64(42), next.MaxOutputTokens, "the next call keeps the options of the running one")
+		require.Equal(t, []string{"queued"}, agent.QueuedPromptsList(sess.ID))
+
+		next, ok = agent.nextCall(running)
+		require.True(t, ok)
+		require.Equal(t, "queued", next.Prompt)
+		require.Zero(t, agent.QueuedPrompts(sess.ID))
+
+		_, ok = agent.nextCall(running)
+		require.False(t, ok)
+	})
+
+	t.Run("move clamps the destination", func(t *testing.T) {
+		sess, err := env.sessions.Create(t.Context(), "New Session")
+		require.NoError(t, err)
+		agent := stubAgent(t, env, &stubModel{name: "large"})
+		for _, prompt := range []string{"a", "b", "c"} {
+			agent.enqueue(SessionAgentCall{SessionID: sess.ID, Prompt: prompt})
+		}
+
+		require.NoError(t, agent.MoveQueuedPrompt(sess.ID, 0, 10))
+		require.Equal(t, []string{"b", "c", "a"}, agent.QueuedPromptsList(sess.ID))
+		require.NoError(t, agent.MoveQueuedPrompt(sess.ID, 2, -5))
+		require.Equal(t, []string{"a", "b", "c"}, agent.QueuedPromptsList(sess.ID))
+		require.ErrorIs(t, agent.MoveQueuedPrompt(sess.ID, 3, 0), ErrQueuedPromptNotFound)
+		require.ErrorIs(t, agent.MoveQueuedPrompt(sess.ID, -1, 0), ErrQueuedPromptNotFound)
+
+		stored, err := env.sessions.Get(t.Context(), sess.ID)
+		require.NoError(t, err)
+		require.Len(t, stored.QueuedPrompts, 3)
+		require.Equal(t, "a", stored.QueuedPrompts[0].Prompt)
+	})
+}
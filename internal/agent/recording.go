@@ -0,0 +1,21 @@
+package agent
+
+import (
+	"context"
+
+	"github.com/uglyswap/push/internal/recording"
+	"github.com/uglyswap/push/pkg/fantasy"
+)
+
+// recordedTool stores the results of a tool in the recording of the run, or
+// answers from it when the run is replayed.
+type recordedTool struct {
+	fantasy.AgentTool
+	recording *recording.Recording
+}
+
+func (t recordedTool) Execute(ctx context.Context, input string) (fantasy.ToolResultOutput, error) {
+	return t.recording.Tool(t.Name(), input, func() (fantasy.ToolResultOutput, error) {
+		return t.AgentTool.Execute(ctx, input)
+	})
+}
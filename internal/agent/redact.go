@@ -0,0 +1,71 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/uglyswap/push/internal/message"
+	"github.com/uglyswap/push/internal/redact"
+	"github.com/uglyswap/push/pkg/fantasy"
+)
+
+// redactedTool masks the secrets in the results of a tool before they reach
+// the model.
+type redactedTool struct {
+	fantasy.AgentTool
+	redactor *redact.Redactor
+}
+
+func (t redactedTool) Execute(ctx context.Context, input string) (fantasy.ToolResultOutput, error) {
+	output, err := t.AgentTool.Execute(ctx, input)
+	if err != nil {
+		return output, err
+	}
+	return redactToolOutput(t.redactor, output), nil
+}
+
+func redactToolOutput(r *redact.Redactor, output fantasy.ToolResultOutput) fantasy.ToolResultOutput {
+	switch out := output.(type) {
+	case fantasy.ToolResultOutputContentText:
+		out.Text = r.Redact(out.Text)
+		return out
+	case fantasy.ToolResultOutputContentError:
+		if out.Error != nil {
+			if redacted := r.Redact(out.Error.Error()); redacted != out.Error.Error() {
+				out.Error = errors.New(redacted)
+			}
+		}
+		return out
+	case fantasy.ToolResultOutputContentMedia:
+		out.Text = r.Redact(out.Text)
+		return out
+	}
+	return output
+}
+
+// redactMetadata masks the secrets in the JSON metadata of a tool result,
+// such as the file contents of the edit tools. The metadata is dropped if
+// redacting it would break its JSON.
+func redactMetadata(r *redact.Redactor, metadata string) string {
+	redacted := r.Redact(metadata)
+	if redacted != metadata && !json.Valid([]byte(redacted)) {
+		return ""
+	}
+	return redacted
+}
+
+// redactAttachments masks the secrets in the text attachments of a prompt.
+func redactAttachments(r *redact.Redactor, attachments []message.Attachment) []message.Attachment {
+	if r == nil || len(attachments) == 0 {
+		return attachments
+	}
+	redacted := make([]message.Attachment, len(attachments))
+	for i, attachment := range attachments {
+		if attachment.IsText() {
+			attachment.Content = []byte(r.Redact(string(attachment.Content)))
+		}
+		redacted[i] = attachment
+	}
+	return redacted
+}
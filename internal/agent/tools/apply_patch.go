@@ -0,0 +1,201 @@
+package tools
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/uglyswap/push/pkg/fantasy"
+	"github.com/uglyswap/push/internal/csync"
+	"github.com/uglyswap/push/internal/filepathext"
+	"github.com/uglyswap/push/internal/fsext"
+	"github.com/uglyswap/push/internal/history"
+	"github.com/uglyswap/push/internal/lsp"
+	"github.com/uglyswap/push/internal/patch"
+	"github.com/uglyswap/push/internal/permission"
+)
+
+type ApplyPatchParams struct {
+	Patch string `json:"patch" description:"The patch to apply, either a unified diff or a structured patch between '*** Begin Patch' and '*** End Patch'"`
+}
+
+const ApplyPatchToolName = "apply_patch"
+
+//go:embed apply_patch.md
+var applyPatchDescription []byte
+
+func NewApplyPatchTool(lspClients *csync.Map[string, *lsp.Client], permissions permission.Service, files history.Service, workingDir string) fantasy.AgentTool {
+	return fantasy.NewAgentTool(
+		ApplyPatchToolName,
+		string(applyPatchDescription),
+		func(ctx context.Context, params ApplyPatchParams, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+			workingDir := GetWorkingDirFromContext(ctx, workingDir)
+			if params.Patch == "" {
+				return fantasy.NewTextErrorResponse("patch is required"), nil
+			}
+
+			patchFiles, err := patch.Parse(params.Patch)
+			if err != nil {
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("invalid patch: %s", err)), nil
+			}
+
+			unredactPatch(ctx, patchFiles)
+
+			changes, err := patchChanges(patchFiles, workingDir)
+			if err != nil {
+				return fantasy.NewTextErrorResponse(err.Error()), nil
+			}
+
+			// Secrets of any file of the patch may be moved around freely.
+			var existing strings.Builder
+			for _, change := range changes {
+				existing.WriteString(change.OldContent)
+			}
+			for i, change := range changes {
+				if change.Deleted {
+					continue
+				}
+				changes[i].NewContent, err = unredactWrite(ctx, permissions, call.ID, ApplyPatchToolName, change.Path, existing.String(), change.NewContent)
+				if err != nil {
+					return fantasy.ToolResponse{}, err
+				}
+			}
+
+			description := fmt.Sprintf("Apply patch to %d file(s)", len(patchFiles))
+			f := fileChanges{ctx, permissions, files, lspClients, workingDir}
+			meta, err := f.apply(ApplyPatchToolName, description, call, changes)
+			if err != nil {
+				return fantasy.ToolResponse{}, err
+			}
+
+			text := fmt.Sprintf("<result>\nPatch applied: changed %d file(s)\n%s</result>\n", len(changes), formatFileChanges(changes, workingDir))
+			for _, change := range changes {
+				if !change.Deleted {
+					text += getDiagnostics(change.Path, lspClients)
+					break
+				}
+			}
+			return fantasy.WithResponseMetadata(fantasy.NewTextResponse(text), meta), nil
+		})
+}
+
+// unredactPatch substitutes the placeholders of the lines locating the hunks
+// back with their secrets, so that they match the files. Added lines are
+// left to unredactWrite.
+func unredactPatch(ctx context.Context, patchFiles []patch.File) {
+	for i := range patchFiles {
+		for j := range patchFiles[i].Hunks {
+			hunk := &patchFiles[i].Hunks[j]
+			hunk.Anchor = unredactMatch(ctx, hunk.Anchor)
+			for k := range hunk.Lines {
+				if hunk.Lines[k].Kind != '+' {
+					hunk.Lines[k].Text = unredactMatch(ctx, hunk.Lines[k].Text)
+				}
+			}
+		}
+	}
+}
+
+// patchChanges computes the changes of every file of the patch without
+// writing anything, so that a hunk failing to apply leaves all files as they
+// were.
+func patchChanges(patchFiles []patch.File, workingDir string) ([]FileChange, error) {
+	var changes []FileChange
+	seen := make(map[string]bool)
+	claim := func(path string) error {
+		if seen[path] {
+			return fmt.Errorf("%s is changed more than once in the patch", path)
+		}
+		seen[path] = true
+		return nil
+	}
+
+	for _, pf := range patchFiles {
+		path := filepathext.SmartJoin(workingDir, pf.Path)
+		if err := claim(path); err != nil {
+			return nil, err
+		}
+
+		if pf.Operation == patch.OperationAdd {
+			if _, err := os.Stat(path); err == nil {
+				return nil, fmt.Errorf("file already exists: %s", path)
+			}
+			changes = append(changes, FileChange{Path: path, NewContent: pf.Content, Created: true})
+			continue
+		}
+
+		oldContent, err := readPatchedFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		if pf.Operation == patch.OperationDelete {
+			changes = append(changes, FileChange{Path: path, OldContent: oldContent, Deleted: true})
+			continue
+		}
+
+		content, isCrlf := fsext.ToUnixLineEndings(oldContent)
+		newContent, err := patch.Apply(content, pf.Hunks)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		if isCrlf {
+			newContent, _ = fsext.ToWindowsLineEndings(newContent)
+		}
+
+		if pf.MoveTo == "" {
+			if newContent == oldContent {
+				return nil, fmt.Errorf("%s: patch does not change the file", path)
+			}
+			changes = append(changes, FileChange{Path: path, OldContent: oldContent, NewContent: newContent})
+			continue
+		}
+
+		target := filepathext.SmartJoin(workingDir, pf.MoveTo)
+		if err := claim(target); err != nil {
+			return nil, err
+		}
+		if _, err := os.Stat(target); err == nil {
+			return nil, fmt.Errorf("cannot move %s: %s already exists", path, target)
+		}
+		changes = append(changes,
+			FileChange{Path: path, OldContent: oldContent, Deleted: true},
+			FileChange{Path: target, NewContent: newContent, Created: true},
+		)
+	}
+	return changes, nil
+}
+
+// readPatchedFile returns the content of a file updated or deleted by the
+// patch, which must have been read since it was last modified.
+func readPatchedFile(path string) (string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", fmt.Errorf("file not found: %s", path)
+		}
+		return "", fmt.Errorf("failed to access file: %w", err)
+	}
+	if info.IsDir() {
+		return "", fmt.Errorf("path is a directory, not a file: %s", path)
+	}
+
+	lastRead := getLastReadTime(path)
+	if lastRead.IsZero() {
+		return "", fmt.Errorf("you must read %s before patching it. Use the View tool first", path)
+	}
+	if modTime := info.ModTime(); modTime.After(lastRead) {
+		return "", fmt.Errorf("file %s has been modified since it was last read (mod time: %s, last read: %s)",
+			path, modTime.Format(time.RFC3339), lastRead.Format(time.RFC3339),
+		)
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read file: %w", err)
+	}
+	return string(content), nil
+}
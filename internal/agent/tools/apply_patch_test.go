@@ -0,0 +1,77 @@
+package tools
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/uglyswap/push/internal/patch"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPatchChanges(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	updated := filepath.Join(dir, "updated.go")
+	moved := filepath.Join(dir, "moved.go")
+	deleted := filepath.Join(dir, "deleted.go")
+	require.NoError(t, os.WriteFile(updated, []byte("package main\n\nfunc a() {}\n"), 0o644))
+	require.NoError(t, os.WriteFile(moved, []byte("package main\n"), 0o644))
+	require.NoError(t, os.WriteFile(deleted, []byte("gone\n"), 0o644))
+	for _, path := range []string{updated, moved, deleted} {
+		recordFileRead(path)
+	}
+
+	files, err := patch.Parse(`*** Begin Patch
+*** Update File: updated.go
+@@
+-func a() {}
++func b() {}
+*** Update File: moved.go
+*** Move to: sub/moved.go
+*** Delete File: deleted.go
+*** Add File: added.go
++package main
+*** End Patch
+`)
+	require.NoError(t, err)
+
+	changes, err := patchChanges(files, dir)
+	require.NoError(t, err)
+	require.Equal(t, []FileChange{
+		{Path: updated, OldContent: "package main\n\nfunc a() {}\n", NewContent: "package main\n\nfunc b() {}\n"},
+		{Path: moved, OldContent: "package main\n", Deleted: true},
+		{Path: filepath.Join(dir, "sub", "moved.go"), NewContent: "package main\n", Created: true},
+		{Path: deleted, OldContent: "gone\n", Deleted: true},
+		{Path: filepath.Join(dir, "added.go"), NewContent: "package main\n", Created: true},
+	}, changes)
+}
+
+func TestPatchChangesErrors(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "main.go")
+	require.NoError(t, os.WriteFile(path, []byte("package main\n"), 0o644))
+
+	parse := func(text string) []patch.File {
+		files, err := patch.Parse(text)
+		require.NoError(t, err)
+		return files
+	}
+	update := parse("*** Begin Patch\n*** Update File: main.go\n-package main\n+package foo\n*** End Patch\n")
+
+	_, err := patchChanges(update, dir)
+	require.ErrorContains(t, err, "you must read")
+
+	recordFileRead(path)
+	_, err = patchChanges(parse("*** Begin Patch\n*** Update File: main.go\n-package other\n+package foo\n*** End Patch\n"), dir)
+	require.ErrorContains(t, err, "lines not found")
+
+	_, err = patchChanges(parse("*** Begin Patch\n*** Add File: main.go\n+package main\n*** End Patch\n"), dir)
+	require.ErrorContains(t, err, "already exists")
+
+	_, err = patchChanges(append(update, update...), dir)
+	require.ErrorContains(t, err, "more than once")
+}
@@ -64,6 +64,10 @@ type bashDescriptionData struct {
 	MaxOutputLength int
 	Attribution     config.Attribution
 	ModelName       string
+	// GeneratedWith and Trailer are the attribution lines, shared with the
+	// checkpoint commits.
+	GeneratedWith template.HTML
+	Trailer       template.HTML
 }
 
 var bannedCommands = []string{
@@ -147,6 +151,8 @@ func bashDescription(attribution *config.Attribution, modelName string) string {
 		MaxOutputLength: MaxOutputLength,
 		Attribution:     *attribution,
 		ModelName:       modelName,
+		GeneratedWith:   template.HTML(attribution.GeneratedWithLine()),
+		Trailer:         template.HTML(attribution.Trailer(modelName)),
 	}); err != nil {
 		// this should never happen.
 		panic("failed to execute bash description template: " + err.Error())
@@ -191,6 +197,7 @@ func NewBashTool(permissions permission.Service, workingDir string, attribution
 		BashToolName,
 		string(bashDescription(attribution, modelName)),
 		func(ctx context.Context, params BashParams, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+			workingDir := GetWorkingDirFromContext(ctx, workingDir)
 			if params.Command == "" {
 				return fantasy.NewTextErrorResponse("missing command"), nil
 			}
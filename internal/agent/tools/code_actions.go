@@ -0,0 +1,125 @@
+package tools
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+	"strings"
+
+	"github.com/uglyswap/push/pkg/fantasy"
+	"github.com/uglyswap/push/internal/csync"
+	"github.com/uglyswap/push/internal/filepathext"
+	"github.com/uglyswap/push/internal/history"
+	"github.com/uglyswap/push/internal/lsp"
+	"github.com/uglyswap/push/internal/permission"
+	"github.com/charmbracelet/x/powernap/pkg/lsp/protocol"
+)
+
+type CodeActionsParams struct {
+	FilePath string `json:"file_path" description:"The path to the file"`
+	Line     int    `json:"line" description:"The first line (1-based) of the range"`
+	EndLine  int    `json:"end_line,omitempty" description:"The last line (1-based) of the range, defaults to line"`
+	Kind     string `json:"kind,omitempty" description:"Only return actions of this kind, e.g. quickfix, refactor or source.organizeImports"`
+	Title    string `json:"title,omitempty" description:"The title of the action to apply. Leave empty to list the available actions"`
+}
+
+const CodeActionsToolName = "lsp_code_actions"
+
+//go:embed code_actions.md
+var codeActionsDescription []byte
+
+func NewCodeActionsTool(lspClients *csync.Map[string, *lsp.Client], permissions permission.Service, files history.Service, workingDir string) fantasy.AgentTool {
+	return fantasy.NewAgentTool(
+		CodeActionsToolName,
+		string(codeActionsDescription),
+		func(ctx context.Context, params CodeActionsParams, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+			workingDir := GetWorkingDirFromContext(ctx, workingDir)
+			if params.FilePath == "" {
+				return fantasy.NewTextErrorResponse("file_path is required"), nil
+			}
+			if params.Line < 1 {
+				return fantasy.NewTextErrorResponse("line must be 1 or greater"), nil
+			}
+			endLine := max(params.EndLine, params.Line)
+
+			filePath := filepathext.SmartJoin(workingDir, params.FilePath)
+			client := clientForFile(lspClients, filePath)
+			if client == nil {
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("no LSP client handles %s", filePath)), nil
+			}
+
+			var only []protocol.CodeActionKind
+			if params.Kind != "" {
+				only = []protocol.CodeActionKind{protocol.CodeActionKind(params.Kind)}
+			}
+			actions, err := client.CodeActions(ctx, filePath, params.Line, endLine, only)
+			if err != nil {
+				return fantasy.NewTextErrorResponse(err.Error()), nil
+			}
+			if len(actions) == 0 {
+				return fantasy.NewTextResponse("No code actions available."), nil
+			}
+
+			if params.Title == "" {
+				return fantasy.NewTextResponse(formatCodeActions(actions)), nil
+			}
+
+			action, ok := findCodeAction(actions, params.Title)
+			if !ok {
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("no code action titled '%s'. Available actions:\n%s", params.Title, formatCodeActions(actions))), nil
+			}
+			if action.Disabled != nil {
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("code action '%s' is disabled: %s", action.Title, action.Disabled.Reason)), nil
+			}
+			action, err = client.ResolveCodeAction(ctx, action)
+			if err != nil {
+				return fantasy.NewTextErrorResponse(err.Error()), nil
+			}
+			if action.Edit == nil {
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("code action '%s' runs a server command and cannot be applied", action.Title)), nil
+			}
+
+			return applyWorkspaceEdit(
+				fileChanges{ctx, permissions, files, lspClients, workingDir},
+				CodeActionsToolName,
+				fmt.Sprintf("Apply code action %q", action.Title),
+				call,
+				*action.Edit,
+			)
+		})
+}
+
+func findCodeAction(actions []protocol.CodeAction, title string) (protocol.CodeAction, bool) {
+	for _, action := range actions {
+		if action.Title == title {
+			return action, true
+		}
+	}
+	for _, action := range actions {
+		if strings.EqualFold(strings.TrimSpace(action.Title), strings.TrimSpace(title)) {
+			return action, true
+		}
+	}
+	return protocol.CodeAction{}, false
+}
+
+func formatCodeActions(actions []protocol.CodeAction) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Found %d code action(s):\n\n", len(actions))
+	for _, action := range actions {
+		fmt.Fprintf(&sb, "- %s", action.Title)
+		if action.Kind != "" {
+			fmt.Fprintf(&sb, " (%s)", action.Kind)
+		}
+		if action.IsPreferred {
+			sb.WriteString(" [preferred]")
+		}
+		if action.Disabled != nil {
+			fmt.Fprintf(&sb, " [disabled: %s]", action.Disabled.Reason)
+		} else if action.Edit == nil && action.Data == nil && action.Command != nil {
+			sb.WriteString(" [command, cannot be applied]")
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
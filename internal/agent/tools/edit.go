@@ -48,6 +48,7 @@ var editDescription []byte
 
 type editContext struct {
 	ctx         context.Context
+	lspClients  *csync.Map[string, *lsp.Client]
 	permissions permission.Service
 	files       history.Service
 	workingDir  string
@@ -58,16 +59,18 @@ func NewEditTool(lspClients *csync.Map[string, *lsp.Client], permissions permiss
 		EditToolName,
 		string(editDescription),
 		func(ctx context.Context, params EditParams, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+			workingDir := GetWorkingDirFromContext(ctx, workingDir)
 			if params.FilePath == "" {
 				return fantasy.NewTextErrorResponse("file_path is required"), nil
 			}
 
 			params.FilePath = filepathext.SmartJoin(workingDir, params.FilePath)
+			params.OldString = unredactMatch(ctx, params.OldString)
 
 			var response fantasy.ToolResponse
 			var err error
 
-			editCtx := editContext{ctx, permissions, files, workingDir}
+			editCtx := editContext{ctx, lspClients, permissions, files, workingDir}
 
 			if params.OldString == "" {
 				response, err = createNewFile(editCtx, params.FilePath, params.NewString, call)
@@ -116,12 +119,18 @@ func createNewFile(edit editContext, filePath, content string, call fantasy.Tool
 		return fantasy.ToolResponse{}, fmt.Errorf("session ID is required for creating a new file")
 	}
 
+	content, err = unredactWrite(edit.ctx, edit.permissions, call.ID, EditToolName, filePath, "", content)
+	if err != nil {
+		return fantasy.ToolResponse{}, err
+	}
+
 	_, additions, removals := diff.GenerateDiff(
 		"",
 		content,
 		strings.TrimPrefix(filePath, edit.workingDir),
 	)
-	p := edit.permissions.Request(
+	content, note, err := requestWrite(
+		edit.permissions,
 		permission.CreatePermissionRequest{
 			SessionID:   sessionID,
 			Path:        fsext.PathOrPrefix(filePath, edit.workingDir),
@@ -135,9 +144,13 @@ func createNewFile(edit editContext, filePath, content string, call fantasy.Tool
 				NewContent: content,
 			},
 		},
+		content,
 	)
-	if !p {
-		return fantasy.ToolResponse{}, permission.ErrorPermissionDenied
+	if err != nil {
+		return fantasy.ToolResponse{}, err
+	}
+	if note != "" {
+		_, additions, removals = diff.GenerateDiff("", content, strings.TrimPrefix(filePath, edit.workingDir))
 	}
 
 	err = os.WriteFile(filePath, []byte(content), 0o644)
@@ -145,6 +158,12 @@ func createNewFile(edit editContext, filePath, content string, call fantasy.Tool
 		return fantasy.ToolResponse{}, fmt.Errorf("failed to write file: %w", err)
 	}
 
+	if formatted := formatOnWrite(edit.ctx, edit.lspClients, filePath, content); formatted != content {
+		note += formattedNote
+		content = formatted
+		_, additions, removals = diff.GenerateDiff("", content, strings.TrimPrefix(filePath, edit.workingDir))
+	}
+
 	// File can't be in the history so we create a new file history
 	_, err = edit.files.Create(edit.ctx, sessionID, filePath, "")
 	if err != nil {
@@ -153,7 +172,7 @@ func createNewFile(edit editContext, filePath, content string, call fantasy.Tool
 	}
 
 	// Add the new content to the file history
-	_, err = edit.files.CreateVersion(edit.ctx, sessionID, filePath, content)
+	_, err = edit.files.CreateVersion(edit.ctx, sessionID, filePath, content, call.ID)
 	if err != nil {
 		// Log error but don't fail the operation
 		slog.Error("Error creating file history version", "error", err)
@@ -163,7 +182,7 @@ func createNewFile(edit editContext, filePath, content string, call fantasy.Tool
 	recordFileRead(filePath)
 
 	return fantasy.WithResponseMetadata(
-		fantasy.NewTextResponse("File created: "+filePath),
+		fantasy.NewTextResponse("File created: "+filePath+note),
 		EditResponseMetadata{
 			OldContent: "",
 			NewContent: content,
@@ -206,28 +225,9 @@ func deleteContent(edit editContext, filePath, oldString string, replaceAll bool
 
 	oldContent, isCrlf := fsext.ToUnixLineEndings(string(content))
 
-	var newContent string
-	var deletionCount int
-
-	if replaceAll {
-		newContent = strings.ReplaceAll(oldContent, oldString, "")
-		deletionCount = strings.Count(oldContent, oldString)
-		if deletionCount == 0 {
-			return fantasy.NewTextErrorResponse("old_string not found in file. Make sure it matches exactly, including whitespace and line breaks"), nil
-		}
-	} else {
-		index := strings.Index(oldContent, oldString)
-		if index == -1 {
-			return fantasy.NewTextErrorResponse("old_string not found in file. Make sure it matches exactly, including whitespace and line breaks"), nil
-		}
-
-		lastIndex := strings.LastIndex(oldContent, oldString)
-		if index != lastIndex {
-			return fantasy.NewTextErrorResponse("old_string appears multiple times in the file. Please provide more context to ensure a unique match, or set replace_all to true"), nil
-		}
-
-		newContent = oldContent[:index] + oldContent[index+len(oldString):]
-		deletionCount = 1
+	newContent, err := replaceText(oldContent, oldString, "", replaceAll)
+	if err != nil {
+		return fantasy.NewTextErrorResponse(err.Error()), nil
 	}
 
 	sessionID := GetSessionFromContext(edit.ctx)
@@ -242,7 +242,8 @@ func deleteContent(edit editContext, filePath, oldString string, replaceAll bool
 		strings.TrimPrefix(filePath, edit.workingDir),
 	)
 
-	p := edit.permissions.Request(
+	newContent, note, err := requestWrite(
+		edit.permissions,
 		permission.CreatePermissionRequest{
 			SessionID:   sessionID,
 			Path:        fsext.PathOrPrefix(filePath, edit.workingDir),
@@ -256,9 +257,13 @@ func deleteContent(edit editContext, filePath, oldString string, replaceAll bool
 				NewContent: newContent,
 			},
 		},
+		newContent,
 	)
-	if !p {
-		return fantasy.ToolResponse{}, permission.ErrorPermissionDenied
+	if err != nil {
+		return fantasy.ToolResponse{}, err
+	}
+	if note != "" {
+		_, additions, removals = diff.GenerateDiff(oldContent, newContent, strings.TrimPrefix(filePath, edit.workingDir))
 	}
 
 	if isCrlf {
@@ -270,6 +275,12 @@ func deleteContent(edit editContext, filePath, oldString string, replaceAll bool
 		return fantasy.ToolResponse{}, fmt.Errorf("failed to write file: %w", err)
 	}
 
+	if formatted := formatOnWrite(edit.ctx, edit.lspClients, filePath, newContent); formatted != newContent {
+		note += formattedNote
+		newContent = formatted
+		_, additions, removals = diff.GenerateDiff(oldContent, newContent, strings.TrimPrefix(filePath, edit.workingDir))
+	}
+
 	// Check if file exists in history
 	file, err := edit.files.GetByPathAndSession(edit.ctx, filePath, sessionID)
 	if err != nil {
@@ -281,13 +292,13 @@ func deleteContent(edit editContext, filePath, oldString string, replaceAll bool
 	}
 	if file.Content != oldContent {
 		// User Manually changed the content store an intermediate version
-		_, err = edit.files.CreateVersion(edit.ctx, sessionID, filePath, oldContent)
+		_, err = edit.files.CreateVersion(edit.ctx, sessionID, filePath, oldContent, "")
 		if err != nil {
 			slog.Error("Error creating file history version", "error", err)
 		}
 	}
 	// Store the new version
-	_, err = edit.files.CreateVersion(edit.ctx, sessionID, filePath, "")
+	_, err = edit.files.CreateVersion(edit.ctx, sessionID, filePath, newContent, call.ID)
 	if err != nil {
 		slog.Error("Error creating file history version", "error", err)
 	}
@@ -296,7 +307,7 @@ func deleteContent(edit editContext, filePath, oldString string, replaceAll bool
 	recordFileRead(filePath)
 
 	return fantasy.WithResponseMetadata(
-		fantasy.NewTextResponse("Content deleted from file: "+filePath),
+		fantasy.NewTextResponse("Content deleted from file: "+filePath+note),
 		EditResponseMetadata{
 			OldContent: oldContent,
 			NewContent: newContent,
@@ -339,33 +350,18 @@ func replaceContent(edit editContext, filePath, oldString, newString string, rep
 
 	oldContent, isCrlf := fsext.ToUnixLineEndings(string(content))
 
-	var newContent string
-	var replacementCount int
-
-	if replaceAll {
-		newContent = strings.ReplaceAll(oldContent, oldString, newString)
-		replacementCount = strings.Count(oldContent, oldString)
-		if replacementCount == 0 {
-			return fantasy.NewTextErrorResponse("old_string not found in file. Make sure it matches exactly, including whitespace and line breaks"), nil
-		}
-	} else {
-		index := strings.Index(oldContent, oldString)
-		if index == -1 {
-			return fantasy.NewTextErrorResponse("old_string not found in file. Make sure it matches exactly, including whitespace and line breaks"), nil
-		}
-
-		lastIndex := strings.LastIndex(oldContent, oldString)
-		if index != lastIndex {
-			return fantasy.NewTextErrorResponse("old_string appears multiple times in the file. Please provide more context to ensure a unique match, or set replace_all to true"), nil
-		}
-
-		newContent = oldContent[:index] + newString + oldContent[index+len(oldString):]
-		replacementCount = 1
+	newContent, err := replaceText(oldContent, oldString, newString, replaceAll)
+	if err != nil {
+		return fantasy.NewTextErrorResponse(err.Error()), nil
 	}
 
 	if oldContent == newContent {
 		return fantasy.NewTextErrorResponse("new content is the same as old content. No changes made."), nil
 	}
+	newContent, err = unredactWrite(edit.ctx, edit.permissions, call.ID, EditToolName, filePath, oldContent, newContent)
+	if err != nil {
+		return fantasy.ToolResponse{}, err
+	}
 	sessionID := GetSessionFromContext(edit.ctx)
 
 	if sessionID == "" {
@@ -377,7 +373,8 @@ func replaceContent(edit editContext, filePath, oldString, newString string, rep
 		strings.TrimPrefix(filePath, edit.workingDir),
 	)
 
-	p := edit.permissions.Request(
+	newContent, note, err := requestWrite(
+		edit.permissions,
 		permission.CreatePermissionRequest{
 			SessionID:   sessionID,
 			Path:        fsext.PathOrPrefix(filePath, edit.workingDir),
@@ -391,9 +388,13 @@ func replaceContent(edit editContext, filePath, oldString, newString string, rep
 				NewContent: newContent,
 			},
 		},
+		newContent,
 	)
-	if !p {
-		return fantasy.ToolResponse{}, permission.ErrorPermissionDenied
+	if err != nil {
+		return fantasy.ToolResponse{}, err
+	}
+	if note != "" {
+		_, additions, removals = diff.GenerateDiff(oldContent, newContent, strings.TrimPrefix(filePath, edit.workingDir))
 	}
 
 	if isCrlf {
@@ -405,6 +406,12 @@ func replaceContent(edit editContext, filePath, oldString, newString string, rep
 		return fantasy.ToolResponse{}, fmt.Errorf("failed to write file: %w", err)
 	}
 
+	if formatted := formatOnWrite(edit.ctx, edit.lspClients, filePath, newContent); formatted != newContent {
+		note += formattedNote
+		newContent = formatted
+		_, additions, removals = diff.GenerateDiff(oldContent, newContent, strings.TrimPrefix(filePath, edit.workingDir))
+	}
+
 	// Check if file exists in history
 	file, err := edit.files.GetByPathAndSession(edit.ctx, filePath, sessionID)
 	if err != nil {
@@ -416,13 +423,13 @@ func replaceContent(edit editContext, filePath, oldString, newString string, rep
 	}
 	if file.Content != oldContent {
 		// User Manually changed the content store an intermediate version
-		_, err = edit.files.CreateVersion(edit.ctx, sessionID, filePath, oldContent)
+		_, err = edit.files.CreateVersion(edit.ctx, sessionID, filePath, oldContent, "")
 		if err != nil {
 			slog.Debug("Error creating file history version", "error", err)
 		}
 	}
 	// Store the new version
-	_, err = edit.files.CreateVersion(edit.ctx, sessionID, filePath, newContent)
+	_, err = edit.files.CreateVersion(edit.ctx, sessionID, filePath, newContent, call.ID)
 	if err != nil {
 		slog.Error("Error creating file history version", "error", err)
 	}
@@ -431,7 +438,7 @@ func replaceContent(edit editContext, filePath, oldString, newString string, rep
 	recordFileRead(filePath)
 
 	return fantasy.WithResponseMetadata(
-		fantasy.NewTextResponse("Content replaced in file: "+filePath),
+		fantasy.NewTextResponse("Content replaced in file: "+filePath+note),
 		EditResponseMetadata{
 			OldContent: oldContent,
 			NewContent: newContent,
@@ -0,0 +1,186 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/uglyswap/push/pkg/fantasy"
+	"github.com/uglyswap/push/internal/csync"
+	"github.com/uglyswap/push/internal/diff"
+	"github.com/uglyswap/push/internal/fsext"
+	"github.com/uglyswap/push/internal/history"
+	"github.com/uglyswap/push/internal/lsp"
+	"github.com/uglyswap/push/internal/permission"
+)
+
+// FileChange is the change of a single file by a tool editing several files
+// at once.
+type FileChange struct {
+	Path       string `json:"path"`
+	OldContent string `json:"old_content,omitempty"`
+	NewContent string `json:"new_content,omitempty"`
+	Created    bool   `json:"created,omitempty"`
+	Deleted    bool   `json:"deleted,omitempty"`
+}
+
+type MultiFilePermissionsParams struct {
+	Changes []FileChange `json:"changes"`
+}
+
+type MultiFileResponseMetadata struct {
+	Changes   []FileChange `json:"changes"`
+	Additions int          `json:"additions"`
+	Removals  int          `json:"removals"`
+}
+
+// fileChanges applies the changes of a multi-file edit after a single
+// permission request.
+type fileChanges struct {
+	ctx         context.Context
+	permissions permission.Service
+	files       history.Service
+	lspClients  *csync.Map[string, *lsp.Client]
+	workingDir  string
+}
+
+// apply asks for permission to make all changes, writes them, and records the
+// resulting file versions in the history. If a file cannot be written the
+// files already written are restored, so the edit is applied entirely or not
+// at all.
+func (f fileChanges) apply(toolName, description string, call fantasy.ToolCall, changes []FileChange) (MultiFileResponseMetadata, error) {
+	sessionID := GetSessionFromContext(f.ctx)
+	if sessionID == "" {
+		return MultiFileResponseMetadata{}, fmt.Errorf("session ID is required for editing files")
+	}
+
+	meta := MultiFileResponseMetadata{Changes: changes}
+	for _, change := range changes {
+		_, additions, removals := diff.GenerateDiff(
+			change.OldContent,
+			change.NewContent,
+			strings.TrimPrefix(change.Path, f.workingDir),
+		)
+		meta.Additions += additions
+		meta.Removals += removals
+	}
+
+	p := f.permissions.Request(
+		permission.CreatePermissionRequest{
+			SessionID:   sessionID,
+			Path:        f.permissionPath(changes),
+			ToolCallID:  call.ID,
+			ToolName:    toolName,
+			Action:      "write",
+			Description: description,
+			Params:      MultiFilePermissionsParams{Changes: changes},
+		},
+	)
+	if !p {
+		return MultiFileResponseMetadata{}, permission.ErrorPermissionDenied
+	}
+
+	for i, change := range changes {
+		if err := writeFileChange(change); err != nil {
+			for _, written := range changes[:i] {
+				if rollbackErr := writeFileChange(revertFileChange(written)); rollbackErr != nil {
+					slog.Error("Error restoring file", "path", written.Path, "error", rollbackErr)
+				}
+			}
+			return MultiFileResponseMetadata{}, err
+		}
+	}
+
+	for _, change := range changes {
+		f.recordHistory(sessionID, call.ID, change)
+		if change.Deleted {
+			continue
+		}
+		recordFileWrite(change.Path)
+		recordFileRead(change.Path)
+		notifyLSPs(f.ctx, f.lspClients, change.Path)
+	}
+	return meta, nil
+}
+
+// permissionPath returns the working directory when every change is inside
+// it, and the first path outside of it otherwise.
+func (f fileChanges) permissionPath(changes []FileChange) string {
+	for _, change := range changes {
+		if path := fsext.PathOrPrefix(change.Path, f.workingDir); path != f.workingDir {
+			return path
+		}
+	}
+	return f.workingDir
+}
+
+func (f fileChanges) recordHistory(sessionID, toolCallID string, change FileChange) {
+	file, err := f.files.GetByPathAndSession(f.ctx, change.Path, sessionID)
+	if err != nil {
+		file, err = f.files.Create(f.ctx, sessionID, change.Path, change.OldContent)
+		if err != nil {
+			slog.Error("Error creating file history", "error", err)
+			return
+		}
+	}
+	if file.Content != change.OldContent {
+		// User Manually changed the content store an intermediate version
+		if _, err := f.files.CreateVersion(f.ctx, sessionID, change.Path, change.OldContent, ""); err != nil {
+			slog.Error("Error creating file history version", "error", err)
+		}
+	}
+	if _, err := f.files.CreateVersion(f.ctx, sessionID, change.Path, change.NewContent, toolCallID); err != nil {
+		slog.Error("Error creating file history version", "error", err)
+	}
+}
+
+func writeFileChange(change FileChange) error {
+	if change.Deleted {
+		if err := os.Remove(change.Path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed to delete file: %w", err)
+		}
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(change.Path), 0o755); err != nil {
+		return fmt.Errorf("failed to create parent directories: %w", err)
+	}
+	if err := os.WriteFile(change.Path, []byte(change.NewContent), 0o644); err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+	return nil
+}
+
+func revertFileChange(change FileChange) FileChange {
+	return FileChange{
+		Path:       change.Path,
+		OldContent: change.NewContent,
+		NewContent: change.OldContent,
+		Created:    change.Deleted,
+		Deleted:    change.Created,
+	}
+}
+
+// formatFileChanges summarizes the changed files for the model.
+func formatFileChanges(changes []FileChange, workingDir string) string {
+	var sb strings.Builder
+	for _, change := range changes {
+		path := change.Path
+		if rel, err := filepath.Rel(workingDir, path); err == nil && !strings.HasPrefix(rel, "..") {
+			path = rel
+		}
+		switch {
+		case change.Created:
+			fmt.Fprintf(&sb, "- created %s\n", path)
+		case change.Deleted:
+			fmt.Fprintf(&sb, "- deleted %s\n", path)
+		default:
+			_, additions, removals := diff.GenerateDiff(change.OldContent, change.NewContent, path)
+			fmt.Fprintf(&sb, "- updated %s (+%d -%d)\n", path, additions, removals)
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,93 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/uglyswap/push/pkg/fantasy"
+	"github.com/uglyswap/push/internal/csync"
+	"github.com/uglyswap/push/internal/history"
+	"github.com/uglyswap/push/internal/lsp"
+	"github.com/uglyswap/push/internal/permission"
+	"github.com/uglyswap/push/internal/pubsub"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestFileChanges(t *testing.T, dir string) fileChanges {
+	ctx := context.WithValue(t.Context(), SessionIDContextKey, "session")
+	return fileChanges{
+		ctx:         ctx,
+		permissions: &mockPermissionService{Broker: pubsub.NewBroker[permission.PermissionRequest]()},
+		files:       &mockHistoryService{Broker: pubsub.NewBroker[history.File]()},
+		lspClients:  csync.NewMap[string, *lsp.Client](),
+		workingDir:  dir,
+	}
+}
+
+func TestFileChangesApply(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	updated := filepath.Join(dir, "updated.go")
+	deleted := filepath.Join(dir, "deleted.go")
+	created := filepath.Join(dir, "sub", "created.go")
+	require.NoError(t, os.WriteFile(updated, []byte("old\n"), 0o644))
+	require.NoError(t, os.WriteFile(deleted, []byte("gone\n"), 0o644))
+
+	meta, err := newTestFileChanges(t, dir).apply(RenameToolName, "Rename", fantasy.ToolCall{ID: "call"}, []FileChange{
+		{Path: updated, OldContent: "old\n", NewContent: "new\n"},
+		{Path: deleted, OldContent: "gone\n", Deleted: true},
+		{Path: created, NewContent: "hello\n", Created: true},
+	})
+	require.NoError(t, err)
+	require.Equal(t, 2, meta.Additions)
+	require.Equal(t, 2, meta.Removals)
+
+	content, err := os.ReadFile(updated)
+	require.NoError(t, err)
+	require.Equal(t, "new\n", string(content))
+	require.NoFileExists(t, deleted)
+	content, err = os.ReadFile(created)
+	require.NoError(t, err)
+	require.Equal(t, "hello\n", string(content))
+}
+
+func TestFileChangesApplyRollsBack(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	updated := filepath.Join(dir, "updated.go")
+	require.NoError(t, os.WriteFile(updated, []byte("old\n"), 0o644))
+	// A file cannot be created below a regular file.
+	invalid := filepath.Join(updated, "child.go")
+
+	_, err := newTestFileChanges(t, dir).apply(RenameToolName, "Rename", fantasy.ToolCall{ID: "call"}, []FileChange{
+		{Path: updated, OldContent: "old\n", NewContent: "new\n"},
+		{Path: invalid, NewContent: "x\n", Created: true},
+	})
+	require.Error(t, err)
+
+	content, err := os.ReadFile(updated)
+	require.NoError(t, err)
+	require.Equal(t, "old\n", string(content))
+}
+
+func TestSymbolColumn(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "main.go")
+	require.NoError(t, os.WriteFile(path, []byte("package main\n\nfunc main() { fmt.Println() }\n"), 0o644))
+
+	col, err := symbolColumn(path, 3, "main")
+	require.NoError(t, err)
+	require.Equal(t, 6, col)
+
+	col, err = symbolColumn(path, 3, "fmt.Println")
+	require.NoError(t, err)
+	require.Equal(t, 19, col)
+
+	_, err = symbolColumn(path, 1, "missing")
+	require.Error(t, err)
+}
@@ -0,0 +1,117 @@
+package tools
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/uglyswap/push/internal/csync"
+	"github.com/uglyswap/push/internal/lsp"
+	"github.com/uglyswap/push/internal/lsp/util"
+	"github.com/charmbracelet/x/powernap/pkg/lsp/protocol"
+)
+
+// formattedNote is appended to the result of an edit when the file was
+// reformatted, so the model knows the file differs from what it wrote.
+const formattedNote = "\nThe file was reformatted after writing. View it again before editing the changed lines."
+
+// formatTimeout bounds how long an edit waits for the language server to
+// format the file.
+const formatTimeout = 10 * time.Second
+
+// formatOnWrite formats the file just written with content, and organizes its
+// imports, through the LSP servers configured with format_on_write. It returns
+// the content of the file afterwards, which is content itself when no server
+// is configured or formatting fails.
+func formatOnWrite(ctx context.Context, lspClients *csync.Map[string, *lsp.Client], path, content string) string {
+	if lspClients == nil {
+		return content
+	}
+	for client := range lspClients.Seq() {
+		if !client.FormatOnWrite() || !client.HandlesFile(path) {
+			continue
+		}
+		content = formatWithClient(ctx, client, path, content)
+	}
+	return content
+}
+
+func formatWithClient(ctx context.Context, client *lsp.Client, path, content string) string {
+	ctx, cancel := context.WithTimeout(ctx, formatTimeout)
+	defer cancel()
+
+	if err := client.OpenFileOnDemand(ctx, path); err != nil {
+		slog.Warn("Failed to open file for formatting", "path", path, "error", err)
+		return content
+	}
+	if err := client.NotifyChange(ctx, path); err != nil {
+		slog.Warn("Failed to sync file for formatting", "path", path, "error", err)
+		return content
+	}
+
+	if edits, err := client.Format(ctx, path, content); err != nil {
+		slog.Warn("Failed to format file", "path", path, "error", err)
+	} else {
+		content = writeFormatted(ctx, client, path, content, edits)
+	}
+
+	lines := strings.Count(content, "\n") + 1
+	actions, err := client.CodeActions(ctx, path, 1, lines, []protocol.CodeActionKind{protocol.SourceOrganizeImports})
+	if err != nil {
+		slog.Warn("Failed to organize imports", "path", path, "error", err)
+		return content
+	}
+	for _, action := range actions {
+		if action.Kind != protocol.SourceOrganizeImports {
+			continue
+		}
+		action, err = client.ResolveCodeAction(ctx, action)
+		if err != nil || action.Edit == nil {
+			continue
+		}
+		content = writeFormatted(ctx, client, path, content, fileTextEdits(*action.Edit, path))
+		break
+	}
+	return content
+}
+
+// writeFormatted applies edits to content and writes the result back to the
+// file, keeping the server in sync.
+func writeFormatted(ctx context.Context, client *lsp.Client, path, content string, edits []protocol.TextEdit) string {
+	if len(edits) == 0 {
+		return content
+	}
+	formatted, err := util.ApplyTextEditsToContent(content, edits)
+	if err != nil {
+		slog.Warn("Failed to apply formatting edits", "path", path, "error", err)
+		return content
+	}
+	if formatted == content {
+		return content
+	}
+	if err := os.WriteFile(path, []byte(formatted), 0o644); err != nil {
+		slog.Warn("Failed to write formatted file", "path", path, "error", err)
+		return content
+	}
+	_ = client.NotifyChange(ctx, path)
+	return formatted
+}
+
+// fileTextEdits returns the text edits of a workspace edit touching path.
+func fileTextEdits(edit protocol.WorkspaceEdit, path string) []protocol.TextEdit {
+	uri := protocol.URIFromPath(path)
+	edits := edit.Changes[uri]
+	for _, change := range edit.DocumentChanges {
+		if change.TextDocumentEdit == nil || change.TextDocumentEdit.TextDocument.URI != uri {
+			continue
+		}
+		for _, e := range change.TextDocumentEdit.Edits {
+			if textEdit, err := e.AsTextEdit(); err == nil {
+				edits = append(edits, textEdit)
+			}
+		}
+	}
+	return edits
+}
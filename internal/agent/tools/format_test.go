@@ -0,0 +1,49 @@
+package tools
+
+import (
+	"testing"
+
+	"github.com/uglyswap/push/internal/csync"
+	"github.com/uglyswap/push/internal/lsp"
+	"github.com/charmbracelet/x/powernap/pkg/lsp/protocol"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatOnWriteWithoutClients(t *testing.T) {
+	t.Parallel()
+
+	content := "package main\n"
+	require.Equal(t, content, formatOnWrite(t.Context(), nil, "main.go", content))
+	require.Equal(t, content, formatOnWrite(t.Context(), csync.NewMap[string, *lsp.Client](), "main.go", content))
+}
+
+func TestFileTextEdits(t *testing.T) {
+	t.Parallel()
+
+	path := "/project/main.go"
+	uri := protocol.URIFromPath(path)
+	other := protocol.URIFromPath("/project/other.go")
+	edit := protocol.TextEdit{NewText: "import \"fmt\"\n"}
+
+	edits := fileTextEdits(protocol.WorkspaceEdit{
+		Changes: map[protocol.DocumentURI][]protocol.TextEdit{
+			uri:   {edit},
+			other: {{NewText: "ignored"}},
+		},
+		DocumentChanges: []protocol.DocumentChange{
+			{TextDocumentEdit: &protocol.TextDocumentEdit{
+				TextDocument: protocol.OptionalVersionedTextDocumentIdentifier{
+					TextDocumentIdentifier: protocol.TextDocumentIdentifier{URI: uri},
+				},
+				Edits: []protocol.Or_TextDocumentEdit_edits_Elem{{Value: edit}},
+			}},
+			{TextDocumentEdit: &protocol.TextDocumentEdit{
+				TextDocument: protocol.OptionalVersionedTextDocumentIdentifier{
+					TextDocumentIdentifier: protocol.TextDocumentIdentifier{URI: other},
+				},
+				Edits: []protocol.Or_TextDocumentEdit_edits_Elem{{Value: edit}},
+			}},
+		},
+	}, path)
+	require.Equal(t, []protocol.TextEdit{edit, edit}, edits)
+}
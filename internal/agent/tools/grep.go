@@ -117,6 +117,7 @@ func NewGrepTool(workingDir string) fantasy.AgentTool {
 		GrepToolName,
 		string(grepDescription),
 		func(ctx context.Context, params GrepParams, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+			workingDir := GetWorkingDirFromContext(ctx, workingDir)
 			if params.Pattern == "" {
 				return fantasy.NewTextErrorResponse("pattern is required"), nil
 			}
@@ -0,0 +1,313 @@
+package tools
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// textMatch is a region of the content matched by old_string, along with the
+// text replacing it.
+type textMatch struct {
+	start, end  int
+	replacement string
+}
+
+// lineComparer reports whether a line of the file matches a line of
+// old_string.
+type lineComparer func(fileLine, oldLine string) bool
+
+// replaceText replaces oldString with newString in content. It tries, in
+// order: an exact match, a match ignoring CRLF line endings in old_string, a
+// line by line match ignoring trailing whitespace, and a line by line match
+// ignoring indentation, which re-indents newString like the matched lines.
+// When nothing matches, the error shows the region of content closest to
+// oldString.
+func replaceText(content, oldString, newString string, replaceAll bool) (string, error) {
+	matches := findMatches(content, oldString, newString)
+	if len(matches) == 0 {
+		return "", errors.New("old_string not found. Make sure it matches exactly, including whitespace and line breaks" + closestMatchHint(content, oldString))
+	}
+	if len(matches) > 1 && !replaceAll {
+		return "", errors.New("old_string appears multiple times. Please provide more context to ensure a unique match, or set replace_all to true")
+	}
+
+	var sb strings.Builder
+	last := 0
+	for _, m := range matches {
+		sb.WriteString(content[last:m.start])
+		sb.WriteString(m.replacement)
+		last = m.end
+	}
+	sb.WriteString(content[last:])
+	return sb.String(), nil
+}
+
+// findMatches returns the non-overlapping matches of the first strategy
+// finding any.
+func findMatches(content, oldString, newString string) []textMatch {
+	if matches := exactMatches(content, oldString, newString); len(matches) > 0 {
+		return matches
+	}
+	if strings.Contains(oldString, "\r\n") {
+		oldString = strings.ReplaceAll(oldString, "\r\n", "\n")
+		newString = strings.ReplaceAll(newString, "\r\n", "\n")
+		if matches := exactMatches(content, oldString, newString); len(matches) > 0 {
+			return matches
+		}
+	}
+	if matches := lineMatches(content, oldString, newString, func(fileLine, oldLine string) bool {
+		return strings.TrimRight(fileLine, " \t") == strings.TrimRight(oldLine, " \t")
+	}, false); len(matches) > 0 {
+		return matches
+	}
+	return lineMatches(content, oldString, newString, func(fileLine, oldLine string) bool {
+		return strings.TrimSpace(fileLine) == strings.TrimSpace(oldLine)
+	}, true)
+}
+
+func exactMatches(content, oldString, newString string) []textMatch {
+	var matches []textMatch
+	for offset := 0; ; {
+		i := strings.Index(content[offset:], oldString)
+		if i == -1 {
+			return matches
+		}
+		start := offset + i
+		offset = start + len(oldString)
+		matches = append(matches, textMatch{start, offset, newString})
+	}
+}
+
+// lineMatches matches old_string against whole lines of content.
+func lineMatches(content, oldString, newString string, equal lineComparer, reindent bool) []textMatch {
+	oldLines := strings.Split(strings.TrimSuffix(oldString, "\n"), "\n")
+	if strings.TrimSpace(oldString) == "" {
+		return nil
+	}
+	lines, starts := splitLinesWithOffsets(content)
+
+	var matches []textMatch
+	for i := 0; i+len(oldLines) <= len(lines); i++ {
+		if !blockMatches(lines[i:i+len(oldLines)], oldLines, equal) {
+			continue
+		}
+		last := i + len(oldLines) - 1
+		end := starts[last] + len(lines[last])
+		if strings.HasSuffix(oldString, "\n") && end < len(content) {
+			end++
+		}
+		replacement := newString
+		if reindent {
+			replacement = reindentText(newString, oldLines, lines[i:i+len(oldLines)])
+		}
+		matches = append(matches, textMatch{starts[i], end, replacement})
+		i = last
+	}
+	return matches
+}
+
+func blockMatches(fileLines, oldLines []string, equal lineComparer) bool {
+	for j, oldLine := range oldLines {
+		if !equal(fileLines[j], oldLine) {
+			return false
+		}
+	}
+	return true
+}
+
+// reindentText moves the lines of newString from the indentation of
+// oldLines to the indentation of the matched file lines. Lines indented like
+// a line of old_string take the indentation of the matching file line, and
+// other lines are shifted like the first line, their extra indentation
+// switched to the indent style of the file.
+func reindentText(newString string, oldLines, fileLines []string) string {
+	indents := make(map[string]string)
+	var from, to string
+	for j, oldLine := range oldLines {
+		if strings.TrimSpace(oldLine) == "" {
+			continue
+		}
+		indent := leadingWhitespace(oldLine)
+		if _, ok := indents[indent]; !ok {
+			indents[indent] = leadingWhitespace(fileLines[j])
+		}
+		if len(indents) == 1 {
+			from, to = indent, indents[indent]
+		}
+	}
+	oldUnit := indentUnit(slices.Collect(maps.Keys(indents)))
+	fileUnit := indentUnit(slices.Collect(maps.Values(indents)))
+
+	lines := strings.Split(newString, "\n")
+	for j, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		indent := leadingWhitespace(line)
+		if mapped, ok := indents[indent]; ok {
+			lines[j] = mapped + line[len(indent):]
+		} else if strings.HasPrefix(line, from) {
+			lines[j] = to + restyleIndent(indent[len(from):], oldUnit, fileUnit) + line[len(indent):]
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// indentUnit returns one level of the given indents: a tab when they use
+// tabs, or else the smallest step in spaces between them. It returns an
+// empty string when the indents tell nothing.
+func indentUnit(indents []string) string {
+	widths := []int{0}
+	for _, indent := range indents {
+		if strings.Contains(indent, "\t") {
+			return "\t"
+		}
+		widths = append(widths, len(indent))
+	}
+	slices.Sort(widths)
+	step := 0
+	for i := 1; i < len(widths); i++ {
+		if diff := widths[i] - widths[i-1]; diff > 0 && (step == 0 || diff < step) {
+			step = diff
+		}
+	}
+	return strings.Repeat(" ", step)
+}
+
+// restyleIndent converts indent from levels of oldUnit to levels of
+// fileUnit, so that tabs and spaces are not mixed. Without a known file
+// style it is kept as is.
+func restyleIndent(indent, oldUnit, fileUnit string) string {
+	if fileUnit == "" || oldUnit == fileUnit {
+		return indent
+	}
+	if oldUnit == "" {
+		// A single level of indentation in old_string, guess its width.
+		oldUnit = "\t"
+		if strings.Contains(indent, " ") {
+			oldUnit = "    "
+		}
+	}
+	levels := strings.Count(indent, oldUnit)
+	return strings.Repeat(fileUnit, levels) + strings.ReplaceAll(indent, oldUnit, "")
+}
+
+func leadingWhitespace(line string) string {
+	return line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+}
+
+func splitLinesWithOffsets(content string) ([]string, []int) {
+	lines := strings.Split(content, "\n")
+	starts := make([]int, len(lines))
+	offset := 0
+	for i, line := range lines {
+		starts[i] = offset
+		offset += len(line) + 1
+	}
+	return lines, starts
+}
+
+// maxHintCandidates is how many regions, ranked by a cheap line comparison,
+// are scored by edit distance when looking for the closest match.
+const maxHintCandidates = 5
+
+// minHintSimilarity is the similarity below which no closest match is shown.
+const minHintSimilarity = 0.5
+
+// closestMatchHint describes the region of content most similar to
+// oldString, so the model can fix its old_string on the next attempt.
+func closestMatchHint(content, oldString string) string {
+	oldString = strings.ReplaceAll(oldString, "\r\n", "\n")
+	oldLines := strings.Split(strings.TrimSuffix(oldString, "\n"), "\n")
+	lines, _ := splitLinesWithOffsets(strings.TrimSuffix(content, "\n"))
+	if strings.TrimSpace(oldString) == "" || len(lines) < len(oldLines) {
+		return ""
+	}
+
+	type candidate struct {
+		line  int
+		score float64
+	}
+	candidates := make([]candidate, 0, len(lines)-len(oldLines)+1)
+	for i := 0; i+len(oldLines) <= len(lines); i++ {
+		var score float64
+		for j, oldLine := range oldLines {
+			score += affixSimilarity(strings.TrimSpace(lines[i+j]), strings.TrimSpace(oldLine))
+		}
+		candidates = append(candidates, candidate{i, score})
+	}
+	slices.SortStableFunc(candidates, func(a, b candidate) int {
+		return cmp.Compare(b.score, a.score)
+	})
+
+	best, bestSimilarity := 0, 0.0
+	for _, c := range candidates[:min(len(candidates), maxHintCandidates)] {
+		region := strings.Join(lines[c.line:c.line+len(oldLines)], "\n")
+		if similarity := textSimilarity(region, strings.TrimSuffix(oldString, "\n")); similarity > bestSimilarity {
+			best, bestSimilarity = c.line, similarity
+		}
+	}
+	if bestSimilarity < minHintSimilarity {
+		return ""
+	}
+
+	region := strings.Join(lines[best:best+len(oldLines)], "\n")
+	return fmt.Sprintf("\nClosest match (lines %d-%d, %.0f%% similar):\n%s", best+1, best+len(oldLines), bestSimilarity*100, region)
+}
+
+// affixSimilarity is a cheap similarity of two lines, the share of their
+// characters in a common prefix or suffix.
+func affixSimilarity(a, b string) float64 {
+	longest := max(len(a), len(b))
+	if longest == 0 {
+		return 1
+	}
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	return float64(prefix+suffix) / float64(longest)
+}
+
+// maxDistanceLength bounds the length of texts compared by edit distance,
+// beyond which the cheap line comparison is used instead.
+const maxDistanceLength = 4000
+
+// textSimilarity returns 1 minus the edit distance of a and b relative to the
+// longest of them.
+func textSimilarity(a, b string) float64 {
+	ra, rb := []rune(a), []rune(b)
+	longest := max(len(ra), len(rb))
+	if longest == 0 {
+		return 1
+	}
+	if longest > maxDistanceLength {
+		return affixSimilarity(a, b)
+	}
+
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return 1 - float64(prev[len(rb)])/float64(longest)
+}
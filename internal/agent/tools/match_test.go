@@ -0,0 +1,92 @@
+package tools
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReplaceText(t *testing.T) {
+	t.Parallel()
+
+	content := "func main() {\n\tif ok {\n\t\tprintln(\"a\")  \n\t}\n}\n"
+
+	tests := []struct {
+		name       string
+		oldString  string
+		newString  string
+		replaceAll bool
+		want       string
+	}{
+		{
+			name:      "exact",
+			oldString: "println(\"a\")",
+			newString: "println(\"b\")",
+			want:      "func main() {\n\tif ok {\n\t\tprintln(\"b\")  \n\t}\n}\n",
+		},
+		{
+			name:      "crlf",
+			oldString: "\tif ok {\r\n",
+			newString: "\tif !ok {\r\n",
+			want:      "func main() {\n\tif !ok {\n\t\tprintln(\"a\")  \n\t}\n}\n",
+		},
+		{
+			name:      "trailing whitespace",
+			oldString: "\t\tprintln(\"a\")\n\t}",
+			newString: "\t\tprintln(\"b\")\n\t}",
+			want:      "func main() {\n\tif ok {\n\t\tprintln(\"b\")\n\t}\n}\n",
+		},
+		{
+			name:      "indentation",
+			oldString: "if ok {\n    println(\"a\")\n}\n",
+			newString: "if ok {\n    println(\"a\")\n    println(\"b\")\n}\n",
+			want:      "func main() {\n\tif ok {\n\t\tprintln(\"a\")\n\t\tprintln(\"b\")\n\t}\n}\n",
+		},
+		{
+			name:      "indentation of new lines",
+			oldString: "if ok {\n    println(\"a\")\n}\n",
+			newString: "if ok {\n    if again {\n        println(\"b\")\n    }\n}\n",
+			want:      "func main() {\n\tif ok {\n\t\tif again {\n\t\t\tprintln(\"b\")\n\t\t}\n\t}\n}\n",
+		},
+		{
+			name:       "replace all",
+			oldString:  "\t",
+			newString:  "  ",
+			replaceAll: true,
+			want:       "func main() {\n  if ok {\n    println(\"a\")  \n  }\n}\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := replaceText(content, tt.oldString, tt.newString, tt.replaceAll)
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestReindentText(t *testing.T) {
+	t.Parallel()
+
+	got := reindentText("\tif x {\n\t\ty()\n\t}", []string{"\tif x {", "\t}"}, []string{"  if x {", "  }"})
+	require.Equal(t, "  if x {\n    y()\n  }", got, "new lines follow the spaces of the file")
+}
+
+func TestReplaceTextErrors(t *testing.T) {
+	t.Parallel()
+
+	content := "func main() {\n\tprintln(\"hello world\")\n}\n\nfunc other() {}\n"
+
+	_, err := replaceText(content, "\tprintln(\"hello wrld\")\n}", "x", false)
+	require.ErrorContains(t, err, "not found")
+	require.ErrorContains(t, err, "Closest match (lines 2-3")
+	require.ErrorContains(t, err, "println(\"hello world\")")
+
+	_, err = replaceText(content, "completely unrelated text", "x", false)
+	require.ErrorContains(t, err, "not found")
+	require.NotContains(t, err.Error(), "Closest match")
+
+	_, err = replaceText(content, "func", "fn", false)
+	require.ErrorContains(t, err, "multiple times")
+}
@@ -22,6 +22,7 @@ import (
 	"github.com/uglyswap/push/internal/home"
 	"github.com/uglyswap/push/internal/permission"
 	"github.com/uglyswap/push/internal/pubsub"
+	"github.com/uglyswap/push/internal/tracing"
 	"github.com/uglyswap/push/internal/version"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
@@ -64,6 +65,7 @@ const (
 	EventStateChanged EventType = iota
 	EventToolsListChanged
 	EventPromptsListChanged
+	EventResourcesListChanged
 )
 
 // Event represents an event in the MCP system
@@ -77,8 +79,9 @@ type Event struct {
 
 // Counts number of available tools, prompts, etc.
 type Counts struct {
-	Tools   int
-	Prompts int
+	Tools     int
+	Prompts   int
+	Resources int
 }
 
 // ClientInfo holds information about an MCP client's state
@@ -188,13 +191,23 @@ func Initialize(ctx context.Context, permissions permission.Service, cfg *config
 				return
 			}
 
+			resources, templates, err := getResources(ctx, session)
+			if err != nil {
+				slog.Error("error listing resources", "error", err)
+				updateState(name, StateError, err, nil, Counts{})
+				session.Close()
+				return
+			}
+
 			updateTools(name, tools)
 			updatePrompts(name, prompts)
+			updateResources(name, resources, templates)
 			sessions.Set(name, session)
 
 			updateState(name, StateConnected, nil, session, Counts{
-				Tools:   len(tools),
-				Prompts: len(prompts),
+				Tools:     len(tools),
+				Prompts:   len(prompts),
+				Resources: len(resources) + len(templates),
 			})
 		}(name, m)
 	}
@@ -279,22 +292,30 @@ func createSession(ctx context.Context, name string, m config.MCPConfig, resolve
 		},
 		&mcp.ClientOptions{
 			ToolListChangedHandler: func(context.Context, *mcp.ToolListChangedRequest) {
-				broker.Publish(pubsub.UpdatedEvent, Event{
-					Type: EventToolsListChanged,
-					Name: name,
-				})
+				go refreshOnListChanged(ctx, name, EventToolsListChanged, RefreshTools)
 			},
 			PromptListChangedHandler: func(context.Context, *mcp.PromptListChangedRequest) {
-				broker.Publish(pubsub.UpdatedEvent, Event{
-					Type: EventPromptsListChanged,
-					Name: name,
-				})
+				go refreshOnListChanged(ctx, name, EventPromptsListChanged, RefreshPrompts)
+			},
+			ResourceListChangedHandler: func(context.Context, *mcp.ResourceListChangedRequest) {
+				go refreshOnListChanged(ctx, name, EventResourcesListChanged, RefreshResources)
 			},
 			LoggingMessageHandler: func(_ context.Context, req *mcp.LoggingMessageRequest) {
 				slog.Info("MCP log", "name", name, "data", req.Params.Data)
 			},
 		},
 	)
+	client.AddSendingMiddleware(func(next mcp.MethodHandler) mcp.MethodHandler {
+		return func(ctx context.Context, method string, req mcp.Request) (mcp.Result, error) {
+			ctx, span := tracing.Start(ctx, "mcp."+method,
+				tracing.AttrMCPServer.String(name),
+				tracing.AttrRPCMethod.String(method),
+			)
+			result, err := next(ctx, method, req)
+			tracing.End(span, err)
+			return result, err
+		}
+	})
 
 	session, err := client.Connect(mcpCtx, transport, nil)
 	if err != nil {
@@ -311,6 +332,20 @@ func createSession(ctx context.Context, name string, m config.MCPConfig, resolve
 	return session, nil
 }
 
+// refreshOnListChanged refreshes the list the server said changed and then
+// lets subscribers know about it. It runs outside of the notification handler
+// since refreshing needs to call back into the server.
+func refreshOnListChanged(ctx context.Context, name string, eventType EventType, refresh func(context.Context, string)) {
+	m := config.Get().MCP[name]
+	refreshCtx, cancel := context.WithTimeout(ctx, mcpTimeout(m))
+	defer cancel()
+	refresh(refreshCtx, name)
+	broker.Publish(pubsub.UpdatedEvent, Event{
+		Type: eventType,
+		Name: name,
+	})
+}
+
 // maybeStdioErr if a stdio mcp prints an error in non-json format, it'll fail
 // to parse, and the cli will then close it, causing the EOF error.
 // so, if we got an EOF err, and the transport is STDIO, we try to exec it
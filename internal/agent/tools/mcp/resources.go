@@ -0,0 +1,152 @@
+package mcp
+
+import (
+	"context"
+	"errors"
+	"iter"
+	"log/slog"
+	"path"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/uglyswap/push/internal/csync"
+	"github.com/uglyswap/push/internal/message"
+)
+
+type (
+	Resource         = mcp.Resource
+	ResourceTemplate = mcp.ResourceTemplate
+	ResourceContents = mcp.ResourceContents
+)
+
+var (
+	allResources         = csync.NewMap[string, []*Resource]()
+	allResourceTemplates = csync.NewMap[string, []*ResourceTemplate]()
+)
+
+// Resources returns all available MCP resources.
+func Resources() iter.Seq2[string, []*Resource] {
+	return allResources.Seq2()
+}
+
+// ResourceTemplates returns all available MCP resource templates.
+func ResourceTemplates() iter.Seq2[string, []*ResourceTemplate] {
+	return allResourceTemplates.Seq2()
+}
+
+// ReadResource reads the contents of the resource with the given URI.
+func ReadResource(ctx context.Context, clientName, uri string) ([]*ResourceContents, error) {
+	c, err := getOrRenewClient(ctx, clientName)
+	if err != nil {
+		return nil, err
+	}
+	result, err := c.ReadResource(ctx, &mcp.ReadResourceParams{URI: uri})
+	if err != nil {
+		return nil, err
+	}
+	return result.Contents, nil
+}
+
+// ResourceAttachment converts the contents of a resource into an attachment.
+// Text contents are joined together, otherwise the first binary content is
+// used.
+func ResourceAttachment(name string, contents []*ResourceContents) (message.Attachment, error) {
+	if len(contents) == 0 {
+		return message.Attachment{}, errors.New("resource has no contents")
+	}
+
+	var texts []string
+	var blob *ResourceContents
+	for _, content := range contents {
+		switch {
+		case content.Blob != nil:
+			if blob == nil {
+				blob = content
+			}
+		default:
+			texts = append(texts, content.Text)
+		}
+	}
+	uri := contents[0].URI
+	if name == "" {
+		name = path.Base(uri)
+	}
+	if len(texts) > 0 {
+		mimeType := contents[0].MIMEType
+		if !strings.HasPrefix(mimeType, "text/") {
+			// Only text/* attachments are inlined into the prompt.
+			mimeType = "text/plain"
+		}
+		return message.Attachment{
+			FilePath: uri,
+			FileName: name,
+			MimeType: mimeType,
+			Content:  []byte(strings.Join(texts, "\n")),
+		}, nil
+	}
+	return message.Attachment{
+		FilePath: blob.URI,
+		FileName: name,
+		MimeType: blob.MIMEType,
+		Content:  blob.Blob,
+	}, nil
+}
+
+// RefreshResources gets the updated list of resources and resource templates
+// from the MCP and updates the global state.
+func RefreshResources(ctx context.Context, name string) {
+	session, ok := sessions.Get(name)
+	if !ok {
+		slog.Warn("refresh resources: no session", "name", name)
+		return
+	}
+
+	resources, templates, err := getResources(ctx, session)
+	if err != nil {
+		updateState(name, StateError, err, nil, Counts{})
+		return
+	}
+
+	updateResources(name, resources, templates)
+
+	prev, _ := states.Get(name)
+	prev.Counts.Resources = len(resources) + len(templates)
+	updateState(name, StateConnected, nil, session, prev.Counts)
+}
+
+func getResources(ctx context.Context, c *mcp.ClientSession) ([]*Resource, []*ResourceTemplate, error) {
+	if c.InitializeResult().Capabilities.Resources == nil {
+		return nil, nil, nil
+	}
+	var resources []*Resource
+	for resource, err := range c.Resources(ctx, nil) {
+		if err != nil {
+			return nil, nil, err
+		}
+		resources = append(resources, resource)
+	}
+	var templates []*ResourceTemplate
+	for template, err := range c.ResourceTemplates(ctx, nil) {
+		if err != nil {
+			// Templates are optional, not every server implements them.
+			slog.Debug("error listing resource templates", "error", err)
+			templates = nil
+			break
+		}
+		templates = append(templates, template)
+	}
+	return resources, templates, nil
+}
+
+func updateResources(name string, resources []*Resource, templates []*ResourceTemplate) {
+	if len(resources) == 0 {
+		allResources.Del(name)
+	} else {
+		allResources.Set(name, resources)
+	}
+	if len(templates) == 0 {
+		allResourceTemplates.Del(name)
+	} else {
+		allResourceTemplates.Set(name, templates)
+	}
+}
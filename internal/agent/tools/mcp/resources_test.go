@@ -0,0 +1,40 @@
+package mcp
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestResourceAttachment(t *testing.T) {
+	t.Parallel()
+
+	t.Run("joins text contents", func(t *testing.T) {
+		t.Parallel()
+		attachment, err := ResourceAttachment("", []*ResourceContents{
+			{URI: "file:///docs/schema.sql", MIMEType: "application/sql", Text: "create table a;"},
+			{URI: "file:///docs/schema.sql", Text: "create table b;"},
+		})
+		require.NoError(t, err)
+		require.Equal(t, "schema.sql", attachment.FileName)
+		require.Equal(t, "text/plain", attachment.MimeType)
+		require.Equal(t, "create table a;\ncreate table b;", string(attachment.Content))
+	})
+
+	t.Run("uses binary contents", func(t *testing.T) {
+		t.Parallel()
+		attachment, err := ResourceAttachment("logo", []*ResourceContents{
+			{URI: "res://logo", MIMEType: "image/png", Blob: []byte{0x89, 'P', 'N', 'G'}},
+		})
+		require.NoError(t, err)
+		require.Equal(t, "logo", attachment.FileName)
+		require.Equal(t, "image/png", attachment.MimeType)
+		require.Equal(t, []byte{0x89, 'P', 'N', 'G'}, attachment.Content)
+	})
+
+	t.Run("fails without contents", func(t *testing.T) {
+		t.Parallel()
+		_, err := ResourceAttachment("empty", nil)
+		require.Error(t, err)
+	})
+}
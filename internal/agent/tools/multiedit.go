@@ -62,6 +62,7 @@ func NewMultiEditTool(lspClients *csync.Map[string, *lsp.Client], permissions pe
 		MultiEditToolName,
 		string(multieditDescription),
 		func(ctx context.Context, params MultiEditParams, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+			workingDir := GetWorkingDirFromContext(ctx, workingDir)
 			if params.FilePath == "" {
 				return fantasy.NewTextErrorResponse("file_path is required"), nil
 			}
@@ -71,6 +72,9 @@ func NewMultiEditTool(lspClients *csync.Map[string, *lsp.Client], permissions pe
 			}
 
 			params.FilePath = filepathext.SmartJoin(workingDir, params.FilePath)
+			for i := range params.Edits {
+				params.Edits[i].OldString = unredactMatch(ctx, params.Edits[i].OldString)
+			}
 
 			// Validate all edits before applying any
 			if err := validateEdits(params.Edits); err != nil {
@@ -80,7 +84,7 @@ func NewMultiEditTool(lspClients *csync.Map[string, *lsp.Client], permissions pe
 			var response fantasy.ToolResponse
 			var err error
 
-			editCtx := editContext{ctx, permissions, files, workingDir}
+			editCtx := editContext{ctx, lspClients, permissions, files, workingDir}
 			// Handle file creation case (first edit has empty old_string)
 			if len(params.Edits) > 0 && params.Edits[0].OldString == "" {
 				response, err = processMultiEditWithCreation(editCtx, params, call)
@@ -162,6 +166,11 @@ func processMultiEditWithCreation(edit editContext, params MultiEditParams, call
 		return fantasy.ToolResponse{}, fmt.Errorf("session ID is required for creating a new file")
 	}
 
+	currentContent, err := unredactWrite(edit.ctx, edit.permissions, call.ID, MultiEditToolName, params.FilePath, "", currentContent)
+	if err != nil {
+		return fantasy.ToolResponse{}, err
+	}
+
 	// Check permissions
 	_, additions, removals := diff.GenerateDiff("", currentContent, strings.TrimPrefix(params.FilePath, edit.workingDir))
 
@@ -172,7 +181,7 @@ func processMultiEditWithCreation(edit editContext, params MultiEditParams, call
 	} else {
 		description = fmt.Sprintf("Create file %s with %d edits", params.FilePath, editsApplied)
 	}
-	p := edit.permissions.Request(permission.CreatePermissionRequest{
+	currentContent, note, err := requestWrite(edit.permissions, permission.CreatePermissionRequest{
 		SessionID:   sessionID,
 		Path:        fsext.PathOrPrefix(params.FilePath, edit.workingDir),
 		ToolCallID:  call.ID,
@@ -184,24 +193,33 @@ func processMultiEditWithCreation(edit editContext, params MultiEditParams, call
 			OldContent: "",
 			NewContent: currentContent,
 		},
-	})
-	if !p {
-		return fantasy.ToolResponse{}, permission.ErrorPermissionDenied
+	}, currentContent)
+	if err != nil {
+		return fantasy.ToolResponse{}, err
+	}
+	if note != "" {
+		_, additions, removals = diff.GenerateDiff("", currentContent, strings.TrimPrefix(params.FilePath, edit.workingDir))
 	}
 
 	// Write the file
-	err := os.WriteFile(params.FilePath, []byte(currentContent), 0o644)
+	err = os.WriteFile(params.FilePath, []byte(currentContent), 0o644)
 	if err != nil {
 		return fantasy.ToolResponse{}, fmt.Errorf("failed to write file: %w", err)
 	}
 
+	if formatted := formatOnWrite(edit.ctx, edit.lspClients, params.FilePath, currentContent); formatted != currentContent {
+		note += formattedNote
+		currentContent = formatted
+		_, additions, removals = diff.GenerateDiff("", currentContent, strings.TrimPrefix(params.FilePath, edit.workingDir))
+	}
+
 	// Update file history
 	_, err = edit.files.Create(edit.ctx, sessionID, params.FilePath, "")
 	if err != nil {
 		return fantasy.ToolResponse{}, fmt.Errorf("error creating file history: %w", err)
 	}
 
-	_, err = edit.files.CreateVersion(edit.ctx, sessionID, params.FilePath, currentContent)
+	_, err = edit.files.CreateVersion(edit.ctx, sessionID, params.FilePath, currentContent, call.ID)
 	if err != nil {
 		slog.Error("Error creating file history version", "error", err)
 	}
@@ -217,7 +235,7 @@ func processMultiEditWithCreation(edit editContext, params MultiEditParams, call
 	}
 
 	return fantasy.WithResponseMetadata(
-		fantasy.NewTextResponse(message),
+		fantasy.NewTextResponse(message+note),
 		MultiEditResponseMetadata{
 			OldContent:   "",
 			NewContent:   currentContent,
@@ -303,6 +321,11 @@ func processMultiEditExistingFile(edit editContext, params MultiEditParams, call
 		return fantasy.ToolResponse{}, fmt.Errorf("session ID is required for editing file")
 	}
 
+	currentContent, err = unredactWrite(edit.ctx, edit.permissions, call.ID, MultiEditToolName, params.FilePath, oldContent, currentContent)
+	if err != nil {
+		return fantasy.ToolResponse{}, err
+	}
+
 	// Generate diff and check permissions
 	_, additions, removals := diff.GenerateDiff(oldContent, currentContent, strings.TrimPrefix(params.FilePath, edit.workingDir))
 
@@ -313,7 +336,7 @@ func processMultiEditExistingFile(edit editContext, params MultiEditParams, call
 	} else {
 		description = fmt.Sprintf("Apply %d edits to file %s", editsApplied, params.FilePath)
 	}
-	p := edit.permissions.Request(permission.CreatePermissionRequest{
+	currentContent, note, err := requestWrite(edit.permissions, permission.CreatePermissionRequest{
 		SessionID:   sessionID,
 		Path:        fsext.PathOrPrefix(params.FilePath, edit.workingDir),
 		ToolCallID:  call.ID,
@@ -325,9 +348,12 @@ func processMultiEditExistingFile(edit editContext, params MultiEditParams, call
 			OldContent: oldContent,
 			NewContent: currentContent,
 		},
-	})
-	if !p {
-		return fantasy.ToolResponse{}, permission.ErrorPermissionDenied
+	}, currentContent)
+	if err != nil {
+		return fantasy.ToolResponse{}, err
+	}
+	if note != "" {
+		_, additions, removals = diff.GenerateDiff(oldContent, currentContent, strings.TrimPrefix(params.FilePath, edit.workingDir))
 	}
 
 	if isCrlf {
@@ -340,6 +366,12 @@ func processMultiEditExistingFile(edit editContext, params MultiEditParams, call
 		return fantasy.ToolResponse{}, fmt.Errorf("failed to write file: %w", err)
 	}
 
+	if formatted := formatOnWrite(edit.ctx, edit.lspClients, params.FilePath, currentContent); formatted != currentContent {
+		note += formattedNote
+		currentContent = formatted
+		_, additions, removals = diff.GenerateDiff(oldContent, currentContent, strings.TrimPrefix(params.FilePath, edit.workingDir))
+	}
+
 	// Update file history
 	file, err := edit.files.GetByPathAndSession(edit.ctx, params.FilePath, sessionID)
 	if err != nil {
@@ -350,14 +382,14 @@ func processMultiEditExistingFile(edit editContext, params MultiEditParams, call
 	}
 	if file.Content != oldContent {
 		// User manually changed the content, store an intermediate version
-		_, err = edit.files.CreateVersion(edit.ctx, sessionID, params.FilePath, oldContent)
+		_, err = edit.files.CreateVersion(edit.ctx, sessionID, params.FilePath, oldContent, "")
 		if err != nil {
 			slog.Error("Error creating file history version", "error", err)
 		}
 	}
 
 	// Store the new version
-	_, err = edit.files.CreateVersion(edit.ctx, sessionID, params.FilePath, currentContent)
+	_, err = edit.files.CreateVersion(edit.ctx, sessionID, params.FilePath, currentContent, call.ID)
 	if err != nil {
 		slog.Error("Error creating file history version", "error", err)
 	}
@@ -373,7 +405,7 @@ func processMultiEditExistingFile(edit editContext, params MultiEditParams, call
 	}
 
 	return fantasy.WithResponseMetadata(
-		fantasy.NewTextResponse(message),
+		fantasy.NewTextResponse(message+note),
 		MultiEditResponseMetadata{
 			OldContent:   oldContent,
 			NewContent:   currentContent,
@@ -394,29 +426,6 @@ func applyEditToContent(content string, edit MultiEditOperation) (string, error)
 		return "", fmt.Errorf("old_string cannot be empty for content replacement")
 	}
 
-	var newContent string
-	var replacementCount int
-
-	if edit.ReplaceAll {
-		newContent = strings.ReplaceAll(content, edit.OldString, edit.NewString)
-		replacementCount = strings.Count(content, edit.OldString)
-		if replacementCount == 0 {
-			return "", fmt.Errorf("old_string not found in content. Make sure it matches exactly, including whitespace and line breaks")
-		}
-	} else {
-		index := strings.Index(content, edit.OldString)
-		if index == -1 {
-			return "", fmt.Errorf("old_string not found in content. Make sure it matches exactly, including whitespace and line breaks")
-		}
-
-		lastIndex := strings.LastIndex(content, edit.OldString)
-		if index != lastIndex {
-			return "", fmt.Errorf("old_string appears multiple times in the content. Please provide more context to ensure a unique match, or set replace_all to true")
-		}
-
-		newContent = content[:index] + edit.NewString + content[index+len(edit.OldString):]
-		replacementCount = 1
-	}
-
-	return newContent, nil
+	newContent, err := replaceText(content, edit.OldString, edit.NewString, edit.ReplaceAll)
+	return newContent, err
 }
@@ -22,8 +22,14 @@ func (m *mockPermissionService) Request(req permission.CreatePermissionRequest)
 	return true
 }
 
+func (m *mockPermissionService) RequestReview(req permission.CreatePermissionRequest) (bool, *permission.Review) {
+	return true, nil
+}
+
 func (m *mockPermissionService) Grant(req permission.PermissionRequest) {}
 
+func (m *mockPermissionService) GrantReviewed(req permission.PermissionRequest, review permission.Review) {}
+
 func (m *mockPermissionService) Deny(req permission.PermissionRequest) {}
 
 func (m *mockPermissionService) GrantPersistent(req permission.PermissionRequest) {}
@@ -48,7 +54,7 @@ func (m *mockHistoryService) Create(ctx context.Context, sessionID, path, conten
 	return history.File{Path: path, Content: content}, nil
 }
 
-func (m *mockHistoryService) CreateVersion(ctx context.Context, sessionID, path, content string) (history.File, error) {
+func (m *mockHistoryService) CreateVersion(ctx context.Context, sessionID, path, content, toolCallID string) (history.File, error) {
 	return history.File{}, nil
 }
 
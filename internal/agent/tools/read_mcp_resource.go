@@ -0,0 +1,108 @@
+package tools
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/uglyswap/push/internal/agent/tools/mcp"
+	"github.com/uglyswap/push/pkg/fantasy"
+)
+
+type ReadMCPResourceParams struct {
+	Server string `json:"server,omitempty" description:"The name of the MCP server publishing the resource"`
+	URI    string `json:"uri,omitempty" description:"The URI of the resource to read. Leave empty to list the available resources"`
+}
+
+const ReadMCPResourceToolName = "read_mcp_resource"
+
+//go:embed read_mcp_resource.md
+var readMCPResourceDescription []byte
+
+func NewReadMCPResourceTool() fantasy.AgentTool {
+	return fantasy.NewAgentTool(
+		ReadMCPResourceToolName,
+		string(readMCPResourceDescription),
+		func(ctx context.Context, params ReadMCPResourceParams, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+			if params.URI == "" {
+				return fantasy.NewTextResponse(listMCPResources(params.Server)), nil
+			}
+
+			server := params.Server
+			if server == "" {
+				server = findMCPResourceServer(params.URI)
+				if server == "" {
+					return fantasy.NewTextErrorResponse("server is required for resources that are not listed"), nil
+				}
+			}
+
+			contents, err := mcp.ReadResource(ctx, server, params.URI)
+			if err != nil {
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("failed to read resource: %s", err)), nil
+			}
+			if len(contents) == 0 {
+				return fantasy.NewTextResponse("The resource is empty."), nil
+			}
+
+			var texts []string
+			for _, content := range contents {
+				if content.Blob == nil {
+					texts = append(texts, content.Text)
+					continue
+				}
+				if strings.HasPrefix(content.MIMEType, "image/") && GetSupportsImagesFromContext(ctx) {
+					return fantasy.NewImageResponse(content.Blob, content.MIMEType), nil
+				}
+				texts = append(texts, fmt.Sprintf("<binary content of %s: %d bytes of %s>", content.URI, len(content.Blob), content.MIMEType))
+			}
+			return fantasy.NewTextResponse(strings.Join(texts, "\n")), nil
+		})
+}
+
+func listMCPResources(server string) string {
+	var sb strings.Builder
+	for name, resources := range mcp.Resources() {
+		if server != "" && name != server {
+			continue
+		}
+		for _, resource := range resources {
+			fmt.Fprintf(&sb, "- server: %s, uri: %s, name: %s", name, resource.URI, resource.Name)
+			if resource.MIMEType != "" {
+				fmt.Fprintf(&sb, ", type: %s", resource.MIMEType)
+			}
+			if resource.Description != "" {
+				fmt.Fprintf(&sb, "\n  %s", resource.Description)
+			}
+			sb.WriteString("\n")
+		}
+	}
+	for name, templates := range mcp.ResourceTemplates() {
+		if server != "" && name != server {
+			continue
+		}
+		for _, template := range templates {
+			fmt.Fprintf(&sb, "- server: %s, uri template: %s, name: %s", name, template.URITemplate, template.Name)
+			if template.Description != "" {
+				fmt.Fprintf(&sb, "\n  %s", template.Description)
+			}
+			sb.WriteString("\n")
+		}
+	}
+	if sb.Len() == 0 {
+		return "No MCP resources available."
+	}
+	return sb.String()
+}
+
+func findMCPResourceServer(uri string) string {
+	for name, resources := range mcp.Resources() {
+		if slices.ContainsFunc(resources, func(resource *mcp.Resource) bool {
+			return resource.URI == uri
+		}) {
+			return name
+		}
+	}
+	return ""
+}
@@ -0,0 +1,139 @@
+package tools
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/uglyswap/push/pkg/fantasy"
+	"github.com/uglyswap/push/internal/csync"
+	"github.com/uglyswap/push/internal/filepathext"
+	"github.com/uglyswap/push/internal/history"
+	"github.com/uglyswap/push/internal/lsp"
+	"github.com/uglyswap/push/internal/lsp/util"
+	"github.com/uglyswap/push/internal/permission"
+	"github.com/charmbracelet/x/powernap/pkg/lsp/protocol"
+)
+
+type RenameParams struct {
+	FilePath string `json:"file_path" description:"The path to a file where the symbol appears"`
+	Line     int    `json:"line" description:"The line number (1-based) where the symbol appears"`
+	Symbol   string `json:"symbol" description:"The current name of the symbol, as written on that line"`
+	NewName  string `json:"new_name" description:"The new name of the symbol"`
+}
+
+const RenameToolName = "lsp_rename"
+
+//go:embed rename.md
+var renameDescription []byte
+
+func NewRenameTool(lspClients *csync.Map[string, *lsp.Client], permissions permission.Service, files history.Service, workingDir string) fantasy.AgentTool {
+	return fantasy.NewAgentTool(
+		RenameToolName,
+		string(renameDescription),
+		func(ctx context.Context, params RenameParams, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+			workingDir := GetWorkingDirFromContext(ctx, workingDir)
+			if params.FilePath == "" {
+				return fantasy.NewTextErrorResponse("file_path is required"), nil
+			}
+			if params.Symbol == "" || params.NewName == "" {
+				return fantasy.NewTextErrorResponse("symbol and new_name are required"), nil
+			}
+			if params.Line < 1 {
+				return fantasy.NewTextErrorResponse("line must be 1 or greater"), nil
+			}
+
+			filePath := filepathext.SmartJoin(workingDir, params.FilePath)
+			client := clientForFile(lspClients, filePath)
+			if client == nil {
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("no LSP client handles %s", filePath)), nil
+			}
+
+			character, err := symbolColumn(filePath, params.Line, params.Symbol)
+			if err != nil {
+				return fantasy.NewTextErrorResponse(err.Error()), nil
+			}
+
+			edit, err := client.Rename(ctx, filePath, params.Line, character, params.NewName)
+			if err != nil {
+				return fantasy.NewTextErrorResponse(err.Error()), nil
+			}
+			if edit == nil {
+				return fantasy.NewTextErrorResponse(fmt.Sprintf("symbol '%s' cannot be renamed", params.Symbol)), nil
+			}
+
+			return applyWorkspaceEdit(
+				fileChanges{ctx, permissions, files, lspClients, workingDir},
+				RenameToolName,
+				fmt.Sprintf("Rename %s to %s", params.Symbol, params.NewName),
+				call,
+				*edit,
+			)
+		})
+}
+
+// applyWorkspaceEdit applies a workspace edit computed by a language server
+// with a single permission request and reports the changed files.
+func applyWorkspaceEdit(f fileChanges, toolName, description string, call fantasy.ToolCall, edit protocol.WorkspaceEdit) (fantasy.ToolResponse, error) {
+	preview, err := util.PreviewWorkspaceEdit(edit)
+	if err != nil {
+		return fantasy.NewTextErrorResponse(err.Error()), nil
+	}
+	if len(preview) == 0 {
+		return fantasy.NewTextResponse("No changes were needed."), nil
+	}
+
+	changes := make([]FileChange, 0, len(preview))
+	for _, change := range preview {
+		changes = append(changes, FileChange{
+			Path:       change.Path,
+			OldContent: change.OldContent,
+			NewContent: change.NewContent,
+			Created:    change.Created,
+			Deleted:    change.Deleted,
+		})
+	}
+
+	meta, err := f.apply(toolName, description, call, changes)
+	if err != nil {
+		return fantasy.ToolResponse{}, err
+	}
+
+	text := fmt.Sprintf("<result>\n%s: changed %d file(s)\n%s</result>\n", description, len(changes), formatFileChanges(changes, f.workingDir))
+	for _, change := range changes {
+		if !change.Deleted {
+			text += getDiagnostics(change.Path, f.lspClients)
+			break
+		}
+	}
+	return fantasy.WithResponseMetadata(fantasy.NewTextResponse(text), meta), nil
+}
+
+func clientForFile(lspClients *csync.Map[string, *lsp.Client], path string) *lsp.Client {
+	for client := range lspClients.Seq() {
+		if client.HandlesFile(path) {
+			return client
+		}
+	}
+	return nil
+}
+
+// symbolColumn returns the 1-based column of symbol on the given 1-based line.
+// For qualified symbols the column of the last component is returned.
+func symbolColumn(path string, line int, symbol string) (int, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read file: %w", err)
+	}
+	lines := strings.Split(string(content), "\n")
+	if line > len(lines) {
+		return 0, fmt.Errorf("line %d is beyond the end of %s", line, path)
+	}
+	idx := strings.Index(lines[line-1], symbol)
+	if idx == -1 {
+		return 0, fmt.Errorf("symbol '%s' not found on line %d of %s", symbol, line, path)
+	}
+	return idx + getSymbolOffset(symbol) + 1, nil
+}
@@ -0,0 +1,20 @@
+package tools
+
+import (
+	"github.com/uglyswap/push/internal/permission"
+)
+
+// requestWrite asks the user for permission to write content, the new
+// content of the file the request is about. The user can review the change
+// hunk by hunk, in which case the returned content is the one they applied
+// and the returned note tells the model which hunks were rejected.
+func requestWrite(permissions permission.Service, req permission.CreatePermissionRequest, content string) (string, string, error) {
+	granted, review := permissions.RequestReview(req)
+	if !granted {
+		return "", "", permission.ErrorPermissionDenied
+	}
+	if review == nil {
+		return content, "", nil
+	}
+	return review.Content, "\n" + review.Summary(), nil
+}
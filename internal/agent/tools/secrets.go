@@ -0,0 +1,71 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/uglyswap/push/internal/permission"
+)
+
+// RestoreSecretsPermissionsParams describes the secrets the agent asks to
+// write back to a file in place of their placeholders.
+type RestoreSecretsPermissionsParams struct {
+	FilePath     string   `json:"file_path"`
+	Placeholders []string `json:"placeholders"`
+}
+
+// restoreSecretsAction is the permission action auditing the substitution of
+// secrets into files.
+const restoreSecretsAction = "restore_secrets"
+
+// unredactMatch substitutes the placeholders of text used to locate content
+// in a file, such as old_string, back with their secrets. Nothing is written,
+// so no permission is needed.
+func unredactMatch(ctx context.Context, text string) string {
+	return GetRedactorFromContext(ctx).Restore(text)
+}
+
+// unredactWrite substitutes the placeholders of text about to be written to
+// filePath back with their secrets. Secrets already in the file, whose
+// placeholders the model saw when reading it, are kept as they are. Others
+// are only written back when restore_on_write is enabled and the user allows
+// it; otherwise their placeholders are written as is.
+func unredactWrite(ctx context.Context, permissions permission.Service, toolCallID, toolName, filePath, fileContent, text string) (string, error) {
+	r := GetRedactorFromContext(ctx)
+	placeholders := r.Placeholders(text)
+	if len(placeholders) == 0 {
+		return text, nil
+	}
+
+	var known, added []string
+	for _, placeholder := range placeholders {
+		if secret, _ := r.Secret(placeholder); strings.Contains(fileContent, secret) {
+			known = append(known, placeholder)
+		} else {
+			added = append(added, placeholder)
+		}
+	}
+	if len(added) > 0 && r.RestoreOnWrite() {
+		granted := permissions.Request(permission.CreatePermissionRequest{
+			SessionID:   GetSessionFromContext(ctx),
+			Path:        filePath,
+			ToolCallID:  toolCallID,
+			ToolName:    toolName,
+			Action:      restoreSecretsAction,
+			Description: fmt.Sprintf("Write %d redacted secrets back to %s", len(added), filePath),
+			Params: RestoreSecretsPermissionsParams{
+				FilePath:     filePath,
+				Placeholders: added,
+			},
+		})
+		if !granted {
+			return "", permission.ErrorPermissionDenied
+		}
+		known = append(known, added...)
+	}
+	if len(known) == 0 {
+		return text, nil
+	}
+	return r.Restore(text, known...), nil
+}
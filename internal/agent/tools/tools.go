@@ -2,6 +2,8 @@ package tools
 
 import (
 	"context"
+
+	"github.com/uglyswap/push/internal/redact"
 )
 
 type (
@@ -9,6 +11,8 @@ type (
 	messageIDContextKey string
 	supportsImagesKey   string
 	modelNameKey        string
+	workingDirKey       string
+	redactorKey         string
 )
 
 const (
@@ -20,6 +24,12 @@ const (
 	SupportsImagesContextKey supportsImagesKey = "supports_images"
 	// ModelNameContextKey is the key for the model name in the context.
 	ModelNameContextKey modelNameKey = "model_name"
+	// WorkingDirContextKey is the key for the working directory of the
+	// session in the context, when it differs from the project's.
+	WorkingDirContextKey workingDirKey = "working_dir"
+	// RedactorContextKey is the key for the redactor masking secrets in the
+	// results of the session's tools.
+	RedactorContextKey redactorKey = "redactor"
 )
 
 // GetSessionFromContext retrieves the session ID from the context.
@@ -72,3 +82,20 @@ func GetModelNameFromContext(ctx context.Context) string {
 	}
 	return s
 }
+
+// GetWorkingDirFromContext retrieves the working directory of the session
+// from the context, or returns fallback when it is not set.
+func GetWorkingDirFromContext(ctx context.Context, fallback string) string {
+	workingDir, ok := ctx.Value(WorkingDirContextKey).(string)
+	if !ok || workingDir == "" {
+		return fallback
+	}
+	return workingDir
+}
+
+// GetRedactorFromContext retrieves the redactor of the session from the
+// context, or nil when secrets are not redacted.
+func GetRedactorFromContext(ctx context.Context) *redact.Redactor {
+	r, _ := ctx.Value(RedactorContextKey).(*redact.Redactor)
+	return r
+}
@@ -55,6 +55,7 @@ func NewWriteTool(lspClients *csync.Map[string, *lsp.Client], permissions permis
 		WriteToolName,
 		string(writeDescription),
 		func(ctx context.Context, params WriteParams, call fantasy.ToolCall) (fantasy.ToolResponse, error) {
+			workingDir := GetWorkingDirFromContext(ctx, workingDir)
 			if params.FilePath == "" {
 				return fantasy.NewTextErrorResponse("file_path is required"), nil
 			}
@@ -104,13 +105,20 @@ func NewWriteTool(lspClients *csync.Map[string, *lsp.Client], permissions permis
 				return fantasy.ToolResponse{}, fmt.Errorf("session_id is required")
 			}
 
-			diff, additions, removals := diff.GenerateDiff(
+			params.Content, err = unredactWrite(ctx, permissions, call.ID, WriteToolName, filePath, oldContent, params.Content)
+			if err != nil {
+				return fantasy.ToolResponse{}, err
+			}
+
+			fileDiff, additions, removals := diff.GenerateDiff(
 				oldContent,
 				params.Content,
 				strings.TrimPrefix(filePath, workingDir),
 			)
 
-			p := permissions.Request(
+			var note string
+			params.Content, note, err = requestWrite(
+				permissions,
 				permission.CreatePermissionRequest{
 					SessionID:   sessionID,
 					Path:        fsext.PathOrPrefix(filePath, workingDir),
@@ -124,9 +132,13 @@ func NewWriteTool(lspClients *csync.Map[string, *lsp.Client], permissions permis
 						NewContent: params.Content,
 					},
 				},
+				params.Content,
 			)
-			if !p {
-				return fantasy.ToolResponse{}, permission.ErrorPermissionDenied
+			if err != nil {
+				return fantasy.ToolResponse{}, err
+			}
+			if note != "" {
+				fileDiff, additions, removals = diff.GenerateDiff(oldContent, params.Content, strings.TrimPrefix(filePath, workingDir))
 			}
 
 			err = os.WriteFile(filePath, []byte(params.Content), 0o644)
@@ -134,6 +146,12 @@ func NewWriteTool(lspClients *csync.Map[string, *lsp.Client], permissions permis
 				return fantasy.ToolResponse{}, fmt.Errorf("error writing file: %w", err)
 			}
 
+			if formatted := formatOnWrite(ctx, lspClients, filePath, params.Content); formatted != params.Content {
+				note += formattedNote
+				params.Content = formatted
+				fileDiff, additions, removals = diff.GenerateDiff(oldContent, params.Content, strings.TrimPrefix(filePath, workingDir))
+			}
+
 			// Check if file exists in history
 			file, err := files.GetByPathAndSession(ctx, filePath, sessionID)
 			if err != nil {
@@ -145,13 +163,13 @@ func NewWriteTool(lspClients *csync.Map[string, *lsp.Client], permissions permis
 			}
 			if file.Content != oldContent {
 				// User Manually changed the content store an intermediate version
-				_, err = files.CreateVersion(ctx, sessionID, filePath, oldContent)
+				_, err = files.CreateVersion(ctx, sessionID, filePath, oldContent, "")
 				if err != nil {
 					slog.Error("Error creating file history version", "error", err)
 				}
 			}
 			// Store the new version
-			_, err = files.CreateVersion(ctx, sessionID, filePath, params.Content)
+			_, err = files.CreateVersion(ctx, sessionID, filePath, params.Content, call.ID)
 			if err != nil {
 				slog.Error("Error creating file history version", "error", err)
 			}
@@ -161,12 +179,12 @@ func NewWriteTool(lspClients *csync.Map[string, *lsp.Client], permissions permis
 
 			notifyLSPs(ctx, lspClients, params.FilePath)
 
-			result := fmt.Sprintf("File successfully written: %s", filePath)
+			result := fmt.Sprintf("File successfully written: %s", filePath) + note
 			result = fmt.Sprintf("<result>\n%s\n</result>", result)
 			result += getDiagnostics(filePath, lspClients)
 			return fantasy.WithResponseMetadata(fantasy.NewTextResponse(result),
 				WriteResponseMetadata{
-					Diff:      diff,
+					Diff:      fileDiff,
 					Additions: additions,
 					Removals:  removals,
 				},
@@ -0,0 +1,28 @@
+package agent
+
+import (
+	"context"
+
+	"github.com/uglyswap/push/internal/agent/tools"
+	"github.com/uglyswap/push/internal/tracing"
+	"github.com/uglyswap/push/pkg/fantasy"
+)
+
+// tracedTool records a span for each call of a tool.
+type tracedTool struct {
+	fantasy.AgentTool
+}
+
+func (t tracedTool) Execute(ctx context.Context, input string) (fantasy.ToolResultOutput, error) {
+	ctx, span := tracing.Start(ctx, "tool."+t.Name(),
+		tracing.AttrSessionID.String(tools.GetSessionFromContext(ctx)),
+		tracing.AttrToolName.String(t.Name()),
+	)
+	output, err := t.AgentTool.Execute(ctx, input)
+	spanErr := err
+	if out, ok := output.(fantasy.ToolResultOutputContentError); ok && spanErr == nil {
+		spanErr = out.Error
+	}
+	tracing.End(span, spanErr)
+	return output, err
+}
@@ -0,0 +1,61 @@
+package agent
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/uglyswap/push/internal/tracing"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestRunTraceTurn(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+	env := testEnv(t)
+	sess, err := env.sessions.Create(t.Context(), "New Session")
+	require.NoError(t, err)
+
+	t.Run("queued prompts keep the running turn", func(t *testing.T) {
+		agent := stubAgent(t, env, &stubModel{name: "large"})
+		_, turn := tracing.StartTurn(t.Context(), sess.ID)
+		defer tracing.EndTurn(sess.ID, turn, nil)
+		agent.activeRequests.Set(sess.ID, func() {})
+
+		result, err := agent.Run(t.Context(), SessionAgentCall{SessionID: sess.ID, Prompt: "later"})
+		require.NoError(t, err)
+		require.Nil(t, result)
+		require.Len(t, agent.queue(sess.ID), 1)
+		require.Equal(t, turn.SpanContext(), trace.SpanContextFromContext(tracing.SessionContext(sess.ID)))
+	})
+
+	t.Run("turns name the model that answered", func(t *testing.T) {
+		exporter.Reset()
+		large := &stubModel{name: "large", errs: []error{contextOverflow()}}
+		agent := stubAgent(t, env, large, &stubModel{name: "fallback"})
+		sess, err := env.sessions.Create(t.Context(), "New Session")
+		require.NoError(t, err)
+
+		_, err = agent.Run(t.Context(), SessionAgentCall{SessionID: sess.ID, Prompt: "hello"})
+		require.NoError(t, err)
+
+		var models []string
+		for _, span := range exporter.GetSpans() {
+			if span.Name != "agent.turn" {
+				continue
+			}
+			for _, attr := range span.Attributes {
+				if attr.Key == tracing.AttrModel {
+					models = append(models, attr.Value.AsString())
+				}
+			}
+		}
+		require.Equal(t, []string{"fallback"}, models)
+	})
+}
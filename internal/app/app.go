@@ -31,12 +31,16 @@ import (
 	"github.com/uglyswap/push/internal/message"
 	"github.com/uglyswap/push/internal/permission"
 	"github.com/uglyswap/push/internal/pubsub"
+	"github.com/uglyswap/push/internal/recording"
+	"github.com/uglyswap/push/internal/redact"
 	"github.com/uglyswap/push/internal/session"
+	"github.com/uglyswap/push/internal/tracing"
 	"github.com/uglyswap/push/internal/shell"
 	"github.com/uglyswap/push/internal/tui/components/anim"
 	"github.com/uglyswap/push/internal/tui/styles"
 	"github.com/uglyswap/push/internal/update"
 	"github.com/uglyswap/push/internal/version"
+	"github.com/uglyswap/push/internal/worktree"
 	"github.com/uglyswap/push/pkg/fantasy"
 )
 
@@ -50,6 +54,17 @@ type App struct {
 
 	LSPClients *csync.Map[string, *lsp.Client]
 
+	// Worktrees isolates sessions in git worktrees, when enabled.
+	Worktrees *worktree.Manager
+
+	// Redactor masks secrets in tool results, attachments and logs, unless
+	// redaction is disabled.
+	Redactor *redact.Redactor
+
+	// Recording captures the provider traffic and tool results of the run,
+	// or plays them back, when set from the command line.
+	Recording *recording.Recording
+
 	config *config.Config
 
 	serviceEventsWG *sync.WaitGroup
@@ -90,6 +105,43 @@ func New(ctx context.Context, conn *sql.DB, cfg *config.Config) (*App, error) {
 		tuiWG:           &sync.WaitGroup{},
 	}
 
+	if cfg.Options.SessionWorktrees {
+		app.Worktrees = worktree.New(cfg.Options.DataDirectory, cfg.WorkingDir())
+	}
+
+	redactor, err := redact.New(cfg.Options.Redaction)
+	if err != nil {
+		return nil, err
+	}
+	if redactor != nil {
+		app.Redactor = redactor
+		log.SetRedactor(redactor.Redact)
+	}
+
+	shutdownTracing, err := tracing.Setup(ctx, cfg.Options.Tracing, cfg.Options.DataDirectory)
+	if err != nil {
+		return nil, err
+	}
+	app.cleanupFuncs = append(app.cleanupFuncs, func() error {
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		return shutdownTracing(shutdownCtx)
+	})
+
+	if rc := cfg.Options.Recording; rc != nil {
+		var rec *recording.Recording
+		if rc.Replay {
+			rec, err = recording.Replay(rc.Dir, recording.Options{LiveTools: rc.LiveTools})
+		} else {
+			rec, err = recording.Record(rc.Dir, cfg)
+		}
+		if err != nil {
+			return nil, err
+		}
+		app.Recording = rec
+		app.cleanupFuncs = append(app.cleanupFuncs, rec.Close)
+	}
+
 	app.setupEvents()
 
 	// Initialize LSP clients in the background.
@@ -353,6 +405,9 @@ func (app *App) InitCoderAgent(ctx context.Context) error {
 		app.Permissions,
 		app.History,
 		app.LSPClients,
+		app.Worktrees,
+		app.Redactor,
+		app.Recording,
 	)
 	if err != nil {
 		slog.Error("Failed to create coder agent", "err", err)
@@ -0,0 +1,27 @@
+package app
+
+import (
+	"context"
+	"errors"
+
+	"github.com/uglyswap/push/internal/checkpoint"
+)
+
+// SessionCheckpoints returns the checkpoints manager of the working tree of a
+// session, its worktree when it has one.
+func (app *App) SessionCheckpoints(sessionID string) *checkpoint.Manager {
+	dir := app.config.WorkingDir()
+	if wt, err := app.SessionWorktree(sessionID); err == nil {
+		dir = wt.Path
+	}
+	return checkpoint.New(dir, app.config.Options.Attribution)
+}
+
+// RestoreCheckpoint brings the working tree of an idle session back to one of
+// its checkpoints.
+func (app *App) RestoreCheckpoint(ctx context.Context, sessionID, hash string) error {
+	if app.AgentCoordinator != nil && app.AgentCoordinator.IsSessionBusy(sessionID) {
+		return errors.New("session is busy, wait for the agent to finish")
+	}
+	return app.SessionCheckpoints(sessionID).Restore(ctx, hash)
+}
@@ -0,0 +1,186 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+
+	"github.com/uglyswap/push/internal/message"
+	"github.com/uglyswap/push/internal/session"
+)
+
+// Prompt is a prompt of a session, as sent to the agent.
+type Prompt struct {
+	Text        string
+	Attachments []message.Attachment
+}
+
+// TurnPrompt returns the prompt of the turn messageID belongs to.
+func (app *App) TurnPrompt(ctx context.Context, sessionID, messageID string) (Prompt, error) {
+	msgs, start, err := app.promptTurn(ctx, sessionID, messageID)
+	if err != nil {
+		return Prompt{}, err
+	}
+	return promptOf(msgs[start]), nil
+}
+
+// TruncateAtPrompt deletes the prompt of the turn messageID belongs to and
+// every later message of an idle session, so the prompt can be sent again,
+// as is or changed. It returns the deleted prompt.
+func (app *App) TruncateAtPrompt(ctx context.Context, sessionID, messageID string) (Prompt, error) {
+	msgs, start, err := app.promptTurn(ctx, sessionID, messageID)
+	if err != nil {
+		return Prompt{}, err
+	}
+	if err := app.deleteMessages(ctx, sessionID, msgs, start, len(msgs)); err != nil {
+		return Prompt{}, err
+	}
+	return promptOf(msgs[start]), nil
+}
+
+// BranchAtPrompt creates a session with copies of the messages of an idle
+// session before the prompt of the turn messageID belongs to, so the prompt
+// can be sent again there while the original conversation is kept. It
+// returns the new session and the prompt.
+func (app *App) BranchAtPrompt(ctx context.Context, sessionID, messageID string) (session.Session, Prompt, error) {
+	msgs, start, err := app.promptTurn(ctx, sessionID, messageID)
+	if err != nil {
+		return session.Session{}, Prompt{}, err
+	}
+	sess, err := app.Sessions.Get(ctx, sessionID)
+	if err != nil {
+		return session.Session{}, Prompt{}, err
+	}
+	branch, err := app.Sessions.Create(ctx, sess.Title+" (branch)")
+	if err != nil {
+		return session.Session{}, Prompt{}, err
+	}
+
+	copied := make(map[string]string, start)
+	for _, msg := range msgs[:start] {
+		parts := msg.Parts
+		if msg.Role != message.Assistant {
+			// Creating the message adds its finish part again.
+			parts = slices.DeleteFunc(slices.Clone(parts), func(p message.ContentPart) bool {
+				_, ok := p.(message.Finish)
+				return ok
+			})
+		}
+		created, err := app.Messages.Create(ctx, branch.ID, message.CreateMessageParams{
+			Role:             msg.Role,
+			Parts:            parts,
+			Model:            msg.Model,
+			Provider:         msg.Provider,
+			IsSummaryMessage: msg.IsSummaryMessage,
+		})
+		if err != nil {
+			return session.Session{}, Prompt{}, fmt.Errorf("failed to copy message: %w", err)
+		}
+		copied[msg.ID] = created.ID
+	}
+
+	if id, ok := copied[sess.SummaryMessageID]; ok {
+		branch.SummaryMessageID = id
+		branch.SummaryUntilMessageID = copied[sess.SummaryUntilMessageID]
+		if branch, err = app.Sessions.Save(ctx, branch); err != nil {
+			return session.Session{}, Prompt{}, err
+		}
+	}
+	return branch, promptOf(msgs[start]), nil
+}
+
+// DeleteTurn deletes the prompt of the turn messageID belongs to and the
+// messages answering it, up to the next prompt, from an idle session.
+func (app *App) DeleteTurn(ctx context.Context, sessionID, messageID string) error {
+	msgs, start, err := app.promptTurn(ctx, sessionID, messageID)
+	if err != nil {
+		return err
+	}
+	end := start + 1
+	for end < len(msgs) && msgs[end].Role != message.User {
+		end++
+	}
+	return app.deleteMessages(ctx, sessionID, msgs, start, end)
+}
+
+// promptTurn returns the messages of an idle session and the index of the
+// prompt of the turn messageID belongs to.
+func (app *App) promptTurn(ctx context.Context, sessionID, messageID string) ([]message.Message, int, error) {
+	if app.AgentCoordinator != nil && app.AgentCoordinator.IsSessionBusy(sessionID) {
+		return nil, 0, errors.New("session is busy, wait for the agent to finish")
+	}
+	msgs, err := app.Messages.List(ctx, sessionID)
+	if err != nil {
+		return nil, 0, err
+	}
+	i := slices.IndexFunc(msgs, func(m message.Message) bool { return m.ID == messageID })
+	if i < 0 {
+		return nil, 0, fmt.Errorf("message %s not found", messageID)
+	}
+	for ; i >= 0; i-- {
+		if msgs[i].Role == message.User {
+			return msgs, i, nil
+		}
+	}
+	return nil, 0, errors.New("no prompt found for this message")
+}
+
+// deleteMessages deletes msgs[start:end] from a session. The summary is
+// forgotten when it is one of them. When the last message it summarizes is
+// deleted, it summarizes up to the message before the deleted ones, and it is
+// deleted too when no message is left before them.
+func (app *App) deleteMessages(ctx context.Context, sessionID string, msgs []message.Message, start, end int) error {
+	sess, err := app.Sessions.Get(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+	deleted := msgs[start:end]
+	isDeleted := func(id string) bool {
+		return slices.ContainsFunc(deleted, func(m message.Message) bool { return m.ID == id })
+	}
+	if sess.SummaryMessageID != "" && !isDeleted(sess.SummaryMessageID) && isDeleted(sess.SummaryUntilMessageID) {
+		summaryID := sess.SummaryMessageID
+		if start > 0 {
+			sess.SummaryUntilMessageID = msgs[start-1].ID
+		} else {
+			sess.SummaryMessageID = ""
+			sess.SummaryUntilMessageID = ""
+		}
+		if sess, err = app.Sessions.Save(ctx, sess); err != nil {
+			return err
+		}
+		if start == 0 {
+			if err := app.Messages.Delete(ctx, summaryID); err != nil {
+				return fmt.Errorf("failed to delete summary: %w", err)
+			}
+		}
+	}
+	for _, msg := range deleted {
+		if msg.ID == sess.SummaryMessageID {
+			sess.SummaryMessageID = ""
+			sess.SummaryUntilMessageID = ""
+			if sess, err = app.Sessions.Save(ctx, sess); err != nil {
+				return err
+			}
+		}
+		if err := app.Messages.Delete(ctx, msg.ID); err != nil {
+			return fmt.Errorf("failed to delete message: %w", err)
+		}
+	}
+	return nil
+}
+
+func promptOf(msg message.Message) Prompt {
+	p := Prompt{Text: msg.Content().Text}
+	for _, bc := range msg.BinaryContent() {
+		p.Attachments = append(p.Attachments, message.Attachment{
+			FilePath: bc.Path,
+			FileName: filepath.Base(bc.Path),
+			MimeType: bc.MIMEType,
+			Content:  bc.Data,
+		})
+	}
+	return p
+}
@@ -0,0 +1,204 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/uglyswap/push/internal/agent"
+	"github.com/uglyswap/push/internal/db"
+	"github.com/uglyswap/push/internal/message"
+	"github.com/uglyswap/push/internal/session"
+)
+
+// busyCoordinator reports every session as busy.
+type busyCoordinator struct {
+	agent.Coordinator
+}
+
+func (busyCoordinator) IsSessionBusy(string) bool { return true }
+
+// promptsTest is a session with three turns, the second one with an
+// attachment and a tool call, and a summary of the first one.
+type promptsTest struct {
+	app     *App
+	session session.Session
+	// msgs are the messages of the session by their text.
+	msgs map[string]message.Message
+}
+
+func newPromptsTest(t *testing.T) promptsTest {
+	t.Helper()
+	conn, err := db.Connect(t.Context(), t.TempDir())
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	q := db.New(conn)
+	app := &App{Sessions: session.NewService(q), Messages: message.NewService(q)}
+
+	sess, err := app.Sessions.Create(t.Context(), "Session")
+	require.NoError(t, err)
+	pt := promptsTest{app: app, session: sess, msgs: make(map[string]message.Message)}
+	for _, m := range []struct {
+		role    message.MessageRole
+		text    string
+		summary bool
+		parts   []message.ContentPart
+	}{
+		{role: message.User, text: "first"},
+		{role: message.Assistant, text: "first answer"},
+		{role: message.User, text: "second", parts: []message.ContentPart{
+			message.BinaryContent{Path: "/tmp/notes.txt", MIMEType: "text/plain", Data: []byte("notes")},
+		}},
+		{role: message.Assistant, text: "second call", parts: []message.ContentPart{
+			message.ToolCall{ID: "call", Name: "view", Input: "{}", Finished: true},
+		}},
+		{role: message.Tool, parts: []message.ContentPart{
+			message.ToolResult{ToolCallID: "call", Name: "view", Content: "second result"},
+		}},
+		{role: message.Assistant, text: "second answer"},
+		{role: message.Assistant, text: "summary", summary: true},
+		{role: message.User, text: "third"},
+		{role: message.Assistant, text: "third answer"},
+	} {
+		var parts []message.ContentPart
+		if m.text != "" {
+			parts = append(parts, message.TextContent{Text: m.text})
+		}
+		msg, err := app.Messages.Create(t.Context(), sess.ID, message.CreateMessageParams{
+			Role:             m.role,
+			Parts:            append(parts, m.parts...),
+			IsSummaryMessage: m.summary,
+		})
+		require.NoError(t, err)
+		key := m.text
+		if key == "" {
+			key = "second result"
+		}
+		pt.msgs[key] = msg
+	}
+
+	pt.session.SummaryMessageID = pt.msgs["summary"].ID
+	pt.session.SummaryUntilMessageID = pt.msgs["first answer"].ID
+	pt.session, err = app.Sessions.Save(t.Context(), pt.session)
+	require.NoError(t, err)
+	return pt
+}
+
+// texts returns the texts of the messages of a session, or the content of
+// the tool results.
+func (pt promptsTest) texts(t *testing.T, sessionID string) []string {
+	t.Helper()
+	msgs, err := pt.app.Messages.List(t.Context(), sessionID)
+	require.NoError(t, err)
+	texts := make([]string, len(msgs))
+	for i, msg := range msgs {
+		texts[i] = msg.Content().Text
+		if results := msg.ToolResults(); len(results) > 0 {
+			texts[i] = results[0].Content
+		}
+	}
+	return texts
+}
+
+func TestTruncateAtPrompt(t *testing.T) {
+	t.Parallel()
+
+	pt := newPromptsTest(t)
+	prompt, err := pt.app.TruncateAtPrompt(t.Context(), pt.session.ID, pt.msgs["second result"].ID)
+	require.NoError(t, err)
+	require.Equal(t, "second", prompt.Text)
+	require.Len(t, prompt.Attachments, 1)
+	require.Equal(t, "notes.txt", prompt.Attachments[0].FileName)
+	require.Equal(t, "text/plain", prompt.Attachments[0].MimeType)
+	require.Equal(t, []byte("notes"), prompt.Attachments[0].Content)
+
+	require.Equal(t, []string{"first", "first answer"}, pt.texts(t, pt.session.ID))
+	sess, err := pt.app.Sessions.Get(t.Context(), pt.session.ID)
+	require.NoError(t, err)
+	require.Empty(t, sess.SummaryMessageID, "the deleted summary is forgotten")
+	require.Empty(t, sess.SummaryUntilMessageID)
+}
+
+func TestBranchAtPrompt(t *testing.T) {
+	t.Parallel()
+
+	pt := newPromptsTest(t)
+	branch, prompt, err := pt.app.BranchAtPrompt(t.Context(), pt.session.ID, pt.msgs["third answer"].ID)
+	require.NoError(t, err)
+	require.Equal(t, "third", prompt.Text)
+	require.Equal(t, "Session (branch)", branch.Title)
+
+	before := []string{"first", "first answer", "second", "second call", "second result", "second answer", "summary"}
+	require.Equal(t, before, pt.texts(t, branch.ID))
+	require.Equal(t, append(before, "third", "third answer"), pt.texts(t, pt.session.ID), "the original session is kept")
+
+	// The summary points to the copies of its messages.
+	branch, err = pt.app.Sessions.Get(t.Context(), branch.ID)
+	require.NoError(t, err)
+	summary, err := pt.app.Messages.Get(t.Context(), branch.SummaryMessageID)
+	require.NoError(t, err)
+	require.Equal(t, branch.ID, summary.SessionID)
+	require.True(t, summary.IsSummaryMessage)
+	require.Equal(t, "summary", summary.Content().Text)
+	until, err := pt.app.Messages.Get(t.Context(), branch.SummaryUntilMessageID)
+	require.NoError(t, err)
+	require.Equal(t, branch.ID, until.SessionID)
+	require.Equal(t, "first answer", until.Content().Text)
+
+	// Without the summary in the copied messages, the branch has none.
+	branch, _, err = pt.app.BranchAtPrompt(t.Context(), pt.session.ID, pt.msgs["second"].ID)
+	require.NoError(t, err)
+	require.Equal(t, []string{"first", "first answer"}, pt.texts(t, branch.ID))
+	require.Empty(t, branch.SummaryMessageID)
+	require.Empty(t, branch.SummaryUntilMessageID)
+}
+
+func TestDeleteTurn(t *testing.T) {
+	t.Parallel()
+
+	pt := newPromptsTest(t)
+	require.NoError(t, pt.app.DeleteTurn(t.Context(), pt.session.ID, pt.msgs["second call"].ID))
+	require.Equal(t, []string{"first", "first answer", "third", "third answer"}, pt.texts(t, pt.session.ID), "the turn runs up to the next prompt")
+	sess, err := pt.app.Sessions.Get(t.Context(), pt.session.ID)
+	require.NoError(t, err)
+	require.Empty(t, sess.SummaryMessageID, "the deleted summary is forgotten")
+
+	require.NoError(t, pt.app.DeleteTurn(t.Context(), pt.session.ID, pt.msgs["third answer"].ID))
+	require.Equal(t, []string{"first", "first answer"}, pt.texts(t, pt.session.ID), "the last turn ends with the session")
+
+	require.NoError(t, pt.app.DeleteTurn(t.Context(), pt.session.ID, pt.msgs["first"].ID))
+	require.Empty(t, pt.texts(t, pt.session.ID))
+
+	_, err = pt.app.TurnPrompt(t.Context(), pt.session.ID, pt.msgs["first"].ID)
+	require.ErrorContains(t, err, "not found")
+
+	// A later summary of the first two turns.
+	pt = newPromptsTest(t)
+	pt.session.SummaryMessageID = pt.msgs["third answer"].ID
+	pt.session.SummaryUntilMessageID = pt.msgs["second answer"].ID
+	pt.session, err = pt.app.Sessions.Save(t.Context(), pt.session)
+	require.NoError(t, err)
+	require.NoError(t, pt.app.DeleteTurn(t.Context(), pt.session.ID, pt.msgs["second"].ID))
+	sess, err = pt.app.Sessions.Get(t.Context(), pt.session.ID)
+	require.NoError(t, err)
+	require.Equal(t, pt.msgs["third answer"].ID, sess.SummaryMessageID)
+	require.Equal(t, pt.msgs["first answer"].ID, sess.SummaryUntilMessageID, "the summary ends before the deleted turn")
+
+	pt = newPromptsTest(t)
+	require.NoError(t, pt.app.DeleteTurn(t.Context(), pt.session.ID, pt.msgs["first"].ID))
+	require.Equal(t, []string{"second", "second call", "second result", "second answer", "third", "third answer"}, pt.texts(t, pt.session.ID), "the summary of deleted turns only is deleted")
+	sess, err = pt.app.Sessions.Get(t.Context(), pt.session.ID)
+	require.NoError(t, err)
+	require.Empty(t, sess.SummaryMessageID)
+	require.Empty(t, sess.SummaryUntilMessageID)
+}
+
+func TestPromptsBusySession(t *testing.T) {
+	t.Parallel()
+
+	pt := newPromptsTest(t)
+	pt.app.AgentCoordinator = busyCoordinator{}
+	require.ErrorContains(t, pt.app.DeleteTurn(t.Context(), pt.session.ID, pt.msgs["first"].ID), "busy")
+	_, err := pt.app.TruncateAtPrompt(t.Context(), pt.session.ID, pt.msgs["first"].ID)
+	require.ErrorContains(t, err, "busy")
+	require.Len(t, pt.texts(t, pt.session.ID), len(pt.msgs))
+}
@@ -0,0 +1,103 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"github.com/uglyswap/push/internal/message"
+	"github.com/uglyswap/push/internal/worktree"
+)
+
+// SessionWorktree returns the worktree of a session, or worktree.ErrNotFound
+// when the session has none or worktrees are disabled.
+func (app *App) SessionWorktree(sessionID string) (worktree.Worktree, error) {
+	if app.Worktrees == nil {
+		return worktree.Worktree{}, worktree.ErrNotFound
+	}
+	return app.Worktrees.Get(sessionID)
+}
+
+// MergeSessionWorktree merges the branch of the session's worktree into the
+// checked out branch of the project and removes the worktree.
+func (app *App) MergeSessionWorktree(ctx context.Context, sessionID string) error {
+	wt, err := app.releaseSessionWorktree(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+	title := sessionID
+	if sess, err := app.Sessions.Get(ctx, sessionID); err == nil && sess.Title != "" {
+		title = sess.Title
+	}
+	return app.Worktrees.Merge(ctx, wt, app.mergeMessage(ctx, sessionID, fmt.Sprintf("Merge session %q", title)))
+}
+
+// mergeMessage returns the commit message of the merge of a session's
+// worktree, with the configured attribution, as checkpoints write it.
+func (app *App) mergeMessage(ctx context.Context, sessionID, subject string) string {
+	attribution := app.config.Options.Attribution
+	if attribution == nil {
+		return subject
+	}
+	var sb strings.Builder
+	sb.WriteString(subject)
+	if attribution.GeneratedWith {
+		sb.WriteString("\n\n")
+		sb.WriteString(attribution.GeneratedWithLine())
+	}
+	if trailer := attribution.Trailer(app.sessionModelName(ctx, sessionID)); trailer != "" {
+		sb.WriteString("\n\n")
+		sb.WriteString(trailer)
+	}
+	sb.WriteString("\n")
+	return sb.String()
+}
+
+// sessionModelName returns the name of the model of the last answer of a
+// session, or an empty string when it is not known.
+func (app *App) sessionModelName(ctx context.Context, sessionID string) string {
+	msgs, err := app.Messages.List(ctx, sessionID)
+	if err != nil {
+		return ""
+	}
+	for _, msg := range slices.Backward(msgs) {
+		if msg.Role != message.Assistant || msg.Model == "" {
+			continue
+		}
+		if model := app.config.GetModel(msg.Provider, msg.Model); model != nil {
+			return model.Name
+		}
+		return msg.Model
+	}
+	return ""
+}
+
+// DiscardSessionWorktree removes the session's worktree and its branch.
+func (app *App) DiscardSessionWorktree(ctx context.Context, sessionID string) error {
+	wt, err := app.releaseSessionWorktree(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+	return app.Worktrees.Discard(ctx, wt)
+}
+
+// releaseSessionWorktree returns the worktree of an idle session and removes
+// it from the language servers' workspaces.
+func (app *App) releaseSessionWorktree(ctx context.Context, sessionID string) (worktree.Worktree, error) {
+	wt, err := app.SessionWorktree(sessionID)
+	if err != nil {
+		return worktree.Worktree{}, err
+	}
+	if app.AgentCoordinator != nil && app.AgentCoordinator.IsSessionBusy(sessionID) {
+		return worktree.Worktree{}, errors.New("session is busy, wait for the agent to finish")
+	}
+	for client := range app.LSPClients.Seq() {
+		if err := client.RemoveWorkspaceFolder(ctx, wt.Path); err != nil {
+			slog.Warn("Failed to remove worktree from LSP workspace", "lsp", client.GetName(), "error", err)
+		}
+	}
+	return wt, nil
+}
@@ -0,0 +1,35 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/uglyswap/push/internal/config"
+	"github.com/uglyswap/push/internal/csync"
+	"github.com/uglyswap/push/internal/message"
+)
+
+func TestMergeMessage(t *testing.T) {
+	t.Parallel()
+
+	pt := newPromptsTest(t)
+	pt.app.config = &config.Config{
+		Options:   &config.Options{},
+		Providers: csync.NewMap[string, config.ProviderConfig](),
+	}
+	require.Equal(t, "Merge", pt.app.mergeMessage(t.Context(), pt.session.ID, "Merge"), "no attribution is configured")
+
+	pt.app.config.Options.Attribution = &config.Attribution{TrailerStyle: config.TrailerStyleAssistedBy, GeneratedWith: true}
+	_, err := pt.app.Messages.Create(t.Context(), pt.session.ID, message.CreateMessageParams{
+		Role:     message.Assistant,
+		Parts:    []message.ContentPart{message.TextContent{Text: "done"}},
+		Model:    "gpt-4o",
+		Provider: "openai",
+	})
+	require.NoError(t, err)
+	attribution := pt.app.config.Options.Attribution
+	require.Equal(t,
+		"Merge\n\n"+attribution.GeneratedWithLine()+"\n\n"+attribution.Trailer("gpt-4o")+"\n",
+		pt.app.mergeMessage(t.Context(), pt.session.ID, "Merge"),
+	)
+}
@@ -212,6 +212,22 @@ func (c *Cache) Keys() []string {
 	return keys
 }
 
+// Entries returns a snapshot of all non-expired entries, most recently used
+// first.
+func (c *Cache) Entries() []Entry {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	entries := make([]Entry, 0, len(c.entries))
+	for element := c.lruList.Front(); element != nil; element = element.Next() {
+		entry := element.Value.(*Entry)
+		if !entry.IsExpired() {
+			entries = append(entries, *entry)
+		}
+	}
+	return entries
+}
+
 // remove removes an element from the cache (must be called with lock held).
 func (c *Cache) remove(element *list.Element) {
 	entry := element.Value.(*Entry)
@@ -289,9 +305,27 @@ func (c *Cache) Persist() error {
 		return fmt.Errorf("failed to create directory: %w", err)
 	}
 
-	if err := os.WriteFile(c.persistPath, data, 0644); err != nil {
+	// Write a temporary file and rename it, so a crash or a concurrent
+	// reader never sees a partly written cache.
+	tmp, err := os.CreateTemp(dir, filepath.Base(c.persistPath)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create cache file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write cache file: %w", err)
+	}
+	if err := tmp.Chmod(0644); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write cache file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
 		return fmt.Errorf("failed to write cache file: %w", err)
 	}
+	if err := os.Rename(tmp.Name(), c.persistPath); err != nil {
+		return fmt.Errorf("failed to replace cache file: %w", err)
+	}
 
 	return nil
 }
@@ -0,0 +1,313 @@
+// Package checkpoint records the state of the working tree after each agent
+// turn as a commit on a hidden ref of the session, without touching the index
+// or HEAD, so that any turn can be inspected and restored later.
+package checkpoint
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/uglyswap/push/internal/config"
+	"github.com/uglyswap/push/internal/gitutil"
+)
+
+// RefPrefix prefixes the ref holding the checkpoints of every session.
+const RefPrefix = "refs/push/checkpoints/"
+
+// SessionTrailer is the commit trailer naming the session of a checkpoint.
+const SessionTrailer = "Push-Checkpoint"
+
+// emptyTree is the hash of the empty git tree.
+const emptyTree = "4b825dc642cb6eb9a060e54bf8d69288fbee4904"
+
+// fallbackIdentity commits checkpoints as push when the user has no git
+// identity configured.
+var fallbackIdentity = []string{
+	"GIT_AUTHOR_NAME=push",
+	"GIT_AUTHOR_EMAIL=push@localhost",
+	"GIT_COMMITTER_NAME=push",
+	"GIT_COMMITTER_EMAIL=push@localhost",
+}
+
+// ErrNotGitRepo is returned when the working directory is not in a git
+// repository.
+var ErrNotGitRepo = errors.New("not a git repository")
+
+// Checkpoint is the state of the working tree at the end of an agent turn.
+type Checkpoint struct {
+	Hash      string    `json:"hash"`
+	SessionID string    `json:"session_id"`
+	Message   string    `json:"message"`
+	Time      time.Time `json:"time"`
+}
+
+// FileChange is a file changed by a checkpoint since the previous one.
+type FileChange = gitutil.FileChange
+
+// Manager records and restores the checkpoints of the working tree at dir.
+type Manager struct {
+	dir         string
+	attribution *config.Attribution
+}
+
+// New returns a manager for the working tree at dir. The commits it creates
+// carry the trailer of the given attribution style.
+func New(dir string, attribution *config.Attribution) *Manager {
+	return &Manager{
+		dir:         dir,
+		attribution: attribution,
+	}
+}
+
+// Ref returns the ref holding the checkpoints of a session.
+func Ref(sessionID string) string {
+	return RefPrefix + sessionID
+}
+
+// Create records the working tree, including untracked files not ignored by
+// git, as a new checkpoint of the session. It returns false when nothing
+// changed since the previous checkpoint, or HEAD for the first one.
+func (m *Manager) Create(ctx context.Context, sessionID, subject, modelName string) (Checkpoint, bool, error) {
+	if err := m.check(ctx); err != nil {
+		return Checkpoint{}, false, err
+	}
+	tree, err := gitutil.WriteTree(ctx, m.dir)
+	if err != nil {
+		return Checkpoint{}, false, err
+	}
+
+	parent, _ := gitutil.Run(ctx, m.dir, "rev-parse", "--verify", "--quiet", Ref(sessionID))
+	if parent == "" {
+		parent, _ = gitutil.Run(ctx, m.dir, "rev-parse", "--verify", "--quiet", "HEAD")
+	}
+	parentTree := emptyTree
+	if parent != "" {
+		if parentTree, err = gitutil.Run(ctx, m.dir, "rev-parse", parent+"^{tree}"); err != nil {
+			return Checkpoint{}, false, fmt.Errorf("failed to resolve checkpoint parent: %w", err)
+		}
+	}
+	if tree == parentTree {
+		return Checkpoint{}, false, nil
+	}
+
+	args := []string{"commit-tree", tree, "-F", "-"}
+	if parent != "" {
+		args = append(args, "-p", parent)
+	}
+	var env []string
+	if _, err := gitutil.Run(ctx, m.dir, "var", "GIT_COMMITTER_IDENT"); err != nil {
+		env = fallbackIdentity
+	}
+	message := m.message(sessionID, subject, modelName)
+	out, err := gitutil.RunWith(ctx, m.dir, env, strings.NewReader(message), args...)
+	if err != nil {
+		return Checkpoint{}, false, fmt.Errorf("failed to commit checkpoint: %w", err)
+	}
+	hash := strings.TrimSpace(out)
+	if _, err := gitutil.Run(ctx, m.dir, "update-ref", "-m", "checkpoint: "+subject, Ref(sessionID), hash); err != nil {
+		return Checkpoint{}, false, fmt.Errorf("failed to update %s: %w", Ref(sessionID), err)
+	}
+	return Checkpoint{
+		Hash:      hash,
+		SessionID: sessionID,
+		Message:   subject,
+		Time:      time.Now(),
+	}, true, nil
+}
+
+// List returns the checkpoints of a session, newest first.
+func (m *Manager) List(ctx context.Context, sessionID string) ([]Checkpoint, error) {
+	if err := m.check(ctx); err != nil {
+		return nil, err
+	}
+	ref := Ref(sessionID)
+	if _, err := gitutil.Run(ctx, m.dir, "rev-parse", "--verify", "--quiet", ref); err != nil {
+		return nil, nil
+	}
+	out, err := gitutil.Run(ctx, m.dir, "log", "--first-parent", logFormat, ref)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list checkpoints: %w", err)
+	}
+
+	var checkpoints []Checkpoint
+	for record := range strings.SplitSeq(out, "\x1e") {
+		cp, ok := parseCheckpoint(record)
+		// The first commit not made for the session is the one the session
+		// started from.
+		if !ok || cp.SessionID != sessionID {
+			break
+		}
+		checkpoints = append(checkpoints, cp)
+	}
+	return checkpoints, nil
+}
+
+// Get returns the checkpoint with the given hash, which may be abbreviated.
+func (m *Manager) Get(ctx context.Context, hash string) (Checkpoint, error) {
+	out, err := gitutil.Run(ctx, m.dir, "log", "-1", logFormat, hash+"^{commit}", "--")
+	if err != nil {
+		return Checkpoint{}, fmt.Errorf("unknown checkpoint %s: %w", hash, err)
+	}
+	cp, ok := parseCheckpoint(out)
+	if !ok {
+		return Checkpoint{}, fmt.Errorf("%s is not a checkpoint", hash)
+	}
+	return cp, nil
+}
+
+// logFormat is the git log format of the records read by parseCheckpoint.
+const logFormat = "--format=%H%x00%ct%x00%s%x00%(trailers:key=" + SessionTrailer + ",valueonly,separator=)%x1e"
+
+func parseCheckpoint(record string) (Checkpoint, bool) {
+	fields := strings.Split(strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(record), "\x1e")), "\x00")
+	if len(fields) != 4 || strings.TrimSpace(fields[3]) == "" {
+		return Checkpoint{}, false
+	}
+	seconds, _ := strconv.ParseInt(fields[1], 10, 64)
+	return Checkpoint{
+		Hash:      fields[0],
+		SessionID: strings.TrimSpace(fields[3]),
+		Message:   fields[2],
+		Time:      time.Unix(seconds, 0),
+	}, true
+}
+
+// Sessions returns the IDs of the sessions having checkpoints.
+func (m *Manager) Sessions(ctx context.Context) ([]string, error) {
+	if err := m.check(ctx); err != nil {
+		return nil, err
+	}
+	out, err := gitutil.Run(ctx, m.dir, "for-each-ref", "--sort=-committerdate", "--format=%(refname)", RefPrefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list checkpoint refs: %w", err)
+	}
+	var sessions []string
+	for ref := range strings.Lines(out) {
+		if ref = strings.TrimSpace(ref); ref != "" {
+			sessions = append(sessions, strings.TrimPrefix(ref, RefPrefix))
+		}
+	}
+	return sessions, nil
+}
+
+// Changes returns the files changed by a checkpoint since the previous one.
+func (m *Manager) Changes(ctx context.Context, hash string) ([]FileChange, error) {
+	out, err := gitutil.Raw(ctx, m.dir, "diff-tree", "-r", "--root", "--no-commit-id", "--no-renames", "--name-status", "-z", hash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list checkpoint changes: %w", err)
+	}
+
+	changes := gitutil.ParseNameStatus(out)
+	for i, change := range changes {
+		if !change.Created {
+			if changes[i].OldContent, err = gitutil.Raw(ctx, m.dir, "show", hash+"^:"+change.Path); err != nil {
+				return nil, fmt.Errorf("failed to read %s before checkpoint: %w", change.Path, err)
+			}
+		}
+		if !change.Deleted {
+			if changes[i].NewContent, err = gitutil.Raw(ctx, m.dir, "show", hash+":"+change.Path); err != nil {
+				return nil, fmt.Errorf("failed to read %s at checkpoint: %w", change.Path, err)
+			}
+		}
+	}
+	return changes, nil
+}
+
+// Diff returns the changes of a checkpoint as a unified diff.
+func (m *Manager) Diff(ctx context.Context, hash string) (string, error) {
+	out, err := gitutil.Raw(ctx, m.dir, "diff-tree", "-p", "--root", "--no-commit-id", hash)
+	if err != nil {
+		return "", fmt.Errorf("failed to diff checkpoint: %w", err)
+	}
+	return out, nil
+}
+
+// Restore brings the working tree back to a checkpoint, leaving the index,
+// HEAD and files ignored by git alone. The current state is checkpointed
+// first in the session of the checkpoint, so that the restore can itself be
+// undone.
+func (m *Manager) Restore(ctx context.Context, hash string) error {
+	cp, err := m.Get(ctx, hash)
+	if err != nil {
+		return err
+	}
+	hash = cp.Hash
+	if _, _, err := m.Create(ctx, cp.SessionID, "Before restoring "+hash[:7], ""); err != nil {
+		return err
+	}
+	current, err := gitutil.WriteTree(ctx, m.dir)
+	if err != nil {
+		return err
+	}
+
+	out, err := gitutil.Raw(ctx, m.dir, "diff-tree", "-r", "--no-renames", "--name-status", "-z", hash, current)
+	if err != nil {
+		return fmt.Errorf("failed to compare checkpoint: %w", err)
+	}
+	var restore bytes.Buffer
+	for _, change := range gitutil.ParseNameStatus(out) {
+		if change.Created {
+			// Created after the checkpoint.
+			if err := os.Remove(filepath.Join(m.dir, change.Path)); err != nil && !errors.Is(err, os.ErrNotExist) {
+				return fmt.Errorf("failed to remove %s: %w", change.Path, err)
+			}
+			continue
+		}
+		restore.WriteString(change.Path)
+		restore.WriteByte(0)
+	}
+	if restore.Len() == 0 {
+		return nil
+	}
+	if _, err := gitutil.RunWith(ctx, m.dir, nil, &restore, "restore", "--source", hash, "--worktree", "--pathspec-from-file=-", "--pathspec-file-nul"); err != nil {
+		return fmt.Errorf("failed to restore checkpoint: %w", err)
+	}
+	return nil
+}
+
+// message returns the commit message of a checkpoint, with its session
+// trailer and the configured attribution, as the bash tool writes it.
+func (m *Manager) message(sessionID, subject, modelName string) string {
+	var sb strings.Builder
+	sb.WriteString(subject)
+	sb.WriteString("\n\n")
+	if m.attribution != nil && m.attribution.GeneratedWith {
+		sb.WriteString(m.attribution.GeneratedWithLine())
+		sb.WriteString("\n\n")
+	}
+	fmt.Fprintf(&sb, "%s: %s\n", SessionTrailer, sessionID)
+	if m.attribution != nil {
+		if trailer := m.attribution.Trailer(modelName); trailer != "" {
+			sb.WriteString(trailer)
+			sb.WriteString("\n")
+		}
+	}
+	return sb.String()
+}
+
+func (m *Manager) check(ctx context.Context) error {
+	if _, err := gitutil.Run(ctx, m.dir, "rev-parse", "--is-inside-work-tree"); err != nil {
+		return ErrNotGitRepo
+	}
+	return nil
+}
+
+// Subject returns the subject of the checkpoint of a turn started by the
+// given prompt.
+func Subject(prompt string) string {
+	subject, _, _ := strings.Cut(strings.TrimSpace(prompt), "\n")
+	if len([]rune(subject)) > 72 {
+		subject = string([]rune(subject)[:71]) + "…"
+	}
+	if subject == "" {
+		subject = "Agent turn"
+	}
+	return subject
+}
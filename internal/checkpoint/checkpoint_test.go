@@ -0,0 +1,114 @@
+package checkpoint
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/uglyswap/push/internal/config"
+	"github.com/uglyswap/push/internal/gitutil"
+)
+
+func newTestRepo(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	dir := t.TempDir()
+	for _, args := range [][]string{
+		{"init", "-q", "-b", "main"},
+		{"config", "user.name", "test"},
+		{"config", "user.email", "test@example.com"},
+	} {
+		_, err := gitutil.Run(t.Context(), dir, args...)
+		require.NoError(t, err)
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "main.go"), []byte("package main\n"), 0o644))
+	_, err := gitutil.Run(t.Context(), dir, "add", "--all")
+	require.NoError(t, err)
+	_, err = gitutil.Run(t.Context(), dir, "commit", "-q", "-m", "initial")
+	require.NoError(t, err)
+	return dir
+}
+
+func TestManager(t *testing.T) {
+	t.Parallel()
+
+	dir := newTestRepo(t)
+	m := New(dir, &config.Attribution{TrailerStyle: config.TrailerStyleAssistedBy})
+	head, err := gitutil.Run(t.Context(), dir, "rev-parse", "HEAD")
+	require.NoError(t, err)
+
+	_, created, err := m.Create(t.Context(), "session", "Nothing changed", "model")
+	require.NoError(t, err)
+	require.False(t, created)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "main.go"), []byte("package main\n\nfunc main() {}\n"), 0o644))
+	first, created, err := m.Create(t.Context(), "session", "Add main", "model")
+	require.NoError(t, err)
+	require.True(t, created)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "gen.go"), []byte("package main\n"), 0o644))
+	second, created, err := m.Create(t.Context(), "session", "Generate code", "model")
+	require.NoError(t, err)
+	require.True(t, created)
+
+	// Neither HEAD nor the index moved.
+	current, err := gitutil.Run(t.Context(), dir, "rev-parse", "HEAD")
+	require.NoError(t, err)
+	require.Equal(t, head, current)
+	staged, err := gitutil.Run(t.Context(), dir, "diff", "--cached", "--name-only")
+	require.NoError(t, err)
+	require.Empty(t, staged)
+
+	checkpoints, err := m.List(t.Context(), "session")
+	require.NoError(t, err)
+	require.Len(t, checkpoints, 2)
+	require.Equal(t, second.Hash, checkpoints[0].Hash)
+	require.Equal(t, "Generate code", checkpoints[0].Message)
+	require.Equal(t, first.Hash, checkpoints[1].Hash)
+
+	message, err := gitutil.Run(t.Context(), dir, "log", "-1", "--format=%B", first.Hash)
+	require.NoError(t, err)
+	require.Contains(t, message, "Push-Checkpoint: session")
+	require.Contains(t, message, "Assisted-by: model via Crush <crush@charm.land>")
+
+	changes, err := m.Changes(t.Context(), second.Hash)
+	require.NoError(t, err)
+	require.Equal(t, []FileChange{{Path: "gen.go", NewContent: "package main\n", Created: true}}, changes)
+
+	cp, err := m.Get(t.Context(), first.Hash)
+	require.NoError(t, err)
+	require.Equal(t, "session", cp.SessionID)
+	require.Equal(t, "Add main", cp.Message)
+	_, err = m.Get(t.Context(), head)
+	require.Error(t, err)
+
+	sessions, err := m.Sessions(t.Context())
+	require.NoError(t, err)
+	require.Equal(t, []string{"session"}, sessions)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "main.go"), []byte("broken\n"), 0o644))
+	require.NoError(t, m.Restore(t.Context(), first.Hash[:10]))
+	require.NoFileExists(t, filepath.Join(dir, "gen.go"))
+	content, err := os.ReadFile(filepath.Join(dir, "main.go"))
+	require.NoError(t, err)
+	require.Equal(t, "package main\n\nfunc main() {}\n", string(content))
+
+	// The state before the restore was checkpointed.
+	checkpoints, err = m.List(t.Context(), "session")
+	require.NoError(t, err)
+	require.Len(t, checkpoints, 3)
+	require.Contains(t, checkpoints[0].Message, "Before restoring")
+}
+
+func TestSubject(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, "Fix the build", Subject("  Fix the build\nIt fails on CI"))
+	require.Equal(t, "Agent turn", Subject(""))
+	require.Len(t, []rune(Subject(string(make([]byte, 100)))), 72)
+}
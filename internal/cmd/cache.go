@@ -0,0 +1,136 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strconv"
+
+	"charm.land/lipgloss/v2"
+	"charm.land/lipgloss/v2/table"
+	"github.com/charmbracelet/x/term"
+	"github.com/spf13/cobra"
+	"github.com/uglyswap/push/internal/config"
+	"github.com/uglyswap/push/internal/httpcache"
+)
+
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Manage the web response cache",
+	Long:  "Inspect or clear the cache of pages fetched by the fetch, web_fetch, agentic_fetch, download and sourcegraph tools",
+	Example: `
+# Show what is in the cache
+push cache stats
+
+# Remove all cached responses
+push cache clear
+  `,
+}
+
+var cacheStatsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Show web response cache statistics",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		jsonOutput, _ := cmd.Flags().GetBool("json")
+
+		store, err := openHTTPCache(cmd)
+		if err != nil {
+			return err
+		}
+		stats := store.Stats()
+
+		if jsonOutput {
+			data, err := json.Marshal(struct {
+				Entries int            `json:"entries"`
+				Fresh   int            `json:"fresh"`
+				Bytes   int64          `json:"bytes"`
+				Hits    int64          `json:"hits"`
+				Tools   map[string]int `json:"tools"`
+			}{stats.Entries, stats.Fresh, stats.Bytes, stats.Hits, stats.Tools})
+			if err != nil {
+				return err
+			}
+			cmd.Println(string(data))
+			return nil
+		}
+
+		rows := [][]string{
+			{"Entries", strconv.Itoa(stats.Entries)},
+			{"Fresh", strconv.Itoa(stats.Fresh)},
+			{"Size", formatSize(stats.Bytes)},
+			{"Hits", strconv.FormatInt(stats.Hits, 10)},
+		}
+		for _, tool := range slices.Sorted(maps.Keys(stats.Tools)) {
+			rows = append(rows, []string{fmt.Sprintf("Entries (%s)", tool), strconv.Itoa(stats.Tools[tool])})
+		}
+
+		if term.IsTerminal(os.Stdout.Fd()) {
+			// We're in a TTY: make it fancy.
+			t := table.New().
+				Border(lipgloss.RoundedBorder()).
+				StyleFunc(func(row, col int) lipgloss.Style {
+					return lipgloss.NewStyle().Padding(0, 2)
+				}).
+				Rows(rows...)
+			lipgloss.Println(t)
+			return nil
+		}
+
+		// Not a TTY: plain output
+		for _, row := range rows {
+			cmd.Printf("%s\t%s\n", row[0], row[1])
+		}
+		return nil
+	},
+}
+
+var cacheClearCmd = &cobra.Command{
+	Use:   "clear",
+	Short: "Remove all cached web responses",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		store, err := openHTTPCache(cmd)
+		if err != nil {
+			return err
+		}
+		entries := store.Stats().Entries
+		if err := store.Clear(); err != nil {
+			return fmt.Errorf("failed to clear cache: %w", err)
+		}
+		cmd.Printf("Removed %d cached responses.\n", entries)
+		return nil
+	},
+}
+
+func openHTTPCache(cmd *cobra.Command) (*httpcache.Store, error) {
+	cwd, err := cmd.Flags().GetString("cwd")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get current working directory: %v", err)
+	}
+	dataDir, err := cmd.Flags().GetString("data-dir")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get data directory: %v", err)
+	}
+	cfg, err := config.Load(cwd, dataDir, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load configuration: %v", err)
+	}
+	return httpcache.Open(cfg.Options.DataDirectory, cfg.Options.HTTPCache.Limit(), cfg.Options.HTTPCache.SizeLimit()), nil
+}
+
+// formatSize formats byte count as human-readable size.
+func formatSize(bytes int64) string {
+	if bytes < 1024 {
+		return fmt.Sprintf("%d B", bytes)
+	}
+	if bytes < 1024*1024 {
+		return fmt.Sprintf("%.1f KB", float64(bytes)/1024)
+	}
+	return fmt.Sprintf("%.1f MB", float64(bytes)/(1024*1024))
+}
+
+func init() {
+	cacheStatsCmd.Flags().Bool("json", false, "Output as JSON")
+	cacheCmd.AddCommand(cacheStatsCmd, cacheClearCmd)
+}
@@ -0,0 +1,164 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"charm.land/lipgloss/v2"
+	"charm.land/lipgloss/v2/table"
+	"github.com/charmbracelet/x/term"
+	"github.com/spf13/cobra"
+	"github.com/uglyswap/push/internal/checkpoint"
+	"github.com/uglyswap/push/internal/config"
+	"github.com/uglyswap/push/internal/worktree"
+)
+
+var checkpointsCmd = &cobra.Command{
+	Use:   "checkpoints [session-id]",
+	Short: "List the checkpoints recorded after agent turns",
+	Long: `List the checkpoints recorded after every agent turn when options.checkpoints
+is enabled. Each checkpoint is a commit on refs/push/checkpoints/<session-id>
+holding the whole working tree, so that any turn can be inspected and restored.`,
+	Example: `
+# List the checkpoints of every session
+push checkpoints
+
+# Show what a checkpoint changed
+push checkpoints diff 3f2a9c1
+
+# Bring the working tree back to a checkpoint
+push checkpoints restore 3f2a9c1
+  `,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		jsonOutput, _ := cmd.Flags().GetBool("json")
+
+		cfg, err := loadCheckpointsConfig(cmd)
+		if err != nil {
+			return err
+		}
+		manager := checkpoint.New(cfg.WorkingDir(), cfg.Options.Attribution)
+
+		sessions := args
+		if len(sessions) == 0 {
+			if sessions, err = manager.Sessions(cmd.Context()); err != nil {
+				return err
+			}
+		}
+		var checkpoints []checkpoint.Checkpoint
+		for _, sessionID := range sessions {
+			list, err := manager.List(cmd.Context(), sessionID)
+			if err != nil {
+				return err
+			}
+			checkpoints = append(checkpoints, list...)
+		}
+
+		if jsonOutput {
+			data, err := json.Marshal(struct {
+				Checkpoints []checkpoint.Checkpoint `json:"checkpoints"`
+			}{checkpoints})
+			if err != nil {
+				return err
+			}
+			cmd.Println(string(data))
+			return nil
+		}
+
+		if len(checkpoints) == 0 {
+			cmd.Println("No checkpoints recorded yet.")
+			return nil
+		}
+
+		if term.IsTerminal(os.Stdout.Fd()) {
+			// We're in a TTY: make it fancy.
+			t := table.New().
+				Border(lipgloss.RoundedBorder()).
+				StyleFunc(func(row, col int) lipgloss.Style {
+					return lipgloss.NewStyle().Padding(0, 2)
+				}).
+				Headers("Checkpoint", "Session", "Time", "Message")
+			for _, cp := range checkpoints {
+				t.Row(cp.Hash[:7], cp.SessionID, cp.Time.Local().Format("2006-01-02 15:04"), cp.Message)
+			}
+			lipgloss.Println(t)
+			return nil
+		}
+
+		// Not a TTY: plain output
+		for _, cp := range checkpoints {
+			cmd.Printf("%s\t%s\t%s\t%s\n", cp.Hash, cp.SessionID, cp.Time.Format("2006-01-02T15:04:05Z07:00"), cp.Message)
+		}
+		return nil
+	},
+}
+
+var checkpointsDiffCmd = &cobra.Command{
+	Use:   "diff <checkpoint>",
+	Short: "Show the changes recorded by a checkpoint",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := loadCheckpointsConfig(cmd)
+		if err != nil {
+			return err
+		}
+		diff, err := checkpoint.New(cfg.WorkingDir(), cfg.Options.Attribution).Diff(cmd.Context(), args[0])
+		if err != nil {
+			return err
+		}
+		cmd.Print(diff)
+		return nil
+	},
+}
+
+var checkpointsRestoreCmd = &cobra.Command{
+	Use:   "restore <checkpoint>",
+	Short: "Bring the working tree back to a checkpoint",
+	Long: `Bring the working tree of the session back to a checkpoint. The index, HEAD and
+ignored files are left alone, and the current state is checkpointed first so
+that the restore can be undone.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := loadCheckpointsConfig(cmd)
+		if err != nil {
+			return err
+		}
+		cp, err := checkpoint.New(cfg.WorkingDir(), cfg.Options.Attribution).Get(cmd.Context(), args[0])
+		if err != nil {
+			return err
+		}
+
+		// Sessions running in their own worktree are restored there.
+		dir := cfg.WorkingDir()
+		if wt, err := worktree.New(cfg.Options.DataDirectory, cfg.WorkingDir()).Get(cp.SessionID); err == nil {
+			dir = wt.Path
+		}
+		if err := checkpoint.New(dir, cfg.Options.Attribution).Restore(cmd.Context(), cp.Hash); err != nil {
+			return err
+		}
+		cmd.Printf("Restored %s to checkpoint %s (%s).\n", dir, cp.Hash[:7], cp.Message)
+		return nil
+	},
+}
+
+func loadCheckpointsConfig(cmd *cobra.Command) (*config.Config, error) {
+	cwd, err := cmd.Flags().GetString("cwd")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get current working directory: %v", err)
+	}
+	dataDir, err := cmd.Flags().GetString("data-dir")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get data directory: %v", err)
+	}
+	cfg, err := config.Load(cwd, dataDir, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load configuration: %v", err)
+	}
+	return cfg, nil
+}
+
+func init() {
+	checkpointsCmd.Flags().Bool("json", false, "Output as JSON")
+	checkpointsCmd.AddCommand(checkpointsDiffCmd, checkpointsRestoreCmd)
+}
@@ -0,0 +1,220 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"charm.land/lipgloss/v2"
+	"charm.land/lipgloss/v2/table"
+	"github.com/charmbracelet/x/term"
+	"github.com/spf13/cobra"
+	"github.com/uglyswap/push/internal/eval"
+)
+
+var evalCmd = &cobra.Command{
+	Use:   "eval <suite>",
+	Short: "Benchmark push configurations on a suite of tasks",
+	Long: `Run every task of a suite through the non-interactive agent with each given
+configuration, and compare how they fare.
+
+A suite is a directory with one subdirectory per task, holding a task.json
+with the prompt and the command verifying the result, and a repo directory
+the task starts from:
+
+  suite/
+    fix-parser/
+      task.json   {"prompt": "Fix the failing parser test", "verify": "go test ./...", "timeout": "10m"}
+      repo/
+
+Each task runs in a temporary copy of its repository, where the configuration
+file is copied as push.json. The task passes when the verify command exits
+with status 0.
+
+To run offline, point a configuration at a local OpenAI-compatible endpoint,
+or record the runs once with --record and replay them with --replay.`,
+	Example: `
+# Run a suite with your configuration
+push eval ./evals
+
+# Compare two configurations
+push eval --config sonnet=./sonnet.json --config gpt=./gpt.json ./evals
+
+# Record the runs, then replay them offline
+push eval --record --config local=./local.json ./evals
+push eval --replay --config local=./local.json ./evals
+  `,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		configFlags, _ := cmd.Flags().GetStringArray("config")
+		parallel, _ := cmd.Flags().GetInt("parallel")
+		timeout, _ := cmd.Flags().GetDuration("timeout")
+		record, _ := cmd.Flags().GetBool("record")
+		replay, _ := cmd.Flags().GetBool("replay")
+		jsonOutput, _ := cmd.Flags().GetBool("json")
+		if record && replay {
+			return fmt.Errorf("--record and --replay cannot be used together")
+		}
+
+		tasks, err := eval.LoadSuite(args[0])
+		if err != nil {
+			return err
+		}
+		configs, err := parseEvalConfigs(configFlags)
+		if err != nil {
+			return err
+		}
+		executable, err := os.Executable()
+		if err != nil {
+			return fmt.Errorf("failed to locate the push executable: %w", err)
+		}
+
+		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, os.Kill)
+		defer cancel()
+
+		runner := &eval.Runner{
+			Executable: executable,
+			Parallel:   parallel,
+			Timeout:    timeout,
+			Record:     record,
+			Replay:     replay,
+			OnResult: func(result eval.Result) {
+				status := "PASS"
+				if !result.Passed {
+					status = "FAIL"
+				}
+				cmd.PrintErrf("%s  %s (%s) in %s\n", status, result.Task, result.Config, result.Duration.Round(time.Second))
+			},
+		}
+		results := runner.Run(ctx, tasks, configs)
+		summaries := eval.Summarize(configs, results)
+
+		if jsonOutput {
+			data, err := json.Marshal(struct {
+				Results   []eval.Result  `json:"results"`
+				Summaries []eval.Summary `json:"summaries"`
+			}{results, summaries})
+			if err != nil {
+				return err
+			}
+			cmd.Println(string(data))
+			return nil
+		}
+
+		printEvalTable(cmd, evalTaskRows(tasks, configs, results))
+		cmd.Println()
+		printEvalTable(cmd, evalSummaryRows(summaries))
+		return nil
+	},
+}
+
+// parseEvalConfigs parses the --config flags, given as name=path or path,
+// named after the file.
+func parseEvalConfigs(flags []string) ([]eval.Config, error) {
+	if len(flags) == 0 {
+		return []eval.Config{{Name: eval.DefaultConfig}}, nil
+	}
+	var configs []eval.Config
+	seen := make(map[string]bool)
+	for _, flag := range flags {
+		name, path, ok := strings.Cut(flag, "=")
+		if !ok {
+			path = flag
+			name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+		}
+		path, err := filepath.Abs(path)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := os.Stat(path); err != nil {
+			return nil, fmt.Errorf("configuration %s: %w", name, err)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("configuration %s is given twice", name)
+		}
+		seen[name] = true
+		configs = append(configs, eval.Config{Name: name, Path: path})
+	}
+	return configs, nil
+}
+
+// evalTaskRows returns a row per task with its outcome for each
+// configuration.
+func evalTaskRows(tasks []eval.Task, configs []eval.Config, results []eval.Result) [][]string {
+	header := []string{"Task"}
+	for _, cfg := range configs {
+		header = append(header, cfg.Name)
+	}
+	rows := [][]string{header}
+	for i, task := range tasks {
+		row := []string{task.Name}
+		for j := range configs {
+			result := results[i*len(configs)+j]
+			switch {
+			case result.Passed:
+				row = append(row, "pass")
+			case result.Error != "":
+				row = append(row, "fail: "+result.Error)
+			default:
+				row = append(row, "fail")
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// evalSummaryRows returns a row per configuration with its aggregated
+// results.
+func evalSummaryRows(summaries []eval.Summary) [][]string {
+	rows := [][]string{{"Config", "Passed", "Turns", "Tool calls", "Input tokens", "Output tokens", "Cost", "Time"}}
+	for _, s := range summaries {
+		rows = append(rows, []string{
+			s.Config,
+			fmt.Sprintf("%d/%d (%.0f%%)", s.Passed, s.Tasks, s.PassRate()*100),
+			strconv.Itoa(s.Turns),
+			strconv.Itoa(s.ToolCalls),
+			strconv.FormatInt(s.InputTokens, 10),
+			strconv.FormatInt(s.OutputTokens, 10),
+			fmt.Sprintf("$%.4f", s.Cost),
+			s.Duration.Round(time.Second).String(),
+		})
+	}
+	return rows
+}
+
+func printEvalTable(cmd *cobra.Command, rows [][]string) {
+	if term.IsTerminal(os.Stdout.Fd()) {
+		// We're in a TTY: make it fancy.
+		t := table.New().
+			Border(lipgloss.RoundedBorder()).
+			StyleFunc(func(row, col int) lipgloss.Style {
+				return lipgloss.NewStyle().Padding(0, 2)
+			}).
+			Headers(rows[0]...).
+			Rows(rows[1:]...)
+		lipgloss.Println(t)
+		return
+	}
+
+	// Not a TTY: plain output
+	for _, row := range rows {
+		cmd.Println(strings.Join(row, "\t"))
+	}
+}
+
+func init() {
+	evalCmd.Flags().StringArray("config", nil, "Configuration file to evaluate, as name=path; can be repeated")
+	evalCmd.Flags().Int("parallel", max(1, runtime.NumCPU()/2), "Number of tasks run at once")
+	evalCmd.Flags().Duration("timeout", 10*time.Minute, "Time limit of a task without a timeout of its own")
+	evalCmd.Flags().Bool("record", false, "Record the runs in the recordings directory of the tasks")
+	evalCmd.Flags().Bool("replay", false, "Replay the recorded runs offline instead of calling the providers")
+	evalCmd.Flags().Bool("json", false, "Output as JSON")
+}
@@ -0,0 +1,107 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/spf13/cobra"
+	"github.com/uglyswap/push/internal/config"
+	"github.com/uglyswap/push/internal/mcpserver"
+)
+
+var mcpCmd = &cobra.Command{
+	Use:   "mcp",
+	Short: "Model Context Protocol commands",
+}
+
+var mcpServeCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Publish the built-in tools as an MCP server",
+	Long: `Publish push's built-in tools (view, edit, multiedit, write, grep, glob, ls,
+bash, lsp_diagnostics and lsp_references) over the Model Context Protocol.
+There is no one to answer permission prompts, so they are answered by the
+options.mcp_server.permissions policy, which denies everything not allowed by default.`,
+	Example: `
+# Serve over stdio, for agents that spawn push themselves
+push mcp serve
+
+# Serve over streamable HTTP
+push mcp serve --http localhost:8765
+  `,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		addr, _ := cmd.Flags().GetString("http")
+
+		ctx, cancel := signal.NotifyContext(cmd.Context(), os.Interrupt)
+		defer cancel()
+
+		app, err := setupApp(cmd)
+		if err != nil {
+			return err
+		}
+		defer app.Shutdown()
+
+		cfg := app.Config()
+		sess, err := app.Sessions.Create(ctx, "MCP server")
+		if err != nil {
+			return fmt.Errorf("failed to create session for MCP server: %w", err)
+		}
+
+		var policy config.MCPServerPermissions
+		if cfg.Options.MCPServer != nil {
+			policy = cfg.Options.MCPServer.Permissions
+		}
+		go mcpserver.ResolvePermissions(ctx, app.Permissions, policy)
+
+		server, err := mcpserver.New(mcpserver.Options{
+			Config:      cfg,
+			Permissions: app.Permissions,
+			History:     app.History,
+			LSPClients:  app.LSPClients,
+			SessionID:   sess.ID,
+		})
+		if err != nil {
+			return err
+		}
+
+		if addr == "" {
+			slog.Info("Serving MCP over stdio", "session_id", sess.ID)
+			if err := server.Run(ctx, &mcp.StdioTransport{}); err != nil && !errors.Is(err, context.Canceled) {
+				return err
+			}
+			return nil
+		}
+
+		httpServer := &http.Server{
+			Addr: addr,
+			Handler: mcp.NewStreamableHTTPHandler(func(*http.Request) *mcp.Server {
+				return server
+			}, nil),
+			ReadHeaderTimeout: 10 * time.Second,
+		}
+		go func() {
+			<-ctx.Done()
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			_ = httpServer.Shutdown(shutdownCtx)
+		}()
+
+		slog.Info("Serving MCP over HTTP", "addr", addr, "session_id", sess.ID)
+		fmt.Fprintf(os.Stderr, "Serving MCP on http://%s\n", addr)
+		if err := httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			return err
+		}
+		return nil
+	},
+}
+
+func init() {
+	mcpServeCmd.Flags().String("http", "", "Serve over streamable HTTP on the given address instead of stdio")
+	mcpCmd.AddCommand(mcpServeCmd)
+}
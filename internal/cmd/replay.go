@@ -0,0 +1,133 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+
+	"github.com/spf13/cobra"
+	"github.com/uglyswap/push/internal/app"
+	"github.com/uglyswap/push/internal/config"
+	"github.com/uglyswap/push/internal/db"
+	"github.com/uglyswap/push/internal/recording"
+)
+
+var replayCmd = &cobra.Command{
+	Use:   "replay <dir>",
+	Short: "Replay a recorded run offline",
+	Long: `Replay a run recorded with push --record. The prompts are sent again to the
+agent, and the provider requests are answered from the recording, so the run
+is reproduced without network access or API keys.
+
+Tools return their recorded results unless --live-tools is set, in which case
+they run in the current directory and the results differing from the
+recording are reported.`,
+	Example: `
+# Record a session
+push --record ./push-recording
+
+# Replay it offline
+push replay ./push-recording
+
+# Replay it, running the tools to catch regressions
+push replay --live-tools ./push-recording
+  `,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		liveTools, _ := cmd.Flags().GetBool("live-tools")
+		debug, _ := cmd.Flags().GetBool("debug")
+		dataDir, _ := cmd.Flags().GetString("data-dir")
+		dir := args[0]
+
+		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, os.Kill)
+		defer cancel()
+
+		manifest, err := recording.ReadManifest(dir)
+		if err != nil {
+			return err
+		}
+		if len(manifest.Prompts) == 0 {
+			return fmt.Errorf("no prompts recorded in %s", dir)
+		}
+
+		// Keep the sessions of the replay out of the project database.
+		if dataDir == "" {
+			if dataDir, err = os.MkdirTemp("", "push-replay-"); err != nil {
+				return err
+			}
+			defer os.RemoveAll(dataDir)
+		}
+
+		cwd, err := ResolveCwd(cmd)
+		if err != nil {
+			return err
+		}
+		cfg, err := config.Init(cwd, dataDir, debug)
+		if err != nil {
+			return err
+		}
+		manifest.Apply(cfg)
+		cfg.Options.Recording = &config.Recording{Dir: dir, Replay: true, LiveTools: liveTools}
+
+		if err := createDotPushDir(cfg.Options.DataDirectory); err != nil {
+			return err
+		}
+		conn, err := db.Connect(ctx, cfg.Options.DataDirectory)
+		if err != nil {
+			return err
+		}
+		pushApp, err := app.New(ctx, conn, cfg)
+		if err != nil {
+			return err
+		}
+		defer pushApp.Shutdown()
+		if pushApp.AgentCoordinator == nil {
+			return errors.New("the recorded models could not be set up")
+		}
+
+		return replayPrompts(ctx, cmd, pushApp, manifest.Prompts)
+	},
+}
+
+func init() {
+	replayCmd.Flags().Bool("live-tools", false, "Run the tools instead of returning the recorded results, and report the differences")
+}
+
+// replayPrompts sends the recorded prompts to the agent in order, each in a
+// new session standing for the recorded one, and prints the responses.
+func replayPrompts(ctx context.Context, cmd *cobra.Command, pushApp *app.App, prompts []recording.Prompt) error {
+	out := cmd.OutOrStdout()
+	sessions := make(map[string]string)
+	for i, prompt := range prompts {
+		sessionID, ok := sessions[prompt.SessionID]
+		if !ok {
+			sess, err := pushApp.Sessions.Create(ctx, fmt.Sprintf("Replay of %s", prompt.SessionID))
+			if err != nil {
+				return fmt.Errorf("failed to create session: %w", err)
+			}
+			pushApp.Permissions.AutoApproveSession(sess.ID)
+			sessionID = sess.ID
+			sessions[prompt.SessionID] = sessionID
+		}
+
+		fmt.Fprintf(out, "> %s\n\n", prompt.Text)
+		result, err := pushApp.AgentCoordinator.Run(ctx, sessionID, prompt.Text, prompt.Attachments...)
+		if err != nil {
+			return fmt.Errorf("prompt %d failed: %w", i+1, err)
+		}
+		if result != nil {
+			fmt.Fprintf(out, "%s\n\n", result.Response.Content.Text())
+		}
+	}
+
+	divergences := pushApp.Recording.Divergences()
+	for _, d := range divergences {
+		fmt.Fprintf(cmd.ErrOrStderr(), "divergence: %s\n", d)
+	}
+	if len(divergences) > 0 {
+		return fmt.Errorf("the replay diverged from the recording in %d places", len(divergences))
+	}
+	return nil
+}
@@ -35,6 +35,7 @@ func init() {
 	rootCmd.PersistentFlags().StringP("cwd", "c", "", "Current working directory")
 	rootCmd.PersistentFlags().StringP("data-dir", "D", "", "Custom push data directory")
 	rootCmd.PersistentFlags().BoolP("debug", "d", false, "Debug")
+	rootCmd.PersistentFlags().String("record", "", "Record provider traffic and tool results into a directory, to be replayed with push replay")
 	rootCmd.Flags().BoolP("help", "h", false, "Help")
 	rootCmd.Flags().BoolP("yolo", "y", false, "Automatically accept all permissions (dangerous mode)")
 
@@ -46,6 +47,11 @@ func init() {
 		logsCmd,
 		schemaCmd,
 		loginCmd,
+		cacheCmd,
+		mcpCmd,
+		checkpointsCmd,
+		replayCmd,
+		evalCmd,
 	)
 }
 
@@ -76,6 +82,9 @@ push run "Explain the use of context in Go"
 
 # Run in dangerous mode (auto-accept all permissions)
 push -y
+
+# Record the session to attach it to a bug report
+push --record ./push-recording
   `,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		app, err := setupAppWithProgressBar(cmd)
@@ -97,6 +106,8 @@ push -y
 			ui,
 			tea.WithAltScreen(),
 			tea.WithMouseCellMotion(),
+			tea.WithReportFocus(),
+			tea.WithOutput(ui.Output()),
 			tea.WithContext(cmd.Context()))
 		go app.Subscribe(program)
 
@@ -199,6 +210,10 @@ func setupApp(cmd *cobra.Command) (*app.App, error) {
 	}
 	cfg.Permissions.SkipRequests = yolo
 
+	if dir, _ := cmd.Flags().GetString("record"); dir != "" {
+		cfg.Options.Recording = &config.Recording{Dir: dir}
+	}
+
 	if err := createDotPushDir(cfg.Options.DataDirectory); err != nil {
 		return nil, err
 	}
@@ -171,3 +171,12 @@ type PasteMsg string
 func (p PasteMsg) String() string {
 	return string(p)
 }
+
+// FocusMsg is sent when the terminal gains focus, with WithReportFocus.
+type FocusMsg = tea.FocusMsg
+
+// BlurMsg is sent when the terminal loses focus, with WithReportFocus.
+type BlurMsg = tea.BlurMsg
+
+// WithReportFocus enables the focus and blur messages.
+var WithReportFocus = tea.WithReportFocus
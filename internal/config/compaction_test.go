@@ -0,0 +1,64 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompactionThresholds(t *testing.T) {
+	t.Parallel()
+
+	t.Run("defaults", func(t *testing.T) {
+		t.Parallel()
+
+		var c *Compaction
+		prune, summarize := c.Thresholds("gpt-4o", 100_000)
+		require.Equal(t, int64(60_000), prune)
+		require.Equal(t, int64(80_000), summarize)
+
+		prune, summarize = c.Thresholds("claude", 1_000_000)
+		require.Equal(t, int64(600_000), prune)
+		require.Equal(t, int64(980_000), summarize)
+	})
+
+	t.Run("per model overrides", func(t *testing.T) {
+		t.Parallel()
+
+		prune, summarizeAt, modelSummarizeAt := 0.5, 0.7, 0.9
+		c := &Compaction{
+			CompactionThresholds: CompactionThresholds{
+				PruneToolOutputsAt: &prune,
+				SummarizeAt:        &summarizeAt,
+			},
+			Models: map[string]CompactionThresholds{
+				"small-model": {SummarizeAt: &modelSummarizeAt},
+			},
+		}
+
+		pruneTokens, summarizeTokens := c.Thresholds("gpt-4o", 100_000)
+		require.Equal(t, int64(50_000), pruneTokens)
+		require.Equal(t, int64(70_000), summarizeTokens)
+
+		pruneTokens, summarizeTokens = c.Thresholds("small-model", 100_000)
+		require.Equal(t, int64(50_000), pruneTokens)
+		require.Equal(t, int64(90_000), summarizeTokens)
+	})
+}
+
+func TestCompactionLimits(t *testing.T) {
+	t.Parallel()
+
+	var c *Compaction
+	keep, maxChars := c.Limits()
+	require.Equal(t, defaultKeepRecentTurns, keep)
+	require.Equal(t, defaultMaxToolOutputChars, maxChars)
+	require.True(t, c.PruningEnabled())
+
+	keepTurns := 5
+	c = &Compaction{KeepRecentTurns: &keepTurns, DisablePruning: true}
+	keep, maxChars = c.Limits()
+	require.Equal(t, 5, keep)
+	require.Equal(t, defaultMaxToolOutputChars, maxChars)
+	require.False(t, c.PruningEnabled())
+}
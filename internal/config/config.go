@@ -88,6 +88,10 @@ type SelectedModel struct {
 
 	// Override provider specific options.
 	ProviderOptions map[string]any `json:"provider_options,omitempty" jsonschema:"description=Additional provider-specific options for the model"`
+
+	// Ordered list of models to switch to when this model fails with a
+	// retryable provider error. Fallbacks may use a different provider.
+	Fallbacks []SelectedModel `json:"fallbacks,omitempty" jsonschema:"description=Ordered list of models to fall back to on provider errors, rate limits or context overflow"`
 }
 
 type ProviderConfig struct {
@@ -205,15 +209,76 @@ type LSPConfig struct {
 	RootMarkers []string          `json:"root_markers,omitempty" jsonschema:"description=Files or directories that indicate the project root,example=go.mod,example=package.json,example=Cargo.toml"`
 	InitOptions map[string]any    `json:"init_options,omitempty" jsonschema:"description=Initialization options passed to the LSP server during initialize request"`
 	Options     map[string]any    `json:"options,omitempty" jsonschema:"description=LSP server-specific settings passed during initialization"`
+	// FormatOnWrite formats files and organizes their imports through this
+	// server after the agent edits them.
+	FormatOnWrite bool `json:"format_on_write,omitempty" jsonschema:"description=Format files and organize imports through this LSP server after the agent edits them,default=false"`
 }
 
 type TUIOptions struct {
 	CompactMode bool   `json:"compact_mode,omitempty" jsonschema:"description=Enable compact mode for the TUI interface,default=false"`
 	DiffMode    string `json:"diff_mode,omitempty" jsonschema:"description=Diff mode for the TUI interface,enum=unified,enum=split"`
-	// Here we can add themes later or any TUI related options
-	//
+	Theme       string `json:"theme,omitempty" jsonschema:"description=Theme of the TUI interface; auto picks a light or dark theme from the terminal background,default=auto,example=charmtone-light"`
+	// Keybindings maps TUI actions, such as app.commands, to the keys
+	// replacing their defaults. An empty list disables the action.
+	Keybindings map[string][]string `json:"keybindings,omitempty" jsonschema:"description=Keys of TUI actions replacing their defaults; an empty list disables the action"`
 
 	Completions Completions `json:"completions,omitzero" jsonschema:"description=Completions UI options"`
+
+	Notifications Notifications `json:"notifications,omitzero" jsonschema:"description=Notifications sent when the agent needs attention"`
+}
+
+// NotificationMethod is the escape sequence desktop notifications are sent
+// to the terminal with.
+type NotificationMethod string
+
+const (
+	NotificationMethodOSC9   NotificationMethod = "osc9"
+	NotificationMethodOSC777 NotificationMethod = "osc777"
+	NotificationMethodNone   NotificationMethod = "none"
+)
+
+// Notifications defines how the TUI tells that the agent needs attention
+// while the terminal is not focused, and for which events.
+type Notifications struct {
+	Terminal NotificationMethod `json:"terminal,omitempty" jsonschema:"description=Escape sequence sending desktop notifications through the terminal,enum=osc9,enum=osc777,enum=none,default=osc9"`
+	Bell     bool               `json:"bell,omitempty" jsonschema:"description=Ring the terminal bell,default=false"`
+	// Command is run with the title and body of each notification as its
+	// last two arguments.
+	Command string `json:"command,omitempty" jsonschema:"description=Command run with the title and body of each notification as arguments,example=notify-send"`
+
+	TurnFinished *bool `json:"turn_finished,omitempty" jsonschema:"description=Notify when the agent finishes working on a session,default=true"`
+	Permission   *bool `json:"permission,omitempty" jsonschema:"description=Notify when the agent asks for a permission,default=true"`
+	Error        *bool `json:"error,omitempty" jsonschema:"description=Notify when an error is reported,default=true"`
+}
+
+// Method returns the escape sequence notifications are sent with.
+func (n Notifications) Method() NotificationMethod {
+	if n.Terminal == "" {
+		return NotificationMethodOSC9
+	}
+	return n.Terminal
+}
+
+// NotificationEvent is what notifications are sent for.
+type NotificationEvent int
+
+const (
+	NotificationTurnFinished NotificationEvent = iota
+	NotificationPermission
+	NotificationError
+)
+
+// Notifies tells whether notifications are sent for an event.
+func (n Notifications) Notifies(event NotificationEvent) bool {
+	switch event {
+	case NotificationTurnFinished:
+		return ptrValOr(n.TurnFinished, true)
+	case NotificationPermission:
+		return ptrValOr(n.Permission, true)
+	case NotificationError:
+		return ptrValOr(n.Error, true)
+	}
+	return false
 }
 
 // Completions defines options for the completions UI.
@@ -254,12 +319,41 @@ func (Attribution) JSONSchemaExtend(schema *jsonschema.Schema) {
 	}
 }
 
+// GeneratedWithLine returns the line added to commit messages, issues and PRs
+// when GeneratedWith is set.
+func (a Attribution) GeneratedWithLine() string {
+	return "💘 Generated with Crush"
+}
+
+// Trailer returns the commit trailer of the attribution style, naming the
+// model when known, or an empty string when no trailer is added.
+func (a Attribution) Trailer(modelName string) string {
+	switch a.TrailerStyle {
+	case TrailerStyleAssistedBy:
+		if modelName != "" {
+			return "Assisted-by: " + modelName + " via Crush <crush@charm.land>"
+		}
+		return "Assisted-by: Crush <crush@charm.land>"
+	case TrailerStyleCoAuthoredBy:
+		return "Co-Authored-By: Crush <crush@charm.land>"
+	default:
+		return ""
+	}
+}
+
 type Options struct {
 	ContextPaths              []string     `json:"context_paths,omitempty" jsonschema:"description=Paths to files containing context information for the AI,example=.cursorrules,example=PUSH.md"`
 	TUI                       *TUIOptions  `json:"tui,omitempty" jsonschema:"description=Terminal user interface options"`
 	Debug                     bool         `json:"debug,omitempty" jsonschema:"description=Enable debug logging,default=false"`
 	DebugLSP                  bool         `json:"debug_lsp,omitempty" jsonschema:"description=Enable debug logging for LSP servers,default=false"`
 	DisableAutoSummarize      bool         `json:"disable_auto_summarize,omitempty" jsonschema:"description=Disable automatic conversation summarization,default=false"`
+	Compaction                *Compaction  `json:"compaction,omitempty" jsonschema:"description=Context compaction settings for long conversations"`
+	HTTPCache                 *HTTPCache   `json:"http_cache,omitempty" jsonschema:"description=Cache for responses fetched by the web tools"`
+	Redaction                 *Redaction   `json:"redaction,omitempty" jsonschema:"description=Masking of secrets in tool results and attachments before they reach the model, the database or the logs. Off unless enabled"`
+	Tracing                   *Tracing     `json:"tracing,omitempty" jsonschema:"description=OpenTelemetry tracing of agent turns, model calls, tool calls, permission requests and MCP and LSP requests"`
+	MCPServer                 *MCPServer   `json:"mcp_server,omitempty" jsonschema:"description=Settings for exposing the built-in tools with push mcp serve"`
+	SessionWorktrees          bool         `json:"session_worktrees,omitempty" jsonschema:"description=Run each session in its own git worktree on a new branch, to be merged back or discarded,default=false"`
+	Checkpoints               bool         `json:"checkpoints,omitempty" jsonschema:"description=Record the working tree after every agent turn as a commit on a hidden ref of the session,default=false"`
 	DataDirectory             string       `json:"data_directory,omitempty" jsonschema:"description=Directory for storing application data (relative to working directory),default=.crush,example=.crush"` // Relative to the cwd
 	DisabledTools             []string     `json:"disabled_tools,omitempty" jsonschema:"description=List of built-in tools to disable and hide from the agent,example=bash,example=sourcegraph"`
 	DisableProviderAutoUpdate bool         `json:"disable_provider_auto_update,omitempty" jsonschema:"description=Disable providers auto-update,default=true"`
@@ -267,6 +361,15 @@ type Options struct {
 	Attribution               *Attribution `json:"attribution,omitempty" jsonschema:"description=Attribution settings for generated content"`
 	DisableMetrics            bool         `json:"disable_metrics,omitempty" jsonschema:"description=Disable sending metrics,default=false"`
 	InitializeAs              string       `json:"initialize_as,omitempty" jsonschema:"description=Name of the context file to create/update during project initialization,default=AGENTS.md,example=AGENTS.md,example=PUSH.md,example=CLAUDE.md,example=docs/LLMs.md"`
+	Recording                 *Recording   `json:"-"` // Set by push --record and push replay
+}
+
+// Recording captures the provider traffic and tool results of a run in a
+// directory, or plays them back. It is only set from the command line.
+type Recording struct {
+	Dir       string
+	Replay    bool
+	LiveTools bool
 }
 
 type MCPs map[string]MCPConfig
@@ -368,6 +471,182 @@ func (t ToolLs) Limits() (depth, items int) {
 	return ptrValOr(t.MaxDepth, 0), ptrValOr(t.MaxItems, 0)
 }
 
+const (
+	defaultPruneToolOutputsAt = 0.6
+	defaultKeepRecentTurns    = 3
+	defaultMaxToolOutputChars = 2000
+)
+
+// Compaction configures how a conversation is shrunk as it approaches the
+// model's context window. Old tool outputs are pruned first, then older turns
+// are summarized while the most recent ones are kept verbatim, and only if
+// that is not enough the whole conversation is summarized.
+type Compaction struct {
+	CompactionThresholds
+
+	DisablePruning     bool `json:"disable_pruning,omitempty" jsonschema:"description=Disable eliding old tool outputs,default=false"`
+	KeepRecentTurns    *int `json:"keep_recent_turns,omitempty" jsonschema:"description=Number of most recent turns kept verbatim when summarizing,default=3,example=5"`
+	MaxToolOutputChars *int `json:"max_tool_output_chars,omitempty" jsonschema:"description=Old tool outputs longer than this are replaced with a stub when pruning,default=2000,example=4000"`
+
+	// Per model overrides of the thresholds, keyed by model ID.
+	Models map[string]CompactionThresholds `json:"models,omitempty" jsonschema:"description=Per model threshold overrides keyed by model ID"`
+}
+
+// CompactionThresholds are fractions of the model's context window at which
+// each compaction tier kicks in.
+type CompactionThresholds struct {
+	PruneToolOutputsAt *float64 `json:"prune_tool_outputs_at,omitempty" jsonschema:"description=Fraction of the context window at which old tool outputs are pruned,default=0.6,minimum=0,maximum=1"`
+	SummarizeAt        *float64 `json:"summarize_at,omitempty" jsonschema:"description=Fraction of the context window at which older turns are summarized,default=0.8,minimum=0,maximum=1"`
+}
+
+// Thresholds returns the token counts at which tool outputs are pruned and
+// older turns are summarized for the given model.
+func (c *Compaction) Thresholds(modelID string, contextWindow int64) (prune, summarize int64) {
+	var thresholds CompactionThresholds
+	if c != nil {
+		thresholds = c.CompactionThresholds
+		if override, ok := c.Models[modelID]; ok {
+			thresholds.PruneToolOutputsAt = cmp.Or(override.PruneToolOutputsAt, thresholds.PruneToolOutputsAt)
+			thresholds.SummarizeAt = cmp.Or(override.SummarizeAt, thresholds.SummarizeAt)
+		}
+	}
+
+	prune = int64(float64(contextWindow) * ptrValOr(thresholds.PruneToolOutputsAt, defaultPruneToolOutputsAt))
+	if thresholds.SummarizeAt != nil {
+		summarize = int64(float64(contextWindow) * *thresholds.SummarizeAt)
+	} else if contextWindow > 200_000 {
+		summarize = contextWindow - 20_000
+	} else {
+		summarize = int64(float64(contextWindow) * 0.8)
+	}
+	return prune, summarize
+}
+
+// PruningEnabled reports whether old tool outputs may be elided.
+func (c *Compaction) PruningEnabled() bool {
+	return c == nil || !c.DisablePruning
+}
+
+// Limits returns how many recent turns are kept verbatim and the size above
+// which old tool outputs are pruned.
+func (c *Compaction) Limits() (keepRecentTurns, maxToolOutputChars int) {
+	if c == nil {
+		return defaultKeepRecentTurns, defaultMaxToolOutputChars
+	}
+	return ptrValOr(c.KeepRecentTurns, defaultKeepRecentTurns), ptrValOr(c.MaxToolOutputChars, defaultMaxToolOutputChars)
+}
+
+// MCPServer configures the tools published by `push mcp serve`.
+type MCPServer struct {
+	Tools       []string             `json:"tools,omitempty" jsonschema:"description=Built-in tools to publish (defaults to all supported tools),example=view,example=edit,example=bash"`
+	Permissions MCPServerPermissions `json:"permissions,omitzero" jsonschema:"description=How permission requests are answered since there is no one to ask"`
+}
+
+// MCPServerPermissions is the policy answering permission requests of tools
+// called through `push mcp serve`. Entries are either a tool name or a
+// tool:action pair, deny rules take precedence over allow rules.
+type MCPServerPermissions struct {
+	Default string   `json:"default,omitempty" jsonschema:"description=Answer for requests not matched by allow or deny,enum=allow,enum=deny,default=deny"`
+	Allow   []string `json:"allow,omitempty" jsonschema:"description=Tools or tool:action pairs that are always allowed,example=edit,example=bash:execute"`
+	Deny    []string `json:"deny,omitempty" jsonschema:"description=Tools or tool:action pairs that are always denied,example=bash"`
+}
+
+// Allows reports whether the policy grants the given tool action.
+func (p MCPServerPermissions) Allows(toolName, action string) bool {
+	commandKey := toolName + ":" + action
+	if slices.Contains(p.Deny, commandKey) || slices.Contains(p.Deny, toolName) {
+		return false
+	}
+	if slices.Contains(p.Allow, commandKey) || slices.Contains(p.Allow, toolName) {
+		return true
+	}
+	return p.Default == "allow"
+}
+
+const (
+	defaultHTTPCacheTTL        = 15 * time.Minute
+	defaultHTTPCacheMaxEntries = 500
+	defaultHTTPCacheMaxSizeMB  = 100
+)
+
+// defaultHTTPCacheTTLs are the tool specific defaults, other tools use
+// defaultHTTPCacheTTL.
+var defaultHTTPCacheTTLs = map[string]time.Duration{
+	"sourcegraph": 5 * time.Minute,
+}
+
+// Redaction configures the masking of secrets, such as API keys or private
+// keys, in tool results and attachments. Secrets are replaced by stable
+// placeholders before reaching the model, the database or the logs. It is
+// off unless enabled.
+//
+// The secrets behind the placeholders are only kept in memory, so that they
+// never reach the disk: placeholders stored in a session before a restart
+// stay placeholders.
+type Redaction struct {
+	Enabled        bool              `json:"enabled,omitempty" jsonschema:"description=Redact secrets in tool results and attachments. Off by default. The secrets are only kept in memory so placeholders from before a restart can no longer be restored,default=false"`
+	DisabledRules  []string          `json:"disabled_rules,omitempty" jsonschema:"description=Built-in rules to turn off,example=high_entropy,example=jwt"`
+	Patterns       map[string]string `json:"patterns,omitempty" jsonschema:"description=Additional rules as a name to regular expression map. The first capture group or the whole match is redacted,example={\"internal_token\":\"itk_[a-z0-9]{32}\"}"`
+	RestoreOnWrite bool              `json:"restore_on_write,omitempty" jsonschema:"description=Substitute secrets back when the agent writes their placeholders to files that did not contain them, after asking for permission. Only secrets seen since push started can be restored,default=false"`
+}
+
+// Tracing configures the export of OpenTelemetry spans. The PUSH_TRACING
+// environment variable, set to otlp or file, overrides the exporter.
+type Tracing struct {
+	Exporter string `json:"exporter,omitempty" jsonschema:"description=Where spans are exported: otlp sends them to an OTLP/HTTP collector and file appends them to traces.jsonl in the data directory,enum=otlp,enum=file"`
+	Endpoint string `json:"endpoint,omitempty" jsonschema:"description=URL of the OTLP/HTTP collector; defaults to OTEL_EXPORTER_OTLP_ENDPOINT or http://localhost:4318,example=http://localhost:4318"`
+}
+
+// HTTPCache configures the response cache shared by the fetch, web_fetch,
+// agentic_fetch, download and sourcegraph tools. Responses are stored in the
+// data directory and reused across sessions.
+type HTTPCache struct {
+	Disabled   bool           `json:"disabled,omitempty" jsonschema:"description=Disable caching of web tool responses,default=false"`
+	MaxEntries int            `json:"max_entries,omitempty" jsonschema:"description=Maximum number of cached responses,default=500,example=1000"`
+	MaxSizeMB  int            `json:"max_size_mb,omitempty" jsonschema:"description=Maximum total size in megabytes of the cached response bodies,default=100,example=250"`
+	TTL        map[string]int `json:"ttl,omitempty" jsonschema:"description=Per tool time to live in seconds of responses without caching headers. Zero disables caching for that tool,example={\"fetch\":3600,\"sourcegraph\":0}"`
+}
+
+// Enabled reports whether web tool responses may be cached.
+func (h *HTTPCache) Enabled() bool {
+	return h == nil || !h.Disabled
+}
+
+// TTLFor returns how long responses fetched by the given tool stay fresh
+// when the server does not say otherwise. Zero means the tool's responses
+// are not cached.
+func (h *HTTPCache) TTLFor(tool string) time.Duration {
+	if !h.Enabled() {
+		return 0
+	}
+	if h != nil {
+		if seconds, ok := h.TTL[tool]; ok {
+			return time.Duration(max(seconds, 0)) * time.Second
+		}
+	}
+	if ttl, ok := defaultHTTPCacheTTLs[tool]; ok {
+		return ttl
+	}
+	return defaultHTTPCacheTTL
+}
+
+// Limit returns the maximum number of cached responses.
+func (h *HTTPCache) Limit() int {
+	if h == nil || h.MaxEntries <= 0 {
+		return defaultHTTPCacheMaxEntries
+	}
+	return h.MaxEntries
+}
+
+// SizeLimit returns the maximum total size in bytes of the cached response
+// bodies.
+func (h *HTTPCache) SizeLimit() int64 {
+	if h == nil || h.MaxSizeMB <= 0 {
+		return defaultHTTPCacheMaxSizeMB << 20
+	}
+	return int64(h.MaxSizeMB) << 20
+}
+
 // Config holds the configuration for crush.
 type Config struct {
 	Schema string `json:"$schema,omitempty"`
@@ -473,6 +752,14 @@ func (c *Config) SetCompactMode(enabled bool) error {
 	return c.SetConfigField("options.tui.compact_mode", enabled)
 }
 
+func (c *Config) SetTheme(name string) error {
+	if c.Options == nil {
+		c.Options = &Options{}
+	}
+	c.Options.TUI.Theme = name
+	return c.SetConfigField("options.tui.theme", name)
+}
+
 func (c *Config) Resolve(key string) (string, error) {
 	if c.resolver == nil {
 		return "", fmt.Errorf("no variable resolver configured")
@@ -481,6 +768,13 @@ func (c *Config) Resolve(key string) (string, error) {
 }
 
 func (c *Config) UpdatePreferredModel(modelType SelectedModelType, model SelectedModel) error {
+	// Keep the configured fallback chain when switching the primary model,
+	// minus the model that just became primary.
+	if model.Fallbacks == nil {
+		model.Fallbacks = slices.DeleteFunc(slices.Clone(c.Models[modelType].Fallbacks), func(fb SelectedModel) bool {
+			return fb.Provider == model.Provider && fb.Model == model.Model
+		})
+	}
 	c.Models[modelType] = model
 	if err := c.SetConfigField(fmt.Sprintf("models.%s", modelType), model); err != nil {
 		return fmt.Errorf("failed to update preferred model: %w", err)
@@ -690,8 +984,12 @@ func allToolNames() []string {
 		"download",
 		"edit",
 		"multiedit",
+		"apply_patch",
 		"lsp_diagnostics",
 		"lsp_references",
+		"lsp_rename",
+		"lsp_code_actions",
+		"read_mcp_resource",
 		"fetch",
 		"agentic_fetch",
 		"glob",
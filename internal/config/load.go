@@ -601,11 +601,37 @@ func (c *Config) configureSelectedModels(knownProviders []catwalk.Provider) erro
 			small.Think = smallModelSelected.Think
 		}
 	}
+	if largeModelConfigured {
+		large.Fallbacks = c.validFallbacks(SelectedModelTypeLarge, largeModelSelected.Fallbacks)
+	}
+	if smallModelConfigured {
+		small.Fallbacks = c.validFallbacks(SelectedModelTypeSmall, smallModelSelected.Fallbacks)
+	}
 	c.Models[SelectedModelTypeLarge] = large
 	c.Models[SelectedModelTypeSmall] = small
 	return nil
 }
 
+// validFallbacks drops fallback models that are not available from any
+// configured provider and fills in their default max tokens.
+func (c *Config) validFallbacks(modelType SelectedModelType, fallbacks []SelectedModel) []SelectedModel {
+	var valid []SelectedModel
+	for _, fb := range fallbacks {
+		model := c.GetModel(fb.Provider, fb.Model)
+		if model == nil {
+			slog.Warn("Skipping unknown fallback model", "type", modelType, "provider", fb.Provider, "model", fb.Model)
+			continue
+		}
+		if fb.MaxTokens == 0 {
+			fb.MaxTokens = model.DefaultMaxTokens
+		}
+		// Fallback chains are flat.
+		fb.Fallbacks = nil
+		valid = append(valid, fb)
+	}
+	return valid
+}
+
 // lookupConfigs searches config files recursively from CWD up to FS root
 func lookupConfigs(cwd string) []string {
 	// prepend default config paths
@@ -487,7 +487,7 @@ func TestConfig_setupAgentsWithDisabledTools(t *testing.T) {
 	coderAgent, ok := cfg.Agents[AgentCoder]
 	require.True(t, ok)
 
-	assert.Equal(t, []string{"agent", "bash", "job_output", "job_kill", "multiedit", "lsp_diagnostics", "lsp_references", "fetch", "agentic_fetch", "glob", "ls", "sourcegraph", "todos", "view", "write"}, coderAgent.AllowedTools)
+	assert.Equal(t, []string{"agent", "bash", "job_output", "job_kill", "multiedit", "apply_patch", "lsp_diagnostics", "lsp_references", "lsp_rename", "lsp_code_actions", "read_mcp_resource", "fetch", "agentic_fetch", "glob", "ls", "sourcegraph", "todos", "view", "write"}, coderAgent.AllowedTools)
 
 	taskAgent, ok := cfg.Agents[AgentTask]
 	require.True(t, ok)
@@ -510,7 +510,7 @@ func TestConfig_setupAgentsWithEveryReadOnlyToolDisabled(t *testing.T) {
 	cfg.SetupAgents()
 	coderAgent, ok := cfg.Agents[AgentCoder]
 	require.True(t, ok)
-	assert.Equal(t, []string{"agent", "bash", "job_output", "job_kill", "download", "edit", "multiedit", "lsp_diagnostics", "lsp_references", "fetch", "agentic_fetch", "todos", "write"}, coderAgent.AllowedTools)
+	assert.Equal(t, []string{"agent", "bash", "job_output", "job_kill", "download", "edit", "multiedit", "apply_patch", "lsp_diagnostics", "lsp_references", "lsp_rename", "lsp_code_actions", "read_mcp_resource", "fetch", "agentic_fetch", "todos", "write"}, coderAgent.AllowedTools)
 
 	taskAgent, ok := cfg.Agents[AgentTask]
 	require.True(t, ok)
@@ -1248,3 +1248,68 @@ func TestConfig_configureSelectedModels(t *testing.T) {
 		require.Equal(t, int64(100), large.MaxTokens)
 	})
 }
+
+func TestConfig_configureSelectedModelsFallbacks(t *testing.T) {
+	knownProviders := []catwalk.Provider{
+		{
+			ID:                  "openai",
+			APIKey:              "abc",
+			DefaultLargeModelID: "large-model",
+			DefaultSmallModelID: "small-model",
+			Models: []catwalk.Model{
+				{
+					ID:               "large-model",
+					DefaultMaxTokens: 1000,
+				},
+				{
+					ID:               "small-model",
+					DefaultMaxTokens: 500,
+				},
+			},
+		},
+		{
+			ID:                  "anthropic",
+			APIKey:              "abc",
+			DefaultLargeModelID: "a-large-model",
+			DefaultSmallModelID: "a-small-model",
+			Models: []catwalk.Model{
+				{
+					ID:               "a-large-model",
+					DefaultMaxTokens: 800,
+				},
+				{
+					ID:               "a-small-model",
+					DefaultMaxTokens: 200,
+				},
+			},
+		},
+	}
+
+	cfg := &Config{
+		Models: map[SelectedModelType]SelectedModel{
+			"large": {
+				Model:    "large-model",
+				Provider: "openai",
+				Fallbacks: []SelectedModel{
+					{Model: "a-large-model", Provider: "anthropic"},
+					{Model: "missing-model", Provider: "anthropic"},
+					{Model: "small-model", Provider: "openai", MaxTokens: 100},
+				},
+			},
+		},
+	}
+	cfg.setDefaults("/tmp", "")
+	env := env.NewFromMap(map[string]string{})
+	resolver := NewEnvironmentVariableResolver(env)
+	require.NoError(t, cfg.configureProviders(env, resolver, knownProviders))
+	require.NoError(t, cfg.configureSelectedModels(knownProviders))
+
+	large := cfg.Models[SelectedModelTypeLarge]
+	require.Len(t, large.Fallbacks, 2)
+	require.Equal(t, "a-large-model", large.Fallbacks[0].Model)
+	require.Equal(t, "anthropic", large.Fallbacks[0].Provider)
+	require.Equal(t, int64(800), large.Fallbacks[0].MaxTokens)
+	require.Equal(t, "small-model", large.Fallbacks[1].Model)
+	require.Equal(t, int64(100), large.Fallbacks[1].MaxTokens)
+	require.Empty(t, cfg.Models[SelectedModelTypeSmall].Fallbacks)
+}
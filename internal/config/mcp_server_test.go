@@ -0,0 +1,24 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMCPServerPermissionsAllows(t *testing.T) {
+	t.Parallel()
+
+	policy := MCPServerPermissions{
+		Allow: []string{"edit", "bash:execute"},
+		Deny:  []string{"write"},
+	}
+	require.True(t, policy.Allows("edit", "write"))
+	require.True(t, policy.Allows("bash", "execute"))
+	require.False(t, policy.Allows("bash", "kill"))
+	require.False(t, policy.Allows("write", "write"))
+
+	policy.Default = "allow"
+	require.True(t, policy.Allows("bash", "kill"))
+	require.False(t, policy.Allows("write", "write"))
+}
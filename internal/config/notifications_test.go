@@ -0,0 +1,25 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotifications(t *testing.T) {
+	t.Parallel()
+
+	var defaults Notifications
+	require.Equal(t, NotificationMethodOSC9, defaults.Method())
+	require.True(t, defaults.Notifies(NotificationTurnFinished))
+	require.True(t, defaults.Notifies(NotificationPermission))
+	require.True(t, defaults.Notifies(NotificationError))
+	require.False(t, defaults.Notifies(NotificationEvent(-1)), "unknown events are not notified")
+
+	off := false
+	n := Notifications{Terminal: NotificationMethodNone, Permission: &off}
+	require.Equal(t, NotificationMethodNone, n.Method())
+	require.True(t, n.Notifies(NotificationTurnFinished))
+	require.False(t, n.Notifies(NotificationPermission))
+	require.True(t, n.Notifies(NotificationError))
+}
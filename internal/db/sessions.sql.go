@@ -33,7 +33,7 @@ INSERT INTO sessions (
     null,
     strftime('%s', 'now'),
     strftime('%s', 'now')
-) RETURNING id, parent_session_id, title, message_count, prompt_tokens, completion_tokens, cost, updated_at, created_at, summary_message_id, todos
+) RETURNING id, parent_session_id, title, message_count, prompt_tokens, completion_tokens, cost, updated_at, created_at, summary_message_id, todos, summary_until_message_id, queued_prompts
 `
 
 type CreateSessionParams struct {
@@ -69,6 +69,8 @@ func (q *Queries) CreateSession(ctx context.Context, arg CreateSessionParams) (S
 		&i.CreatedAt,
 		&i.SummaryMessageID,
 		&i.Todos,
+		&i.SummaryUntilMessageID,
+		&i.QueuedPrompts,
 	)
 	return i, err
 }
@@ -84,7 +86,7 @@ func (q *Queries) DeleteSession(ctx context.Context, id string) error {
 }
 
 const getSessionByID = `-- name: GetSessionByID :one
-SELECT id, parent_session_id, title, message_count, prompt_tokens, completion_tokens, cost, updated_at, created_at, summary_message_id, todos
+SELECT id, parent_session_id, title, message_count, prompt_tokens, completion_tokens, cost, updated_at, created_at, summary_message_id, todos, summary_until_message_id, queued_prompts
 FROM sessions
 WHERE id = ? LIMIT 1
 `
@@ -104,12 +106,14 @@ func (q *Queries) GetSessionByID(ctx context.Context, id string) (Session, error
 		&i.CreatedAt,
 		&i.SummaryMessageID,
 		&i.Todos,
+		&i.SummaryUntilMessageID,
+		&i.QueuedPrompts,
 	)
 	return i, err
 }
 
 const listSessions = `-- name: ListSessions :many
-SELECT id, parent_session_id, title, message_count, prompt_tokens, completion_tokens, cost, updated_at, created_at, summary_message_id, todos
+SELECT id, parent_session_id, title, message_count, prompt_tokens, completion_tokens, cost, updated_at, created_at, summary_message_id, todos, summary_until_message_id, queued_prompts
 FROM sessions
 WHERE parent_session_id is NULL
 ORDER BY updated_at DESC
@@ -136,6 +140,8 @@ func (q *Queries) ListSessions(ctx context.Context) ([]Session, error) {
 			&i.CreatedAt,
 			&i.SummaryMessageID,
 			&i.Todos,
+			&i.SummaryUntilMessageID,
+			&i.QueuedPrompts,
 		); err != nil {
 			return nil, err
 		}
@@ -158,19 +164,21 @@ SET
     completion_tokens = ?,
     summary_message_id = ?,
     cost = ?,
-    todos = ?
+    todos = ?,
+    summary_until_message_id = ?
 WHERE id = ?
-RETURNING id, parent_session_id, title, message_count, prompt_tokens, completion_tokens, cost, updated_at, created_at, summary_message_id, todos
+RETURNING id, parent_session_id, title, message_count, prompt_tokens, completion_tokens, cost, updated_at, created_at, summary_message_id, todos, summary_until_message_id, queued_prompts
 `
 
 type UpdateSessionParams struct {
-	Title            string         `json:"title"`
-	PromptTokens     int64          `json:"prompt_tokens"`
-	CompletionTokens int64          `json:"completion_tokens"`
-	SummaryMessageID sql.NullString `json:"summary_message_id"`
-	Cost             float64        `json:"cost"`
-	Todos            sql.NullString `json:"todos"`
-	ID               string         `json:"id"`
+	Title                 string         `json:"title"`
+	PromptTokens          int64          `json:"prompt_tokens"`
+	CompletionTokens      int64          `json:"completion_tokens"`
+	SummaryMessageID      sql.NullString `json:"summary_message_id"`
+	Cost                  float64        `json:"cost"`
+	Todos                 sql.NullString `json:"todos"`
+	SummaryUntilMessageID sql.NullString `json:"summary_until_message_id"`
+	ID                    string         `json:"id"`
 }
 
 func (q *Queries) UpdateSession(ctx context.Context, arg UpdateSessionParams) (Session, error) {
@@ -181,6 +189,7 @@ func (q *Queries) UpdateSession(ctx context.Context, arg UpdateSessionParams) (S
 		arg.SummaryMessageID,
 		arg.Cost,
 		arg.Todos,
+		arg.SummaryUntilMessageID,
 		arg.ID,
 	)
 	var i Session
@@ -196,10 +205,29 @@ func (q *Queries) UpdateSession(ctx context.Context, arg UpdateSessionParams) (S
 		&i.CreatedAt,
 		&i.SummaryMessageID,
 		&i.Todos,
+		&i.SummaryUntilMessageID,
+		&i.QueuedPrompts,
 	)
 	return i, err
 }
 
+const updateSessionQueuedPrompts = `-- name: UpdateSessionQueuedPrompts :exec
+UPDATE sessions
+SET
+    queued_prompts = ?
+WHERE id = ?
+`
+
+type UpdateSessionQueuedPromptsParams struct {
+	QueuedPrompts sql.NullString `json:"queued_prompts"`
+	ID            string         `json:"id"`
+}
+
+func (q *Queries) UpdateSessionQueuedPrompts(ctx context.Context, arg UpdateSessionQueuedPromptsParams) error {
+	_, err := q.exec(ctx, q.updateSessionQueuedPromptsStmt, updateSessionQueuedPrompts, arg.QueuedPrompts, arg.ID)
+	return err
+}
+
 const updateSessionTitleAndUsage = `-- name: UpdateSessionTitleAndUsage :exec
 UPDATE sessions
 SET
@@ -42,7 +42,8 @@ SET
     completion_tokens = ?,
     summary_message_id = ?,
     cost = ?,
-    todos = ?
+    todos = ?,
+    summary_until_message_id = ?
 WHERE id = ?
 RETURNING *;
 
@@ -55,6 +56,12 @@ SET
     cost = cost + ?
 WHERE id = ?;
 
+-- name: UpdateSessionQueuedPrompts :exec
+UPDATE sessions
+SET
+    queued_prompts = ?
+WHERE id = ?;
+
 
 -- name: DeleteSession :exec
 DELETE FROM sessions
@@ -0,0 +1,73 @@
+package diff
+
+import (
+	"strings"
+
+	"github.com/aymanbagabas/go-udiff"
+)
+
+// Hunk is a group of nearby changed lines between two versions of a file,
+// with the unchanged lines around them.
+type Hunk struct {
+	// OldStart and NewStart are the 1-based lines where the hunk starts in
+	// the old and the new version.
+	OldStart, NewStart int
+	// OldLines and NewLines are the number of lines the hunk spans in each
+	// version.
+	OldLines, NewLines int
+	// Old and New are the text of the hunk in each version.
+	Old, New string
+}
+
+// Hunks splits the changes from before to after into hunks, in the order
+// they appear in the file.
+func Hunks(before, after string) []Hunk {
+	edits := udiff.Strings(before, after)
+	unified, err := udiff.ToUnifiedDiff("", "", before, edits, udiff.DefaultContextLines)
+	if err != nil {
+		return nil
+	}
+	hunks := make([]Hunk, 0, len(unified.Hunks))
+	for _, h := range unified.Hunks {
+		hunk := Hunk{OldStart: h.FromLine, NewStart: h.ToLine}
+		var old, new strings.Builder
+		for _, l := range h.Lines {
+			if l.Kind != udiff.Insert {
+				old.WriteString(l.Content)
+				hunk.OldLines++
+			}
+			if l.Kind != udiff.Delete {
+				new.WriteString(l.Content)
+				hunk.NewLines++
+			}
+		}
+		hunk.Old, hunk.New = old.String(), new.String()
+		hunks = append(hunks, hunk)
+	}
+	return hunks
+}
+
+// ApplyHunks replaces the lines of before covered by each hunk with its new
+// text. The hunks must come from Hunks for the same before content, in
+// order, but can be a subset of them with their new text changed.
+func ApplyHunks(before string, hunks []Hunk) string {
+	lines := strings.SplitAfter(before, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	var out strings.Builder
+	next := 0
+	for _, h := range hunks {
+		start := max(h.OldStart-1, next)
+		for _, l := range lines[next:min(start, len(lines))] {
+			out.WriteString(l)
+		}
+		out.WriteString(h.New)
+		next = min(start+h.OldLines, len(lines))
+	}
+	for _, l := range lines[next:] {
+		out.WriteString(l)
+	}
+	return out.String()
+}
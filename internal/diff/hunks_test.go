@@ -0,0 +1,59 @@
+package diff
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func numberedLines(n int) []string {
+	lines := make([]string, n)
+	for i := range lines {
+		lines[i] = fmt.Sprintf("line %d\n", i+1)
+	}
+	return lines
+}
+
+func TestHunks(t *testing.T) {
+	t.Parallel()
+
+	lines := numberedLines(30)
+	before := strings.Join(lines, "")
+	lines[2] = "changed 3\n"
+	lines[25] = "changed 26\n"
+	after := strings.Join(lines, "")
+
+	hunks := Hunks(before, after)
+	require.Len(t, hunks, 2)
+	require.Equal(t, 1, hunks[0].OldStart)
+	require.Equal(t, 6, hunks[0].OldLines)
+	require.Contains(t, hunks[0].Old, "line 3\n")
+	require.Contains(t, hunks[0].New, "changed 3\n")
+	require.Equal(t, 23, hunks[1].OldStart)
+
+	require.Equal(t, after, ApplyHunks(before, hunks))
+	require.Equal(t, before, ApplyHunks(before, nil))
+
+	// Only the second hunk.
+	partial := ApplyHunks(before, hunks[1:])
+	require.Contains(t, partial, "line 3\n")
+	require.Contains(t, partial, "changed 26\n")
+
+	// The first hunk, as edited by the user.
+	edited := hunks[0]
+	edited.New = strings.Replace(edited.New, "changed 3", "edited 3", 1)
+	partial = ApplyHunks(before, []Hunk{edited})
+	require.Contains(t, partial, "edited 3\n")
+	require.Contains(t, partial, "line 26\n")
+}
+
+func TestHunksNewFile(t *testing.T) {
+	t.Parallel()
+
+	hunks := Hunks("", "package main\n\nfunc main() {}")
+	require.Len(t, hunks, 1)
+	require.Zero(t, hunks[0].OldLines)
+	require.Equal(t, "package main\n\nfunc main() {}", ApplyHunks("", hunks))
+}
@@ -0,0 +1,373 @@
+// Package eval runs suites of tasks through the non-interactive agent, with
+// one or more push configurations, and compares how they fare.
+//
+// A suite is a directory with one subdirectory per task, holding:
+//
+//   - task.json, the prompt and the command verifying the result;
+//   - repo/, the repository the task starts from;
+//   - recordings/<config>/, the runs recorded with --record, replayed
+//     offline with --replay.
+package eval
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/uglyswap/push/internal/db"
+	"github.com/uglyswap/push/internal/message"
+	"github.com/uglyswap/push/internal/session"
+	"github.com/uglyswap/push/internal/shell"
+	"golang.org/x/sync/errgroup"
+)
+
+const (
+	taskFile      = "task.json"
+	repoDir       = "repo"
+	recordingsDir = "recordings"
+
+	// projectConfig is the name under which a configuration is copied into
+	// the task repository, where it takes precedence over the other ones.
+	projectConfig = "push.json"
+
+	// DefaultConfig names the configuration of the user, used when no other
+	// is given.
+	DefaultConfig = "default"
+
+	// maxOutput is the number of trailing bytes of output kept in results.
+	maxOutput = 4096
+)
+
+// Task is a task of a suite.
+type Task struct {
+	Name string `json:"-"`
+	Dir  string `json:"-"`
+
+	Prompt string `json:"prompt"`
+	// Verify is the shell command run in the repository after the agent;
+	// the task passes when it exits with status 0.
+	Verify string `json:"verify"`
+	// Timeout bounds the agent run and the verification, such as "10m".
+	Timeout string `json:"timeout,omitempty"`
+}
+
+// Config is a push configuration to evaluate.
+type Config struct {
+	Name string `json:"name"`
+	// Path is the configuration file copied into the task repositories.
+	// When empty, the configuration of the user is used as is.
+	Path string `json:"path,omitempty"`
+}
+
+// Result is the outcome of a task with a configuration.
+type Result struct {
+	Task         string        `json:"task"`
+	Config       string        `json:"config"`
+	Passed       bool          `json:"passed"`
+	Error        string        `json:"error,omitempty"`
+	Output       string        `json:"output,omitempty"`
+	Turns        int           `json:"turns"`
+	ToolCalls    int           `json:"tool_calls"`
+	InputTokens  int64         `json:"input_tokens"`
+	OutputTokens int64         `json:"output_tokens"`
+	Cost         float64       `json:"cost"`
+	Duration     time.Duration `json:"duration"`
+}
+
+// LoadSuite returns the tasks of the suite in dir, sorted by name.
+func LoadSuite(dir string) ([]Task, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read suite: %w", err)
+	}
+	var tasks []Task
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		taskDir := filepath.Join(dir, entry.Name())
+		data, err := os.ReadFile(filepath.Join(taskDir, taskFile))
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read task %s: %w", entry.Name(), err)
+		}
+		task := Task{Name: entry.Name(), Dir: taskDir}
+		if err := json.Unmarshal(data, &task); err != nil {
+			return nil, fmt.Errorf("failed to parse task %s: %w", entry.Name(), err)
+		}
+		if task.Prompt == "" || task.Verify == "" {
+			return nil, fmt.Errorf("task %s needs a prompt and a verify command", entry.Name())
+		}
+		if task.Timeout != "" {
+			if _, err := time.ParseDuration(task.Timeout); err != nil {
+				return nil, fmt.Errorf("task %s has an invalid timeout: %w", entry.Name(), err)
+			}
+		}
+		tasks = append(tasks, task)
+	}
+	if len(tasks) == 0 {
+		return nil, fmt.Errorf("no tasks found in %s", dir)
+	}
+	slices.SortFunc(tasks, func(a, b Task) int { return strings.Compare(a.Name, b.Name) })
+	return tasks, nil
+}
+
+// Runner runs the tasks of a suite.
+type Runner struct {
+	// Executable is the push binary running the agent.
+	Executable string
+	// Parallel is the number of tasks run at once.
+	Parallel int
+	// Timeout bounds each task without a timeout of its own.
+	Timeout time.Duration
+	// Record stores the runs in the recordings of the tasks.
+	Record bool
+	// Replay plays the recordings of the tasks back instead of calling the
+	// providers. The tools run for real.
+	Replay bool
+	// OnResult, when set, is called as each task finishes, one call at a
+	// time.
+	OnResult func(Result)
+
+	// agentCommand builds the command running the agent; tests replace it.
+	agentCommand func(ctx context.Context, task Task, cfg Config, workDir, dataDir string) (*exec.Cmd, error)
+}
+
+// Run runs every task with every configuration, in a temporary copy of the
+// task repository, and returns the results by task then configuration.
+func (r *Runner) Run(ctx context.Context, tasks []Task, configs []Config) []Result {
+	results := make([]Result, len(tasks)*len(configs))
+	var mu sync.Mutex
+	var g errgroup.Group
+	g.SetLimit(max(1, r.Parallel))
+	for i, task := range tasks {
+		for j, cfg := range configs {
+			g.Go(func() error {
+				result := r.runTask(ctx, task, cfg)
+				results[i*len(configs)+j] = result
+				if r.OnResult != nil {
+					mu.Lock()
+					r.OnResult(result)
+					mu.Unlock()
+				}
+				return nil
+			})
+		}
+	}
+	_ = g.Wait()
+	return results
+}
+
+func (r *Runner) runTask(ctx context.Context, task Task, cfg Config) (result Result) {
+	result = Result{Task: task.Name, Config: cfg.Name}
+	start := time.Now()
+	defer func() { result.Duration = time.Since(start) }()
+
+	timeout := r.Timeout
+	if task.Timeout != "" {
+		timeout, _ = time.ParseDuration(task.Timeout)
+	}
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	base, err := os.MkdirTemp("", "push-eval-")
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	defer os.RemoveAll(base)
+	workDir := filepath.Join(base, repoDir)
+	dataDir := filepath.Join(base, "data")
+	if err := prepareRepo(task, cfg, workDir); err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	command := r.agentCommand
+	if command == nil {
+		command = r.pushCommand
+	}
+	cmd, err := command(ctx, task, cfg, workDir, dataDir)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	output, err := cmd.CombinedOutput()
+	readMetrics(context.WithoutCancel(ctx), dataDir, &result)
+	if err != nil {
+		result.Error = fmt.Sprintf("agent failed: %v", err)
+		if ctx.Err() != nil {
+			result.Error = "timed out"
+		}
+		result.Output = tail(string(output))
+		return result
+	}
+
+	stdout, stderr, err := shell.NewShell(&shell.Options{WorkingDir: workDir}).Exec(ctx, task.Verify)
+	result.Passed = err == nil
+	result.Output = tail(stdout + stderr)
+	if err != nil && ctx.Err() != nil {
+		result.Error = "timed out"
+	}
+	return result
+}
+
+// prepareRepo copies the repository of the task to dir, with the
+// configuration file when there is one.
+func prepareRepo(task Task, cfg Config, dir string) error {
+	src := filepath.Join(task.Dir, repoDir)
+	if _, err := os.Stat(src); errors.Is(err, fs.ErrNotExist) {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return fmt.Errorf("failed to create task repository: %w", err)
+		}
+	} else if err := os.CopyFS(dir, os.DirFS(src)); err != nil {
+		return fmt.Errorf("failed to copy task repository: %w", err)
+	}
+	if cfg.Path == "" {
+		return nil
+	}
+	data, err := os.ReadFile(cfg.Path)
+	if err != nil {
+		return fmt.Errorf("failed to read configuration %s: %w", cfg.Name, err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, projectConfig), data, 0o644); err != nil {
+		return fmt.Errorf("failed to write configuration %s: %w", cfg.Name, err)
+	}
+	return nil
+}
+
+// RecordingDir returns the directory holding the recorded run of a task with
+// a configuration.
+func RecordingDir(task Task, cfg Config) string {
+	return filepath.Join(task.Dir, recordingsDir, cfg.Name)
+}
+
+// pushCommand runs the task with push run, recording it if asked to, or
+// replays its recording.
+func (r *Runner) pushCommand(ctx context.Context, task Task, cfg Config, workDir, dataDir string) (*exec.Cmd, error) {
+	recording, err := filepath.Abs(RecordingDir(task, cfg))
+	if err != nil {
+		return nil, err
+	}
+	var args []string
+	switch {
+	case r.Replay:
+		if _, err := os.Stat(recording); err != nil {
+			return nil, fmt.Errorf("no recording of %s with %s: %w", task.Name, cfg.Name, err)
+		}
+		args = []string{"replay", "--live-tools", "--cwd", workDir, "--data-dir", dataDir, recording}
+	case r.Record:
+		if err := os.RemoveAll(recording); err != nil {
+			return nil, fmt.Errorf("failed to remove previous recording: %w", err)
+		}
+		args = []string{"run", "--quiet", "--cwd", workDir, "--data-dir", dataDir, "--record", recording, task.Prompt}
+	default:
+		args = []string{"run", "--quiet", "--cwd", workDir, "--data-dir", dataDir, task.Prompt}
+	}
+	cmd := exec.CommandContext(ctx, r.Executable, args...)
+	cmd.Dir = workDir
+	cmd.Env = append(os.Environ(), "PUSH_DISABLE_METRICS=1")
+	return cmd, nil
+}
+
+// readMetrics fills the turns, tool calls, tokens and cost of a result from
+// the sessions the agent left in its data directory.
+func readMetrics(ctx context.Context, dataDir string, result *Result) {
+	if _, err := os.Stat(dataDir); err != nil {
+		return
+	}
+	conn, err := db.Connect(ctx, dataDir)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	q := db.New(conn)
+	sessions, err := session.NewService(q).List(ctx)
+	if err != nil {
+		return
+	}
+	messages := message.NewService(q)
+	for _, sess := range sessions {
+		result.InputTokens += sess.PromptTokens
+		result.OutputTokens += sess.CompletionTokens
+		result.Cost += sess.Cost
+		msgs, err := messages.List(ctx, sess.ID)
+		if err != nil {
+			continue
+		}
+		for _, msg := range msgs {
+			if msg.Role != message.Assistant {
+				continue
+			}
+			result.Turns++
+			result.ToolCalls += len(msg.ToolCalls())
+		}
+	}
+}
+
+func tail(s string) string {
+	s = string(bytes.ToValidUTF8([]byte(s[max(0, len(s)-maxOutput):]), nil))
+	return strings.TrimSpace(s)
+}
+
+// Summary aggregates the results of a configuration.
+type Summary struct {
+	Config       string        `json:"config"`
+	Tasks        int           `json:"tasks"`
+	Passed       int           `json:"passed"`
+	Turns        int           `json:"turns"`
+	ToolCalls    int           `json:"tool_calls"`
+	InputTokens  int64         `json:"input_tokens"`
+	OutputTokens int64         `json:"output_tokens"`
+	Cost         float64       `json:"cost"`
+	Duration     time.Duration `json:"duration"`
+}
+
+// PassRate returns the share of tasks passed.
+func (s Summary) PassRate() float64 {
+	if s.Tasks == 0 {
+		return 0
+	}
+	return float64(s.Passed) / float64(s.Tasks)
+}
+
+// Summarize aggregates results by configuration, in the order of configs.
+func Summarize(configs []Config, results []Result) []Summary {
+	summaries := make([]Summary, len(configs))
+	for i, cfg := range configs {
+		summaries[i].Config = cfg.Name
+		for _, result := range results {
+			if result.Config != cfg.Name {
+				continue
+			}
+			s := &summaries[i]
+			s.Tasks++
+			if result.Passed {
+				s.Passed++
+			}
+			s.Turns += result.Turns
+			s.ToolCalls += result.ToolCalls
+			s.InputTokens += result.InputTokens
+			s.OutputTokens += result.OutputTokens
+			s.Cost += result.Cost
+			s.Duration += result.Duration
+		}
+	}
+	return summaries
+}
@@ -0,0 +1,124 @@
+package eval
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func writeTask(t *testing.T, suite, name, task string, files map[string]string) {
+	t.Helper()
+	dir := filepath.Join(suite, name)
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, repoDir), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, taskFile), []byte(task), 0o644))
+	for path, content := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, repoDir, path), []byte(content), 0o644))
+	}
+}
+
+func TestLoadSuite(t *testing.T) {
+	t.Parallel()
+
+	suite := t.TempDir()
+	writeTask(t, suite, "b-task", `{"prompt": "do b", "verify": "true"}`, nil)
+	writeTask(t, suite, "a-task", `{"prompt": "do a", "verify": "true", "timeout": "1m"}`, nil)
+	require.NoError(t, os.Mkdir(filepath.Join(suite, "not-a-task"), 0o755))
+
+	tasks, err := LoadSuite(suite)
+	require.NoError(t, err)
+	require.Len(t, tasks, 2)
+	require.Equal(t, "a-task", tasks[0].Name)
+	require.Equal(t, "do a", tasks[0].Prompt)
+	require.Equal(t, "b-task", tasks[1].Name)
+
+	writeTask(t, suite, "c-task", `{"prompt": "no verify"}`, nil)
+	_, err = LoadSuite(suite)
+	require.Error(t, err)
+
+	_, err = LoadSuite(t.TempDir())
+	require.Error(t, err)
+}
+
+func TestRun(t *testing.T) {
+	t.Parallel()
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake agent is a shell script")
+	}
+
+	suite := t.TempDir()
+	writeTask(t, suite, "create", `{"prompt": "create done.txt", "verify": "test -f done.txt"}`, nil)
+	writeTask(t, suite, "fix", `{"prompt": "fix", "verify": "grep -q fixed main.txt"}`, map[string]string{"main.txt": "broken"})
+	tasks, err := LoadSuite(suite)
+	require.NoError(t, err)
+
+	cfgPath := filepath.Join(t.TempDir(), "fast.json")
+	require.NoError(t, os.WriteFile(cfgPath, []byte(`{}`), 0o644))
+	configs := []Config{{Name: DefaultConfig}, {Name: "fast", Path: cfgPath}}
+
+	var finished int
+	runner := &Runner{
+		Parallel: 2,
+		OnResult: func(Result) { finished++ },
+		// The fake agent only completes tasks with the fast configuration,
+		// recognized by the configuration file copied in the repository.
+		agentCommand: func(ctx context.Context, task Task, cfg Config, workDir, dataDir string) (*exec.Cmd, error) {
+			cmd := exec.CommandContext(ctx, "sh", "-c", "test -f push.json || exit 0; touch done.txt; echo fixed > main.txt")
+			cmd.Dir = workDir
+			return cmd, nil
+		},
+	}
+	results := runner.Run(context.Background(), tasks, configs)
+	require.Len(t, results, 4)
+	require.Equal(t, 4, finished)
+
+	require.Equal(t, "create", results[0].Task)
+	require.Equal(t, DefaultConfig, results[0].Config)
+	require.False(t, results[0].Passed)
+	require.True(t, results[1].Passed, results[1].Output)
+	require.Equal(t, "fix", results[2].Task)
+	require.False(t, results[2].Passed)
+	require.True(t, results[3].Passed, results[3].Output)
+	for _, result := range results {
+		require.Positive(t, result.Duration, "%s with %s", result.Task, result.Config)
+	}
+
+	// The fixtures are left untouched.
+	data, err := os.ReadFile(filepath.Join(tasks[1].Dir, repoDir, "main.txt"))
+	require.NoError(t, err)
+	require.Equal(t, "broken", string(data))
+
+	summaries := Summarize(configs, results)
+	require.Equal(t, []string{DefaultConfig, "fast"}, []string{summaries[0].Config, summaries[1].Config})
+	require.Equal(t, 0, summaries[0].Passed)
+	require.Equal(t, 2, summaries[1].Passed)
+	require.InDelta(t, 1.0, summaries[1].PassRate(), 0.001)
+}
+
+func TestRunAgentFailure(t *testing.T) {
+	t.Parallel()
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake agent is a shell script")
+	}
+
+	suite := t.TempDir()
+	writeTask(t, suite, "task", `{"prompt": "p", "verify": "true"}`, nil)
+	tasks, err := LoadSuite(suite)
+	require.NoError(t, err)
+
+	runner := &Runner{
+		agentCommand: func(ctx context.Context, task Task, cfg Config, workDir, dataDir string) (*exec.Cmd, error) {
+			return exec.CommandContext(ctx, "sh", "-c", "echo no provider configured; exit 1"), nil
+		},
+	}
+	results := runner.Run(context.Background(), tasks, []Config{{Name: DefaultConfig}})
+	require.Len(t, results, 1)
+	require.False(t, results[0].Passed)
+	require.Contains(t, results[0].Error, "agent failed")
+	require.Equal(t, "no provider configured", results[0].Output)
+	require.Positive(t, results[0].Duration)
+}
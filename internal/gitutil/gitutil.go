@@ -0,0 +1,101 @@
+// Package gitutil runs the git commands shared by the packages keeping track
+// of the working tree, such as checkpoints and session worktrees.
+package gitutil
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// FileChange is a file that differs between two states of a working tree.
+type FileChange struct {
+	Path       string
+	OldContent string
+	NewContent string
+	Created    bool
+	Deleted    bool
+}
+
+// Run runs git in dir and returns its output without surrounding space.
+func Run(ctx context.Context, dir string, args ...string) (string, error) {
+	out, err := RunWith(ctx, dir, nil, nil, args...)
+	return strings.TrimSpace(out), err
+}
+
+// Raw runs git in dir and returns its output as is, for file contents and
+// NUL separated records.
+func Raw(ctx context.Context, dir string, args ...string) (string, error) {
+	return RunWith(ctx, dir, nil, nil, args...)
+}
+
+// RunWith runs git in dir with the given environment variables added and
+// stdin, and returns its output as is.
+func RunWith(ctx context.Context, dir string, env []string, stdin io.Reader, args ...string) (string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "git", append([]string{"-C", dir}, args...)...)
+	cmd.Env = append(os.Environ(), env...)
+	cmd.Stdin = stdin
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("%w: %s", err, msg)
+		}
+		return "", err
+	}
+	return stdout.String(), nil
+}
+
+// ParseNameStatus parses the output of a diff run with --name-status,
+// --no-renames and -z into the changed files, without their contents.
+func ParseNameStatus(out string) []FileChange {
+	var changes []FileChange
+	fields := strings.Split(strings.TrimSuffix(out, "\x00"), "\x00")
+	for i := 0; i+1 < len(fields); i += 2 {
+		status, path := fields[i], fields[i+1]
+		changes = append(changes, FileChange{
+			Path:    path,
+			Created: status == "A",
+			Deleted: status == "D",
+		})
+	}
+	return changes
+}
+
+// WriteTree writes the working tree at dir, including untracked files not
+// ignored by git, as a tree object. It goes through a copy of the index so
+// that the real one is left alone.
+func WriteTree(ctx context.Context, dir string) (string, error) {
+	tmp, err := os.MkdirTemp("", "push-git-")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(tmp)
+
+	env := []string{"GIT_INDEX_FILE=" + filepath.Join(tmp, "index")}
+	index, err := Run(ctx, dir, "rev-parse", "--path-format=absolute", "--git-path", "index")
+	if err != nil {
+		return "", fmt.Errorf("failed to locate git index: %w", err)
+	}
+	// Starting from the real index spares hashing the files that did not
+	// change since it was last written.
+	if data, err := os.ReadFile(index); err == nil {
+		if err := os.WriteFile(filepath.Join(tmp, "index"), data, 0o600); err != nil {
+			return "", err
+		}
+	}
+	if _, err := RunWith(ctx, dir, env, nil, "add", "--all"); err != nil {
+		return "", fmt.Errorf("failed to snapshot working tree: %w", err)
+	}
+	tree, err := RunWith(ctx, dir, env, nil, "write-tree")
+	if err != nil {
+		return "", fmt.Errorf("failed to snapshot working tree: %w", err)
+	}
+	return strings.TrimSpace(tree), nil
+}
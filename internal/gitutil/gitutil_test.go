@@ -0,0 +1,18 @@
+package gitutil
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseNameStatus(t *testing.T) {
+	t.Parallel()
+
+	require.Empty(t, ParseNameStatus(""))
+	require.Equal(t, []FileChange{
+		{Path: "new.go", Created: true},
+		{Path: "old file.go", Deleted: true},
+		{Path: "main.go"},
+	}, ParseNameStatus("A\x00new.go\x00D\x00old file.go\x00M\x00main.go\x00"))
+}
@@ -21,14 +21,17 @@ type File struct {
 	Path      string
 	Content   string
 	Version   int64
-	CreatedAt int64
-	UpdatedAt int64
+	// ToolCallID is the tool call that wrote the version, empty for the
+	// initial version and the changes made outside of the tools.
+	ToolCallID string
+	CreatedAt  int64
+	UpdatedAt  int64
 }
 
 type Service interface {
 	pubsub.Subscriber[File]
 	Create(ctx context.Context, sessionID, path, content string) (File, error)
-	CreateVersion(ctx context.Context, sessionID, path, content string) (File, error)
+	CreateVersion(ctx context.Context, sessionID, path, content, toolCallID string) (File, error)
 	Get(ctx context.Context, id string) (File, error)
 	GetByPathAndSession(ctx context.Context, path, sessionID string) (File, error)
 	ListBySession(ctx context.Context, sessionID string) ([]File, error)
@@ -52,10 +55,10 @@ func NewService(q *db.Queries, db *sql.DB) Service {
 }
 
 func (s *service) Create(ctx context.Context, sessionID, path, content string) (File, error) {
-	return s.createWithVersion(ctx, sessionID, path, content, InitialVersion)
+	return s.createWithVersion(ctx, sessionID, path, content, "", InitialVersion)
 }
 
-func (s *service) CreateVersion(ctx context.Context, sessionID, path, content string) (File, error) {
+func (s *service) CreateVersion(ctx context.Context, sessionID, path, content, toolCallID string) (File, error) {
 	// Get the latest version for this path
 	files, err := s.q.ListFilesByPath(ctx, path)
 	if err != nil {
@@ -71,10 +74,10 @@ func (s *service) CreateVersion(ctx context.Context, sessionID, path, content st
 	latestFile := files[0] // Files are ordered by version DESC, created_at DESC
 	nextVersion := latestFile.Version + 1
 
-	return s.createWithVersion(ctx, sessionID, path, content, nextVersion)
+	return s.createWithVersion(ctx, sessionID, path, content, toolCallID, nextVersion)
 }
 
-func (s *service) createWithVersion(ctx context.Context, sessionID, path, content string, version int64) (File, error) {
+func (s *service) createWithVersion(ctx context.Context, sessionID, path, content, toolCallID string, version int64) (File, error) {
 	// Maximum number of retries for transaction conflicts
 	const maxRetries = 3
 	var file File
@@ -98,6 +101,10 @@ func (s *service) createWithVersion(ctx context.Context, sessionID, path, conten
 			Path:      path,
 			Content:   content,
 			Version:   version,
+			ToolCallID: sql.NullString{
+				String: toolCallID,
+				Valid:  toolCallID != "",
+			},
 		})
 		if txErr != nil {
 			// Rollback the transaction
@@ -199,12 +206,13 @@ func (s *service) DeleteSessionFiles(ctx context.Context, sessionID string) erro
 
 func (s *service) fromDBItem(item db.File) File {
 	return File{
-		ID:        item.ID,
-		SessionID: item.SessionID,
-		Path:      item.Path,
-		Content:   item.Content,
-		Version:   item.Version,
-		CreatedAt: item.CreatedAt,
-		UpdatedAt: item.UpdatedAt,
+		ID:         item.ID,
+		SessionID:  item.SessionID,
+		Path:       item.Path,
+		Content:    item.Content,
+		Version:    item.Version,
+		ToolCallID: item.ToolCallID.String,
+		CreatedAt:  item.CreatedAt,
+		UpdatedAt:  item.UpdatedAt,
 	}
 }
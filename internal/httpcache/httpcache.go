@@ -0,0 +1,416 @@
+// Package httpcache caches HTTP responses fetched by the web tools on disk so
+// the same pages are not downloaded again within and across sessions.
+package httpcache
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/uglyswap/push/internal/cache"
+)
+
+// FileName is the name of the cache file inside the data directory.
+const FileName = "http_cache.json"
+
+const (
+	// maxBodySize is the largest response body that is cached. Larger
+	// responses are passed through untouched.
+	maxBodySize = 2 * 1024 * 1024
+
+	// staleRetention is how long responses that can be revalidated are kept
+	// after they stop being fresh.
+	staleRetention = 7 * 24 * time.Hour
+
+	// persistDelay batches writes of the cache file.
+	persistDelay = time.Second
+)
+
+// Path returns the location of the cache file for the given data directory.
+func Path(dataDir string) string {
+	return filepath.Join(dataDir, FileName)
+}
+
+// Store is a persisted HTTP response cache shared by several tools.
+type Store struct {
+	cache *cache.Cache
+	// maxBytes caps the total size of the cached bodies.
+	maxBytes int64
+
+	mu           sync.Mutex
+	persistTimer *time.Timer
+}
+
+// Open loads the cache stored in the given data directory. It keeps at most
+// maxEntries responses whose bodies take at most maxBytes in total.
+func Open(dataDir string, maxEntries int, maxBytes int64) *Store {
+	return &Store{
+		cache: cache.New(cache.Config{
+			MaxSize:     maxEntries,
+			DefaultTTL:  staleRetention,
+			PersistPath: Path(dataDir),
+		}),
+		maxBytes: maxBytes,
+	}
+}
+
+// Client returns an HTTP client whose requests go through the cache. The
+// tool name is recorded with each response and ttl is how long responses
+// without caching headers stay fresh. A zero ttl disables caching.
+func (s *Store) Client(tool string, ttl, timeout time.Duration) *http.Client {
+	var transport http.RoundTripper = http.DefaultTransport.(*http.Transport).Clone()
+	if s != nil && ttl > 0 {
+		transport = &Transport{
+			Base:  transport,
+			Store: s,
+			Tool:  tool,
+			TTL:   ttl,
+		}
+	}
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+	}
+}
+
+// Clear removes all cached responses and the cache file contents.
+func (s *Store) Clear() error {
+	s.cache.Clear()
+	return s.cache.Persist()
+}
+
+// Stats summarizes the cached responses.
+type Stats struct {
+	Entries int
+	Fresh   int
+	Bytes   int64
+	Hits    int64
+	Tools   map[string]int
+}
+
+// Stats returns a summary of the cached responses.
+func (s *Store) Stats() Stats {
+	stats := Stats{Tools: make(map[string]int)}
+	now := time.Now()
+	for _, entry := range s.cache.Entries() {
+		resp, ok := decode(entry.Value)
+		if !ok {
+			continue
+		}
+		stats.Entries++
+		stats.Hits += entry.Hits
+		stats.Bytes += int64(len(resp.Body))
+		stats.Tools[resp.Tool]++
+		if now.Before(resp.FreshUntil) {
+			stats.Fresh++
+		}
+	}
+	return stats
+}
+
+func (s *Store) get(key string) (*response, bool) {
+	value, ok := s.cache.Get(key)
+	if !ok {
+		return nil, false
+	}
+	return decode(value)
+}
+
+// put stores a response, evicting the least recently used ones when the
+// bodies take more than maxBytes.
+func (s *Store) put(key string, resp *response, retention time.Duration) {
+	s.cache.SetWithTTL(key, resp, retention)
+	var total int64
+	for _, entry := range s.cache.Entries() {
+		total += bodySize(entry.Value)
+		if total > s.maxBytes && entry.Key != key {
+			s.cache.Delete(entry.Key)
+		}
+	}
+	s.schedulePersist()
+}
+
+// bodySize returns the size of the body of a cached response without
+// decoding the values loaded from disk, whose body is base64 encoded.
+func bodySize(value any) int64 {
+	switch v := value.(type) {
+	case *response:
+		return int64(len(v.Body))
+	case map[string]any:
+		body, _ := v["body"].(string)
+		return int64(base64.StdEncoding.DecodedLen(len(body)))
+	}
+	return 0
+}
+
+// schedulePersist writes the cache to disk shortly after it changed, so a
+// burst of requests only results in a single write. Hits are not persisted:
+// they only move entries in the eviction order.
+func (s *Store) schedulePersist() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.persistTimer != nil {
+		return
+	}
+	s.persistTimer = time.AfterFunc(persistDelay, func() {
+		s.mu.Lock()
+		s.persistTimer = nil
+		s.mu.Unlock()
+		if err := s.cache.Persist(); err != nil {
+			slog.Warn("Failed to persist HTTP cache", "error", err)
+		}
+	})
+}
+
+// response is what gets stored for a cached request.
+type response struct {
+	Tool       string      `json:"tool"`
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header"`
+	Body       []byte      `json:"body"`
+	FreshUntil time.Time   `json:"fresh_until"`
+}
+
+// decode returns the cached response for a cache value. Values loaded from
+// disk come back as generic JSON and need to be converted.
+func decode(value any) (*response, bool) {
+	if resp, ok := value.(*response); ok {
+		return resp, true
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, false
+	}
+	var resp response
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil, false
+	}
+	return &resp, true
+}
+
+func (r *response) validators() (etag, lastModified string) {
+	return r.Header.Get("ETag"), r.Header.Get("Last-Modified")
+}
+
+func (r *response) toHTTP(req *http.Request) *http.Response {
+	header := r.Header.Clone()
+	header.Set("X-From-Cache", "1")
+	return &http.Response{
+		Status:        strconv.Itoa(r.StatusCode) + " " + http.StatusText(r.StatusCode),
+		StatusCode:    r.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(r.Body)),
+		ContentLength: int64(len(r.Body)),
+		Request:       req,
+	}
+}
+
+// Transport is an http.RoundTripper that serves responses from a Store while
+// they are fresh and revalidates them with the server using their ETag or
+// Last-Modified header once they are stale.
+type Transport struct {
+	Base  http.RoundTripper
+	Store *Store
+	// Tool is the name of the tool the responses are cached for.
+	Tool string
+	// TTL is how long responses without caching headers stay fresh. It
+	// also caps the freshness servers ask for.
+	TTL time.Duration
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !cacheableRequest(req) {
+		return t.Base.RoundTrip(req)
+	}
+	key, err := requestKey(req)
+	if err != nil {
+		return nil, err
+	}
+
+	cached, ok := t.Store.get(key)
+	if ok && time.Now().Before(cached.FreshUntil) {
+		return cached.toHTTP(req), nil
+	}
+
+	outReq := req
+	if ok {
+		etag, lastModified := cached.validators()
+		outReq = req.Clone(req.Context())
+		if etag != "" {
+			outReq.Header.Set("If-None-Match", etag)
+		}
+		if lastModified != "" {
+			outReq.Header.Set("If-Modified-Since", lastModified)
+		}
+		if req.GetBody != nil {
+			if outReq.Body, err = req.GetBody(); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	resp, err := t.Base.RoundTrip(outReq)
+	if err != nil {
+		return nil, err
+	}
+
+	if ok && resp.StatusCode == http.StatusNotModified {
+		resp.Body.Close()
+		// Cached responses are shared, update a copy.
+		updated := *cached
+		updated.Header = cached.Header.Clone()
+		for name, values := range resp.Header {
+			updated.Header[name] = values
+		}
+		freshFor, _ := t.freshness(updated.Header)
+		updated.FreshUntil = time.Now().Add(freshFor)
+		t.Store.put(key, &updated, max(freshFor, staleRetention))
+		return updated.toHTTP(req), nil
+	}
+
+	return t.store(key, resp)
+}
+
+// store caches the response if the server allows it and returns a response
+// whose body can still be read by the caller.
+func (t *Transport) store(key string, resp *http.Response) (*http.Response, error) {
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Vary") == "*" {
+		return resp, nil
+	}
+	freshFor, storable := t.freshness(resp.Header)
+	if !storable {
+		return resp, nil
+	}
+	etag, lastModified := resp.Header.Get("ETag"), resp.Header.Get("Last-Modified")
+	retention := freshFor
+	if etag != "" || lastModified != "" {
+		retention = max(freshFor, staleRetention)
+	}
+	if retention <= 0 {
+		return resp, nil
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxBodySize+1))
+	if err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+	if len(body) > maxBodySize {
+		resp.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(body), resp.Body), resp.Body}
+		return resp, nil
+	}
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	header := resp.Header.Clone()
+	header.Del("Set-Cookie")
+	t.Store.put(key, &response{
+		Tool:       t.Tool,
+		StatusCode: resp.StatusCode,
+		Header:     header,
+		Body:       body,
+		FreshUntil: time.Now().Add(freshFor),
+	}, retention)
+	return resp, nil
+}
+
+// freshness returns how long a response with the given headers may be
+// served without revalidation, and whether it may be stored at all.
+func (t *Transport) freshness(header http.Header) (time.Duration, bool) {
+	directives := parseCacheControl(header.Get("Cache-Control"))
+	if _, ok := directives["no-store"]; ok {
+		return 0, false
+	}
+	if _, ok := directives["no-cache"]; ok {
+		return 0, true
+	}
+	if maxAge, ok := directives["max-age"]; ok {
+		seconds, err := strconv.Atoi(maxAge)
+		if err != nil {
+			return 0, true
+		}
+		return min(time.Duration(max(seconds, 0))*time.Second, t.TTL), true
+	}
+	if expires := header.Get("Expires"); expires != "" {
+		expiresAt, err := http.ParseTime(expires)
+		if err != nil {
+			return 0, true
+		}
+		date, err := http.ParseTime(header.Get("Date"))
+		if err != nil {
+			date = time.Now()
+		}
+		return min(max(expiresAt.Sub(date), 0), t.TTL), true
+	}
+	return t.TTL, true
+}
+
+// cacheableRequest reports whether the request may be answered from the
+// cache. Requests carrying their own validators or asking to bypass caches
+// always go to the server.
+func cacheableRequest(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		// Only replayable bodies can be hashed into the key.
+		if req.Body != nil && req.GetBody == nil {
+			return false
+		}
+	default:
+		return false
+	}
+	if req.Header.Get("If-None-Match") != "" || req.Header.Get("If-Modified-Since") != "" || req.Header.Get("Range") != "" {
+		return false
+	}
+	directives := parseCacheControl(req.Header.Get("Cache-Control"))
+	_, noStore := directives["no-store"]
+	_, noCache := directives["no-cache"]
+	return !noStore && !noCache
+}
+
+// requestKey identifies a request by its method, URL and body.
+func requestKey(req *http.Request) (string, error) {
+	h := sha256.New()
+	io.WriteString(h, req.Method+" "+req.URL.String()+"\n")
+	io.WriteString(h, req.Header.Get("Accept")+"\n")
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return "", err
+		}
+		defer body.Close()
+		if _, err := io.Copy(h, body); err != nil {
+			return "", err
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func parseCacheControl(value string) map[string]string {
+	directives := make(map[string]string)
+	for part := range strings.SplitSeq(value, ",") {
+		name, arg, _ := strings.Cut(strings.TrimSpace(part), "=")
+		if name == "" {
+			continue
+		}
+		directives[strings.ToLower(name)] = strings.Trim(arg, `"`)
+	}
+	return directives
+}
@@ -0,0 +1,146 @@
+package httpcache
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func get(t *testing.T, client *http.Client, url string) (string, *http.Response) {
+	t.Helper()
+	resp, err := client.Get(url)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return string(body), resp
+}
+
+func TestTransportServesFreshResponses(t *testing.T) {
+	t.Parallel()
+
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Write([]byte("hello"))
+	}))
+	defer srv.Close()
+
+	client := Open(t.TempDir(), 10, 1<<20).Client("fetch", time.Minute, time.Second)
+
+	body, resp := get(t, client, srv.URL)
+	require.Equal(t, "hello", body)
+	require.Empty(t, resp.Header.Get("X-From-Cache"))
+
+	body, resp = get(t, client, srv.URL)
+	require.Equal(t, "hello", body)
+	require.Equal(t, "1", resp.Header.Get("X-From-Cache"))
+	require.Equal(t, int32(1), requests.Load())
+}
+
+func TestTransportRevalidatesWithETag(t *testing.T) {
+	t.Parallel()
+
+	var requests, notModified atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("ETag", `"v1"`)
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			notModified.Add(1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Write([]byte("docs"))
+	}))
+	defer srv.Close()
+
+	client := Open(t.TempDir(), 10, 1<<20).Client("fetch", time.Minute, time.Second)
+
+	body, _ := get(t, client, srv.URL)
+	require.Equal(t, "docs", body)
+
+	body, resp := get(t, client, srv.URL)
+	require.Equal(t, "docs", body)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, int32(2), requests.Load())
+	require.Equal(t, int32(1), notModified.Load())
+}
+
+func TestTransportHonorsNoStore(t *testing.T) {
+	t.Parallel()
+
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Header().Set("Cache-Control", "no-store")
+		w.Write([]byte("secret"))
+	}))
+	defer srv.Close()
+
+	store := Open(t.TempDir(), 10, 1<<20)
+	client := store.Client("fetch", time.Minute, time.Second)
+	get(t, client, srv.URL)
+	get(t, client, srv.URL)
+	require.Equal(t, int32(2), requests.Load())
+	require.Zero(t, store.Stats().Entries)
+}
+
+func TestStorePersistsAcrossOpens(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "max-age=600")
+		w.Write([]byte("cached"))
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	store := Open(dir, 10, 1<<20)
+	get(t, store.Client("sourcegraph", time.Hour, time.Second), srv.URL)
+	require.NoError(t, store.cache.Persist())
+
+	reopened := Open(dir, 10, 1<<20)
+	stats := reopened.Stats()
+	require.Equal(t, 1, stats.Entries)
+	require.Equal(t, 1, stats.Fresh)
+	require.Equal(t, map[string]int{"sourcegraph": 1}, stats.Tools)
+
+	srv.Close()
+	body, resp := get(t, reopened.Client("sourcegraph", time.Hour, time.Second), srv.URL)
+	require.Equal(t, "cached", body)
+	require.Equal(t, "1", resp.Header.Get("X-From-Cache"))
+	require.Nil(t, reopened.persistTimer, "hits do not rewrite the cache file")
+
+	require.NoError(t, reopened.Clear())
+	require.Zero(t, Open(dir, 10, 1<<20).Stats().Entries)
+}
+
+func TestStoreCapsTotalSize(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("page " + r.URL.Path))
+	}))
+	defer srv.Close()
+
+	store := Open(t.TempDir(), 10, 16)
+	client := store.Client("fetch", time.Minute, time.Second)
+	get(t, client, srv.URL+"/a")
+	get(t, client, srv.URL+"/b")
+	get(t, client, srv.URL+"/a")
+	get(t, client, srv.URL+"/c")
+
+	stats := store.Stats()
+	require.Equal(t, 2, stats.Entries, "the least recently used response is evicted")
+	require.LessOrEqual(t, stats.Bytes, int64(16))
+	_, resp := get(t, client, srv.URL+"/a")
+	require.Equal(t, "1", resp.Header.Get("X-From-Cache"))
+	_, resp = get(t, client, srv.URL+"/b")
+	require.Empty(t, resp.Header.Get("X-From-Cache"))
+}
@@ -38,7 +38,7 @@ func Setup(logFile string, debug bool) {
 			AddSource: true,
 		})
 
-		slog.SetDefault(slog.New(logger))
+		slog.SetDefault(slog.New(redactingHandler{logger}))
 		initialized.Store(true)
 	})
 }
@@ -0,0 +1,75 @@
+package log
+
+import (
+	"context"
+	"log/slog"
+	"sync/atomic"
+)
+
+var redactor atomic.Pointer[func(string) string]
+
+// SetRedactor sets the function masking secrets in the message and the
+// string attributes of every log record.
+func SetRedactor(fn func(string) string) {
+	if fn == nil {
+		redactor.Store(nil)
+		return
+	}
+	redactor.Store(&fn)
+}
+
+// redactingHandler masks secrets in records with the function set by
+// SetRedactor before passing them on.
+type redactingHandler struct {
+	slog.Handler
+}
+
+func (h redactingHandler) Handle(ctx context.Context, record slog.Record) error {
+	fn := redactor.Load()
+	if fn == nil {
+		return h.Handler.Handle(ctx, record)
+	}
+	redacted := slog.NewRecord(record.Time, record.Level, (*fn)(record.Message), record.PC)
+	record.Attrs(func(attr slog.Attr) bool {
+		redacted.AddAttrs(redactAttr(attr, *fn))
+		return true
+	})
+	return h.Handler.Handle(ctx, redacted)
+}
+
+// WithAttrs masks secrets in attrs with the function set when it is called,
+// as the wrapped handler may format them right away.
+func (h redactingHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if fn := redactor.Load(); fn != nil {
+		redacted := make([]slog.Attr, len(attrs))
+		for i, attr := range attrs {
+			redacted[i] = redactAttr(attr, *fn)
+		}
+		attrs = redacted
+	}
+	return redactingHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h redactingHandler) WithGroup(name string) slog.Handler {
+	return redactingHandler{h.Handler.WithGroup(name)}
+}
+
+func redactAttr(attr slog.Attr, fn func(string) string) slog.Attr {
+	attr.Value = attr.Value.Resolve()
+	switch attr.Value.Kind() {
+	case slog.KindString:
+		attr.Value = slog.StringValue(fn(attr.Value.String()))
+	case slog.KindGroup:
+		group := attr.Value.Group()
+		redacted := make([]slog.Attr, len(group))
+		for i, a := range group {
+			redacted[i] = redactAttr(a, fn)
+		}
+		attr.Value = slog.GroupValue(redacted...)
+	case slog.KindAny:
+		if err, ok := attr.Value.Any().(error); ok {
+			attr.Value = slog.StringValue(fn(err.Error()))
+		}
+	}
+	return attr
+}
@@ -0,0 +1,29 @@
+package log
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestRedactingHandler(t *testing.T) {
+	SetRedactor(func(s string) string {
+		return strings.ReplaceAll(s, "sk-secret", "[REDACTED]")
+	})
+	t.Cleanup(func() { SetRedactor(nil) })
+
+	var buf bytes.Buffer
+	logger := slog.New(redactingHandler{slog.NewTextHandler(&buf, nil)})
+	logger.With("token", "sk-secret", slog.Group("auth", "key", "sk-secret")).
+		Info("using sk-secret", "err", errors.New("bad key sk-secret"))
+
+	out := buf.String()
+	if strings.Contains(out, "sk-secret") {
+		t.Fatalf("secret leaked into the log: %s", out)
+	}
+	if got := strings.Count(out, "[REDACTED]"); got != 4 {
+		t.Fatalf("expected 4 redacted values, got %d: %s", got, out)
+	}
+}
@@ -16,15 +16,25 @@ import (
 	"github.com/uglyswap/push/internal/csync"
 	"github.com/uglyswap/push/internal/fsext"
 	"github.com/uglyswap/push/internal/home"
-	powernap "github.com/charmbracelet/x/powernap/pkg/lsp"
+	"github.com/uglyswap/push/internal/version"
 	"github.com/charmbracelet/x/powernap/pkg/lsp/protocol"
 	"github.com/charmbracelet/x/powernap/pkg/transport"
 )
 
 type Client struct {
-	client *powernap.Client
+	conn   *transport.Connection
+	cancel context.CancelFunc
 	name   string
 
+	// Root of the workspace, and its folder, sent on initialization
+	rootURI         string
+	workspaceFolder protocol.WorkspaceFolder
+
+	// Capabilities of the server, set on initialization
+	capabilities protocol.ServerCapabilities
+	initialized  atomic.Bool
+	shutdown     atomic.Bool
+
 	// File types this LSP server handles (e.g., .go, .rs, .py)
 	fileTypes []string
 
@@ -42,9 +52,12 @@ type Client struct {
 
 	// Server state
 	serverState atomic.Value
+
+	// Folders added to the workspace after initialization
+	workspaceFolders *csync.Map[string, bool]
 }
 
-// New creates a new LSP client using the powernap implementation.
+// New starts the LSP server and creates a client connected to it.
 func New(ctx context.Context, name string, config config.LSPConfig, resolver config.VariableResolver) (*Client, error) {
 	// Convert working directory to file URI
 	workDir, err := os.Getwd()
@@ -52,48 +65,43 @@ func New(ctx context.Context, name string, config config.LSPConfig, resolver con
 		return nil, fmt.Errorf("failed to get working directory: %w", err)
 	}
 
-	rootURI := string(protocol.URIFromPath(workDir))
-
 	command, err := resolver.ResolveValue(config.Command)
 	if err != nil {
 		return nil, fmt.Errorf("invalid lsp command: %w", err)
 	}
 
-	// Create powernap client config
-	clientConfig := powernap.ClientConfig{
-		Command: home.Long(command),
-		Args:    config.Args,
-		RootURI: rootURI,
-		Environment: func() map[string]string {
-			env := make(map[string]string)
-			maps.Copy(env, config.Env)
-			return env
-		}(),
-		Settings:    config.Options,
-		InitOptions: config.InitOptions,
-		WorkspaceFolders: []protocol.WorkspaceFolder{
-			{
-				URI:  rootURI,
-				Name: filepath.Base(workDir),
-			},
-		},
+	// The server outlives the context it is started with, until Close.
+	serverCtx, cancel := context.WithCancel(context.Background())
+	stream, err := startServer(serverCtx, home.Long(command), config.Args, config.Env)
+	if err != nil {
+		cancel()
+		return nil, fmt.Errorf("failed to start language server: %w", err)
 	}
-
-	// Create the powernap client
-	powernapClient, err := powernap.NewClient(clientConfig)
+	conn, err := transport.NewConnection(serverCtx, stream, slog.Default())
 	if err != nil {
-		return nil, fmt.Errorf("failed to create lsp client: %w", err)
+		cancel()
+		return nil, fmt.Errorf("failed to create lsp connection: %w", err)
 	}
 
 	client := &Client{
-		client:      powernapClient,
-		name:        name,
-		fileTypes:   config.FileTypes,
-		diagnostics: csync.NewVersionedMap[protocol.DocumentURI, []protocol.Diagnostic](),
-		openFiles:   csync.NewMap[string, *OpenFileInfo](),
-		config:      config,
+		conn:            conn,
+		cancel:          cancel,
+		name:            name,
+		rootURI:         string(protocol.URIFromPath(workDir)),
+		workspaceFolder: workspaceFolder(workDir),
+		fileTypes:       config.FileTypes,
+		diagnostics:     csync.NewVersionedMap[protocol.DocumentURI, []protocol.Diagnostic](),
+		openFiles:       csync.NewMap[string, *OpenFileInfo](),
+		config:          config,
+
+		workspaceFolders: csync.NewMap[string, bool](),
 	}
 
+	// Servers may ask for their settings while initializing.
+	conn.RegisterHandler("workspace/configuration", func(context.Context, string, json.RawMessage) (any, error) {
+		return []any{config.Options}, nil
+	})
+
 	// Initialize server state
 	client.serverState.Store(StateStarting)
 
@@ -102,28 +110,40 @@ func New(ctx context.Context, name string, config config.LSPConfig, resolver con
 
 // Initialize initializes the LSP client and returns the server capabilities.
 func (c *Client) Initialize(ctx context.Context, workspaceDir string) (*protocol.InitializeResult, error) {
-	if err := c.client.Initialize(ctx, false); err != nil {
+	if c.initialized.Load() {
+		return nil, fmt.Errorf("lsp client already initialized")
+	}
+	params := map[string]any{
+		"processId": os.Getpid(),
+		"clientInfo": map[string]any{
+			"name":    "push",
+			"version": version.Version,
+		},
+		"locale":                "en-us",
+		"rootPath":              strings.TrimPrefix(c.rootURI, "file://"), // Deprecated but some servers still use it
+		"rootUri":               c.rootURI,
+		"capabilities":          clientCapabilities(),
+		"workspaceFolders":      []protocol.WorkspaceFolder{c.workspaceFolder},
+		"initializationOptions": c.config.InitOptions,
+		"trace":                 "off",
+	}
+	var result protocol.InitializeResult
+	if err := c.call(ctx, "initialize", params, &result); err != nil {
 		return nil, fmt.Errorf("failed to initialize the lsp client: %w", err)
 	}
-
-	// Convert powernap capabilities to protocol capabilities
-	caps := c.client.GetCapabilities()
-	protocolCaps := protocol.ServerCapabilities{
-		TextDocumentSync: caps.TextDocumentSync,
-		CompletionProvider: func() *protocol.CompletionOptions {
-			if caps.CompletionProvider != nil {
-				return &protocol.CompletionOptions{
-					TriggerCharacters:   caps.CompletionProvider.TriggerCharacters,
-					AllCommitCharacters: caps.CompletionProvider.AllCommitCharacters,
-					ResolveProvider:     caps.CompletionProvider.ResolveProvider,
-				}
-			}
-			return nil
-		}(),
+	c.capabilities = result.Capabilities
+	if err := c.notify(ctx, "initialized", map[string]any{}); err != nil {
+		return nil, fmt.Errorf("failed to initialize the lsp client: %w", err)
 	}
-
-	result := &protocol.InitializeResult{
-		Capabilities: protocolCaps,
+	c.initialized.Store(true)
+
+	// gopls sets up its workspace views once it gets its settings and a
+	// change in the workspace.
+	if strings.Contains(c.config.Command, "gopls") {
+		_ = c.notify(ctx, "workspace/didChangeConfiguration", map[string]any{"settings": c.config.Options})
+		_ = c.notify(ctx, "workspace/didChangeWatchedFiles", protocol.DidChangeWatchedFilesParams{
+			Changes: []protocol.FileEvent{{URI: protocol.DocumentURI(c.rootURI), Type: protocol.Created}},
+		})
 	}
 
 	c.RegisterServerRequestHandler("workspace/applyEdit", HandleApplyEdit)
@@ -134,7 +154,7 @@ func (c *Client) Initialize(ctx context.Context, workspaceDir string) (*protocol
 		HandleDiagnostics(c, params)
 	})
 
-	return result, nil
+	return &result, nil
 }
 
 // Close closes the LSP client.
@@ -145,12 +165,18 @@ func (c *Client) Close(ctx context.Context) error {
 
 	c.CloseAllFiles(ctx)
 
-	// Shutdown and exit the client
-	if err := c.client.Shutdown(ctx); err != nil {
-		slog.Warn("Failed to shutdown LSP client", "error", err)
+	// Shutdown and exit the server
+	if !c.shutdown.Swap(true) {
+		if err := c.call(ctx, "shutdown", nil, nil); err != nil {
+			slog.Warn("Failed to shutdown LSP client", "error", err)
+		}
 	}
-
-	return c.client.Exit()
+	err := c.notify(ctx, "exit", nil)
+	c.cancel()
+	if err != nil {
+		return fmt.Errorf("exit notification failed: %w", err)
+	}
+	return nil
 }
 
 // ServerState represents the state of an LSP server
@@ -214,7 +240,7 @@ func (c *Client) WaitForServerReady(ctx context.Context) error {
 			return fmt.Errorf("timeout waiting for LSP server to be ready")
 		case <-ticker.C:
 			// Check if client is running
-			if !c.client.IsRunning() {
+			if !c.conn.IsConnected() || !c.initialized.Load() || c.shutdown.Load() {
 				if cfg != nil && cfg.Options.DebugLSP {
 					slog.Debug("LSP server not ready yet", "server", c.name)
 				}
@@ -278,7 +304,15 @@ func (c *Client) OpenFile(ctx context.Context, filepath string) error {
 	}
 
 	// Notify the server about the opened document
-	if err = c.client.NotifyDidOpenTextDocument(ctx, uri, string(DetectLanguageID(uri)), 1, string(content)); err != nil {
+	params := protocol.DidOpenTextDocumentParams{
+		TextDocument: protocol.TextDocumentItem{
+			URI:        protocol.DocumentURI(uri),
+			LanguageID: DetectLanguageID(uri),
+			Version:    1,
+			Text:       string(content),
+		},
+	}
+	if err = c.notify(ctx, "textDocument/didOpen", params); err != nil {
 		return err
 	}
 
@@ -316,7 +350,13 @@ func (c *Client) NotifyChange(ctx context.Context, filepath string) error {
 		},
 	}
 
-	return c.client.NotifyDidChangeTextDocument(ctx, uri, int(fileInfo.Version), changes)
+	return c.notify(ctx, "textDocument/didChange", protocol.DidChangeTextDocumentParams{
+		TextDocument: protocol.VersionedTextDocumentIdentifier{
+			Version:                fileInfo.Version,
+			TextDocumentIdentifier: protocol.TextDocumentIdentifier{URI: protocol.DocumentURI(uri)},
+		},
+		ContentChanges: changes,
+	})
 }
 
 // IsFileOpen checks if a file is currently open.
@@ -334,7 +374,10 @@ func (c *Client) CloseAllFiles(ctx context.Context) {
 		if debugLSP {
 			slog.Debug("Closing file", "file", uri)
 		}
-		if err := c.client.NotifyDidCloseTextDocument(ctx, uri); err != nil {
+		params := protocol.DidCloseTextDocumentParams{
+			TextDocument: protocol.TextDocumentIdentifier{URI: protocol.DocumentURI(uri)},
+		}
+		if err := c.notify(ctx, "textDocument/didClose", params); err != nil {
 			slog.Warn("Error closing rile", "uri", uri, "error", err)
 			continue
 		}
@@ -391,17 +434,17 @@ func (c *Client) ClearDiagnosticsForURI(uri protocol.DocumentURI) {
 
 // RegisterNotificationHandler registers a notification handler.
 func (c *Client) RegisterNotificationHandler(method string, handler transport.NotificationHandler) {
-	c.client.RegisterNotificationHandler(method, handler)
+	c.conn.RegisterNotificationHandler(method, handler)
 }
 
 // RegisterServerRequestHandler handles server requests.
 func (c *Client) RegisterServerRequestHandler(method string, handler transport.Handler) {
-	c.client.RegisterHandler(method, handler)
+	c.conn.RegisterHandler(method, handler)
 }
 
 // DidChangeWatchedFiles sends a workspace/didChangeWatchedFiles notification to the server.
 func (c *Client) DidChangeWatchedFiles(ctx context.Context, params protocol.DidChangeWatchedFilesParams) error {
-	return c.client.NotifyDidChangeWatchedFiles(ctx, params.Changes)
+	return c.notify(ctx, "workspace/didChangeWatchedFiles", params)
 }
 
 // openKeyConfigFiles opens important configuration files that help initialize the server.
@@ -452,7 +495,21 @@ func (c *Client) FindReferences(ctx context.Context, filepath string, line, char
 	}
 	// NOTE: line and character should be 0-based.
 	// See: https://microsoft.github.io/language-server-protocol/specifications/lsp/3.17/specification/#position
-	return c.client.FindReferences(ctx, filepath, line-1, character-1, includeDeclaration)
+	params := protocol.ReferenceParams{
+		TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+			TextDocument: protocol.TextDocumentIdentifier{URI: protocol.URIFromPath(filepath)},
+			Position: protocol.Position{
+				Line:      uint32(line - 1),
+				Character: uint32(character - 1),
+			},
+		},
+		Context: protocol.ReferenceContext{IncludeDeclaration: includeDeclaration},
+	}
+	var locations []protocol.Location
+	if err := c.call(ctx, "textDocument/references", params, &locations); err != nil {
+		return nil, fmt.Errorf("find references request failed: %w", err)
+	}
+	return locations, nil
 }
 
 // HasRootMarkers checks if any of the specified root marker patterns exist in the given directory.
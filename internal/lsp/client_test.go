@@ -19,14 +19,14 @@ func TestClient(t *testing.T) {
 		Env:       map[string]string{},
 	}
 
-	// Test creating a powernap client - this will likely fail with echo
+	// Test creating a client - this will likely fail with echo
 	// but we can still test the basic structure
 	client, err := New(ctx, "test", cfg, config.NewEnvironmentVariableResolver(env.NewFromMap(map[string]string{
 		"THE_CMD": "echo",
 	})))
 	if err != nil {
 		// Expected to fail with echo command, skip the rest
-		t.Skipf("Powernap client creation failed as expected with dummy command: %v", err)
+		t.Skipf("Client creation failed as expected with dummy command: %v", err)
 		return
 	}
 
@@ -0,0 +1,218 @@
+package lsp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/x/powernap/pkg/lsp/protocol"
+	"github.com/uglyswap/push/internal/tracing"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	methodTextDocumentRename     = "textDocument/rename"
+	methodTextDocumentCodeAction = "textDocument/codeAction"
+	methodCodeActionResolve      = "codeAction/resolve"
+	methodTextDocumentFormatting = "textDocument/formatting"
+	methodDidChangeWorkspaceDirs = "workspace/didChangeWorkspaceFolders"
+)
+
+// call sends a request to the server and decodes its result into result.
+func (c *Client) call(ctx context.Context, method string, params, result any) error {
+	ctx, span := c.startSpan(ctx, method)
+	err := c.conn.Call(ctx, method, params, result)
+	tracing.End(span, err)
+	return err
+}
+
+// startSpan starts the span of a request to the server.
+func (c *Client) startSpan(ctx context.Context, method string) (context.Context, trace.Span) {
+	return tracing.Start(ctx, "lsp."+method,
+		tracing.AttrLSPServer.String(c.name),
+		tracing.AttrRPCMethod.String(method),
+	)
+}
+
+// notify sends a notification to the server.
+func (c *Client) notify(ctx context.Context, method string, params any) error {
+	return c.conn.Notify(ctx, method, params)
+}
+
+// AddWorkspaceFolder adds dir to the workspace folders of the server, so
+// files below it are analyzed like the ones of the working directory. Adding
+// the same folder again does nothing.
+func (c *Client) AddWorkspaceFolder(ctx context.Context, dir string) error {
+	added := false
+	c.workspaceFolders.GetOrSet(dir, func() bool {
+		added = true
+		return true
+	})
+	if !added {
+		return nil
+	}
+	params := protocol.DidChangeWorkspaceFoldersParams{
+		Event: protocol.WorkspaceFoldersChangeEvent{
+			Added:   []protocol.WorkspaceFolder{workspaceFolder(dir)},
+			Removed: []protocol.WorkspaceFolder{},
+		},
+	}
+	if err := c.notify(ctx, methodDidChangeWorkspaceDirs, params); err != nil {
+		c.workspaceFolders.Del(dir)
+		return fmt.Errorf("failed to add workspace folder: %w", err)
+	}
+	return nil
+}
+
+// RemoveWorkspaceFolder removes a folder added with AddWorkspaceFolder.
+func (c *Client) RemoveWorkspaceFolder(ctx context.Context, dir string) error {
+	if _, ok := c.workspaceFolders.Take(dir); !ok {
+		return nil
+	}
+	params := protocol.DidChangeWorkspaceFoldersParams{
+		Event: protocol.WorkspaceFoldersChangeEvent{
+			Added:   []protocol.WorkspaceFolder{},
+			Removed: []protocol.WorkspaceFolder{workspaceFolder(dir)},
+		},
+	}
+	if err := c.notify(ctx, methodDidChangeWorkspaceDirs, params); err != nil {
+		return fmt.Errorf("failed to remove workspace folder: %w", err)
+	}
+	return nil
+}
+
+func workspaceFolder(dir string) protocol.WorkspaceFolder {
+	return protocol.WorkspaceFolder{URI: string(protocol.URIFromPath(dir)), Name: filepath.Base(dir)}
+}
+
+// Rename asks the server for the edits renaming the symbol at the given
+// position to newName.
+func (c *Client) Rename(ctx context.Context, filepath string, line, character int, newName string) (*protocol.WorkspaceEdit, error) {
+	if err := c.OpenFileOnDemand(ctx, filepath); err != nil {
+		return nil, err
+	}
+	// NOTE: line and character are 1-based here, like FindReferences.
+	params := protocol.RenameParams{
+		TextDocument: protocol.TextDocumentIdentifier{URI: protocol.URIFromPath(filepath)},
+		Position: protocol.Position{
+			Line:      uint32(line - 1),
+			Character: uint32(character - 1),
+		},
+		NewName: newName,
+	}
+	var result *protocol.WorkspaceEdit
+	if err := c.call(ctx, methodTextDocumentRename, params, &result); err != nil {
+		return nil, fmt.Errorf("rename request failed: %w", err)
+	}
+	return result, nil
+}
+
+// CodeActions returns the code actions available for the given 1-based line
+// range. Diagnostics reported for these lines are sent along so the server can
+// offer quick fixes. Bare commands are returned as code actions with only
+// Command set.
+func (c *Client) CodeActions(ctx context.Context, filepath string, startLine, endLine int, only []protocol.CodeActionKind) ([]protocol.CodeAction, error) {
+	if err := c.OpenFileOnDemand(ctx, filepath); err != nil {
+		return nil, err
+	}
+	uri := protocol.URIFromPath(filepath)
+	rng := protocol.Range{
+		Start: protocol.Position{Line: uint32(startLine - 1)},
+		End:   protocol.Position{Line: uint32(endLine)},
+	}
+	diagnostics := []protocol.Diagnostic{}
+	for _, diagnostic := range c.GetFileDiagnostics(uri) {
+		if diagnostic.Range.End.Line >= rng.Start.Line && diagnostic.Range.Start.Line <= rng.End.Line {
+			diagnostics = append(diagnostics, diagnostic)
+		}
+	}
+	params := protocol.CodeActionParams{
+		TextDocument: protocol.TextDocumentIdentifier{URI: uri},
+		Range:        rng,
+		Context: protocol.CodeActionContext{
+			Diagnostics: diagnostics,
+			Only:        only,
+		},
+	}
+	var result []json.RawMessage
+	if err := c.call(ctx, methodTextDocumentCodeAction, params, &result); err != nil {
+		return nil, fmt.Errorf("code action request failed: %w", err)
+	}
+
+	actions := make([]protocol.CodeAction, 0, len(result))
+	for _, raw := range result {
+		// A Command has a string "command" field, a CodeAction an object.
+		var probe struct {
+			Command json.RawMessage `json:"command"`
+		}
+		if err := json.Unmarshal(raw, &probe); err != nil {
+			return nil, fmt.Errorf("invalid code action: %w", err)
+		}
+		if len(probe.Command) > 0 && probe.Command[0] == '"' {
+			var command protocol.Command
+			if err := json.Unmarshal(raw, &command); err != nil {
+				return nil, fmt.Errorf("invalid code action: %w", err)
+			}
+			actions = append(actions, protocol.CodeAction{Title: command.Title, Command: &command})
+			continue
+		}
+		var action protocol.CodeAction
+		if err := json.Unmarshal(raw, &action); err != nil {
+			return nil, fmt.Errorf("invalid code action: %w", err)
+		}
+		actions = append(actions, action)
+	}
+	return actions, nil
+}
+
+// ResolveCodeAction fills in the edit of a code action the server computes
+// lazily. Actions that already carry an edit are returned as is.
+func (c *Client) ResolveCodeAction(ctx context.Context, action protocol.CodeAction) (protocol.CodeAction, error) {
+	if action.Edit != nil || action.Data == nil {
+		return action, nil
+	}
+	var resolved protocol.CodeAction
+	if err := c.call(ctx, methodCodeActionResolve, action, &resolved); err != nil {
+		return action, fmt.Errorf("code action resolve request failed: %w", err)
+	}
+	return resolved, nil
+}
+
+// FormatOnWrite reports whether files should be formatted through this server
+// after the agent edits them.
+func (c *Client) FormatOnWrite() bool {
+	return c.config.FormatOnWrite
+}
+
+// Format asks the server for the edits formatting the whole file. The file
+// must be open and its content up to date on the server.
+func (c *Client) Format(ctx context.Context, filepath string, content string) ([]protocol.TextEdit, error) {
+	params := protocol.DocumentFormattingParams{
+		TextDocument: protocol.TextDocumentIdentifier{URI: protocol.URIFromPath(filepath)},
+		Options: protocol.FormattingOptions{
+			TabSize:      4,
+			InsertSpaces: !indentsWithTabs(content),
+		},
+	}
+	var result []protocol.TextEdit
+	if err := c.call(ctx, methodTextDocumentFormatting, params, &result); err != nil {
+		return nil, fmt.Errorf("formatting request failed: %w", err)
+	}
+	return result, nil
+}
+
+// indentsWithTabs reports whether the first indented line of content is
+// indented with a tab.
+func indentsWithTabs(content string) bool {
+	for line := range strings.Lines(content) {
+		switch {
+		case strings.HasPrefix(line, "\t"):
+			return true
+		case strings.HasPrefix(line, " "):
+			return false
+		}
+	}
+	return false
+}
@@ -0,0 +1,239 @@
+package lsp
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/x/powernap/pkg/transport"
+)
+
+// startServer starts the language server process and returns the stream of
+// its standard input and output. The process is killed when ctx is
+// canceled.
+func startServer(ctx context.Context, command string, args []string, env map[string]string) (io.ReadWriteCloser, error) {
+	cmd := exec.CommandContext(ctx, command, args...)
+	if len(env) > 0 {
+		cmd.Env = os.Environ()
+		for k, v := range env {
+			cmd.Env = append(cmd.Env, k+"="+v)
+		}
+	}
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create stdin pipe: %w", err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create stdout pipe: %w", err)
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create stderr pipe: %w", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start process: %w", err)
+	}
+
+	go func() {
+		buf := make([]byte, 4096)
+		for {
+			n, err := stderr.Read(buf)
+			if n > 0 {
+				slog.Error("Language server stderr", "command", command, "output", string(buf[:n]))
+			}
+			if err != nil {
+				if err != io.EOF {
+					slog.Error("Error reading stderr", "error", err)
+				}
+				return
+			}
+		}
+	}()
+
+	exited := make(chan struct{})
+	go func() {
+		defer close(exited)
+		if err := cmd.Wait(); err != nil {
+			slog.Error("Language server process exited with error", "command", command, "error", err)
+		} else {
+			slog.Info("Language server process exited normally", "command", command)
+		}
+	}()
+
+	return transport.NewStreamTransport(stdout, stdin, &serverCloser{
+		cmd:    cmd,
+		stdin:  stdin,
+		exited: exited,
+	}), nil
+}
+
+// serverCloser closes the input of the language server and kills it if it
+// does not exit soon after.
+type serverCloser struct {
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	exited <-chan struct{}
+	once   sync.Once
+	err    error
+}
+
+func (c *serverCloser) Close() error {
+	c.once.Do(func() {
+		c.err = c.stdin.Close()
+		select {
+		case <-c.exited:
+		case <-time.After(5 * time.Second):
+			if err := c.cmd.Process.Kill(); err != nil {
+				c.err = err
+			}
+		}
+	})
+	return c.err
+}
+
+// clientCapabilities returns the capabilities announced to the servers.
+func clientCapabilities() map[string]any {
+	return map[string]any{
+		"textDocument": map[string]any{
+			"synchronization": map[string]any{
+				"dynamicRegistration": true,
+				"willSave":            true,
+				"willSaveWaitUntil":   true,
+				"didSave":             true,
+			},
+			"completion": map[string]any{
+				"dynamicRegistration": true,
+				"completionItem": map[string]any{
+					"snippetSupport":          false,
+					"commitCharactersSupport": true,
+					"documentationFormat":     []string{"markdown", "plaintext"},
+					"deprecatedSupport":       true,
+					"preselectSupport":        true,
+					"insertReplaceSupport":    true,
+					"tagSupport": map[string]any{
+						"valueSet": []int{1}, // Deprecated
+					},
+					"resolveSupport": map[string]any{
+						"properties": []string{"documentation", "detail", "additionalTextEdits"},
+					},
+				},
+				"contextSupport": true,
+			},
+			"hover": map[string]any{
+				"dynamicRegistration": true,
+				"contentFormat":       []string{"markdown", "plaintext"},
+			},
+			"definition": map[string]any{
+				"dynamicRegistration": true,
+				"linkSupport":         true,
+			},
+			"references": map[string]any{
+				"dynamicRegistration": true,
+			},
+			"documentHighlight": map[string]any{
+				"dynamicRegistration": true,
+			},
+			"documentSymbol": map[string]any{
+				"dynamicRegistration":               true,
+				"hierarchicalDocumentSymbolSupport": true,
+			},
+			"formatting": map[string]any{
+				"dynamicRegistration": true,
+			},
+			"rangeFormatting": map[string]any{
+				"dynamicRegistration": true,
+			},
+			"rename": map[string]any{
+				"dynamicRegistration": true,
+				"prepareSupport":      true,
+			},
+			"publishDiagnostics": map[string]any{
+				"relatedInformation":     true,
+				"versionSupport":         true,
+				"tagSupport":             map[string]any{"valueSet": []int{1, 2}},
+				"codeDescriptionSupport": true,
+				"dataSupport":            true,
+			},
+			"codeAction": map[string]any{
+				"dynamicRegistration": true,
+				"codeActionLiteralSupport": map[string]any{
+					"codeActionKind": map[string]any{
+						"valueSet": []string{
+							"quickfix",
+							"refactor",
+							"refactor.extract",
+							"refactor.inline",
+							"refactor.rewrite",
+							"source",
+							"source.organizeImports",
+						},
+					},
+				},
+				"isPreferredSupport": true,
+				"dataSupport":        true,
+				"resolveSupport": map[string]any{
+					"properties": []string{"edit"},
+				},
+			},
+		},
+		"workspace": map[string]any{
+			"applyEdit": true,
+			"workspaceEdit": map[string]any{
+				"documentChanges":       true,
+				"resourceOperations":    []string{"create", "rename", "delete"},
+				"failureHandling":       "textOnlyTransactional",
+				"normalizesLineEndings": true,
+			},
+			"didChangeConfiguration": map[string]any{
+				"dynamicRegistration": true,
+			},
+			"didChangeWatchedFiles": map[string]any{
+				"dynamicRegistration":    true,
+				"relativePatternSupport": true,
+			},
+			"symbol": map[string]any{
+				"dynamicRegistration": true,
+			},
+			"configuration":    true,
+			"workspaceFolders": true,
+			"fileOperations": map[string]any{
+				"dynamicRegistration": true,
+				"didCreate":           true,
+				"willCreate":          true,
+				"didRename":           true,
+				"willRename":          true,
+				"didDelete":           true,
+				"willDelete":          true,
+			},
+		},
+		"window": map[string]any{
+			"workDoneProgress": true,
+			"showMessage": map[string]any{
+				"messageActionItem": map[string]any{
+					"additionalPropertiesSupport": true,
+				},
+			},
+			"showDocument": map[string]any{
+				"support": true,
+			},
+		},
+		"general": map[string]any{
+			"regularExpressions": map[string]any{
+				"engine":  "ECMAScript",
+				"version": "ES2020",
+			},
+			"markdown": map[string]any{
+				"parser":  "marked",
+				"version": "1.1.0",
+			},
+			"positionEncodings": []string{"utf-16"},
+		},
+	}
+}
@@ -22,6 +22,26 @@ func applyTextEdits(uri protocol.DocumentURI, edits []protocol.TextEdit) error {
 		return fmt.Errorf("failed to read file: %w", err)
 	}
 
+	newContent, err := applyTextEditsToContent(content, edits)
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(path, newContent, 0o644); err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+
+	return nil
+}
+
+// ApplyTextEditsToContent returns content with the given edits applied.
+func ApplyTextEditsToContent(content string, edits []protocol.TextEdit) (string, error) {
+	newContent, err := applyTextEditsToContent([]byte(content), edits)
+	return string(newContent), err
+}
+
+// applyTextEditsToContent returns content with the given edits applied.
+func applyTextEditsToContent(content []byte, edits []protocol.TextEdit) ([]byte, error) {
 	// Detect line ending style
 	var lineEnding string
 	if bytes.Contains(content, []byte("\r\n")) {
@@ -40,7 +60,7 @@ func applyTextEdits(uri protocol.DocumentURI, edits []protocol.TextEdit) error {
 	for i, edit1 := range edits {
 		for j := i + 1; j < len(edits); j++ {
 			if rangesOverlap(edit1.Range, edits[j].Range) {
-				return fmt.Errorf("overlapping edits detected between edit %d and %d", i, j)
+				return nil, fmt.Errorf("overlapping edits detected between edit %d and %d", i, j)
 			}
 		}
 	}
@@ -59,7 +79,7 @@ func applyTextEdits(uri protocol.DocumentURI, edits []protocol.TextEdit) error {
 	for _, edit := range sortedEdits {
 		newLines, err := applyTextEdit(lines, edit)
 		if err != nil {
-			return fmt.Errorf("failed to apply edit: %w", err)
+			return nil, fmt.Errorf("failed to apply edit: %w", err)
 		}
 		lines = newLines
 	}
@@ -78,11 +98,7 @@ func applyTextEdits(uri protocol.DocumentURI, edits []protocol.TextEdit) error {
 		newContent.WriteString(lineEnding)
 	}
 
-	if err := os.WriteFile(path, []byte(newContent.String()), 0o644); err != nil {
-		return fmt.Errorf("failed to write file: %w", err)
-	}
-
-	return nil
+	return []byte(newContent.String()), nil
 }
 
 func applyTextEdit(lines []string, edit protocol.TextEdit) ([]string, error) {
@@ -0,0 +1,177 @@
+package util
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+
+	"github.com/charmbracelet/x/powernap/pkg/lsp/protocol"
+)
+
+// FileChange describes the effect of a workspace edit on a single file.
+type FileChange struct {
+	Path       string
+	OldContent string
+	NewContent string
+	// Created is set when the file did not exist before the edit.
+	Created bool
+	// Deleted is set when the file does not exist after the edit.
+	Deleted bool
+}
+
+// fileState is the in-memory state of a file while an edit is previewed.
+type fileState struct {
+	original      string
+	originalExist bool
+	content       string
+	exists        bool
+}
+
+// PreviewWorkspaceEdit computes the files changed by the given WorkspaceEdit
+// without touching the filesystem. Renames are reported as the deletion of the
+// old path and the creation of the new one. Changes are returned in the order
+// the files are first touched.
+func PreviewWorkspaceEdit(edit protocol.WorkspaceEdit) ([]FileChange, error) {
+	states := make(map[string]*fileState)
+	var order []string
+
+	load := func(path string) (*fileState, error) {
+		if state, ok := states[path]; ok {
+			return state, nil
+		}
+		state := &fileState{}
+		content, err := os.ReadFile(path)
+		switch {
+		case err == nil:
+			state.original, state.content = string(content), string(content)
+			state.originalExist, state.exists = true, true
+		case !errors.Is(err, os.ErrNotExist):
+			return nil, fmt.Errorf("failed to read file: %w", err)
+		}
+		states[path] = state
+		order = append(order, path)
+		return state, nil
+	}
+
+	applyEdits := func(uri protocol.DocumentURI, edits []protocol.TextEdit) error {
+		path, err := uri.Path()
+		if err != nil {
+			return fmt.Errorf("invalid URI: %w", err)
+		}
+		state, err := load(path)
+		if err != nil {
+			return err
+		}
+		if !state.exists {
+			return fmt.Errorf("failed to apply text edits: file not found: %s", path)
+		}
+		content, err := applyTextEditsToContent([]byte(state.content), edits)
+		if err != nil {
+			return fmt.Errorf("failed to apply text edits: %w", err)
+		}
+		state.content = string(content)
+		return nil
+	}
+
+	// Handle Changes field in a stable order.
+	uris := make([]protocol.DocumentURI, 0, len(edit.Changes))
+	for uri := range edit.Changes {
+		uris = append(uris, uri)
+	}
+	slices.Sort(uris)
+	for _, uri := range uris {
+		if err := applyEdits(uri, edit.Changes[uri]); err != nil {
+			return nil, err
+		}
+	}
+
+	// Handle DocumentChanges field
+	for _, change := range edit.DocumentChanges {
+		switch {
+		case change.CreateFile != nil:
+			path, err := change.CreateFile.URI.Path()
+			if err != nil {
+				return nil, fmt.Errorf("invalid URI: %w", err)
+			}
+			state, err := load(path)
+			if err != nil {
+				return nil, err
+			}
+			opts := change.CreateFile.Options
+			if state.exists && opts != nil && !opts.Overwrite && opts.IgnoreIfExists {
+				continue
+			}
+			state.content, state.exists = "", true
+		case change.DeleteFile != nil:
+			path, err := change.DeleteFile.URI.Path()
+			if err != nil {
+				return nil, fmt.Errorf("invalid URI: %w", err)
+			}
+			state, err := load(path)
+			if err != nil {
+				return nil, err
+			}
+			if !state.exists {
+				if opts := change.DeleteFile.Options; opts != nil && opts.IgnoreIfNotExists {
+					continue
+				}
+				return nil, fmt.Errorf("failed to delete file: file not found: %s", path)
+			}
+			state.content, state.exists = "", false
+		case change.RenameFile != nil:
+			oldPath, err := change.RenameFile.OldURI.Path()
+			if err != nil {
+				return nil, fmt.Errorf("invalid URI: %w", err)
+			}
+			newPath, err := change.RenameFile.NewURI.Path()
+			if err != nil {
+				return nil, fmt.Errorf("invalid URI: %w", err)
+			}
+			from, err := load(oldPath)
+			if err != nil {
+				return nil, err
+			}
+			to, err := load(newPath)
+			if err != nil {
+				return nil, err
+			}
+			if !from.exists {
+				return nil, fmt.Errorf("failed to rename file: file not found: %s", oldPath)
+			}
+			if opts := change.RenameFile.Options; to.exists && (opts == nil || !opts.Overwrite) {
+				return nil, fmt.Errorf("target file already exists and overwrite is not allowed: %s", newPath)
+			}
+			to.content, to.exists = from.content, true
+			from.content, from.exists = "", false
+		case change.TextDocumentEdit != nil:
+			textEdits := make([]protocol.TextEdit, len(change.TextDocumentEdit.Edits))
+			for i, edit := range change.TextDocumentEdit.Edits {
+				var err error
+				textEdits[i], err = edit.AsTextEdit()
+				if err != nil {
+					return nil, fmt.Errorf("invalid edit type: %w", err)
+				}
+			}
+			if err := applyEdits(change.TextDocumentEdit.TextDocument.URI, textEdits); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	var changes []FileChange
+	for _, path := range order {
+		state := states[path]
+		if state.exists == state.originalExist && state.content == state.original {
+			continue
+		}
+		changes = append(changes, FileChange{
+			Path:       path,
+			OldContent: state.original,
+			NewContent: state.content,
+			Created:    !state.originalExist && state.exists,
+			Deleted:    state.originalExist && !state.exists,
+		})
+	}
+	return changes, nil
+}
@@ -0,0 +1,84 @@
+package util
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/charmbracelet/x/powernap/pkg/lsp/protocol"
+	"github.com/stretchr/testify/require"
+)
+
+func textEdit(line, start, end uint32, text string) protocol.TextEdit {
+	return protocol.TextEdit{
+		Range: protocol.Range{
+			Start: protocol.Position{Line: line, Character: start},
+			End:   protocol.Position{Line: line, Character: end},
+		},
+		NewText: text,
+	}
+}
+
+func TestPreviewWorkspaceEdit(t *testing.T) {
+	t.Parallel()
+
+	t.Run("changes across files", func(t *testing.T) {
+		t.Parallel()
+		dir := t.TempDir()
+		a := filepath.Join(dir, "a.go")
+		b := filepath.Join(dir, "b.go")
+		require.NoError(t, os.WriteFile(a, []byte("func foo() {}\n"), 0o644))
+		require.NoError(t, os.WriteFile(b, []byte("x := foo()\ny := foo()\n"), 0o644))
+
+		changes, err := PreviewWorkspaceEdit(protocol.WorkspaceEdit{
+			Changes: map[protocol.DocumentURI][]protocol.TextEdit{
+				protocol.URIFromPath(a): {textEdit(0, 5, 8, "bar")},
+				protocol.URIFromPath(b): {textEdit(0, 5, 8, "bar"), textEdit(1, 5, 8, "bar")},
+			},
+		})
+		require.NoError(t, err)
+		require.Len(t, changes, 2)
+		require.Equal(t, "func bar() {}\n", changes[0].NewContent)
+		require.Equal(t, "x := bar()\ny := bar()\n", changes[1].NewContent)
+
+		// Nothing is written.
+		content, err := os.ReadFile(a)
+		require.NoError(t, err)
+		require.Equal(t, "func foo() {}\n", string(content))
+	})
+
+	t.Run("rename then edit", func(t *testing.T) {
+		t.Parallel()
+		dir := t.TempDir()
+		oldPath := filepath.Join(dir, "old.go")
+		newPath := filepath.Join(dir, "new.go")
+		require.NoError(t, os.WriteFile(oldPath, []byte("package old\n"), 0o644))
+
+		changes, err := PreviewWorkspaceEdit(protocol.WorkspaceEdit{
+			DocumentChanges: []protocol.DocumentChange{
+				{RenameFile: &protocol.RenameFile{OldURI: protocol.URIFromPath(oldPath), NewURI: protocol.URIFromPath(newPath)}},
+				{TextDocumentEdit: &protocol.TextDocumentEdit{
+					TextDocument: protocol.OptionalVersionedTextDocumentIdentifier{
+						TextDocumentIdentifier: protocol.TextDocumentIdentifier{URI: protocol.URIFromPath(newPath)},
+					},
+					Edits: []protocol.Or_TextDocumentEdit_edits_Elem{{Value: textEdit(0, 8, 11, "new")}},
+				}},
+			},
+		})
+		require.NoError(t, err)
+		require.Equal(t, []FileChange{
+			{Path: oldPath, OldContent: "package old\n", Deleted: true},
+			{Path: newPath, NewContent: "package new\n", Created: true},
+		}, changes)
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		t.Parallel()
+		_, err := PreviewWorkspaceEdit(protocol.WorkspaceEdit{
+			Changes: map[protocol.DocumentURI][]protocol.TextEdit{
+				protocol.URIFromPath(filepath.Join(t.TempDir(), "missing.go")): {textEdit(0, 0, 0, "x")},
+			},
+		})
+		require.Error(t, err)
+	})
+}
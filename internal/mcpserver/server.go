@@ -0,0 +1,195 @@
+// Package mcpserver publishes push's built-in tools over the Model Context
+// Protocol so other agents and editors can use them without the TUI.
+package mcpserver
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/uglyswap/push/internal/agent/tools"
+	"github.com/uglyswap/push/internal/config"
+	"github.com/uglyswap/push/internal/csync"
+	"github.com/uglyswap/push/internal/history"
+	"github.com/uglyswap/push/internal/lsp"
+	"github.com/uglyswap/push/internal/permission"
+	"github.com/uglyswap/push/internal/version"
+	"github.com/uglyswap/push/pkg/fantasy"
+)
+
+// DefaultTools are the built-in tools published when the configuration does
+// not list any.
+var DefaultTools = []string{
+	tools.ViewToolName,
+	tools.EditToolName,
+	tools.MultiEditToolName,
+	tools.WriteToolName,
+	tools.GrepToolName,
+	tools.GlobToolName,
+	tools.LSToolName,
+	tools.BashToolName,
+	tools.DiagnosticsToolName,
+	tools.ReferencesToolName,
+}
+
+// Options holds the services the published tools rely on.
+type Options struct {
+	Config      *config.Config
+	Permissions permission.Service
+	History     history.Service
+	LSPClients  *csync.Map[string, *lsp.Client]
+	// SessionID is the session tool calls are recorded under.
+	SessionID string
+}
+
+// New returns an MCP server publishing the configured built-in tools.
+func New(opts Options) (*mcp.Server, error) {
+	published, err := buildTools(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	server := mcp.NewServer(&mcp.Implementation{
+		Name:    "push",
+		Version: version.Version,
+	}, nil)
+	for _, tool := range published {
+		server.AddTool(&mcp.Tool{
+			Name:        tool.Name(),
+			Description: tool.Description(),
+			InputSchema: inputSchema(tool),
+		}, toolHandler(tool, opts.SessionID))
+	}
+	return server, nil
+}
+
+func buildTools(opts Options) ([]fantasy.AgentTool, error) {
+	cfg := opts.Config
+	names := DefaultTools
+	if cfg.Options.MCPServer != nil && len(cfg.Options.MCPServer.Tools) > 0 {
+		names = cfg.Options.MCPServer.Tools
+	}
+
+	var published []fantasy.AgentTool
+	for _, name := range names {
+		if slices.Contains(cfg.Options.DisabledTools, name) {
+			continue
+		}
+		var tool fantasy.AgentTool
+		switch name {
+		case tools.ViewToolName:
+			tool = tools.NewViewTool(opts.LSPClients, opts.Permissions, cfg.WorkingDir())
+		case tools.EditToolName:
+			tool = tools.NewEditTool(opts.LSPClients, opts.Permissions, opts.History, cfg.WorkingDir())
+		case tools.MultiEditToolName:
+			tool = tools.NewMultiEditTool(opts.LSPClients, opts.Permissions, opts.History, cfg.WorkingDir())
+		case tools.WriteToolName:
+			tool = tools.NewWriteTool(opts.LSPClients, opts.Permissions, opts.History, cfg.WorkingDir())
+		case tools.GrepToolName:
+			tool = tools.NewGrepTool(cfg.WorkingDir())
+		case tools.GlobToolName:
+			tool = tools.NewGlobTool(cfg.WorkingDir())
+		case tools.LSToolName:
+			tool = tools.NewLsTool(opts.Permissions, cfg.WorkingDir(), cfg.Tools.Ls)
+		case tools.BashToolName:
+			tool = tools.NewBashTool(opts.Permissions, cfg.WorkingDir(), cfg.Options.Attribution, "")
+		case tools.DiagnosticsToolName:
+			tool = tools.NewDiagnosticsTool(opts.LSPClients)
+		case tools.ReferencesToolName:
+			tool = tools.NewReferencesTool(opts.LSPClients)
+		default:
+			return nil, fmt.Errorf("tool %q cannot be published over MCP", name)
+		}
+		published = append(published, tool)
+	}
+	return published, nil
+}
+
+// inputSchema returns the tool parameters as a JSON schema object, listing
+// the required ones when the tool describes them apart from its parameters.
+func inputSchema(tool fantasy.AgentTool) map[string]any {
+	schema := tool.Parameters()
+	if _, ok := schema["type"]; !ok {
+		schema = map[string]any{
+			"type":       "object",
+			"properties": schema,
+		}
+	}
+	info, ok := tool.(interface{ Info() fantasy.ToolInfo })
+	if !ok || len(info.Info().Required) == 0 {
+		return schema
+	}
+	if _, ok := schema["required"]; !ok {
+		schema = maps.Clone(schema)
+		schema["required"] = info.Info().Required
+	}
+	return schema
+}
+
+func toolHandler(tool fantasy.AgentTool, sessionID string) mcp.ToolHandler {
+	return func(ctx context.Context, req *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		input := string(req.Params.Arguments)
+		if input == "" {
+			input = "{}"
+		}
+		ctx = context.WithValue(ctx, tools.SessionIDContextKey, sessionID)
+		output, err := tool.Execute(ctx, input)
+		if err != nil {
+			slog.Error("MCP tool call failed", "tool", tool.Name(), "error", err)
+			return errorResult(err), nil
+		}
+		return toCallToolResult(output), nil
+	}
+}
+
+func toCallToolResult(output fantasy.ToolResultOutput) *mcp.CallToolResult {
+	switch output := output.(type) {
+	case fantasy.ToolResultOutputContentText:
+		return &mcp.CallToolResult{Content: []mcp.Content{&mcp.TextContent{Text: output.Text}}}
+	case fantasy.ToolResultOutputContentError:
+		return errorResult(output.Error)
+	case fantasy.ToolResultOutputContentMedia:
+		data, err := base64.StdEncoding.DecodeString(output.Data)
+		if err != nil {
+			return errorResult(fmt.Errorf("invalid media content: %w", err))
+		}
+		content := []mcp.Content{&mcp.ImageContent{Data: data, MIMEType: output.MediaType}}
+		if output.Text != "" {
+			content = append([]mcp.Content{&mcp.TextContent{Text: output.Text}}, content...)
+		}
+		return &mcp.CallToolResult{Content: content}
+	default:
+		data, _ := json.Marshal(output)
+		return &mcp.CallToolResult{Content: []mcp.Content{&mcp.TextContent{Text: string(data)}}}
+	}
+}
+
+func errorResult(err error) *mcp.CallToolResult {
+	msg := "tool call failed"
+	if err != nil {
+		msg = err.Error()
+	}
+	return &mcp.CallToolResult{
+		IsError: true,
+		Content: []mcp.Content{&mcp.TextContent{Text: msg}},
+	}
+}
+
+// ResolvePermissions answers the permission requests of the published tools
+// with the configured policy until ctx is done.
+func ResolvePermissions(ctx context.Context, permissions permission.Service, policy config.MCPServerPermissions) {
+	for event := range permissions.Subscribe(ctx) {
+		request := event.Payload
+		if policy.Allows(request.ToolName, request.Action) {
+			permissions.Grant(request)
+			continue
+		}
+		slog.Info("Denied MCP tool permission", "tool", request.ToolName, "action", request.Action, "path", request.Path)
+		permissions.Deny(request)
+	}
+}
@@ -0,0 +1,87 @@
+package mcpserver
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/stretchr/testify/require"
+	"github.com/uglyswap/push/internal/agent/tools"
+	"github.com/uglyswap/push/pkg/fantasy"
+)
+
+type echoTool struct {
+	sessionID string
+}
+
+func (t *echoTool) Name() string        { return "echo" }
+func (t *echoTool) Description() string { return "Echoes its input" }
+func (t *echoTool) Parameters() map[string]any {
+	return map[string]any{"text": map[string]any{"type": "string"}}
+}
+
+func (t *echoTool) Execute(ctx context.Context, input string) (fantasy.ToolResultOutput, error) {
+	t.sessionID = tools.GetSessionFromContext(ctx)
+	return fantasy.ToolResultOutputContentText{Text: input}, nil
+}
+func (t *echoTool) SetProviderOptions(fantasy.ProviderOptions) {}
+
+func TestToolHandler(t *testing.T) {
+	t.Parallel()
+
+	tool := &echoTool{}
+	require.Equal(t, "object", inputSchema(tool)["type"])
+
+	result, err := toolHandler(tool, "session-1")(t.Context(), &mcp.CallToolRequest{
+		Params: &mcp.CallToolParamsRaw{Name: "echo", Arguments: []byte(`{"text":"hi"}`)},
+	})
+	require.NoError(t, err)
+	require.False(t, result.IsError)
+	require.Equal(t, `{"text":"hi"}`, result.Content[0].(*mcp.TextContent).Text)
+	require.Equal(t, "session-1", tool.sessionID)
+}
+
+// requiredTool describes its required parameters apart from them, as tools
+// built from a fantasy.ToolInfo do.
+type requiredTool struct {
+	echoTool
+}
+
+func (t *requiredTool) Info() fantasy.ToolInfo {
+	return fantasy.ToolInfo{Name: t.Name(), Parameters: t.Parameters(), Required: []string{"text"}}
+}
+
+func TestInputSchema(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, map[string]any{
+		"type":       "object",
+		"properties": map[string]any{"text": map[string]any{"type": "string"}},
+	}, inputSchema(&echoTool{}))
+
+	require.Equal(t, map[string]any{
+		"type":       "object",
+		"properties": map[string]any{"text": map[string]any{"type": "string"}},
+		"required":   []string{"text"},
+	}, inputSchema(&requiredTool{}))
+}
+
+func TestToCallToolResult(t *testing.T) {
+	t.Parallel()
+
+	result := toCallToolResult(fantasy.ToolResultOutputContentError{Error: errors.New("boom")})
+	require.True(t, result.IsError)
+	require.Equal(t, "boom", result.Content[0].(*mcp.TextContent).Text)
+
+	result = toCallToolResult(fantasy.ToolResultOutputContentMedia{
+		Text:      "screenshot",
+		Data:      base64.StdEncoding.EncodeToString([]byte("png")),
+		MediaType: "image/png",
+	})
+	require.Len(t, result.Content, 2)
+	image := result.Content[1].(*mcp.ImageContent)
+	require.Equal(t, []byte("png"), image.Data)
+	require.Equal(t, "image/png", image.MIMEType)
+}
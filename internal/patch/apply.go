@@ -0,0 +1,191 @@
+package patch
+
+import (
+	"fmt"
+	"strings"
+)
+
+// maxFuzz is how many context lines may be dropped from each end of a hunk
+// when it does not match otherwise.
+const maxFuzz = 2
+
+// lineMatchers compare a file line with a hunk line, from the strictest to the
+// most tolerant.
+var lineMatchers = []func(a, b string) bool{
+	func(a, b string) bool { return a == b },
+	func(a, b string) bool { return strings.TrimRight(a, " \t") == strings.TrimRight(b, " \t") },
+	func(a, b string) bool { return strings.TrimSpace(a) == strings.TrimSpace(b) },
+}
+
+// Apply applies the hunks to content, in order. Each hunk is placed where its
+// context and removed lines match, trying exact matches first and then
+// ignoring trailing whitespace, surrounding whitespace and, as a last resort,
+// up to maxFuzz context lines at either end.
+func Apply(content string, hunks []Hunk) (string, error) {
+	hasNewline := content == "" || strings.HasSuffix(content, "\n")
+	lines := splitLines(content)
+
+	// start is the first line the next hunk may match at, and offset the
+	// number of lines added so far, to adjust OldStart hints.
+	start, offset := 0, 0
+	for i, hunk := range hunks {
+		from := start
+		if hunk.Anchor != "" {
+			anchor, ok := findAnchor(lines, hunk.Anchor, start)
+			if !ok {
+				return "", fmt.Errorf("hunk %d: anchor line %q not found", i+1, hunk.Anchor)
+			}
+			from = anchor + 1
+		}
+
+		pos, h, ok := locate(lines, hunk, from, offset)
+		if !ok {
+			return "", fmt.Errorf("hunk %d: lines not found in file:\n%s", i+1, strings.Join(hunk.oldLines(), "\n"))
+		}
+
+		oldLines, newLines := h.oldLines(), h.contextFrom(lines[pos:])
+		replaced := make([]string, 0, len(lines)-len(oldLines)+len(newLines))
+		replaced = append(replaced, lines[:pos]...)
+		replaced = append(replaced, newLines...)
+		replaced = append(replaced, lines[pos+len(oldLines):]...)
+		lines = replaced
+
+		start = pos + len(newLines)
+		offset += len(newLines) - len(oldLines)
+		if hunk.NoNewlineAtEOF {
+			hasNewline = false
+		}
+	}
+
+	result := strings.Join(lines, "\n")
+	if hasNewline && len(lines) > 0 {
+		result += "\n"
+	}
+	return result, nil
+}
+
+// locate returns the line where the hunk applies, along with the hunk
+// actually matched, which has fewer context lines when fuzz was needed.
+func locate(lines []string, hunk Hunk, from, offset int) (int, Hunk, bool) {
+	hint := from
+	if hunk.OldStart > 0 {
+		hint = max(from, hunk.OldStart-1+offset)
+	}
+	if len(hunk.oldLines()) == 0 {
+		// Pure additions go at the hinted line, or at the end of the file.
+		if hunk.OldStart == 0 || hunk.AtEOF {
+			return len(lines), hunk, true
+		}
+		return min(hint, len(lines)), hunk, true
+	}
+
+	for fuzz := 0; fuzz <= maxFuzz; fuzz++ {
+		h, ok := trimContext(hunk, fuzz)
+		if !ok {
+			break
+		}
+		old := h.oldLines()
+		for _, match := range lineMatchers {
+			if pos, ok := findBlock(lines, old, from, hint, h.AtEOF, match); ok {
+				return pos, h, true
+			}
+		}
+	}
+	return 0, hunk, false
+}
+
+// contextFrom returns the new lines of the hunk, taking context lines from
+// the matched file lines so that tolerated whitespace differences are kept.
+func (h Hunk) contextFrom(matched []string) []string {
+	var lines []string
+	old := 0
+	for _, line := range h.Lines {
+		switch line.Kind {
+		case ' ':
+			lines = append(lines, matched[old])
+			old++
+		case '-':
+			old++
+		default:
+			lines = append(lines, line.Text)
+		}
+	}
+	return lines
+}
+
+// trimContext drops up to n context lines from each end of the hunk. It
+// reports false when there is no context left to drop.
+func trimContext(hunk Hunk, n int) (Hunk, bool) {
+	if n == 0 {
+		return hunk, true
+	}
+	lines := hunk.Lines
+	lead, trail := 0, 0
+	for lead < n && lead < len(lines) && lines[lead].Kind == ' ' {
+		lead++
+	}
+	for trail < n && trail < len(lines)-lead && lines[len(lines)-1-trail].Kind == ' ' {
+		trail++
+	}
+	if lead+trail == 0 {
+		return hunk, false
+	}
+	trimmed := hunk
+	trimmed.Lines = lines[lead : len(lines)-trail]
+	if trimmed.OldStart > 0 {
+		trimmed.OldStart += lead
+	}
+	// Trailing context anchored the hunk to the end of the file.
+	if trail > 0 {
+		trimmed.AtEOF = false
+	}
+	return trimmed, len(trimmed.oldLines()) > 0
+}
+
+// findBlock returns the position at or after from where block matches lines,
+// choosing the one closest to hint.
+func findBlock(lines, block []string, from, hint int, atEOF bool, match func(a, b string) bool) (int, bool) {
+	matchesAt := func(pos int) bool {
+		for j, want := range block {
+			if !match(lines[pos+j], want) {
+				return false
+			}
+		}
+		return true
+	}
+
+	last := len(lines) - len(block)
+	if atEOF {
+		if last >= from && matchesAt(last) {
+			return last, true
+		}
+		return 0, false
+	}
+	hint = min(max(hint, from), max(last, from))
+	for d := 0; hint-d >= from || hint+d <= last; d++ {
+		if pos := hint + d; pos <= last && matchesAt(pos) {
+			return pos, true
+		}
+		if pos := hint - d; d > 0 && pos >= from && pos <= last && matchesAt(pos) {
+			return pos, true
+		}
+	}
+	return 0, false
+}
+
+func findAnchor(lines []string, anchor string, from int) (int, bool) {
+	anchor = strings.TrimSpace(anchor)
+	for i := from; i < len(lines); i++ {
+		if strings.TrimSpace(lines[i]) == anchor {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+func splitLines(content string) []string {
+	if content == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+}
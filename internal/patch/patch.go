@@ -0,0 +1,362 @@
+// Package patch parses multi-file patches, either unified diffs or the
+// structured "*** Begin Patch" format, and applies their hunks with context
+// tolerant matching.
+package patch
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Operation is what a patch does to a file.
+type Operation int
+
+const (
+	OperationUpdate Operation = iota
+	OperationAdd
+	OperationDelete
+)
+
+// File is the patch of a single file.
+type File struct {
+	Operation Operation
+	// Path is the path of the file before the patch, or the path of the new
+	// file for additions.
+	Path string
+	// MoveTo is the new path of a moved file.
+	MoveTo string
+	// Hunks are the changes of an updated file.
+	Hunks []Hunk
+	// Content is the content of an added file.
+	Content string
+}
+
+// Hunk is a contiguous change. Context and removed lines must be found in the
+// file; they are replaced by the context and added lines.
+type Hunk struct {
+	// OldStart is the 1-based line the hunk starts at in the original file,
+	// or 0 when unknown. It is only a hint: the hunk is placed where its
+	// lines match, as close to OldStart as possible.
+	OldStart int
+	// Anchor is a line the hunk must come after, such as the function
+	// signature in "@@ func main() {".
+	Anchor string
+	// AtEOF is set when the hunk must match the end of the file.
+	AtEOF bool
+	Lines []Line
+	// NoNewlineAtEOF is set when the patched file must not end with a
+	// newline.
+	NoNewlineAtEOF bool
+}
+
+// Line is a line of a hunk.
+type Line struct {
+	// Kind is ' ' for context, '-' for removed and '+' for added lines.
+	Kind byte
+	Text string
+}
+
+func (h Hunk) oldLines() []string {
+	var lines []string
+	for _, line := range h.Lines {
+		if line.Kind != '+' {
+			lines = append(lines, line.Text)
+		}
+	}
+	return lines
+}
+
+func (h Hunk) newLines() []string {
+	var lines []string
+	for _, line := range h.Lines {
+		if line.Kind != '-' {
+			lines = append(lines, line.Text)
+		}
+	}
+	return lines
+}
+
+// Parse parses a patch in either supported format.
+func Parse(text string) ([]File, error) {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	var files []File
+	var err error
+	if isStructured(text) {
+		files, err = parseStructured(text)
+	} else {
+		files, err = parseUnified(text)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, errors.New("patch does not change any file")
+	}
+	return files, nil
+}
+
+func isStructured(text string) bool {
+	for line := range strings.Lines(text) {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		return strings.HasPrefix(line, "*** ")
+	}
+	return false
+}
+
+// parseStructured parses the "*** Begin Patch" format:
+//
+//	*** Begin Patch
+//	*** Add File: path
+//	+line
+//	*** Update File: path
+//	*** Move to: new path
+//	@@ optional anchor line
+//	 context
+//	-removed
+//	+added
+//	*** Delete File: path
+//	*** End Patch
+func parseStructured(text string) ([]File, error) {
+	var files []File
+	var file *File
+	var hunk *Hunk
+
+	flushHunk := func() {
+		if file != nil && hunk != nil && len(hunk.Lines) > 0 {
+			file.Hunks = append(file.Hunks, *hunk)
+		}
+		hunk = nil
+	}
+	flushFile := func() {
+		flushHunk()
+		if file != nil {
+			files = append(files, *file)
+		}
+		file = nil
+	}
+
+	lines := strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+	for i, line := range lines {
+		lineNum := i + 1
+		switch {
+		case line == "*** Begin Patch":
+		case line == "*** End Patch":
+			flushFile()
+		case strings.HasPrefix(line, "*** Add File: "):
+			flushFile()
+			file = &File{Operation: OperationAdd, Path: strings.TrimSpace(strings.TrimPrefix(line, "*** Add File: "))}
+		case strings.HasPrefix(line, "*** Delete File: "):
+			flushFile()
+			file = &File{Operation: OperationDelete, Path: strings.TrimSpace(strings.TrimPrefix(line, "*** Delete File: "))}
+		case strings.HasPrefix(line, "*** Update File: "):
+			flushFile()
+			file = &File{Operation: OperationUpdate, Path: strings.TrimSpace(strings.TrimPrefix(line, "*** Update File: "))}
+		case strings.HasPrefix(line, "*** Move to: "):
+			if file == nil || file.Operation != OperationUpdate {
+				return nil, fmt.Errorf("line %d: move outside of an updated file", lineNum)
+			}
+			file.MoveTo = strings.TrimSpace(strings.TrimPrefix(line, "*** Move to: "))
+		case line == "*** End of File":
+			if hunk == nil {
+				return nil, fmt.Errorf("line %d: end of file marker outside of a hunk", lineNum)
+			}
+			hunk.AtEOF = true
+		case file == nil:
+			if strings.TrimSpace(line) == "" {
+				continue
+			}
+			return nil, fmt.Errorf("line %d: expected a file header, got %q", lineNum, line)
+		case file.Operation == OperationAdd:
+			if !strings.HasPrefix(line, "+") {
+				return nil, fmt.Errorf("line %d: lines of an added file must start with +", lineNum)
+			}
+			file.Content += line[1:] + "\n"
+		case file.Operation == OperationDelete:
+			if strings.TrimSpace(line) != "" {
+				return nil, fmt.Errorf("line %d: unexpected content for a deleted file", lineNum)
+			}
+		case strings.HasPrefix(line, "@@"):
+			flushHunk()
+			hunk = &Hunk{Anchor: strings.TrimSpace(strings.TrimPrefix(line, "@@"))}
+		default:
+			if hunk == nil {
+				hunk = &Hunk{}
+			}
+			kind, text := lineKind(line)
+			if kind == 0 {
+				return nil, fmt.Errorf("line %d: hunk lines must start with ' ', '-' or '+', got %q", lineNum, line)
+			}
+			hunk.Lines = append(hunk.Lines, Line{Kind: kind, Text: text})
+		}
+	}
+	flushFile()
+	return files, nil
+}
+
+var hunkHeader = regexp.MustCompile(`^@@ -(\d+)(?:,\d+)? \+\d+(?:,\d+)? @@`)
+
+// parseUnified parses a unified diff, as produced by diff -u or git diff.
+func parseUnified(text string) ([]File, error) {
+	var files []File
+	var file *File
+	var hunk *Hunk
+	var oldPath, newPath string
+
+	flushHunk := func() {
+		if file != nil && hunk != nil && len(hunk.Lines) > 0 {
+			file.Hunks = append(file.Hunks, *hunk)
+		}
+		hunk = nil
+	}
+	flushFile := func() {
+		flushHunk()
+		if file != nil {
+			if file.Operation == OperationAdd {
+				for _, h := range file.Hunks {
+					file.Content += strings.Join(h.newLines(), "\n") + "\n"
+					if h.NoNewlineAtEOF {
+						file.Content = strings.TrimSuffix(file.Content, "\n")
+					}
+				}
+				file.Hunks = nil
+			}
+			files = append(files, *file)
+		}
+		file = nil
+		oldPath, newPath = "", ""
+	}
+	startFile := func() {
+		if file != nil {
+			return
+		}
+		file = &File{}
+	}
+
+	lines := strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		lineNum := i + 1
+		isFileHeader := strings.HasPrefix(line, "--- ") && i+1 < len(lines) && strings.HasPrefix(lines[i+1], "+++ ")
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			flushFile()
+			startFile()
+			if a, b, ok := strings.Cut(strings.TrimPrefix(line, "diff --git "), " "); ok {
+				oldPath, newPath = stripPrefix(a), stripPrefix(b)
+				file.Path = oldPath
+				if oldPath != newPath {
+					file.MoveTo = newPath
+				}
+			}
+		case isFileHeader:
+			if file != nil && (len(file.Hunks) > 0 || hunk != nil) {
+				flushFile()
+			}
+			startFile()
+			oldPath = parseHeaderPath(strings.TrimPrefix(line, "--- "))
+			newPath = parseHeaderPath(strings.TrimPrefix(lines[i+1], "+++ "))
+			i++
+			switch {
+			case oldPath == "/dev/null":
+				file.Operation = OperationAdd
+				file.Path = newPath
+			case newPath == "/dev/null":
+				file.Operation = OperationDelete
+				file.Path = oldPath
+			default:
+				file.Path = oldPath
+				file.MoveTo = ""
+				if newPath != oldPath {
+					file.MoveTo = newPath
+				}
+			}
+		case file != nil && hunk == nil && strings.HasPrefix(line, "new file mode"):
+			file.Operation = OperationAdd
+			file.MoveTo = ""
+			if newPath != "" {
+				file.Path = newPath
+			}
+		case file != nil && hunk == nil && strings.HasPrefix(line, "deleted file mode"):
+			file.Operation = OperationDelete
+			file.MoveTo = ""
+		case file != nil && hunk == nil && strings.HasPrefix(line, "rename from "):
+			file.Path = strings.TrimPrefix(line, "rename from ")
+		case file != nil && hunk == nil && strings.HasPrefix(line, "rename to "):
+			file.MoveTo = strings.TrimPrefix(line, "rename to ")
+		case strings.HasPrefix(line, "@@"):
+			if file == nil {
+				return nil, fmt.Errorf("line %d: hunk without a file header", lineNum)
+			}
+			flushHunk()
+			hunk = &Hunk{}
+			if m := hunkHeader.FindStringSubmatch(line); m != nil {
+				hunk.OldStart, _ = strconv.Atoi(m[1])
+			}
+		case hunk != nil && strings.HasPrefix(line, `\`):
+			// "\ No newline at end of file" after the last added or context
+			// line means the new file has no trailing newline.
+			if n := len(hunk.Lines); n > 0 && hunk.Lines[n-1].Kind != '-' {
+				hunk.NoNewlineAtEOF = true
+			}
+		case hunk != nil:
+			kind, text := lineKind(line)
+			if kind == 0 {
+				// Anything else ends the hunk, like the next "diff" or
+				// "index" line.
+				flushHunk()
+				continue
+			}
+			hunk.Lines = append(hunk.Lines, Line{Kind: kind, Text: text})
+		}
+	}
+	flushFile()
+
+	for _, f := range files {
+		if f.Path == "" {
+			return nil, errors.New("patch has a file without a path")
+		}
+		if f.Operation == OperationUpdate && len(f.Hunks) == 0 && f.MoveTo == "" {
+			return nil, fmt.Errorf("patch for %s has no hunks", f.Path)
+		}
+	}
+	return files, nil
+}
+
+// lineKind returns the kind and text of a hunk line. Empty lines are treated
+// as empty context lines, since editors and models often strip the space.
+func lineKind(line string) (byte, string) {
+	if line == "" {
+		return ' ', ""
+	}
+	switch line[0] {
+	case ' ', '-', '+':
+		return line[0], line[1:]
+	}
+	return 0, ""
+}
+
+// parseHeaderPath returns the path of a "---" or "+++" header line, without
+// the a/ or b/ prefix and the optional timestamp.
+func parseHeaderPath(s string) string {
+	if path, _, ok := strings.Cut(s, "\t"); ok {
+		s = path
+	}
+	s = strings.TrimSpace(s)
+	if s == "/dev/null" {
+		return s
+	}
+	return stripPrefix(s)
+}
+
+func stripPrefix(path string) string {
+	if strings.HasPrefix(path, "a/") || strings.HasPrefix(path, "b/") {
+		return path[2:]
+	}
+	return path
+}
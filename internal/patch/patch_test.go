@@ -0,0 +1,170 @@
+package patch
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseUnified(t *testing.T) {
+	t.Parallel()
+
+	files, err := Parse(`diff --git a/main.go b/main.go
+index 1111111..2222222 100644
+--- a/main.go
++++ b/main.go
+@@ -1,3 +1,3 @@
+ package main
+-func foo() {}
++func bar() {}
+ 
+diff --git a/new.go b/new.go
+new file mode 100644
+--- /dev/null
++++ b/new.go
+@@ -0,0 +1,2 @@
++package main
++var x = 1
+diff --git a/old.go b/old.go
+deleted file mode 100644
+--- a/old.go
++++ /dev/null
+@@ -1 +0,0 @@
+-package main
+diff --git a/a.go b/b.go
+similarity index 100%
+rename from a.go
+rename to b.go
+`)
+	require.NoError(t, err)
+	require.Len(t, files, 4)
+
+	require.Equal(t, OperationUpdate, files[0].Operation)
+	require.Equal(t, "main.go", files[0].Path)
+	require.Len(t, files[0].Hunks, 1)
+	require.Equal(t, 1, files[0].Hunks[0].OldStart)
+	require.Equal(t, []string{"package main", "func foo() {}", ""}, files[0].Hunks[0].oldLines())
+	require.Equal(t, []string{"package main", "func bar() {}", ""}, files[0].Hunks[0].newLines())
+
+	require.Equal(t, File{Operation: OperationAdd, Path: "new.go", Content: "package main\nvar x = 1\n"}, files[1])
+	require.Equal(t, OperationDelete, files[2].Operation)
+	require.Equal(t, "old.go", files[2].Path)
+	require.Equal(t, File{Operation: OperationUpdate, Path: "a.go", MoveTo: "b.go"}, files[3])
+}
+
+func TestParseStructured(t *testing.T) {
+	t.Parallel()
+
+	files, err := Parse(`*** Begin Patch
+*** Add File: hello.txt
++hello
+*** Update File: main.go
+*** Move to: cmd/main.go
+@@ func main() {
+-	println("a")
++	println("b")
+*** Delete File: old.txt
+*** End Patch
+`)
+	require.NoError(t, err)
+	require.Equal(t, []File{
+		{Operation: OperationAdd, Path: "hello.txt", Content: "hello\n"},
+		{
+			Operation: OperationUpdate,
+			Path:      "main.go",
+			MoveTo:    "cmd/main.go",
+			Hunks: []Hunk{{
+				Anchor: "func main() {",
+				Lines: []Line{
+					{Kind: '-', Text: "\tprintln(\"a\")"},
+					{Kind: '+', Text: "\tprintln(\"b\")"},
+				},
+			}},
+		},
+		{Operation: OperationDelete, Path: "old.txt"},
+	}, files)
+}
+
+func TestParseErrors(t *testing.T) {
+	t.Parallel()
+
+	_, err := Parse("")
+	require.Error(t, err)
+
+	_, err = Parse("*** Begin Patch\n*** Add File: a.txt\nmissing plus\n*** End Patch\n")
+	require.Error(t, err)
+}
+
+func hunk(oldStart int, lines ...string) Hunk {
+	h := Hunk{OldStart: oldStart}
+	for _, line := range lines {
+		h.Lines = append(h.Lines, Line{Kind: line[0], Text: line[1:]})
+	}
+	return h
+}
+
+func TestApply(t *testing.T) {
+	t.Parallel()
+
+	content := "a\nb\nc\nd\ne\nf\n"
+
+	tests := []struct {
+		name  string
+		hunks []Hunk
+		want  string
+	}{
+		{
+			name:  "exact",
+			hunks: []Hunk{hunk(2, " b", "-c", "+C", " d")},
+			want:  "a\nb\nC\nd\ne\nf\n",
+		},
+		{
+			name:  "wrong line numbers",
+			hunks: []Hunk{hunk(40, " d", "-e", "+E")},
+			want:  "a\nb\nc\nd\nE\nf\n",
+		},
+		{
+			name:  "whitespace differences",
+			hunks: []Hunk{hunk(0, "  b  ", "-c\t", "+C")},
+			want:  "a\nb\nC\nd\ne\nf\n",
+		},
+		{
+			name:  "stale context",
+			hunks: []Hunk{hunk(0, " x", " b", "-c", "+C", " d", " y")},
+			want:  "a\nb\nC\nd\ne\nf\n",
+		},
+		{
+			name: "several hunks",
+			hunks: []Hunk{
+				hunk(1, "-a", "+A1", "+A2"),
+				hunk(5, " d", "-e", "+E"),
+			},
+			want: "A1\nA2\nb\nc\nd\nE\nf\n",
+		},
+		{
+			name:  "anchor",
+			hunks: []Hunk{{Anchor: "d", Lines: []Line{{Kind: '+', Text: "inserted"}, {Kind: ' ', Text: "e"}}}},
+			want:  "a\nb\nc\nd\ninserted\ne\nf\n",
+		},
+		{
+			name:  "no newline at end of file",
+			hunks: []Hunk{{Lines: []Line{{Kind: ' ', Text: "e"}, {Kind: '-', Text: "f"}, {Kind: '+', Text: "F"}}, NoNewlineAtEOF: true}},
+			want:  "a\nb\nc\nd\ne\nF",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := Apply(content, tt.hunks)
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestApplyMismatch(t *testing.T) {
+	t.Parallel()
+
+	_, err := Apply("a\nb\nc\n", []Hunk{hunk(0, " a", "-z", "+Z")})
+	require.ErrorContains(t, err, "hunk 1: lines not found")
+}
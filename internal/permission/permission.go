@@ -10,6 +10,7 @@ import (
 
 	"github.com/uglyswap/push/internal/csync"
 	"github.com/uglyswap/push/internal/pubsub"
+	"github.com/uglyswap/push/internal/tracing"
 	"github.com/google/uuid"
 )
 
@@ -48,12 +49,24 @@ type Service interface {
 	Grant(permission PermissionRequest)
 	Deny(permission PermissionRequest)
 	Request(opts CreatePermissionRequest) bool
+	// RequestReview is Request for a change to a file, which the user can
+	// review hunk by hunk. The review is nil unless the user did so.
+	RequestReview(opts CreatePermissionRequest) (bool, *Review)
+	// GrantReviewed grants a request for only the parts of the change the
+	// user accepted in a review.
+	GrantReviewed(permission PermissionRequest, review Review)
 	AutoApproveSession(sessionID string)
 	SetSkipRequests(skip bool)
 	SkipRequests() bool
 	SubscribeNotifications(ctx context.Context) <-chan pubsub.Event[PermissionNotification]
 }
 
+// response is the answer of the user to a permission request.
+type response struct {
+	granted bool
+	review  *Review
+}
+
 type permissionService struct {
 	*pubsub.Broker[PermissionRequest]
 
@@ -61,7 +74,7 @@ type permissionService struct {
 	workingDir            string
 	sessionPermissions    []PermissionRequest
 	sessionPermissionsMu  sync.RWMutex
-	pendingRequests       *csync.Map[string, chan bool]
+	pendingRequests       *csync.Map[string, chan response]
 	autoApproveSessions   map[string]bool
 	autoApproveSessionsMu sync.RWMutex
 	skip                  bool
@@ -79,7 +92,7 @@ func (s *permissionService) GrantPersistent(permission PermissionRequest) {
 	})
 	respCh, ok := s.pendingRequests.Get(permission.ID)
 	if ok {
-		respCh <- true
+		respCh <- response{granted: true}
 	}
 
 	s.sessionPermissionsMu.Lock()
@@ -92,13 +105,21 @@ func (s *permissionService) GrantPersistent(permission PermissionRequest) {
 }
 
 func (s *permissionService) Grant(permission PermissionRequest) {
+	s.respond(permission, response{granted: true})
+}
+
+func (s *permissionService) GrantReviewed(permission PermissionRequest, review Review) {
+	s.respond(permission, response{granted: true, review: &review})
+}
+
+func (s *permissionService) respond(permission PermissionRequest, resp response) {
 	s.notificationBroker.Publish(pubsub.CreatedEvent, PermissionNotification{
 		ToolCallID: permission.ToolCallID,
 		Granted:    true,
 	})
 	respCh, ok := s.pendingRequests.Get(permission.ID)
 	if ok {
-		respCh <- true
+		respCh <- resp
 	}
 
 	if s.activeRequest != nil && s.activeRequest.ID == permission.ID {
@@ -114,7 +135,7 @@ func (s *permissionService) Deny(permission PermissionRequest) {
 	})
 	respCh, ok := s.pendingRequests.Get(permission.ID)
 	if ok {
-		respCh <- false
+		respCh <- response{}
 	}
 
 	if s.activeRequest != nil && s.activeRequest.ID == permission.ID {
@@ -123,10 +144,29 @@ func (s *permissionService) Deny(permission PermissionRequest) {
 }
 
 func (s *permissionService) Request(opts CreatePermissionRequest) bool {
+	granted, _ := s.RequestReview(opts)
+	return granted
+}
+
+func (s *permissionService) RequestReview(opts CreatePermissionRequest) (granted bool, review *Review) {
 	if s.skip {
-		return true
+		return true, nil
 	}
 
+	// The span covers the time spent waiting for other requests and for the
+	// user, under the turn of the session.
+	_, span := tracing.Start(
+		tracing.SessionContext(opts.SessionID),
+		"permission.wait",
+		tracing.AttrSessionID.String(opts.SessionID),
+		tracing.AttrToolName.String(opts.ToolName),
+		tracing.AttrToolCallID.String(opts.ToolCallID),
+	)
+	defer func() {
+		span.SetAttributes(tracing.AttrGranted.Bool(granted))
+		span.End()
+	}()
+
 	// tell the UI that a permission was requested
 	s.notificationBroker.Publish(pubsub.CreatedEvent, PermissionNotification{
 		ToolCallID: opts.ToolCallID,
@@ -137,7 +177,7 @@ func (s *permissionService) Request(opts CreatePermissionRequest) bool {
 	// Check if the tool/action combination is in the allowlist
 	commandKey := opts.ToolName + ":" + opts.Action
 	if slices.Contains(s.allowedTools, commandKey) || slices.Contains(s.allowedTools, opts.ToolName) {
-		return true
+		return true, nil
 	}
 
 	s.autoApproveSessionsMu.RLock()
@@ -145,7 +185,7 @@ func (s *permissionService) Request(opts CreatePermissionRequest) bool {
 	s.autoApproveSessionsMu.RUnlock()
 
 	if autoApprove {
-		return true
+		return true, nil
 	}
 
 	fileInfo, err := os.Stat(opts.Path)
@@ -176,7 +216,7 @@ func (s *permissionService) Request(opts CreatePermissionRequest) bool {
 	for _, p := range s.sessionPermissions {
 		if p.ToolName == permission.ToolName && p.Action == permission.Action && p.SessionID == permission.SessionID && p.Path == permission.Path {
 			s.sessionPermissionsMu.RUnlock()
-			return true
+			return true, nil
 		}
 	}
 	s.sessionPermissionsMu.RUnlock()
@@ -185,21 +225,22 @@ func (s *permissionService) Request(opts CreatePermissionRequest) bool {
 	for _, p := range s.sessionPermissions {
 		if p.ToolName == permission.ToolName && p.Action == permission.Action && p.SessionID == permission.SessionID && p.Path == permission.Path {
 			s.sessionPermissionsMu.RUnlock()
-			return true
+			return true, nil
 		}
 	}
 	s.sessionPermissionsMu.RUnlock()
 
 	s.activeRequest = &permission
 
-	respCh := make(chan bool, 1)
+	respCh := make(chan response, 1)
 	s.pendingRequests.Set(permission.ID, respCh)
 	defer s.pendingRequests.Del(permission.ID)
 
 	// Publish the request
 	s.Publish(pubsub.CreatedEvent, permission)
 
-	return <-respCh
+	resp := <-respCh
+	return resp.granted, resp.review
 }
 
 func (s *permissionService) AutoApproveSession(sessionID string) {
@@ -229,6 +270,6 @@ func NewPermissionService(workingDir string, skip bool, allowedTools []string) S
 		autoApproveSessions: make(map[string]bool),
 		skip:                skip,
 		allowedTools:        allowedTools,
-		pendingRequests:     csync.NewMap[string, chan bool](),
+		pendingRequests:     csync.NewMap[string, chan response](),
 	}
 }
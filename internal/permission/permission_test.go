@@ -245,3 +245,35 @@ func TestPermissionService_SequentialProperties(t *testing.T) {
 		assert.True(t, result, "Repeated request should be auto-approved due to persistent permission")
 	})
 }
+
+func TestPermissionService_GrantReviewed(t *testing.T) {
+	service := NewPermissionService("/tmp", false, []string{})
+	events := service.Subscribe(t.Context())
+
+	var (
+		granted bool
+		review  *Review
+		wg      sync.WaitGroup
+	)
+	wg.Go(func() {
+		granted, review = service.RequestReview(CreatePermissionRequest{
+			SessionID:   "session1",
+			ToolName:    "edit",
+			Description: "Edit file",
+			Action:      "write",
+			Path:        "/tmp/test.txt",
+		})
+	})
+
+	event := <-events
+	reviewed := Review{
+		Content: "reviewed\n",
+		Hunks:   []ReviewedHunk{{OldStart: 1, OldLines: 1, Status: HunkRejected}},
+	}
+	service.GrantReviewed(event.Payload, reviewed)
+	wg.Wait()
+
+	assert.True(t, granted)
+	assert.Equal(t, &reviewed, review)
+	assert.Contains(t, review.Summary(), "hunk 1 (line 1 of the original file): rejected")
+}
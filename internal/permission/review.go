@@ -0,0 +1,60 @@
+package permission
+
+import (
+	"fmt"
+	"strings"
+)
+
+// HunkStatus tells what the user did with a hunk of a reviewed change.
+type HunkStatus string
+
+const (
+	HunkApplied  HunkStatus = "applied"
+	HunkRejected HunkStatus = "rejected"
+	HunkEdited   HunkStatus = "edited"
+)
+
+// ReviewedHunk is a hunk of a change the user reviewed.
+type ReviewedHunk struct {
+	// OldStart and OldLines locate the hunk in the original file.
+	OldStart int        `json:"old_start"`
+	OldLines int        `json:"old_lines"`
+	Status   HunkStatus `json:"status"`
+}
+
+// Review is the outcome of the hunk by hunk review of a change to a file.
+type Review struct {
+	// Content is the content to write: the original file with the applied
+	// hunks, as the user edited them.
+	Content string         `json:"content"`
+	Hunks   []ReviewedHunk `json:"hunks"`
+}
+
+// Summary tells the model which parts of its change were applied.
+func (r Review) Summary() string {
+	var sb strings.Builder
+	sb.WriteString("The user reviewed the change hunk by hunk:\n")
+	for i, h := range r.Hunks {
+		var where string
+		switch {
+		case h.OldLines == 0:
+			where = fmt.Sprintf("insertion at line %d", h.OldStart)
+		case h.OldLines == 1:
+			where = fmt.Sprintf("line %d", h.OldStart)
+		default:
+			where = fmt.Sprintf("lines %d-%d", h.OldStart, h.OldStart+h.OldLines-1)
+		}
+		var outcome string
+		switch h.Status {
+		case HunkApplied:
+			outcome = "applied"
+		case HunkRejected:
+			outcome = "rejected, these lines were left unchanged"
+		case HunkEdited:
+			outcome = "applied with changes made by the user"
+		}
+		fmt.Fprintf(&sb, "- hunk %d (%s of the original file): %s\n", i+1, where, outcome)
+	}
+	sb.WriteString("View the file again before editing it, and do not redo the rejected changes unless the user asks for them.")
+	return sb.String()
+}
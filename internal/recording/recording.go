@@ -0,0 +1,524 @@
+// Package recording captures the provider HTTP traffic and the tool results
+// of a run in a directory, and plays them back to reproduce the run offline.
+//
+// A recording directory holds:
+//
+//   - session.json, the models, providers and prompts of the run;
+//   - provider.yaml, the provider HTTP exchanges, with auth headers stripped;
+//   - tools.jsonl, the result of every tool call.
+package recording
+
+import (
+	"bufio"
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/uglyswap/push/internal/config"
+	"github.com/uglyswap/push/internal/message"
+	"github.com/uglyswap/push/internal/version"
+	"github.com/uglyswap/push/pkg/fantasy"
+	"gopkg.in/dnaeon/go-vcr.v4/pkg/cassette"
+	"gopkg.in/dnaeon/go-vcr.v4/pkg/recorder"
+)
+
+const (
+	manifestFile = "session.json"
+	cassetteName = "provider"
+	toolsFile    = "tools.jsonl"
+
+	// ReplayAPIKey is the API key given to the providers of a replayed run.
+	// Requests never leave the machine, so it only has to be non-empty.
+	ReplayAPIKey = "replay"
+)
+
+// ErrExists is returned when recording into a directory that already holds a
+// recording.
+var ErrExists = errors.New("directory already holds a recording")
+
+// Manifest describes a recorded run.
+type Manifest struct {
+	Version   string                                            `json:"version"`
+	CreatedAt time.Time                                         `json:"created_at"`
+	Models    map[config.SelectedModelType]config.SelectedModel `json:"models"`
+	Providers []config.ProviderConfig                           `json:"providers"`
+	Prompts   []Prompt                                          `json:"prompts"`
+}
+
+// Prompt is a prompt sent to the agent during the run.
+type Prompt struct {
+	SessionID   string               `json:"session_id"`
+	Text        string               `json:"text"`
+	Attachments []message.Attachment `json:"attachments,omitempty"`
+}
+
+// ToolResult is the recorded result of a tool call.
+type ToolResult struct {
+	Tool      string `json:"tool"`
+	Input     string `json:"input"`
+	Text      string `json:"text,omitempty"`
+	Error     string `json:"error,omitempty"`
+	Data      string `json:"data,omitempty"`
+	MediaType string `json:"media_type,omitempty"`
+}
+
+// Options selects how a recording is used.
+type Options struct {
+	// Replay plays the recording back instead of capturing a new one.
+	Replay bool
+	// LiveTools runs the tools during a replay, and reports the results
+	// differing from the recorded ones, instead of returning the recorded
+	// results.
+	LiveTools bool
+}
+
+// Recording captures or plays back a run. A nil recording does nothing.
+type Recording struct {
+	dir  string
+	opts Options
+
+	recorder *recorder.Recorder
+
+	mu          sync.Mutex
+	manifest    Manifest
+	tools       *os.File
+	results     map[string][]ToolResult
+	divergences []string
+}
+
+// Record starts capturing a run of the given configuration into dir.
+func Record(dir string, cfg *config.Config) (*Recording, error) {
+	if _, err := os.Stat(filepath.Join(dir, manifestFile)); err == nil {
+		return nil, fmt.Errorf("%s: %w", dir, ErrExists)
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create recording directory: %w", err)
+	}
+
+	r := &Recording{
+		dir: dir,
+		manifest: Manifest{
+			Version:   version.Version,
+			CreatedAt: time.Now().UTC(),
+			Models:    cfg.Models,
+		},
+	}
+	for _, model := range cfg.Models {
+		providerCfg, ok := cfg.Providers.Get(model.Provider)
+		if !ok || r.hasProvider(providerCfg.ID) {
+			continue
+		}
+		r.manifest.Providers = append(r.manifest.Providers, sanitizeProvider(providerCfg))
+	}
+	if err := r.saveManifest(); err != nil {
+		return nil, err
+	}
+
+	tools, err := os.OpenFile(filepath.Join(dir, toolsFile), os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create tool results file: %w", err)
+	}
+	r.tools = tools
+
+	r.recorder, err = recorder.New(
+		filepath.Join(dir, cassetteName),
+		recorder.WithMode(recorder.ModeRecordOnly),
+		recorder.WithSkipRequestLatency(true),
+		recorder.WithHook(stripCredentials, recorder.AfterCaptureHook),
+	)
+	if err != nil {
+		_ = tools.Close()
+		return nil, fmt.Errorf("failed to start recording: %w", err)
+	}
+	return r, nil
+}
+
+// Replay loads the recording in dir to play it back.
+func Replay(dir string, opts Options) (*Recording, error) {
+	opts.Replay = true
+	r := &Recording{
+		dir:     dir,
+		opts:    opts,
+		results: make(map[string][]ToolResult),
+	}
+
+	var err error
+	r.manifest, err = ReadManifest(dir)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.loadToolResults(); err != nil {
+		return nil, err
+	}
+
+	r.recorder, err = recorder.New(
+		filepath.Join(dir, cassetteName),
+		recorder.WithMode(recorder.ModeReplayOnly),
+		recorder.WithSkipRequestLatency(true),
+		recorder.WithMatcher(matchRequest),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load provider traffic: %w", err)
+	}
+	return r, nil
+}
+
+// ReadManifest reads the description of the run recorded in dir.
+func ReadManifest(dir string) (Manifest, error) {
+	var m Manifest
+	data, err := os.ReadFile(filepath.Join(dir, manifestFile))
+	if err != nil {
+		return m, fmt.Errorf("failed to read recording: %w", err)
+	}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return m, fmt.Errorf("failed to parse recording: %w", err)
+	}
+	return m, nil
+}
+
+// Apply sets up the configuration to replay the run: the recorded models are
+// selected and their providers configured, with a placeholder API key.
+func (m Manifest) Apply(cfg *config.Config) {
+	for _, p := range m.Providers {
+		p.APIKey = ReplayAPIKey
+		cfg.Providers.Set(p.ID, p)
+	}
+	cfg.Models = m.Models
+}
+
+func (r *Recording) hasProvider(id string) bool {
+	for _, p := range r.manifest.Providers {
+		if p.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// Replaying reports whether the recording is being played back.
+func (r *Recording) Replaying() bool {
+	return r != nil && r.opts.Replay
+}
+
+// Transport returns the round tripper through which the provider requests
+// go, recording them or answering them from the recording.
+func (r *Recording) Transport() http.RoundTripper {
+	if r.opts.Replay {
+		return r.recorder
+	}
+	return savingTransport{r}
+}
+
+// savingTransport records the provider requests, writing the captured
+// traffic after each one, so a run ending in a crash is recorded up to it.
+type savingTransport struct {
+	r *Recording
+}
+
+func (t savingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.r.recorder.RoundTrip(req)
+	if err != nil {
+		return resp, err
+	}
+	if err := t.r.saveCassette(); err != nil {
+		slog.Warn("Failed to save recorded provider traffic", "error", err)
+	}
+	return resp, nil
+}
+
+// AddPrompt records a prompt sent to the agent.
+func (r *Recording) AddPrompt(sessionID, text string, attachments []message.Attachment) error {
+	if r == nil || r.opts.Replay {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.manifest.Prompts = append(r.manifest.Prompts, Prompt{
+		SessionID:   sessionID,
+		Text:        text,
+		Attachments: attachments,
+	})
+	return r.saveManifest()
+}
+
+// Tool runs a tool call through the recording. When recording, execute runs
+// and its result is stored. When replaying, the recorded result is returned,
+// unless tools run live, in which case execute runs and a result differing
+// from the recorded one is reported as a divergence.
+func (r *Recording) Tool(name, input string, execute func() (fantasy.ToolResultOutput, error)) (fantasy.ToolResultOutput, error) {
+	if r == nil {
+		return execute()
+	}
+	if !r.opts.Replay {
+		output, err := execute()
+		if err != nil {
+			return output, err
+		}
+		return output, r.recordTool(toolResult(name, input, output))
+	}
+
+	recorded, ok := r.nextToolResult(name, input)
+	if !r.opts.LiveTools {
+		if !ok {
+			r.diverge("%s was called with an input missing from the recording: %s", name, input)
+			return fantasy.ToolResultOutputContentError{Error: fmt.Errorf("no recorded result for this %s call", name)}, nil
+		}
+		return recorded.output(), nil
+	}
+
+	output, err := execute()
+	if err != nil {
+		return output, err
+	}
+	switch got := toolResult(name, input, output); {
+	case !ok:
+		r.diverge("%s was called with an input missing from the recording: %s", name, input)
+	case got != recorded:
+		r.diverge("%s returned a different result for %s:\nrecorded: %s\ngot:      %s", name, input, recorded.summary(), got.summary())
+	}
+	return output, nil
+}
+
+// Divergences returns the differences between the replay and the recording
+// found so far.
+func (r *Recording) Divergences() []string {
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.divergences...)
+}
+
+// Close stops the recording, writing the captured provider traffic.
+func (r *Recording) Close() error {
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var errs []error
+	if r.recorder != nil {
+		errs = append(errs, r.recorder.Stop())
+	}
+	if r.tools != nil {
+		errs = append(errs, r.tools.Close())
+	}
+	return errors.Join(errs...)
+}
+
+func (r *Recording) diverge(format string, args ...any) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.divergences = append(r.divergences, fmt.Sprintf(format, args...))
+}
+
+// saveManifest writes the manifest. It must be called with the lock held.
+func (r *Recording) saveManifest() error {
+	data, err := json.MarshalIndent(r.manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode recording: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(r.dir, manifestFile), data, 0o600); err != nil {
+		return fmt.Errorf("failed to write recording: %w", err)
+	}
+	return nil
+}
+
+// saveCassette writes the provider traffic captured so far. Stopping the
+// recorder only writes its cassette, and it keeps recording afterwards.
+func (r *Recording) saveCassette() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.recorder.Stop()
+}
+
+func (r *Recording) recordTool(result ToolResult) error {
+	data, err := json.Marshal(result)
+	if err != nil {
+		return fmt.Errorf("failed to encode tool result: %w", err)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, err := r.tools.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to record tool result: %w", err)
+	}
+	return nil
+}
+
+func (r *Recording) loadToolResults() error {
+	f, err := os.Open(filepath.Join(r.dir, toolsFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read tool results: %w", err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 64<<20)
+	for scanner.Scan() {
+		var result ToolResult
+		if err := json.Unmarshal(scanner.Bytes(), &result); err != nil {
+			return fmt.Errorf("failed to parse tool result: %w", err)
+		}
+		key := toolKey(result.Tool, result.Input)
+		r.results[key] = append(r.results[key], result)
+	}
+	return scanner.Err()
+}
+
+// nextToolResult pops the recorded result of a tool call. Calls with the
+// same input get their results in the recorded order.
+func (r *Recording) nextToolResult(name, input string) (ToolResult, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key := toolKey(name, input)
+	results := r.results[key]
+	if len(results) == 0 {
+		return ToolResult{}, false
+	}
+	r.results[key] = results[1:]
+	return results[0], true
+}
+
+func toolKey(name, input string) string {
+	return name + "\x00" + input
+}
+
+func toolResult(name, input string, output fantasy.ToolResultOutput) ToolResult {
+	result := ToolResult{Tool: name, Input: input}
+	switch out := output.(type) {
+	case fantasy.ToolResultOutputContentText:
+		result.Text = out.Text
+	case fantasy.ToolResultOutputContentError:
+		if out.Error != nil {
+			result.Error = out.Error.Error()
+		}
+	case fantasy.ToolResultOutputContentMedia:
+		result.Text = out.Text
+		result.Data = out.Data
+		result.MediaType = out.MediaType
+	}
+	return result
+}
+
+func (t ToolResult) output() fantasy.ToolResultOutput {
+	switch {
+	case t.Error != "":
+		return fantasy.ToolResultOutputContentError{Error: errors.New(t.Error)}
+	case t.MediaType != "":
+		return fantasy.ToolResultOutputContentMedia{Text: t.Text, Data: t.Data, MediaType: t.MediaType}
+	default:
+		return fantasy.ToolResultOutputContentText{Text: t.Text}
+	}
+}
+
+func (t ToolResult) summary() string {
+	s := cmp.Or(t.Error, t.Text)
+	if t.MediaType != "" {
+		s = fmt.Sprintf("%s (%s, %d bytes)", s, t.MediaType, len(t.Data))
+	}
+	const maxSummary = 200
+	if len(s) > maxSummary {
+		s = s[:maxSummary] + "…"
+	}
+	return s
+}
+
+// sanitizeProvider keeps the settings needed to talk to a provider again,
+// without its credentials.
+func sanitizeProvider(p config.ProviderConfig) config.ProviderConfig {
+	return config.ProviderConfig{
+		ID:              p.ID,
+		Name:            p.Name,
+		BaseURL:         p.BaseURL,
+		Type:            p.Type,
+		ExtraBody:       p.ExtraBody,
+		ProviderOptions: p.ProviderOptions,
+		Models:          p.Models,
+	}
+}
+
+// headersToKeep are the headers stored in the recording; the others may hold
+// credentials.
+var headersToKeep = map[string]struct{}{
+	"accept":       {},
+	"content-type": {},
+	"user-agent":   {},
+}
+
+// credentialParams are the query parameters carrying API keys, as some
+// providers take them in the URL.
+var credentialParams = []string{"key", "api_key", "api-key", "access_token"}
+
+func stripCredentials(i *cassette.Interaction) error {
+	for _, headers := range []http.Header{i.Request.Headers, i.Response.Headers} {
+		for k := range headers {
+			if _, ok := headersToKeep[strings.ToLower(k)]; !ok {
+				delete(headers, k)
+			}
+		}
+	}
+	i.Request.URL = stripURLCredentials(i.Request.URL)
+	i.Request.RequestURI = stripURLCredentials(i.Request.RequestURI)
+	i.Request.Form = nil
+	return nil
+}
+
+func stripURLCredentials(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	u.User = nil
+	if u.RawQuery != "" {
+		query := u.Query()
+		for _, param := range credentialParams {
+			query.Del(param)
+		}
+		u.RawQuery = query.Encode()
+	}
+	return u.String()
+}
+
+// matchRequest matches a replayed request with a recorded one by method, URL
+// and model. The rest of the body is not compared: it holds the system
+// prompt, which depends on the machine and the time of the run. Recorded
+// interactions are used once each, so requests are answered in the recorded
+// order.
+func matchRequest(req *http.Request, recorded cassette.Request) bool {
+	if req.Method != recorded.Method || stripURLCredentials(req.URL.String()) != recorded.URL {
+		return false
+	}
+	if req.Body == nil || req.Body == http.NoBody {
+		return true
+	}
+	body, err := io.ReadAll(req.Body)
+	_ = req.Body.Close()
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return false
+	}
+	return requestModel(body) == requestModel([]byte(recorded.Body))
+}
+
+func requestModel(body []byte) string {
+	var payload struct {
+		Model string `json:"model"`
+	}
+	_ = json.Unmarshal(body, &payload)
+	return payload.Model
+}
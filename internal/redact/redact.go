@@ -0,0 +1,212 @@
+// Package redact masks secrets, such as API keys, tokens and private keys,
+// in text before it reaches the model, the database or the logs. Each
+// secret is replaced by a placeholder derived from its value, so the same
+// secret always gets the same placeholder and can be substituted back.
+//
+// Redaction is opt-in. The secrets are only kept in memory, so placeholders
+// stored before a restart can no longer be substituted back.
+package redact
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/uglyswap/push/internal/config"
+	"github.com/uglyswap/push/internal/csync"
+)
+
+// Rule finds secrets in text. The first capture group of the pattern, or the
+// whole match when it has none, is the secret.
+type Rule struct {
+	Name    string
+	Pattern *regexp.Regexp
+	// MinEntropy is the Shannon entropy, in bits per character, below which
+	// a match is not a secret. Zero keeps every match.
+	MinEntropy float64
+}
+
+// HighEntropyRule is the name of the rule catching values assigned to names
+// like token or password, when they look random enough.
+const HighEntropyRule = "high_entropy"
+
+// DefaultRules returns the built-in rules, for the formats of common
+// credentials.
+func DefaultRules() []Rule {
+	return []Rule{
+		{Name: "private_key", Pattern: regexp.MustCompile(`-----BEGIN[A-Z ]*PRIVATE KEY-----[\s\S]*?-----END[A-Z ]*PRIVATE KEY-----`)},
+		{Name: "aws_access_key", Pattern: regexp.MustCompile(`\b((?:AKIA|ASIA)[0-9A-Z]{16})\b`)},
+		{Name: "github_token", Pattern: regexp.MustCompile(`\b(gh[pousr]_[A-Za-z0-9]{36,255}|github_pat_[A-Za-z0-9_]{22,255})\b`)},
+		{Name: "anthropic_api_key", Pattern: regexp.MustCompile(`\b(sk-ant-[A-Za-z0-9_-]{20,})`)},
+		{Name: "openai_api_key", Pattern: regexp.MustCompile(`\b(sk-(?:proj-|svcacct-)?[A-Za-z0-9_-]{20,})`)},
+		{Name: "google_api_key", Pattern: regexp.MustCompile(`\b(AIza[0-9A-Za-z_-]{35})`)},
+		{Name: "slack_token", Pattern: regexp.MustCompile(`\b(xox[abprs]-[A-Za-z0-9-]{10,})`)},
+		{Name: "stripe_key", Pattern: regexp.MustCompile(`\b((?:sk|rk)_(?:live|test)_[0-9A-Za-z]{16,})`)},
+		{Name: "jwt", Pattern: regexp.MustCompile(`\b(eyJ[A-Za-z0-9_-]{10,}\.eyJ[A-Za-z0-9_-]{10,}\.[A-Za-z0-9_-]{10,})`)},
+		{Name: "url_password", Pattern: regexp.MustCompile(`\b[a-z][a-z0-9+.-]*://[^:/\s@]+:([^@\s/]+)@`)},
+		{
+			Name:       HighEntropyRule,
+			Pattern:    regexp.MustCompile(`(?i)(?:secret|token|passw(?:or)?d|pwd|api[_-]?key|access[_-]?key|auth|credential|private[_-]?key)[\w.-]*["']?\s*[:=]\s*["']?([^\s"'` + "`" + `,;]{12,})`),
+			MinEntropy: 3.5,
+		},
+	}
+}
+
+// placeholderPattern matches the placeholders written by Redact.
+var placeholderPattern = regexp.MustCompile(`\[REDACTED:([a-z0-9_]+):([0-9a-f]{8})\]`)
+
+// Redactor replaces the secrets found by its rules with placeholders, and
+// remembers them so they can be substituted back.
+type Redactor struct {
+	rules          []Rule
+	restoreOnWrite bool
+	secrets        *csync.Map[string, string]
+}
+
+// New returns a redactor using the built-in rules, minus the disabled ones,
+// and the user patterns of the configuration. It returns nil unless
+// redaction is enabled; a nil redactor leaves text alone.
+func New(cfg *config.Redaction) (*Redactor, error) {
+	if cfg == nil || !cfg.Enabled {
+		return nil, nil
+	}
+	r := &Redactor{
+		restoreOnWrite: cfg.RestoreOnWrite,
+		secrets:        csync.NewMap[string, string](),
+	}
+	for _, rule := range DefaultRules() {
+		if !slices.Contains(cfg.DisabledRules, rule.Name) {
+			r.rules = append(r.rules, rule)
+		}
+	}
+	names := make([]string, 0, len(cfg.Patterns))
+	for name := range cfg.Patterns {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		pattern, err := regexp.Compile(cfg.Patterns[name])
+		if err != nil {
+			return nil, fmt.Errorf("invalid redaction pattern %q: %w", name, err)
+		}
+		r.rules = append(r.rules, Rule{Name: name, Pattern: pattern})
+	}
+	return r, nil
+}
+
+// RestoreOnWrite reports whether secrets may be substituted back when the
+// agent writes their placeholders to files.
+func (r *Redactor) RestoreOnWrite() bool {
+	return r != nil && r.restoreOnWrite
+}
+
+// Redact replaces the secrets in text with placeholders.
+func (r *Redactor) Redact(text string) string {
+	if r == nil || text == "" {
+		return text
+	}
+	for _, rule := range r.rules {
+		text = r.redactRule(text, rule)
+	}
+	return text
+}
+
+func (r *Redactor) redactRule(text string, rule Rule) string {
+	matches := rule.Pattern.FindAllStringSubmatchIndex(text, -1)
+	if len(matches) == 0 {
+		return text
+	}
+	// Placeholders written by earlier rules are not secrets, nor part of one.
+	placeholders := placeholderPattern.FindAllStringIndex(text, -1)
+	var sb strings.Builder
+	last := 0
+	for _, m := range matches {
+		if slices.ContainsFunc(placeholders, func(p []int) bool { return m[0] < p[1] && p[0] < m[1] }) {
+			continue
+		}
+		start, end := m[0], m[1]
+		if len(m) >= 4 && m[2] >= 0 {
+			start, end = m[2], m[3]
+		}
+		secret := text[start:end]
+		if start < last || entropy(secret) < rule.MinEntropy {
+			continue
+		}
+		placeholder := Placeholder(rule.Name, secret)
+		r.secrets.Set(placeholder, secret)
+		sb.WriteString(text[last:start])
+		sb.WriteString(placeholder)
+		last = end
+	}
+	sb.WriteString(text[last:])
+	return sb.String()
+}
+
+// Placeholder returns the placeholder of a secret found by the given rule.
+func Placeholder(rule, secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return fmt.Sprintf("[REDACTED:%s:%s]", rule, hex.EncodeToString(sum[:4]))
+}
+
+// Placeholders returns the distinct placeholders of text standing for a
+// known secret, in order of appearance.
+func (r *Redactor) Placeholders(text string) []string {
+	if r == nil {
+		return nil
+	}
+	var placeholders []string
+	for _, placeholder := range placeholderPattern.FindAllString(text, -1) {
+		if _, ok := r.secrets.Get(placeholder); ok && !slices.Contains(placeholders, placeholder) {
+			placeholders = append(placeholders, placeholder)
+		}
+	}
+	return placeholders
+}
+
+// Secret returns the secret standing behind a placeholder.
+func (r *Redactor) Secret(placeholder string) (string, bool) {
+	if r == nil {
+		return "", false
+	}
+	return r.secrets.Get(placeholder)
+}
+
+// Restore substitutes the given placeholders of text back with their
+// secrets, or every known placeholder when none are given.
+func (r *Redactor) Restore(text string, placeholders ...string) string {
+	if r == nil {
+		return text
+	}
+	return placeholderPattern.ReplaceAllStringFunc(text, func(placeholder string) string {
+		if len(placeholders) > 0 && !slices.Contains(placeholders, placeholder) {
+			return placeholder
+		}
+		if secret, ok := r.secrets.Get(placeholder); ok {
+			return secret
+		}
+		return placeholder
+	})
+}
+
+// entropy returns the Shannon entropy of s in bits per character.
+func entropy(s string) float64 {
+	if s == "" {
+		return 0
+	}
+	counts := make(map[rune]int)
+	n := 0
+	for _, c := range s {
+		counts[c]++
+		n++
+	}
+	var h float64
+	for _, count := range counts {
+		p := float64(count) / float64(n)
+		h -= p * math.Log2(p)
+	}
+	return h
+}
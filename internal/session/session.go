@@ -10,6 +10,7 @@ import (
 
 	"github.com/uglyswap/push/internal/db"
 	"github.com/uglyswap/push/internal/event"
+	"github.com/uglyswap/push/internal/message"
 	"github.com/uglyswap/push/internal/pubsub"
 	"github.com/google/uuid"
 )
@@ -28,6 +29,13 @@ type Todo struct {
 	ActiveForm string     `json:"active_form"`
 }
 
+// QueuedPrompt is a prompt sent while the agent was busy with the session,
+// waiting for its turn.
+type QueuedPrompt struct {
+	Prompt      string               `json:"prompt"`
+	Attachments []message.Attachment `json:"attachments,omitempty"`
+}
+
 type Session struct {
 	ID               string
 	ParentSessionID  string
@@ -36,10 +44,17 @@ type Session struct {
 	PromptTokens     int64
 	CompletionTokens int64
 	SummaryMessageID string
-	Cost             float64
-	Todos            []Todo
-	CreatedAt        int64
-	UpdatedAt        int64
+	// SummaryUntilMessageID is the last message folded into the summary.
+	// Messages after it are kept verbatim after the summary. When empty the
+	// summary covers everything that came before it.
+	SummaryUntilMessageID string
+	Cost                  float64
+	Todos                 []Todo
+	// QueuedPrompts are kept up to date with SetQueuedPrompts, Save leaves
+	// them as they are.
+	QueuedPrompts []QueuedPrompt
+	CreatedAt     int64
+	UpdatedAt     int64
 }
 
 type Service interface {
@@ -51,6 +66,7 @@ type Service interface {
 	List(ctx context.Context) ([]Session, error)
 	Save(ctx context.Context, session Session) (Session, error)
 	UpdateTitleAndUsage(ctx context.Context, sessionID, title string, promptTokens, completionTokens int64, cost float64) error
+	SetQueuedPrompts(ctx context.Context, sessionID string, prompts []QueuedPrompt) error
 	Delete(ctx context.Context, id string) error
 
 	// Agent tool session management
@@ -148,6 +164,10 @@ func (s *service) Save(ctx context.Context, session Session) (Session, error) {
 			String: todosJSON,
 			Valid:  todosJSON != "",
 		},
+		SummaryUntilMessageID: sql.NullString{
+			String: session.SummaryUntilMessageID,
+			Valid:  session.SummaryUntilMessageID != "",
+		},
 	})
 	if err != nil {
 		return Session{}, err
@@ -169,6 +189,23 @@ func (s *service) UpdateTitleAndUsage(ctx context.Context, sessionID, title stri
 	})
 }
 
+// SetQueuedPrompts stores the prompts queued for a session, so they survive
+// a restart.
+func (s *service) SetQueuedPrompts(ctx context.Context, sessionID string, prompts []QueuedPrompt) error {
+	var data string
+	if len(prompts) > 0 {
+		b, err := json.Marshal(prompts)
+		if err != nil {
+			return err
+		}
+		data = string(b)
+	}
+	return s.q.UpdateSessionQueuedPrompts(ctx, db.UpdateSessionQueuedPromptsParams{
+		ID:            sessionID,
+		QueuedPrompts: sql.NullString{String: data, Valid: data != ""},
+	})
+}
+
 func (s *service) List(ctx context.Context) ([]Session, error) {
 	dbSessions, err := s.q.ListSessions(ctx)
 	if err != nil {
@@ -186,18 +223,26 @@ func (s service) fromDBItem(item db.Session) Session {
 	if err != nil {
 		slog.Error("failed to unmarshal todos", "session_id", item.ID, "error", err)
 	}
+	var queued []QueuedPrompt
+	if item.QueuedPrompts.String != "" {
+		if err := json.Unmarshal([]byte(item.QueuedPrompts.String), &queued); err != nil {
+			slog.Error("failed to unmarshal queued prompts", "session_id", item.ID, "error", err)
+		}
+	}
 	return Session{
-		ID:               item.ID,
-		ParentSessionID:  item.ParentSessionID.String,
-		Title:            item.Title,
-		MessageCount:     item.MessageCount,
-		PromptTokens:     item.PromptTokens,
-		CompletionTokens: item.CompletionTokens,
-		SummaryMessageID: item.SummaryMessageID.String,
-		Cost:             item.Cost,
-		Todos:            todos,
-		CreatedAt:        item.CreatedAt,
-		UpdatedAt:        item.UpdatedAt,
+		ID:                    item.ID,
+		ParentSessionID:       item.ParentSessionID.String,
+		Title:                 item.Title,
+		MessageCount:          item.MessageCount,
+		PromptTokens:          item.PromptTokens,
+		CompletionTokens:      item.CompletionTokens,
+		SummaryMessageID:      item.SummaryMessageID.String,
+		SummaryUntilMessageID: item.SummaryUntilMessageID.String,
+		Cost:                  item.Cost,
+		Todos:                 todos,
+		QueuedPrompts:         queued,
+		CreatedAt:             item.CreatedAt,
+		UpdatedAt:             item.UpdatedAt,
 	}
 }
 
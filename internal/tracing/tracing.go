@@ -0,0 +1,161 @@
+// Package tracing exports OpenTelemetry spans of agent turns, model steps,
+// tool calls, permission requests and MCP and LSP requests.
+//
+// Tracing is off unless an exporter is configured, in which case spans go to
+// an OTLP/HTTP collector or to a JSON lines file in the data directory. When
+// off, spans are no-ops.
+package tracing
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/uglyswap/push/internal/config"
+	"github.com/uglyswap/push/internal/csync"
+	"github.com/uglyswap/push/internal/version"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// ExporterOTLP sends spans to an OTLP/HTTP collector.
+	ExporterOTLP = "otlp"
+	// ExporterFile appends spans to FileName in the data directory.
+	ExporterFile = "file"
+
+	// EnvVar overrides the configured exporter.
+	EnvVar = "PUSH_TRACING"
+
+	// FileName is the file written by the file exporter.
+	FileName = "traces.jsonl"
+
+	tracerName = "github.com/uglyswap/push"
+)
+
+// Attribute keys. The gen_ai ones follow the OpenTelemetry semantic
+// conventions for generative AI.
+const (
+	AttrSessionID    = attribute.Key("push.session.id")
+	AttrModel        = attribute.Key("gen_ai.request.model")
+	AttrProvider     = attribute.Key("gen_ai.provider.name")
+	AttrInputTokens  = attribute.Key("gen_ai.usage.input_tokens")
+	AttrOutputTokens = attribute.Key("gen_ai.usage.output_tokens")
+	AttrCacheRead    = attribute.Key("gen_ai.usage.cache_read_tokens")
+	AttrCacheWrite   = attribute.Key("gen_ai.usage.cache_creation_tokens")
+	AttrFinishReason = attribute.Key("gen_ai.response.finish_reason")
+	AttrToolName     = attribute.Key("gen_ai.tool.name")
+	AttrToolCallID   = attribute.Key("gen_ai.tool.call.id")
+	AttrGranted      = attribute.Key("push.permission.granted")
+	AttrMCPServer    = attribute.Key("push.mcp.server")
+	AttrLSPServer    = attribute.Key("push.lsp.server")
+	AttrRPCMethod    = attribute.Key("rpc.method")
+)
+
+// turns holds the span context of the running turn of each session, for
+// spans started without a context, such as permission requests.
+var turns = csync.NewMap[string, trace.SpanContext]()
+
+// Setup installs the exporter chosen by the configuration or the
+// environment, and returns the function flushing and stopping it. It does
+// nothing when tracing is off.
+func Setup(ctx context.Context, cfg *config.Tracing, dataDir string) (func(context.Context) error, error) {
+	var exporterName, endpoint string
+	if cfg != nil {
+		exporterName, endpoint = cfg.Exporter, cfg.Endpoint
+	}
+	exporterName = cmp.Or(os.Getenv(EnvVar), exporterName)
+
+	var (
+		exporter sdktrace.SpanExporter
+		closer   func() error
+		err      error
+	)
+	switch exporterName {
+	case "":
+		return func(context.Context) error { return nil }, nil
+	case ExporterOTLP:
+		var opts []otlptracehttp.Option
+		if endpoint != "" {
+			opts = append(opts, otlptracehttp.WithEndpointURL(endpoint))
+		}
+		exporter, err = otlptracehttp.New(ctx, opts...)
+	case ExporterFile:
+		var f *os.File
+		f, err = os.OpenFile(filepath.Join(dataDir, FileName), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+		if err == nil {
+			closer = f.Close
+			exporter, err = stdouttrace.New(stdouttrace.WithWriter(f))
+		}
+	default:
+		return nil, fmt.Errorf("unknown tracing exporter %q, expected %s or %s", exporterName, ExporterOTLP, ExporterFile)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to set up tracing: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewSchemaless(
+			attribute.String("service.name", "push"),
+			attribute.String("service.version", version.Version),
+		)),
+	)
+	otel.SetTracerProvider(provider)
+	return func(ctx context.Context) error {
+		err := provider.Shutdown(ctx)
+		if closer != nil {
+			err = errors.Join(err, closer())
+		}
+		return err
+	}, nil
+}
+
+// Start starts a span as a child of the span in ctx.
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(tracerName).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// End ends a span, marking it failed when err is not nil.
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// StartTurn starts the span of an agent turn in a session. Until EndTurn,
+// spans started from SessionContext are its children.
+func StartTurn(ctx context.Context, sessionID string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	ctx, span := Start(ctx, "agent.turn", append(attrs, AttrSessionID.String(sessionID))...)
+	if span.SpanContext().IsValid() {
+		turns.Set(sessionID, span.SpanContext())
+	}
+	return ctx, span
+}
+
+// EndTurn ends the span of an agent turn.
+func EndTurn(sessionID string, span trace.Span, err error) {
+	turns.Del(sessionID)
+	End(span, err)
+}
+
+// SessionContext returns a context holding the span of the running turn of
+// a session, if any.
+func SessionContext(sessionID string) context.Context {
+	ctx := context.Background()
+	if sc, ok := turns.Get(sessionID); ok {
+		ctx = trace.ContextWithSpanContext(ctx, sc)
+	}
+	return ctx
+}
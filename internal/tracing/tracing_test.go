@@ -0,0 +1,70 @@
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/uglyswap/push/internal/config"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestSetupDisabled(t *testing.T) {
+	t.Setenv(EnvVar, "")
+
+	dir := t.TempDir()
+	shutdown, err := Setup(t.Context(), nil, dir)
+	require.NoError(t, err)
+	require.NoError(t, shutdown(t.Context()))
+	require.NoFileExists(t, filepath.Join(dir, FileName))
+}
+
+func TestSetupUnknownExporter(t *testing.T) {
+	t.Setenv(EnvVar, "")
+
+	_, err := Setup(t.Context(), &config.Tracing{Exporter: "zipkin"}, t.TempDir())
+	require.ErrorContains(t, err, "unknown tracing exporter")
+}
+
+func TestFileExporter(t *testing.T) {
+	t.Setenv(EnvVar, ExporterFile)
+
+	dir := t.TempDir()
+	shutdown, err := Setup(t.Context(), nil, dir)
+	require.NoError(t, err)
+
+	ctx, turn := StartTurn(context.Background(), "session-1")
+	_, tool := Start(ctx, "tool.view", AttrToolName.String("view"))
+	End(tool, errors.New("file not found"))
+
+	// Spans started without a context join the running turn of their
+	// session.
+	_, wait := Start(SessionContext("session-1"), "permission.wait")
+	require.Equal(t, turn.SpanContext().TraceID(), wait.SpanContext().TraceID())
+	End(wait, nil)
+	_, other := Start(SessionContext("session-2"), "permission.wait")
+	require.NotEqual(t, turn.SpanContext().TraceID(), other.SpanContext().TraceID())
+	End(other, nil)
+
+	EndTurn("session-1", turn, nil)
+	require.Equal(t, trace.SpanContext{}, trace.SpanContextFromContext(SessionContext("session-1")))
+
+	require.NoError(t, shutdown(context.Background()))
+
+	data, err := os.ReadFile(filepath.Join(dir, FileName))
+	require.NoError(t, err)
+	var names []string
+	for line := range strings.Lines(string(data)) {
+		var span struct{ Name string }
+		require.NoError(t, json.Unmarshal([]byte(line), &span))
+		names = append(names, span.Name)
+	}
+	require.ElementsMatch(t, []string{"agent.turn", "tool.view", "permission.wait", "permission.wait"}, names)
+	require.Contains(t, string(data), "file not found")
+	require.Contains(t, string(data), "session-1")
+}
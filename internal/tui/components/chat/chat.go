@@ -5,6 +5,8 @@ import (
 	"time"
 
 	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/lipgloss"
 	tea "github.com/uglyswap/push/internal/compat/bubbletea"
 	"github.com/atotto/clipboard"
 	"github.com/uglyswap/push/internal/agent"
@@ -17,6 +19,7 @@ import (
 	"github.com/uglyswap/push/internal/tui/components/chat/messages"
 	"github.com/uglyswap/push/internal/tui/components/core/layout"
 	"github.com/uglyswap/push/internal/tui/exp/list"
+	"github.com/uglyswap/push/internal/tui/keymap"
 	"github.com/uglyswap/push/internal/tui/styles"
 	"github.com/uglyswap/push/internal/tui/util"
 )
@@ -30,6 +33,42 @@ type SessionSelectedMsg = session.Session
 
 type SessionClearedMsg struct{}
 
+// ThemeChangedMsg is sent when the theme changes, for the components to
+// render again with it.
+type ThemeChangedMsg struct{}
+
+// MessagesChangedMsg is sent when messages of the session were deleted, for
+// the list to load them again.
+type MessagesChangedMsg struct{}
+
+// EditPromptMsg asks to edit the prompt of the turn a message belongs to
+// and send it again in place of the later messages, or in a new session
+// branching off before the prompt when Branch is set.
+type EditPromptMsg struct {
+	MessageID string
+	Branch    bool
+}
+
+// RetryPromptMsg asks to send the prompt of the turn a message belongs to
+// again in place of the later messages, after choosing the model to use when
+// ChooseModel is set.
+type RetryPromptMsg struct {
+	MessageID   string
+	ChooseModel bool
+}
+
+// DeleteTurnMsg asks to delete the prompt of the turn a message belongs to
+// and the messages answering it.
+type DeleteTurnMsg struct {
+	MessageID string
+}
+
+// GoToMessageMsg selects an item of the message list, a message or a tool
+// call, by ID and scrolls to it.
+type GoToMessageMsg struct {
+	ID string
+}
+
 type SelectionCopyMsg struct {
 	clickCount   int
 	endSelection bool
@@ -52,6 +91,8 @@ type MessageListCmp interface {
 	GoToBottom() tea.Cmd
 	GetSelectedText() string
 	CopySelectedText(bool) tea.Cmd
+	Searching() bool
+	ClearSearch() bool
 }
 
 // messageListCmp implements MessageListCmp, providing a virtualized list
@@ -63,10 +104,18 @@ type messageListCmp struct {
 	session          session.Session
 	listCmp          list.List[list.Item]
 	previousSelected string // Last selected item index for restoring focus
+	confirmDelete    string // ID of the message whose turn a second press deletes
 
 	lastUserMessageTime int64
 	defaultListKeyMap   list.KeyMap
 
+	// Search through the messages
+	search     textinput.Model
+	searching  bool     // the search is being typed
+	query      string   // the search shown
+	matches    []string // IDs of the items matching query, top to bottom
+	searchFrom int      // index of the item selected when the search started
+
 	// Click tracking for double/triple click detection
 	lastClickTime time.Time
 	lastClickX    int
@@ -104,16 +153,34 @@ func (m *messageListCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 	var cmds []tea.Cmd
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
+		if m.searching {
+			return m, m.handleSearchKey(msg)
+		}
 		if m.listCmp.IsFocused() && m.listCmp.HasSelection() {
 			switch {
-			case key.Matches(msg, messages.CopyKey):
+			case key.Matches(msg, messages.CopyKey()):
 				cmds = append(cmds, m.CopySelectedText(true))
 				return m, tea.Batch(cmds...)
-			case key.Matches(msg, messages.ClearSelectionKey):
+			case key.Matches(msg, messages.ClearSelectionKey()):
 				cmds = append(cmds, m.SelectionClear())
 				return m, tea.Batch(cmds...)
 			}
 		}
+		if m.listCmp.IsFocused() && !m.listCmp.HasSelection() {
+			if cmd, ok := m.handleSearchAction(msg); ok {
+				return m, cmd
+			}
+			if cmd, ok := m.handleMessageAction(msg); ok {
+				return m, cmd
+			}
+		}
+		m.confirmDelete = ""
+	case tea.PasteMsg:
+		if m.searching {
+			m.search.SetValue(m.search.Value() + string(msg))
+			m.search.CursorEnd()
+			return m, m.setQuery(m.search.Value())
+		}
 	case tea.MouseMsg:
 		x := msg.X - 1 // Adjust for padding
 		y := msg.Y - 1 // Adjust for padding
@@ -191,13 +258,23 @@ func (m *messageListCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 		}
 		return m, tea.Batch(cmds...)
 	case SessionClearedMsg:
+		m.ClearSearch()
 		m.session = session.Session{}
 		cmds = append(cmds, m.listCmp.SetItems([]list.Item{}))
 		return m, tea.Batch(cmds...)
+	case ThemeChangedMsg, MessagesChangedMsg:
+		// Rebuild the messages, which keep what they rendered.
+		current := m.session
+		m.session = session.Session{}
+		cmds = append(cmds, m.SetSession(current))
+		return m, tea.Batch(cmds...)
 
 	case pubsub.Event[message.Message]:
 		cmds = append(cmds, m.handleMessageEvent(msg))
 		return m, tea.Batch(cmds...)
+	case GoToMessageMsg:
+		cmds = append(cmds, m.listCmp.SetSelected(msg.ID))
+		return m, tea.Batch(cmds...)
 	}
 
 	u, cmd := m.listCmp.Update(msg)
@@ -209,11 +286,15 @@ func (m *messageListCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 // View renders the message list or an initial screen if empty.
 func (m *messageListCmp) View() string {
 	t := styles.CurrentTheme()
+	view := m.listCmp.View()
+	if m.searchBarHeight() > 0 {
+		view = lipgloss.JoinVertical(lipgloss.Left, view, m.searchBarView())
+	}
 	return t.S().Base.
 		Padding(1, 1, 0, 1).
 		Width(m.width).
 		Height(m.height).
-		Render(m.listCmp.View())
+		Render(view)
 }
 
 func (m *messageListCmp) handlePermissionRequest(permission permission.PermissionNotification) tea.Cmd {
@@ -538,6 +619,7 @@ func (m *messageListCmp) SetSession(session session.Session) tea.Cmd {
 		return nil
 	}
 
+	m.ClearSearch()
 	m.session = session
 	sessionMessages, err := m.app.Messages.List(context.Background(), session.ID)
 	if err != nil {
@@ -655,7 +737,8 @@ func (m *messageListCmp) GetSize() (int, int) {
 func (m *messageListCmp) SetSize(width int, height int) tea.Cmd {
 	m.width = width
 	m.height = height
-	return m.listCmp.SetSize(width-2, max(0, height-1)) // for padding
+	// Leave room for the padding and the search bar.
+	return m.listCmp.SetSize(width-2, max(0, height-1-m.searchBarHeight()))
 }
 
 // Blur implements MessageListCmp.
@@ -681,6 +764,50 @@ func (m *messageListCmp) GoToBottom() tea.Cmd {
 	return m.listCmp.GoToBottom()
 }
 
+// selectedMessageID returns the ID of the message of the selected item, the
+// message holding the tool call for tool calls.
+func (m *messageListCmp) selectedMessageID() string {
+	item := m.listCmp.SelectedItem()
+	if item == nil {
+		return ""
+	}
+	switch item := (*item).(type) {
+	case messages.MessageCmp:
+		return item.GetMessage().ID
+	case messages.ToolCallCmp:
+		return item.ParentMessageID()
+	}
+	return ""
+}
+
+// handleMessageAction handles the keys acting on the turn of the selected
+// message. Deleting takes a second press to confirm.
+func (m *messageListCmp) handleMessageAction(msg tea.KeyMsg) (tea.Cmd, bool) {
+	id := m.selectedMessageID()
+	if id == "" {
+		return nil, false
+	}
+	confirmDelete := m.confirmDelete
+	m.confirmDelete = ""
+	switch {
+	case key.Matches(msg, keymap.Binding(keymap.MessagesEdit)):
+		return util.CmdHandler(EditPromptMsg{MessageID: id}), true
+	case key.Matches(msg, keymap.Binding(keymap.MessagesBranch)):
+		return util.CmdHandler(EditPromptMsg{MessageID: id, Branch: true}), true
+	case key.Matches(msg, keymap.Binding(keymap.MessagesRetry)):
+		return util.CmdHandler(RetryPromptMsg{MessageID: id}), true
+	case key.Matches(msg, keymap.Binding(keymap.MessagesRetryWithModel)):
+		return util.CmdHandler(RetryPromptMsg{MessageID: id, ChooseModel: true}), true
+	case key.Matches(msg, keymap.Binding(keymap.MessagesDelete)):
+		if confirmDelete != id {
+			m.confirmDelete = id
+			return util.ReportWarn("Press " + keymap.HelpKey(keymap.MessagesDelete) + " again to delete this prompt and its answer"), true
+		}
+		return util.CmdHandler(DeleteTurnMsg{MessageID: id}), true
+	}
+	return nil, false
+}
+
 const (
 	doubleClickThreshold = 500 * time.Millisecond
 	clickTolerance       = 2 // pixels
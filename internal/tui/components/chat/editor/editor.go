@@ -1,24 +1,23 @@
 package editor
 
 import (
-	"context"
 	"errors"
 	"fmt"
 	"math/rand"
 	"net/http"
 	"os"
 	"path/filepath"
-	"runtime"
 	"slices"
 	"strings"
 	"unicode"
 
 	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/textarea"
-	tea "github.com/uglyswap/push/internal/compat/bubbletea"
-	compattextarea "github.com/uglyswap/push/internal/compat/bubbles/textarea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
 	"github.com/uglyswap/push/internal/app"
+	compattextarea "github.com/uglyswap/push/internal/compat/bubbles/textarea"
+	tea "github.com/uglyswap/push/internal/compat/bubbletea"
 	"github.com/uglyswap/push/internal/fsext"
 	"github.com/uglyswap/push/internal/message"
 	"github.com/uglyswap/push/internal/session"
@@ -32,7 +31,6 @@ import (
 	"github.com/uglyswap/push/internal/tui/styles"
 	"github.com/uglyswap/push/internal/tui/util"
 	"github.com/uglyswap/push/internal/uiutil"
-	"github.com/charmbracelet/x/ansi"
 )
 
 type Editor interface {
@@ -65,7 +63,8 @@ type editorCmp struct {
 	readyPlaceholder   string
 	workingPlaceholder string
 
-	keyMap EditorKeyMap
+	keyMap       EditorKeyMap
+	deleteKeyMap DeleteAttachmentKeyMaps
 
 	// File path completions
 	currentQuery          string
@@ -73,21 +72,6 @@ type editorCmp struct {
 	isCompletionsOpen     bool
 }
 
-var DeleteKeyMaps = DeleteAttachmentKeyMaps{
-	AttachmentDeleteMode: key.NewBinding(
-		key.WithKeys("ctrl+r"),
-		key.WithHelp("ctrl+r+{i}", "delete attachment at index i"),
-	),
-	Escape: key.NewBinding(
-		key.WithKeys("esc", "alt+esc"),
-		key.WithHelp("esc", "cancel delete mode"),
-	),
-	DeleteAllAttachments: key.NewBinding(
-		key.WithKeys("r"),
-		key.WithHelp("ctrl+r+r", "delete all attachments"),
-	),
-}
-
 const maxFileResults = 25
 
 type OpenEditorMsg struct {
@@ -95,39 +79,12 @@ type OpenEditorMsg struct {
 }
 
 func (m *editorCmp) openEditor(value string) tea.Cmd {
-	editor := os.Getenv("EDITOR")
-	if editor == "" {
-		// Use platform-appropriate default editor
-		if runtime.GOOS == "windows" {
-			editor = "notepad"
-		} else {
-			editor = "nvim"
-		}
-	}
-
-	tmpfile, err := os.CreateTemp("", "msg_*.md")
-	if err != nil {
-		return util.ReportError(err)
-	}
-	defer tmpfile.Close() //nolint:errcheck
-	if _, err := tmpfile.WriteString(value); err != nil {
-		return util.ReportError(err)
-	}
-	cmdStr := editor + " " + tmpfile.Name()
-	return util.ExecShell(context.TODO(), cmdStr, func(err error) tea.Msg {
-		if err != nil {
-			return util.ReportError(err)
-		}
-		content, err := os.ReadFile(tmpfile.Name())
-		if err != nil {
-			return util.ReportError(err)
-		}
+	return util.OpenEditor("msg_*.md", value, func(content string) tea.Msg {
 		if len(content) == 0 {
-			return util.ReportWarn("Message is empty")
+			return util.InfoMsg{Type: util.InfoTypeWarn, Msg: "Message is empty"}
 		}
-		os.Remove(tmpfile.Name())
 		return OpenEditorMsg{
-			Text: strings.TrimSpace(string(content)),
+			Text: strings.TrimSpace(content),
 		}
 	})
 }
@@ -256,6 +213,10 @@ func (m *editorCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 	case commands.ToggleYoloModeMsg:
 		m.setEditorPrompt()
 		return m, nil
+	case chat.ThemeChangedMsg:
+		compattextarea.SetStylesOnModel(&m.textarea, styles.CurrentTheme().S().TextArea)
+		m.setEditorPrompt()
+		return m, nil
 	case tea.KeyPressMsg:
 		cur := compattextarea.GetCursorPosition(&m.textarea)
 		curIdx := 0
@@ -279,11 +240,11 @@ func (m *editorCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 		case m.isCompletionsOpen && curIdx <= m.completionsStartIndex:
 			cmds = append(cmds, util.CmdHandler(completions.CloseCompletionsMsg{}))
 		}
-		if key.Matches(msg, DeleteKeyMaps.AttachmentDeleteMode) {
+		if key.Matches(msg, m.deleteKeyMap.AttachmentDeleteMode) {
 			m.deleteMode = true
 			return m, nil
 		}
-		if key.Matches(msg, DeleteKeyMaps.DeleteAllAttachments) && m.deleteMode {
+		if key.Matches(msg, m.deleteKeyMap.DeleteAllAttachments) && m.deleteMode {
 			m.deleteMode = false
 			m.attachments = nil
 			return m, nil
@@ -311,7 +272,7 @@ func (m *editorCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 			}
 			return m, m.openEditor(m.textarea.Value())
 		}
-		if key.Matches(msg, DeleteKeyMaps.Escape) {
+		if key.Matches(msg, m.deleteKeyMap.Escape) {
 			m.deleteMode = false
 			return m, nil
 		}
@@ -426,7 +387,7 @@ func (m *editorCmp) randomizePlaceholders() {
 func (m *editorCmp) View() string {
 	t := styles.CurrentTheme()
 	// Update placeholder
-	if m.app.AgentCoordinator != nil && m.app.AgentCoordinator.IsBusy() {
+	if m.app.AgentCoordinator != nil && m.app.AgentCoordinator.IsSessionBusy(m.session.ID) {
 		m.textarea.Placeholder = m.workingPlaceholder
 	} else {
 		m.textarea.Placeholder = m.readyPlaceholder
@@ -614,9 +575,10 @@ func New(app *app.App) Editor {
 	ta.Focus()
 	e := &editorCmp{
 		// TODO: remove the app instance from here
-		app:      app,
-		textarea: ta,
-		keyMap:   DefaultEditorKeyMap(),
+		app:          app,
+		textarea:     ta,
+		keyMap:       DefaultEditorKeyMap(),
+		deleteKeyMap: DefaultAttachmentsKeyMap(),
 	}
 	e.setEditorPrompt()
 
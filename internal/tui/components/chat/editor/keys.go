@@ -2,6 +2,7 @@ package editor
 
 import (
 	"github.com/charmbracelet/bubbles/key"
+	"github.com/uglyswap/push/internal/tui/keymap"
 )
 
 type EditorKeyMap struct {
@@ -13,38 +14,24 @@ type EditorKeyMap struct {
 
 func DefaultEditorKeyMap() EditorKeyMap {
 	return EditorKeyMap{
-		AddFile: key.NewBinding(
-			key.WithKeys("/"),
-			key.WithHelp("/", "add file"),
-		),
-		SendMessage: key.NewBinding(
-			key.WithKeys("enter"),
-			key.WithHelp("enter", "send"),
-		),
-		OpenEditor: key.NewBinding(
-			key.WithKeys("ctrl+o"),
-			key.WithHelp("ctrl+o", "open editor"),
-		),
-		Newline: key.NewBinding(
-			key.WithKeys("shift+enter", "ctrl+j"),
-			// "ctrl+j" is a common keybinding for newline in many editors. If
-			// the terminal supports "shift+enter", we substitute the help text
-			// to reflect that.
-			key.WithHelp("ctrl+j", "newline"),
-		),
+		AddFile:     keymap.Binding(keymap.EditorAddFile),
+		SendMessage: keymap.Binding(keymap.EditorSend),
+		OpenEditor:  keymap.Binding(keymap.EditorOpenEditor),
+		Newline:     keymap.Binding(keymap.EditorNewline),
 	}
 }
 
 // KeyBindings implements layout.KeyMapProvider
 func (k EditorKeyMap) KeyBindings() []key.Binding {
+	attachments := DefaultAttachmentsKeyMap()
 	return []key.Binding{
 		k.AddFile,
 		k.SendMessage,
 		k.OpenEditor,
 		k.Newline,
-		AttachmentsKeyMaps.AttachmentDeleteMode,
-		AttachmentsKeyMaps.DeleteAllAttachments,
-		AttachmentsKeyMaps.Escape,
+		attachments.AttachmentDeleteMode,
+		attachments.DeleteAllAttachments,
+		attachments.Escape,
 	}
 }
 
@@ -54,18 +41,16 @@ type DeleteAttachmentKeyMaps struct {
 	DeleteAllAttachments key.Binding
 }
 
-// TODO: update this to use the new keymap concepts
-var AttachmentsKeyMaps = DeleteAttachmentKeyMaps{
-	AttachmentDeleteMode: key.NewBinding(
-		key.WithKeys("ctrl+r"),
-		key.WithHelp("ctrl+r+{i}", "delete attachment at index i"),
-	),
-	Escape: key.NewBinding(
-		key.WithKeys("esc", "alt+esc"),
-		key.WithHelp("esc", "cancel delete mode"),
-	),
-	DeleteAllAttachments: key.NewBinding(
-		key.WithKeys("r"),
-		key.WithHelp("ctrl+r+r", "delete all attachments"),
-	),
+// DefaultAttachmentsKeyMap returns the bindings deleting attachments. The
+// others only apply after the delete mode binding, so their help shows both.
+func DefaultAttachmentsKeyMap() DeleteAttachmentKeyMaps {
+	mode := keymap.Binding(keymap.EditorDeleteAttachment)
+	mode.SetHelp(keymap.HelpKey(keymap.EditorDeleteAttachment)+"+{i}", mode.Help().Desc)
+	deleteAll := keymap.Binding(keymap.AttachmentsDeleteAll)
+	deleteAll.SetHelp(keymap.HelpKey(keymap.EditorDeleteAttachment)+"+"+keymap.HelpKey(keymap.AttachmentsDeleteAll), deleteAll.Help().Desc)
+	return DeleteAttachmentKeyMaps{
+		AttachmentDeleteMode: mode,
+		Escape:               keymap.Binding(keymap.AttachmentsCancel),
+		DeleteAllAttachments: deleteAll,
+	}
 }
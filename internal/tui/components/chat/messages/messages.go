@@ -22,15 +22,16 @@ import (
 	"github.com/uglyswap/push/internal/tui/components/core"
 	"github.com/uglyswap/push/internal/tui/components/core/layout"
 	"github.com/uglyswap/push/internal/tui/exp/list"
+	"github.com/uglyswap/push/internal/tui/keymap"
 	"github.com/uglyswap/push/internal/tui/styles"
 	"github.com/uglyswap/push/internal/tui/util"
 )
 
-// CopyKey is the key binding for copying message content to the clipboard.
-var CopyKey = key.NewBinding(key.WithKeys("c", "y", "C", "Y"), key.WithHelp("c/y", "copy"))
+// CopyKey returns the key binding for copying message content to the clipboard.
+func CopyKey() key.Binding { return keymap.Binding(keymap.MessagesCopy) }
 
-// ClearSelectionKey is the key binding for clearing the current selection in the chat interface.
-var ClearSelectionKey = key.NewBinding(key.WithKeys("esc", "alt+esc"), key.WithHelp("esc", "clear selection"))
+// ClearSelectionKey returns the key binding for clearing the current selection in the chat interface.
+func ClearSelectionKey() key.Binding { return keymap.Binding(keymap.MessagesClearSelection) }
 
 // MessageCmp defines the interface for message components in the chat interface.
 // It combines standard UI model interfaces with message-specific functionality.
@@ -103,7 +104,7 @@ func (m *messageCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 			return m, cmd
 		}
 	case tea.KeyMsg:
-		if key.Matches(msg, CopyKey) {
+		if key.Matches(msg, CopyKey()) {
 			return m, func() tea.Msg {
 				_ = clipboard.WriteAll(m.message.Content().Text)
 				return util.InfoMsg{Type: util.InfoTypeInfo, Msg: "Message copied to clipboard"}
@@ -13,6 +13,7 @@ import (
 	"github.com/uglyswap/push/internal/agent/tools"
 	"github.com/uglyswap/push/internal/ansiext"
 	"github.com/uglyswap/push/internal/fsext"
+	"github.com/uglyswap/push/internal/patch"
 	"github.com/uglyswap/push/internal/tui/components/chat/todos"
 	"github.com/uglyswap/push/internal/tui/components/core"
 	"github.com/uglyswap/push/internal/tui/highlight"
@@ -189,6 +190,9 @@ func init() {
 	registry.register(tools.EditToolName, func() renderer { return editRenderer{} })
 	registry.register(tools.MultiEditToolName, func() renderer { return multiEditRenderer{} })
 	registry.register(tools.WriteToolName, func() renderer { return writeRenderer{} })
+	registry.register(tools.RenameToolName, func() renderer { return multiFileRenderer{} })
+	registry.register(tools.CodeActionsToolName, func() renderer { return multiFileRenderer{} })
+	registry.register(tools.ApplyPatchToolName, func() renderer { return multiFileRenderer{} })
 	registry.register(tools.FetchToolName, func() renderer { return simpleFetchRenderer{} })
 	registry.register(tools.AgenticFetchToolName, func() renderer { return agenticFetchRenderer{} })
 	registry.register(tools.WebFetchToolName, func() renderer { return webFetchRenderer{} })
@@ -567,6 +571,82 @@ func (mer multiEditRenderer) Render(v *toolCallCmp) string {
 	})
 }
 
+// -----------------------------------------------------------------------------
+//  Multi-file renderer
+// -----------------------------------------------------------------------------
+
+// multiFileRenderer handles tools changing several files at once, such as
+// LSP renames, code actions and patches
+type multiFileRenderer struct {
+	baseRenderer
+}
+
+// Render displays the diffs of every changed file
+func (mfr multiFileRenderer) Render(v *toolCallCmp) string {
+	t := styles.CurrentTheme()
+	var args []string
+	switch v.call.Name {
+	case tools.RenameToolName:
+		var params tools.RenameParams
+		if err := mfr.unmarshalParams(v.call.Input, &params); err == nil {
+			args = newParamBuilder().
+				addMain(fmt.Sprintf("%s → %s", params.Symbol, params.NewName)).
+				addKeyValue("file", fsext.PrettyPath(params.FilePath)).
+				build()
+		}
+	case tools.CodeActionsToolName:
+		var params tools.CodeActionsParams
+		if err := mfr.unmarshalParams(v.call.Input, &params); err == nil {
+			args = newParamBuilder().
+				addMain(fsext.PrettyPath(params.FilePath)).
+				addKeyValue("line", formatNonZero(params.Line)).
+				addKeyValue("kind", params.Kind).
+				addKeyValue("title", params.Title).
+				build()
+		}
+	case tools.ApplyPatchToolName:
+		var params tools.ApplyPatchParams
+		if err := mfr.unmarshalParams(v.call.Input, &params); err == nil {
+			if files, err := patch.Parse(params.Patch); err == nil {
+				args = newParamBuilder().
+					addMain(fmt.Sprintf("%d file(s)", len(files))).
+					build()
+			}
+		}
+	}
+
+	return mfr.renderWithParams(v, prettifyToolName(v.call.Name), args, func() string {
+		var meta tools.MultiFileResponseMetadata
+		if err := mfr.unmarshalParams(v.result.Metadata, &meta); err != nil || len(meta.Changes) == 0 {
+			return renderPlainContent(v, v.result.Content)
+		}
+
+		var diffs []string
+		for _, change := range meta.Changes {
+			formatter := core.DiffFormatter().
+				Before(fsext.PrettyPath(change.Path), change.OldContent).
+				After(fsext.PrettyPath(change.Path), change.NewContent).
+				Width(v.textWidth() - 2) // -2 for padding
+			if v.textWidth() > 120 {
+				formatter = formatter.Split()
+			}
+			diffs = append(diffs, formatter.String())
+		}
+		// add a message to the bottom if the content was truncated
+		formatted := strings.Join(diffs, "\n")
+		if lipgloss.Height(formatted) > responseContextHeight {
+			contentLines := strings.Split(formatted, "\n")
+			truncateMessage := t.S().Muted.
+				Background(styles.TC(t.BgBaseLighter)).
+				PaddingLeft(2).
+				Width(v.textWidth() - 2).
+				Render(fmt.Sprintf("… (%d lines, %d files)", len(contentLines)-responseContextHeight, len(meta.Changes)))
+			formatted = strings.Join(contentLines[:responseContextHeight], "\n") + "\n" + truncateMessage
+		}
+		return formatted
+	})
+}
+
 // -----------------------------------------------------------------------------
 //  Write renderer
 // -----------------------------------------------------------------------------
@@ -1306,6 +1386,12 @@ func prettifyToolName(name string) string {
 		return "View"
 	case tools.WriteToolName:
 		return "Write"
+	case tools.RenameToolName:
+		return "Rename"
+	case tools.CodeActionsToolName:
+		return "Code Action"
+	case tools.ApplyPatchToolName:
+		return "Apply Patch"
 	default:
 		return name
 	}
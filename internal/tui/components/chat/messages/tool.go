@@ -165,7 +165,7 @@ func (m *toolCallCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 		}
 		return m, tea.Batch(cmds...)
 	case tea.KeyMsg:
-		if key.Matches(msg, CopyKey) {
+		if key.Matches(msg, CopyKey()) {
 			return m, m.copyTool()
 		}
 	}
@@ -416,6 +416,8 @@ func (m *toolCallCmp) formatResultForCopy() string {
 		return m.formatMultiEditResultForCopy()
 	case tools.WriteToolName:
 		return m.formatWriteResultForCopy()
+	case tools.RenameToolName, tools.CodeActionsToolName, tools.ApplyPatchToolName:
+		return m.formatMultiFileResultForCopy()
 	case tools.FetchToolName:
 		return m.formatFetchResultForCopy()
 	case tools.AgenticFetchToolName:
@@ -569,6 +571,28 @@ func (m *toolCallCmp) formatMultiEditResultForCopy() string {
 	return result.String()
 }
 
+func (m *toolCallCmp) formatMultiFileResultForCopy() string {
+	var meta tools.MultiFileResponseMetadata
+	if m.result.Metadata == "" {
+		return m.result.Content
+	}
+
+	if json.Unmarshal([]byte(m.result.Metadata), &meta) != nil || len(meta.Changes) == 0 {
+		return m.result.Content
+	}
+
+	var result strings.Builder
+	result.WriteString(fmt.Sprintf("Changes: +%d -%d in %d files\n", meta.Additions, meta.Removals, len(meta.Changes)))
+	result.WriteString("```diff\n")
+	for _, change := range meta.Changes {
+		diffContent, _, _ := diff.GenerateDiff(change.OldContent, change.NewContent, fsext.PrettyPath(change.Path))
+		result.WriteString(diffContent)
+	}
+	result.WriteString("\n```")
+
+	return result.String()
+}
+
 func (m *toolCallCmp) formatWriteResultForCopy() string {
 	var params tools.WriteParams
 	if json.Unmarshal([]byte(m.call.Input), &params) != nil {
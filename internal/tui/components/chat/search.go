@@ -0,0 +1,323 @@
+package chat
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/cursor"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/uglyswap/push/internal/compat/bubbletea"
+
+	compattextinput "github.com/uglyswap/push/internal/compat/bubbles/textinput"
+	"github.com/uglyswap/push/internal/message"
+	"github.com/uglyswap/push/internal/tui/components/chat/messages"
+	"github.com/uglyswap/push/internal/tui/exp/list"
+	"github.com/uglyswap/push/internal/tui/keymap"
+	"github.com/uglyswap/push/internal/tui/styles"
+	"github.com/uglyswap/push/internal/tui/util"
+)
+
+// toolQueryPrefix starts a query looking only in the calls of a tool, as in
+// "tool:bash go test".
+const toolQueryPrefix = "tool:"
+
+// searchQuery is what the search of the message list looks for.
+type searchQuery struct {
+	tool string // name of the tool whose calls to look in, any item if empty
+	text string // lowercased text to look for
+}
+
+// parseQuery reads a query typed in the search of the message list.
+func parseQuery(query string) searchQuery {
+	query = strings.TrimSpace(query)
+	if rest, ok := strings.CutPrefix(query, toolQueryPrefix); ok {
+		tool, text, _ := strings.Cut(rest, " ")
+		return searchQuery{tool: tool, text: strings.ToLower(strings.TrimSpace(text))}
+	}
+	return searchQuery{text: strings.ToLower(query)}
+}
+
+func (q searchQuery) empty() bool {
+	return q.tool == "" && q.text == ""
+}
+
+// matches tells whether an item of the message list matches the query. The
+// text of the messages, the parameters and results of the tool calls, nested
+// ones included, are looked into rather than what the items render, so items
+// out of view need not be rendered.
+func (q searchQuery) matches(item list.Item) bool {
+	if q.empty() {
+		return false
+	}
+	if q.tool != "" {
+		toolCall, ok := item.(messages.ToolCallCmp)
+		if !ok || !strings.EqualFold(toolCall.GetToolCall().Name, q.tool) {
+			return false
+		}
+	}
+	return strings.Contains(strings.ToLower(searchText(item)), q.text)
+}
+
+// searchText returns the text of an item of the message list the search
+// looks into.
+func searchText(item list.Item) string {
+	switch item := item.(type) {
+	case messages.MessageCmp:
+		msg := item.GetMessage()
+		return msg.ReasoningContent().Thinking + "\n" + msg.Content().Text
+	case messages.ToolCallCmp:
+		call := item.GetToolCall()
+		parts := []string{call.Name, call.Input, item.GetToolResult().Content}
+		for _, nested := range item.GetNestedToolCalls() {
+			parts = append(parts, searchText(nested))
+		}
+		return strings.Join(parts, "\n")
+	}
+	return ""
+}
+
+// isToolCall tells whether an item of the message list is a tool call.
+func isToolCall(item list.Item) bool {
+	_, ok := item.(messages.ToolCallCmp)
+	return ok
+}
+
+// isError tells whether an item of the message list is a failed tool call or
+// a turn ended by an error.
+func isError(item list.Item) bool {
+	switch item := item.(type) {
+	case messages.ToolCallCmp:
+		return item.GetToolResult().IsError
+	case messages.MessageCmp:
+		msg := item.GetMessage()
+		finish := msg.FinishPart()
+		return finish != nil && finish.Reason == message.FinishReasonError
+	}
+	return false
+}
+
+// newSearchInput creates the input the search of the message list is typed
+// in.
+func newSearchInput() textinput.Model {
+	t := styles.CurrentTheme()
+	ti := textinput.New()
+	ti.Prompt = "/"
+	ti.Placeholder = "Search messages, or " + toolQueryPrefix + "<name> for tool calls"
+	ti.Cursor.SetMode(cursor.CursorStatic)
+	compattextinput.SetStylesOnModel(&ti, t.S().TextInput)
+	return ti
+}
+
+// Searching tells whether the search of the message list is being typed, the
+// list taking all the keys.
+func (m *messageListCmp) Searching() bool {
+	return m.searching
+}
+
+// startSearch opens the search input, looking up from the selected item.
+func (m *messageListCmp) startSearch() tea.Cmd {
+	m.searching = true
+	m.searchFrom = m.selectedIndex()
+	m.search = newSearchInput()
+	m.search.Focus()
+	m.search.SetValue(m.query)
+	m.search.CursorEnd()
+	return m.SetSize(m.width, m.height)
+}
+
+// ClearSearch clears the search of the message list, telling whether there
+// was one.
+func (m *messageListCmp) ClearSearch() bool {
+	if !m.searching && m.query == "" {
+		return false
+	}
+	m.searching = false
+	m.query = ""
+	m.matches = nil
+	m.listCmp.SetHighlight("")
+	m.SetSize(m.width, m.height)
+	return true
+}
+
+// searchBarHeight is the height of the bar showing the search under the
+// message list.
+func (m *messageListCmp) searchBarHeight() int {
+	if m.searching || m.query != "" {
+		return 1
+	}
+	return 0
+}
+
+// handleSearchKey handles the keys while the search is typed, looking for
+// the query as it changes.
+func (m *messageListCmp) handleSearchKey(msg tea.KeyMsg) tea.Cmd {
+	switch {
+	case key.Matches(msg, keymap.Binding(keymap.SearchCancel)):
+		m.ClearSearch()
+		return nil
+	case key.Matches(msg, keymap.Binding(keymap.SearchConfirm)):
+		m.searching = false
+		m.search.Blur()
+		if m.query == "" {
+			return m.SetSize(m.width, m.height)
+		}
+		return nil
+	case key.Matches(msg, keymap.Binding(keymap.SearchNext)):
+		return m.goToMatch(1)
+	case key.Matches(msg, keymap.Binding(keymap.SearchPrevious)):
+		return m.goToMatch(-1)
+	}
+
+	var cmd tea.Cmd
+	m.search, cmd = m.search.Update(msg)
+	return tea.Batch(cmd, m.setQuery(m.search.Value()))
+}
+
+// setQuery looks for a new query, selecting the closest match at or above
+// the item selected when the search started.
+func (m *messageListCmp) setQuery(query string) tea.Cmd {
+	if query == m.query {
+		return nil
+	}
+	m.query = query
+	q := parseQuery(query)
+	m.listCmp.SetHighlight(q.text)
+	m.findMatches()
+	if len(m.matches) == 0 {
+		return nil
+	}
+	items := m.listCmp.Items()
+	for i := min(m.searchFrom, len(items)-1); i >= 0; i-- {
+		if q.matches(items[i]) {
+			return m.listCmp.SetSelected(items[i].ID())
+		}
+	}
+	return m.listCmp.SetSelected(m.matches[len(m.matches)-1])
+}
+
+// findMatches lists the items matching the query, top to bottom.
+func (m *messageListCmp) findMatches() {
+	m.matches = m.matches[:0]
+	q := parseQuery(m.query)
+	for _, item := range m.listCmp.Items() {
+		if q.matches(item) {
+			m.matches = append(m.matches, item.ID())
+		}
+	}
+}
+
+// goToMatch selects the match delta matches below the selected item, above
+// it when delta is negative.
+func (m *messageListCmp) goToMatch(delta int) tea.Cmd {
+	if m.query == "" {
+		return nil
+	}
+	// New messages may match since the search started.
+	m.findMatches()
+	q := parseQuery(m.query)
+	if len(m.matches) == 0 {
+		return util.ReportInfo("No match for " + m.query)
+	}
+	return m.goTo(q.matches, delta, true)
+}
+
+// goTo selects the first item satisfying match below the selected item, or
+// above it when delta is negative, wrapping around the list if wrap is set.
+func (m *messageListCmp) goTo(match func(list.Item) bool, delta int, wrap bool) tea.Cmd {
+	items := m.listCmp.Items()
+	if len(items) == 0 {
+		return nil
+	}
+	from := m.selectedIndex()
+	for n := 1; n <= len(items); n++ {
+		i := from + n*delta
+		if wrap {
+			i = (i%len(items) + len(items)) % len(items)
+		} else if i < 0 || i >= len(items) {
+			return nil
+		}
+		if match(items[i]) {
+			return m.listCmp.SetSelected(items[i].ID())
+		}
+	}
+	return nil
+}
+
+// goToLastError selects the last error, or the one before the selected item
+// when it is an error itself.
+func (m *messageListCmp) goToLastError() tea.Cmd {
+	items := m.listCmp.Items()
+	from := m.selectedIndex()
+	if from < 0 || from >= len(items) || !isError(items[from]) {
+		from = len(items)
+	}
+	for i := from - 1; i >= 0; i-- {
+		if isError(items[i]) {
+			return m.listCmp.SetSelected(items[i].ID())
+		}
+	}
+	return util.ReportInfo("No error in this session")
+}
+
+// selectedIndex returns the index of the selected item, past the last item
+// when none is selected.
+func (m *messageListCmp) selectedIndex() int {
+	items := m.listCmp.Items()
+	if selected := m.listCmp.SelectedItem(); selected != nil {
+		for i, item := range items {
+			if item.ID() == (*selected).ID() {
+				return i
+			}
+		}
+	}
+	return len(items)
+}
+
+// handleSearchAction handles the keys searching or moving through the
+// messages.
+func (m *messageListCmp) handleSearchAction(msg tea.KeyMsg) (tea.Cmd, bool) {
+	switch {
+	case key.Matches(msg, keymap.Binding(keymap.MessagesSearch)):
+		return m.startSearch(), true
+	case key.Matches(msg, keymap.Binding(keymap.MessagesSearchNext)):
+		return m.goToMatch(1), true
+	case key.Matches(msg, keymap.Binding(keymap.MessagesSearchPrevious)):
+		return m.goToMatch(-1), true
+	case key.Matches(msg, keymap.Binding(keymap.MessagesNextToolCall)):
+		return m.goTo(isToolCall, 1, false), true
+	case key.Matches(msg, keymap.Binding(keymap.MessagesPrevToolCall)):
+		return m.goTo(isToolCall, -1, false), true
+	case key.Matches(msg, keymap.Binding(keymap.MessagesLastError)):
+		return m.goToLastError(), true
+	}
+	return nil, false
+}
+
+// searchBarView renders the search typed, or the one shown with the
+// position of the selected match.
+func (m *messageListCmp) searchBarView() string {
+	t := styles.CurrentTheme()
+	if m.searching {
+		return m.search.View()
+	}
+	position := "no match"
+	if len(m.matches) > 0 {
+		position = fmt.Sprintf("%d matches", len(m.matches))
+		if selected := m.listCmp.SelectedItem(); selected != nil {
+			for i, id := range m.matches {
+				if id == (*selected).ID() {
+					position = fmt.Sprintf("%d/%d", i+1, len(m.matches))
+				}
+			}
+		}
+	}
+	return t.S().Subtle.Render(fmt.Sprintf(
+		"/%s  %s · %s next · %s previous · %s clear",
+		m.query,
+		position,
+		keymap.HelpKey(keymap.MessagesSearchNext),
+		keymap.HelpKey(keymap.MessagesSearchPrevious),
+		keymap.HelpKey(keymap.SearchCancel),
+	))
+}
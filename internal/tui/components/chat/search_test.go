@@ -0,0 +1,48 @@
+package chat
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/uglyswap/push/internal/message"
+	"github.com/uglyswap/push/internal/tui/components/chat/messages"
+)
+
+func TestSearchQuery_Matches(t *testing.T) {
+	t.Parallel()
+
+	prompt := messages.NewMessageCmp(message.Message{
+		ID:    "prompt",
+		Role:  message.User,
+		Parts: []message.ContentPart{message.TextContent{Text: "Run the Tests please"}},
+	})
+	bash := messages.NewToolCallCmp("answer", message.ToolCall{
+		ID:    "bash",
+		Name:  "bash",
+		Input: `{"command":"go test ./..."}`,
+	}, nil, messages.WithToolCallResult(message.ToolResult{
+		ToolCallID: "bash",
+		Content:    "FAIL internal/app",
+		IsError:    true,
+	}))
+	view := messages.NewToolCallCmp("answer", message.ToolCall{
+		ID:    "view",
+		Name:  "view",
+		Input: `{"file_path":"internal/app/app.go"}`,
+	}, nil)
+
+	require.True(t, parseQuery("tests").matches(prompt), "text ignores case")
+	require.True(t, parseQuery("go test").matches(bash), "tool call parameters")
+	require.True(t, parseQuery("fail").matches(bash), "tool results")
+	require.False(t, parseQuery("").matches(prompt))
+
+	require.True(t, parseQuery("tool:bash").matches(bash))
+	require.False(t, parseQuery("tool:bash").matches(view))
+	require.False(t, parseQuery("tool:bash test").matches(prompt))
+	require.True(t, parseQuery("tool:view internal/app").matches(view))
+	require.False(t, parseQuery("tool:view fail").matches(view))
+
+	require.True(t, isError(bash))
+	require.False(t, isError(view))
+}
@@ -54,7 +54,8 @@ type SessionFile struct {
 	Deletions int
 }
 type SessionFilesMsg struct {
-	Files []SessionFile
+	SessionID string
+	Files     []SessionFile
 }
 
 type Sidebar interface {
@@ -91,6 +92,9 @@ func (m *sidebarCmp) Init() tea.Cmd {
 func (m *sidebarCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case SessionFilesMsg:
+		if msg.SessionID != m.session.ID {
+			return m, nil
+		}
 		m.files = csync.NewMap[string, SessionFile]()
 		for _, file := range msg.Files {
 			m.files.Set(file.FilePath, file)
@@ -176,6 +180,9 @@ func (m *sidebarCmp) View() string {
 }
 
 func (m *sidebarCmp) handleFileHistoryEvent(event pubsub.Event[history.File]) tea.Cmd {
+	if event.Payload.SessionID != m.session.ID {
+		return nil
+	}
 	return func() tea.Msg {
 		file := event.Payload
 		found := false
@@ -261,7 +268,8 @@ func (m *sidebarCmp) loadSessionFiles() tea.Msg {
 	}
 
 	return SessionFilesMsg{
-		Files: sessionFiles,
+		SessionID: m.session.ID,
+		Files:     sessionFiles,
 	}
 }
 
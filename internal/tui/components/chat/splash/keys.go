@@ -2,6 +2,7 @@ package splash
 
 import (
 	"github.com/charmbracelet/bubbles/key"
+	"github.com/uglyswap/push/internal/tui/keymap"
 )
 
 type KeyMap struct {
@@ -18,41 +19,14 @@ type KeyMap struct {
 
 func DefaultKeyMap() KeyMap {
 	return KeyMap{
-		Select: key.NewBinding(
-			key.WithKeys("enter", "ctrl+y"),
-			key.WithHelp("enter", "confirm"),
-		),
-		Next: key.NewBinding(
-			key.WithKeys("down", "ctrl+n"),
-			key.WithHelp("↓", "next item"),
-		),
-		Previous: key.NewBinding(
-			key.WithKeys("up", "ctrl+p"),
-			key.WithHelp("↑", "previous item"),
-		),
-		Yes: key.NewBinding(
-			key.WithKeys("y", "Y"),
-			key.WithHelp("y", "yes"),
-		),
-		No: key.NewBinding(
-			key.WithKeys("n", "N"),
-			key.WithHelp("n", "no"),
-		),
-		Tab: key.NewBinding(
-			key.WithKeys("tab"),
-			key.WithHelp("tab", "switch"),
-		),
-		LeftRight: key.NewBinding(
-			key.WithKeys("left", "right"),
-			key.WithHelp("←/→", "switch"),
-		),
-		Back: key.NewBinding(
-			key.WithKeys("esc", "alt+esc"),
-			key.WithHelp("esc", "back"),
-		),
-		Copy: key.NewBinding(
-			key.WithKeys("c"),
-			key.WithHelp("c", "copy url"),
-		),
+		Select:    keymap.Binding(keymap.SplashSelect),
+		Next:      keymap.Binding(keymap.SplashNext),
+		Previous:  keymap.Binding(keymap.SplashPrevious),
+		Yes:       keymap.Binding(keymap.SplashYes),
+		No:        keymap.Binding(keymap.SplashNo),
+		Tab:       keymap.Binding(keymap.SplashTab),
+		LeftRight: keymap.Binding(keymap.SplashLeftRight),
+		Back:      keymap.Binding(keymap.SplashBack),
+		Copy:      keymap.Binding(keymap.SplashCopy),
 	}
 }
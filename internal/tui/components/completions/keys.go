@@ -2,6 +2,7 @@ package completions
 
 import (
 	"github.com/charmbracelet/bubbles/key"
+	"github.com/uglyswap/push/internal/tui/keymap"
 )
 
 type KeyMap struct {
@@ -15,30 +16,12 @@ type KeyMap struct {
 
 func DefaultKeyMap() KeyMap {
 	return KeyMap{
-		Down: key.NewBinding(
-			key.WithKeys("down"),
-			key.WithHelp("down", "move down"),
-		),
-		Up: key.NewBinding(
-			key.WithKeys("up"),
-			key.WithHelp("up", "move up"),
-		),
-		Select: key.NewBinding(
-			key.WithKeys("enter", "tab", "ctrl+y"),
-			key.WithHelp("enter", "select"),
-		),
-		Cancel: key.NewBinding(
-			key.WithKeys("esc", "alt+esc"),
-			key.WithHelp("esc", "cancel"),
-		),
-		DownInsert: key.NewBinding(
-			key.WithKeys("ctrl+n"),
-			key.WithHelp("ctrl+n", "insert next"),
-		),
-		UpInsert: key.NewBinding(
-			key.WithKeys("ctrl+p"),
-			key.WithHelp("ctrl+p", "insert previous"),
-		),
+		Down:       keymap.Binding(keymap.CompletionsDown),
+		Up:         keymap.Binding(keymap.CompletionsUp),
+		Select:     keymap.Binding(keymap.CompletionsSelect),
+		Cancel:     keymap.Binding(keymap.CompletionsCancel),
+		DownInsert: keymap.Binding(keymap.CompletionsInsertNext),
+		UpInsert:   keymap.Binding(keymap.CompletionsInsertPrevious),
 	}
 }
 
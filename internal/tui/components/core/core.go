@@ -7,7 +7,6 @@ import (
 	"github.com/charmbracelet/bubbles/help"
 	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/lipgloss"
-	"github.com/alecthomas/chroma/v2"
 	"github.com/uglyswap/push/internal/tui/exp/diffview"
 	"github.com/uglyswap/push/internal/tui/styles"
 	"github.com/charmbracelet/x/ansi"
@@ -201,7 +200,6 @@ func SelectableButtonsVertical(buttons []ButtonOpts, spacing int) string {
 func DiffFormatter() *diffview.DiffView {
 	t := styles.CurrentTheme()
 	formatDiff := diffview.New()
-	style := chroma.MustNewStyle("crush", styles.GetChromaTheme())
-	diff := formatDiff.ChromaStyle(style).Style(t.S().Diff).TabWidth(4)
+	diff := formatDiff.ChromaStyle(styles.ChromaStyle()).Style(t.S().Diff).TabWidth(4)
 	return diff
 }
@@ -0,0 +1,274 @@
+package checkpoints
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/uglyswap/push/internal/compat/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/uglyswap/push/internal/checkpoint"
+	"github.com/uglyswap/push/internal/tui/components/core"
+	"github.com/uglyswap/push/internal/tui/components/dialogs"
+	"github.com/uglyswap/push/internal/tui/styles"
+	"github.com/uglyswap/push/internal/tui/util"
+	"github.com/charmbracelet/x/ansi"
+)
+
+const (
+	CheckpointsDialogID dialogs.DialogID = "checkpoints"
+
+	// maxListHeight is the number of checkpoints shown above the diff.
+	maxListHeight = 8
+)
+
+// RestoreCheckpointMsg asks to bring the working tree of a session back to a
+// checkpoint.
+type RestoreCheckpointMsg struct {
+	SessionID string
+	Hash      string
+}
+
+// changesLoadedMsg carries the changes of a checkpoint, loaded in the
+// background.
+type changesLoadedMsg struct {
+	hash    string
+	changes []checkpoint.FileChange
+	err     error
+}
+
+// CheckpointsDialog lists the checkpoints of a session with the changes of
+// the selected one, and restores them.
+type CheckpointsDialog interface {
+	dialogs.DialogModel
+}
+
+type checkpointsDialogCmp struct {
+	wWidth  int
+	wHeight int
+	width   int
+	height  int
+
+	sessionID   string
+	manager     *checkpoint.Manager
+	checkpoints []checkpoint.Checkpoint
+	selected    int
+
+	changes map[string][]checkpoint.FileChange
+	lines   []string
+	err     error
+
+	yOffset        int
+	confirmRestore bool
+	keyMap         KeyMap
+}
+
+// NewCheckpointsDialog creates a dialog listing the given checkpoints of a
+// session, newest first.
+func NewCheckpointsDialog(sessionID string, manager *checkpoint.Manager, checkpoints []checkpoint.Checkpoint) CheckpointsDialog {
+	return &checkpointsDialogCmp{
+		sessionID:   sessionID,
+		manager:     manager,
+		checkpoints: checkpoints,
+		changes:     make(map[string][]checkpoint.FileChange),
+		keyMap:      DefaultKeyMap(),
+	}
+}
+
+func (c *checkpointsDialogCmp) Init() tea.Cmd {
+	return c.loadChanges()
+}
+
+func (c *checkpointsDialogCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		c.wWidth = msg.Width
+		c.wHeight = msg.Height
+		c.width = min(int(float64(c.wWidth)*0.8), 180)
+		c.height = int(float64(c.wHeight) * 0.8)
+		c.lines = nil
+	case changesLoadedMsg:
+		c.err = msg.err
+		if msg.err == nil {
+			c.changes[msg.hash] = msg.changes
+		}
+		c.lines = nil
+	case tea.KeyMsg:
+		restore := key.Matches(msg, c.keyMap.Restore)
+		defer func() { c.confirmRestore = restore && !c.confirmRestore }()
+		switch {
+		case key.Matches(msg, c.keyMap.Close):
+			return c, util.CmdHandler(dialogs.CloseDialogMsg{})
+		case restore:
+			if len(c.checkpoints) == 0 {
+				return c, nil
+			}
+			// Restoring overwrites the working tree, so it takes a second
+			// press to confirm.
+			if !c.confirmRestore {
+				return c, nil
+			}
+			return c, tea.Sequence(
+				util.CmdHandler(dialogs.CloseDialogMsg{}),
+				util.CmdHandler(RestoreCheckpointMsg{
+					SessionID: c.sessionID,
+					Hash:      c.checkpoints[c.selected].Hash,
+				}),
+			)
+		case key.Matches(msg, c.keyMap.Next):
+			return c, c.selectCheckpoint(c.selected + 1)
+		case key.Matches(msg, c.keyMap.Previous):
+			return c, c.selectCheckpoint(c.selected - 1)
+		case key.Matches(msg, c.keyMap.ScrollUp):
+			c.scroll(-c.diffHeight())
+		case key.Matches(msg, c.keyMap.ScrollDown):
+			c.scroll(c.diffHeight())
+		}
+	}
+	return c, nil
+}
+
+func (c *checkpointsDialogCmp) selectCheckpoint(i int) tea.Cmd {
+	if i < 0 || i >= len(c.checkpoints) || i == c.selected {
+		return nil
+	}
+	c.selected = i
+	c.yOffset = 0
+	c.lines = nil
+	return c.loadChanges()
+}
+
+// loadChanges loads the changes of the selected checkpoint unless they are
+// already known.
+func (c *checkpointsDialogCmp) loadChanges() tea.Cmd {
+	if len(c.checkpoints) == 0 {
+		return nil
+	}
+	hash := c.checkpoints[c.selected].Hash
+	if _, ok := c.changes[hash]; ok {
+		return nil
+	}
+	return func() tea.Msg {
+		changes, err := c.manager.Changes(context.Background(), hash)
+		return changesLoadedMsg{hash: hash, changes: changes, err: err}
+	}
+}
+
+func (c *checkpointsDialogCmp) scroll(delta int) {
+	c.yOffset = max(0, min(c.yOffset+delta, len(c.diffLines())-c.diffHeight()))
+}
+
+func (c *checkpointsDialogCmp) listHeight() int {
+	return min(len(c.checkpoints), maxListHeight)
+}
+
+// diffHeight is the number of diff lines shown at once, the dialog height
+// minus the title, the list, help and borders.
+func (c *checkpointsDialogCmp) diffHeight() int {
+	return max(5, c.height-c.listHeight()-8)
+}
+
+// diffLines renders the diffs of the files changed by the selected
+// checkpoint one after the other.
+func (c *checkpointsDialogCmp) diffLines() []string {
+	if c.lines != nil || len(c.checkpoints) == 0 {
+		return c.lines
+	}
+	changes, ok := c.changes[c.checkpoints[c.selected].Hash]
+	if !ok {
+		return nil
+	}
+	var diffs []string
+	for _, change := range changes {
+		before, after := change.Path, change.Path
+		if change.Created {
+			before = "/dev/null"
+		}
+		if change.Deleted {
+			after = "/dev/null"
+		}
+		formatter := core.DiffFormatter().
+			Before(before, change.OldContent).
+			After(after, change.NewContent).
+			Width(c.width - 4)
+		if c.width >= 140 {
+			formatter = formatter.Split()
+		} else {
+			formatter = formatter.Unified()
+		}
+		diffs = append(diffs, formatter.String())
+	}
+	c.lines = strings.Split(strings.Join(diffs, "\n"), "\n")
+	return c.lines
+}
+
+func (c *checkpointsDialogCmp) renderList() string {
+	t := styles.CurrentTheme()
+	if len(c.checkpoints) == 0 {
+		return t.S().Subtle.Render("No checkpoints recorded for this session yet.")
+	}
+
+	// Keep the selected checkpoint in view.
+	start := max(0, c.selected-c.listHeight()+1)
+	rows := make([]string, 0, c.listHeight())
+	for i, cp := range c.checkpoints[start : start+c.listHeight()] {
+		row := fmt.Sprintf("%s  %s  %s", cp.Hash[:7], cp.Time.Local().Format("01-02 15:04"), cp.Message)
+		row = ansi.Truncate(row, c.width-6, "…")
+		if start+i == c.selected {
+			rows = append(rows, t.S().TextSelected.Width(c.width-4).Render(row))
+		} else {
+			rows = append(rows, t.S().Text.Render(row))
+		}
+	}
+	return strings.Join(rows, "\n")
+}
+
+func (c *checkpointsDialogCmp) View() string {
+	t := styles.CurrentTheme()
+	baseStyle := t.S().Base
+
+	var diff string
+	switch lines := c.diffLines(); {
+	case c.err != nil:
+		diff = t.S().Error.Render(c.err.Error())
+	case len(c.checkpoints) == 0:
+	case lines == nil:
+		diff = t.S().Subtle.Render("Loading changes...")
+	default:
+		end := min(len(lines), c.yOffset+c.diffHeight())
+		diff = strings.Join(lines[c.yOffset:end], "\n")
+	}
+
+	footer := help.New().View(c.keyMap)
+	if c.confirmRestore {
+		footer = t.S().Error.Render("Press r again to bring the working tree back to this checkpoint.")
+	}
+
+	return baseStyle.
+		Padding(0, 1).
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(styles.TC(t.BorderFocus)).
+		Width(c.width).
+		Render(lipgloss.JoinVertical(
+			lipgloss.Top,
+			core.Title("Checkpoints", c.width-4),
+			"",
+			c.renderList(),
+			"",
+			diff,
+			"",
+			footer,
+		))
+}
+
+func (c *checkpointsDialogCmp) Position() (int, int) {
+	row := c.wHeight/2 - c.height/2
+	col := c.wWidth/2 - c.width/2
+	return row, col
+}
+
+func (c *checkpointsDialogCmp) ID() dialogs.DialogID {
+	return CheckpointsDialogID
+}
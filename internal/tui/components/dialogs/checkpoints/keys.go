@@ -0,0 +1,60 @@
+package checkpoints
+
+import (
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/uglyswap/push/internal/tui/keymap"
+)
+
+// KeyMap defines the keyboard bindings for the checkpoints dialog.
+type KeyMap struct {
+	Next,
+	Previous,
+	ScrollUp,
+	ScrollDown,
+	Restore,
+	Close key.Binding
+}
+
+func DefaultKeyMap() KeyMap {
+	return KeyMap{
+		Next:       keymap.Binding(keymap.CheckpointsNext),
+		Previous:   keymap.Binding(keymap.CheckpointsPrevious),
+		ScrollUp:   keymap.Binding(keymap.CheckpointsScrollUp),
+		ScrollDown: keymap.Binding(keymap.CheckpointsScrollDown),
+		Restore:    keymap.Binding(keymap.CheckpointsRestore),
+		Close:      keymap.Binding(keymap.CheckpointsClose),
+	}
+}
+
+// KeyBindings implements layout.KeyMapProvider
+func (k KeyMap) KeyBindings() []key.Binding {
+	return []key.Binding{
+		k.Next,
+		k.Previous,
+		k.ScrollUp,
+		k.ScrollDown,
+		k.Restore,
+		k.Close,
+	}
+}
+
+// FullHelp implements help.KeyMap.
+func (k KeyMap) FullHelp() [][]key.Binding {
+	m := [][]key.Binding{}
+	slice := k.KeyBindings()
+	for i := 0; i < len(slice); i += 4 {
+		end := min(i+4, len(slice))
+		m = append(m, slice[i:end])
+	}
+	return m
+}
+
+// ShortHelp implements help.KeyMap.
+func (k KeyMap) ShortHelp() []key.Binding {
+	return []key.Binding{
+		k.Next,
+		k.ScrollDown,
+		k.Restore,
+		k.Close,
+	}
+}
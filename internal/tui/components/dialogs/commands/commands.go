@@ -22,6 +22,7 @@ import (
 	"github.com/uglyswap/push/internal/tui/components/core"
 	"github.com/uglyswap/push/internal/tui/components/dialogs"
 	"github.com/uglyswap/push/internal/tui/exp/list"
+	"github.com/uglyswap/push/internal/tui/keymap"
 	"github.com/uglyswap/push/internal/tui/styles"
 	"github.com/uglyswap/push/internal/tui/util"
 	"github.com/uglyswap/push/internal/uicmd"
@@ -85,6 +86,20 @@ type (
 	CompactMsg             struct {
 		SessionID string
 	}
+	OpenWorktreeMsg struct {
+		SessionID string
+	}
+	OpenCheckpointsMsg struct {
+		SessionID string
+	}
+	OpenFileChangesMsg struct {
+		SessionID string
+	}
+	OpenQueueMsg struct {
+		SessionID string
+	}
+	OpenThemesMsg      struct{}
+	OpenKeybindingsMsg struct{}
 )
 
 func NewCommandDialog(sessionID string) CommandsDialog {
@@ -318,7 +333,7 @@ func (c *commandDialogCmp) defaultCommands() []Command {
 			ID:          "new_session",
 			Title:       "New Session",
 			Description: "start a new session",
-			Shortcut:    "ctrl+n",
+			Shortcut:    keymap.HelpKey(keymap.ChatNewSession),
 			Handler: func(cmd Command) tea.Cmd {
 				return util.CmdHandler(NewSessionsMsg{})
 			},
@@ -327,7 +342,7 @@ func (c *commandDialogCmp) defaultCommands() []Command {
 			ID:          "switch_session",
 			Title:       "Switch Session",
 			Description: "Switch to a different session",
-			Shortcut:    "ctrl+s",
+			Shortcut:    keymap.HelpKey(keymap.AppSessions),
 			Handler: func(cmd Command) tea.Cmd {
 				return util.CmdHandler(SwitchSessionsMsg{})
 			},
@@ -336,7 +351,7 @@ func (c *commandDialogCmp) defaultCommands() []Command {
 			ID:          "switch_model",
 			Title:       "Switch Model",
 			Description: "Switch to a different model",
-			Shortcut:    "ctrl+l",
+			Shortcut:    keymap.HelpKey(keymap.AppModels),
 			Handler: func(cmd Command) tea.Cmd {
 				return util.CmdHandler(SwitchModelMsg{})
 			},
@@ -356,6 +371,52 @@ func (c *commandDialogCmp) defaultCommands() []Command {
 			},
 		})
 	}
+	if c.sessionID != "" {
+		commands = append(commands, Command{
+			ID:          "file_changes",
+			Title:       "File Changes",
+			Description: "Review the files changed in the session, version by version",
+			Handler: func(cmd Command) tea.Cmd {
+				return util.CmdHandler(OpenFileChangesMsg{
+					SessionID: c.sessionID,
+				})
+			},
+		})
+		commands = append(commands, Command{
+			ID:          "queued_prompts",
+			Title:       "Queued Prompts",
+			Description: "Edit, reorder, drop or send now the prompts waiting for the agent",
+			Handler: func(cmd Command) tea.Cmd {
+				return util.CmdHandler(OpenQueueMsg{
+					SessionID: c.sessionID,
+				})
+			},
+		})
+	}
+	if c.sessionID != "" && config.Get().Options.SessionWorktrees {
+		commands = append(commands, Command{
+			ID:          "session_worktree",
+			Title:       "Review Session Worktree",
+			Description: "Review the changes of the session worktree and merge or discard them",
+			Handler: func(cmd Command) tea.Cmd {
+				return util.CmdHandler(OpenWorktreeMsg{
+					SessionID: c.sessionID,
+				})
+			},
+		})
+	}
+	if c.sessionID != "" && config.Get().Options.Checkpoints {
+		commands = append(commands, Command{
+			ID:          "checkpoints",
+			Title:       "Checkpoints",
+			Description: "Browse the checkpoints recorded after each agent turn and restore one",
+			Handler: func(cmd Command) tea.Cmd {
+				return util.CmdHandler(OpenCheckpointsMsg{
+					SessionID: c.sessionID,
+				})
+			},
+		})
+	}
 
 	// Add reasoning toggle for models that support it
 	cfg := config.Get()
@@ -412,7 +473,7 @@ func (c *commandDialogCmp) defaultCommands() []Command {
 			commands = append(commands, Command{
 				ID:          "file_picker",
 				Title:       "Open File Picker",
-				Shortcut:    "ctrl+f",
+				Shortcut:    keymap.HelpKey(keymap.ChatAddAttachment),
 				Description: "Open file picker",
 				Handler: func(cmd Command) tea.Cmd {
 					return util.CmdHandler(OpenFilePickerMsg{})
@@ -426,7 +487,7 @@ func (c *commandDialogCmp) defaultCommands() []Command {
 		commands = append(commands, Command{
 			ID:          "open_external_editor",
 			Title:       "Open External Editor",
-			Shortcut:    "ctrl+o",
+			Shortcut:    keymap.HelpKey(keymap.EditorOpenEditor),
 			Description: "Open external editor to compose message",
 			Handler: func(cmd Command) tea.Cmd {
 				return util.CmdHandler(OpenExternalEditorMsg{})
@@ -443,10 +504,26 @@ func (c *commandDialogCmp) defaultCommands() []Command {
 				return util.CmdHandler(ToggleYoloModeMsg{})
 			},
 		},
+		{
+			ID:          "switch_theme",
+			Title:       "Switch Theme",
+			Description: "Switch to a different theme",
+			Handler: func(cmd Command) tea.Cmd {
+				return util.CmdHandler(OpenThemesMsg{})
+			},
+		},
+		{
+			ID:          "keybindings",
+			Title:       "Keybindings",
+			Description: "Show the keybindings and the keys bound to them",
+			Handler: func(cmd Command) tea.Cmd {
+				return util.CmdHandler(OpenKeybindingsMsg{})
+			},
+		},
 		{
 			ID:          "toggle_help",
 			Title:       "Toggle Help",
-			Shortcut:    "ctrl+g",
+			Shortcut:    keymap.HelpKey(keymap.AppHelp),
 			Description: "Toggle help",
 			Handler: func(cmd Command) tea.Cmd {
 				return util.CmdHandler(ToggleHelpMsg{})
@@ -470,7 +547,7 @@ func (c *commandDialogCmp) defaultCommands() []Command {
 			ID:          "quit",
 			Title:       "Quit",
 			Description: "Quit",
-			Shortcut:    "ctrl+c",
+			Shortcut:    keymap.HelpKey(keymap.AppQuit),
 			Handler: func(cmd Command) tea.Cmd {
 				return util.CmdHandler(QuitMsg{})
 			},
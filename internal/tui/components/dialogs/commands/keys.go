@@ -2,6 +2,7 @@ package commands
 
 import (
 	"github.com/charmbracelet/bubbles/key"
+	"github.com/uglyswap/push/internal/tui/keymap"
 )
 
 type CommandsDialogKeyMap struct {
@@ -14,26 +15,11 @@ type CommandsDialogKeyMap struct {
 
 func DefaultCommandsDialogKeyMap() CommandsDialogKeyMap {
 	return CommandsDialogKeyMap{
-		Select: key.NewBinding(
-			key.WithKeys("enter", "ctrl+y"),
-			key.WithHelp("enter", "confirm"),
-		),
-		Next: key.NewBinding(
-			key.WithKeys("down", "ctrl+n"),
-			key.WithHelp("↓", "next item"),
-		),
-		Previous: key.NewBinding(
-			key.WithKeys("up", "ctrl+p"),
-			key.WithHelp("↑", "previous item"),
-		),
-		Tab: key.NewBinding(
-			key.WithKeys("tab"),
-			key.WithHelp("tab", "switch selection"),
-		),
-		Close: key.NewBinding(
-			key.WithKeys("esc", "alt+esc"),
-			key.WithHelp("esc", "cancel"),
-		),
+		Select:   keymap.Binding(keymap.CommandsSelect),
+		Next:     keymap.Binding(keymap.CommandsNext),
+		Previous: keymap.Binding(keymap.CommandsPrevious),
+		Tab:      keymap.Binding(keymap.CommandsTab),
+		Close:    keymap.Binding(keymap.CommandsClose),
 	}
 }
 
@@ -63,10 +49,7 @@ func (k CommandsDialogKeyMap) FullHelp() [][]key.Binding {
 func (k CommandsDialogKeyMap) ShortHelp() []key.Binding {
 	return []key.Binding{
 		k.Tab,
-		key.NewBinding(
-			key.WithKeys("down", "up"),
-			key.WithHelp("↑↓", "choose"),
-		),
+		keymap.Combined("↑↓", "choose", keymap.CommandsPrevious, keymap.CommandsNext),
 		k.Select,
 		k.Close,
 	}
@@ -81,23 +64,10 @@ type ArgumentsDialogKeyMap struct {
 
 func DefaultArgumentsDialogKeyMap() ArgumentsDialogKeyMap {
 	return ArgumentsDialogKeyMap{
-		Confirm: key.NewBinding(
-			key.WithKeys("enter"),
-			key.WithHelp("enter", "confirm"),
-		),
-
-		Next: key.NewBinding(
-			key.WithKeys("tab", "down"),
-			key.WithHelp("tab/↓", "next"),
-		),
-		Previous: key.NewBinding(
-			key.WithKeys("shift+tab", "up"),
-			key.WithHelp("shift+tab/↑", "previous"),
-		),
-		Close: key.NewBinding(
-			key.WithKeys("esc", "alt+esc"),
-			key.WithHelp("esc", "cancel"),
-		),
+		Confirm:  keymap.Binding(keymap.ArgumentsConfirm),
+		Next:     keymap.Binding(keymap.ArgumentsNext),
+		Previous: keymap.Binding(keymap.ArgumentsPrevious),
+		Close:    keymap.Binding(keymap.ArgumentsClose),
 	}
 }
 
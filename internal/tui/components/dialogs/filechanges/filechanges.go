@@ -0,0 +1,320 @@
+package filechanges
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+	tea "github.com/uglyswap/push/internal/compat/bubbletea"
+
+	"github.com/uglyswap/push/internal/diff"
+	"github.com/uglyswap/push/internal/fsext"
+	"github.com/uglyswap/push/internal/history"
+	"github.com/uglyswap/push/internal/message"
+	"github.com/uglyswap/push/internal/tui/components/chat"
+	"github.com/uglyswap/push/internal/tui/components/core"
+	"github.com/uglyswap/push/internal/tui/components/dialogs"
+	"github.com/uglyswap/push/internal/tui/styles"
+	"github.com/uglyswap/push/internal/tui/util"
+)
+
+const (
+	FileChangesDialogID dialogs.DialogID = "filechanges"
+
+	// maxListHeight is the number of files shown above the diff.
+	maxListHeight = 8
+)
+
+// FileChangesDialog lists the files a session changed with the cumulative
+// diff of the selected one, and steps through its versions.
+type FileChangesDialog interface {
+	dialogs.DialogModel
+}
+
+// fileChanges holds the versions of a file recorded during a session.
+type fileChanges struct {
+	path     string
+	versions []history.File
+	// calls holds the ID of the tool call that wrote each version, empty
+	// when it is not known.
+	calls []string
+
+	additions, removals int
+}
+
+type fileChangesDialogCmp struct {
+	wWidth  int
+	wHeight int
+	width   int
+	height  int
+
+	files    []fileChanges
+	selected int
+	// version is the version of the selected file whose changes are shown,
+	// or 0 to show all of them.
+	version int
+
+	defaultDiffSplitMode bool
+	diffSplitMode        *bool // nil means use defaultDiffSplitMode
+
+	lines   []string
+	yOffset int
+	keyMap  KeyMap
+}
+
+// NewFileChangesDialog creates a dialog showing the changes recorded in the
+// history of a session, files, relating each version to the tool call of
+// msgs that wrote it.
+func NewFileChangesDialog(files []history.File, msgs []message.Message) FileChangesDialog {
+	return &fileChangesDialogCmp{
+		files:  groupFiles(files, msgs),
+		keyMap: DefaultKeyMap(),
+	}
+}
+
+// groupFiles groups the versions of files by path, keeping the files whose
+// content changed.
+func groupFiles(files []history.File, msgs []message.Message) []fileChanges {
+	byPath := map[string]*fileChanges{}
+	var paths []string
+	for _, f := range files {
+		fc, ok := byPath[f.Path]
+		if !ok {
+			fc = &fileChanges{path: f.Path}
+			byPath[f.Path] = fc
+			paths = append(paths, f.Path)
+		}
+		fc.versions = append(fc.versions, f)
+	}
+	slices.Sort(paths)
+
+	// The tool calls of deleted messages can no longer be shown.
+	calls := map[string]bool{}
+	for _, msg := range msgs {
+		for _, tc := range msg.ToolCalls() {
+			calls[tc.ID] = true
+		}
+	}
+
+	var grouped []fileChanges
+	for _, path := range paths {
+		fc := byPath[path]
+		slices.SortStableFunc(fc.versions, func(a, b history.File) int {
+			return int(a.Version - b.Version)
+		})
+		first, last := fc.versions[0], fc.versions[len(fc.versions)-1]
+		if first.Content == last.Content {
+			continue
+		}
+		_, fc.additions, fc.removals = diff.GenerateDiff(first.Content, last.Content, path)
+
+		fc.calls = make([]string, len(fc.versions))
+		for i, v := range fc.versions {
+			if calls[v.ToolCallID] {
+				fc.calls[i] = v.ToolCallID
+			}
+		}
+		grouped = append(grouped, *fc)
+	}
+	return grouped
+}
+
+func (c *fileChangesDialogCmp) Init() tea.Cmd {
+	return nil
+}
+
+func (c *fileChangesDialogCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		c.wWidth = msg.Width
+		c.wHeight = msg.Height
+		c.width = min(int(float64(c.wWidth)*0.8), 180)
+		c.height = int(float64(c.wHeight) * 0.8)
+		c.defaultDiffSplitMode = c.width >= 140
+		c.lines = nil
+	case tea.KeyMsg:
+		switch {
+		case key.Matches(msg, c.keyMap.Close):
+			return c, util.CmdHandler(dialogs.CloseDialogMsg{})
+		case key.Matches(msg, c.keyMap.Next):
+			c.selectFile(c.selected + 1)
+		case key.Matches(msg, c.keyMap.Previous):
+			c.selectFile(c.selected - 1)
+		case key.Matches(msg, c.keyMap.NextVersion):
+			c.selectVersion(c.version + 1)
+		case key.Matches(msg, c.keyMap.PreviousVersion):
+			c.selectVersion(c.version - 1)
+		case key.Matches(msg, c.keyMap.ScrollUp):
+			c.scroll(-c.diffHeight())
+		case key.Matches(msg, c.keyMap.ScrollDown):
+			c.scroll(c.diffHeight())
+		case key.Matches(msg, c.keyMap.ToggleDiffMode):
+			split := !c.useDiffSplitMode()
+			c.diffSplitMode = &split
+			c.lines = nil
+		case key.Matches(msg, c.keyMap.GoToMessage):
+			return c, c.goToMessage()
+		}
+	}
+	return c, nil
+}
+
+func (c *fileChangesDialogCmp) selectFile(i int) {
+	if i < 0 || i >= len(c.files) || i == c.selected {
+		return
+	}
+	c.selected = i
+	c.version = 0
+	c.yOffset = 0
+	c.lines = nil
+}
+
+func (c *fileChangesDialogCmp) selectVersion(v int) {
+	if len(c.files) == 0 || v < 0 || v >= len(c.files[c.selected].versions) || v == c.version {
+		return
+	}
+	c.version = v
+	c.yOffset = 0
+	c.lines = nil
+}
+
+// goToMessage closes the dialog and shows the tool call that wrote the shown
+// version, or the last version when all the changes are shown.
+func (c *fileChangesDialogCmp) goToMessage() tea.Cmd {
+	if len(c.files) == 0 {
+		return nil
+	}
+	fc := c.files[c.selected]
+	v := c.version
+	if v == 0 {
+		v = len(fc.versions) - 1
+	}
+	if fc.calls[v] == "" {
+		return util.ReportWarn("No message found for this version")
+	}
+	return tea.Sequence(
+		util.CmdHandler(dialogs.CloseDialogMsg{}),
+		util.CmdHandler(chat.GoToMessageMsg{ID: fc.calls[v]}),
+	)
+}
+
+func (c *fileChangesDialogCmp) useDiffSplitMode() bool {
+	if c.diffSplitMode != nil {
+		return *c.diffSplitMode
+	}
+	return c.defaultDiffSplitMode
+}
+
+func (c *fileChangesDialogCmp) scroll(delta int) {
+	c.yOffset = max(0, min(c.yOffset+delta, len(c.diffLines())-c.diffHeight()))
+}
+
+func (c *fileChangesDialogCmp) listHeight() int {
+	return min(len(c.files), maxListHeight)
+}
+
+// diffHeight is the number of diff lines shown at once, the dialog height
+// minus the title, the list, the version line, help and borders.
+func (c *fileChangesDialogCmp) diffHeight() int {
+	return max(5, c.height-c.listHeight()-10)
+}
+
+// diffLines renders the diff of the shown version of the selected file.
+func (c *fileChangesDialogCmp) diffLines() []string {
+	if c.lines != nil || len(c.files) == 0 {
+		return c.lines
+	}
+	fc := c.files[c.selected]
+	before, after := fc.versions[0], fc.versions[len(fc.versions)-1]
+	if c.version > 0 {
+		before, after = fc.versions[c.version-1], fc.versions[c.version]
+	}
+	path := fsext.PrettyPath(fc.path)
+	formatter := core.DiffFormatter().
+		Before(path, before.Content).
+		After(path, after.Content).
+		Width(c.width - 4)
+	if c.useDiffSplitMode() {
+		formatter = formatter.Split()
+	} else {
+		formatter = formatter.Unified()
+	}
+	c.lines = strings.Split(formatter.String(), "\n")
+	return c.lines
+}
+
+func (c *fileChangesDialogCmp) renderList() string {
+	t := styles.CurrentTheme()
+	if len(c.files) == 0 {
+		return t.S().Subtle.Render("No files changed in this session yet.")
+	}
+
+	// Keep the selected file in view.
+	start := max(0, c.selected-c.listHeight()+1)
+	rows := make([]string, 0, c.listHeight())
+	for i, fc := range c.files[start : start+c.listHeight()] {
+		row := fmt.Sprintf("%s  +%d -%d", fsext.PrettyPath(fc.path), fc.additions, fc.removals)
+		row = ansi.Truncate(row, c.width-6, "…")
+		if start+i == c.selected {
+			rows = append(rows, t.S().TextSelected.Width(c.width-4).Render(row))
+		} else {
+			rows = append(rows, t.S().Text.Render(row))
+		}
+	}
+	return strings.Join(rows, "\n")
+}
+
+// renderVersion tells which changes of the selected file are shown.
+func (c *fileChangesDialogCmp) renderVersion() string {
+	t := styles.CurrentTheme()
+	fc := c.files[c.selected]
+	last := len(fc.versions) - 1
+	if c.version == 0 {
+		return t.S().Muted.Render(fmt.Sprintf("All changes, %d versions", last))
+	}
+	at := time.Unix(fc.versions[c.version].CreatedAt, 0).Local().Format("15:04:05")
+	return t.S().Muted.Render(fmt.Sprintf("Version %d of %d, written at %s", c.version, last, at))
+}
+
+func (c *fileChangesDialogCmp) View() string {
+	t := styles.CurrentTheme()
+	baseStyle := t.S().Base
+
+	parts := []string{
+		core.Title("File Changes", c.width-4),
+		"",
+		c.renderList(),
+	}
+	if lines := c.diffLines(); len(lines) > 0 {
+		end := min(len(lines), c.yOffset+c.diffHeight())
+		parts = append(parts,
+			"",
+			c.renderVersion(),
+			"",
+			strings.Join(lines[c.yOffset:end], "\n"),
+		)
+	}
+	parts = append(parts, "", help.New().View(c.keyMap))
+
+	return baseStyle.
+		Padding(0, 1).
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(styles.TC(t.BorderFocus)).
+		Width(c.width).
+		Render(lipgloss.JoinVertical(lipgloss.Top, parts...))
+}
+
+func (c *fileChangesDialogCmp) Position() (int, int) {
+	row := c.wHeight/2 - c.height/2
+	col := c.wWidth/2 - c.width/2
+	return row, col
+}
+
+func (c *fileChangesDialogCmp) ID() dialogs.DialogID {
+	return FileChangesDialogID
+}
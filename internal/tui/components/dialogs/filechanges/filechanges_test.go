@@ -0,0 +1,46 @@
+package filechanges
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/uglyswap/push/internal/history"
+	"github.com/uglyswap/push/internal/message"
+)
+
+func TestGroupFiles(t *testing.T) {
+	t.Parallel()
+
+	files := []history.File{
+		{Path: "/work/a.go", Version: 0, Content: "one\n"},
+		{Path: "/work/b.go", Version: 0, Content: "same\n"},
+		{Path: "/work/b.go", Version: 1, Content: "same\n", ToolCallID: "call-b"},
+		{Path: "/work/a.go", Version: 1, Content: "one\ntwo\n", ToolCallID: "call-1"},
+		{Path: "/work/a.go", Version: 3, Content: "three\n", ToolCallID: "call-deleted"},
+		{Path: "/work/a.go", Version: 2, Content: "two\n", ToolCallID: "call-2"},
+	}
+	msgs := []message.Message{
+		{
+			Role: message.Assistant,
+			Parts: []message.ContentPart{
+				message.ToolCall{ID: "call-1", Name: "edit", Input: `{"file_path":"/work/a.go"}`},
+				message.ToolCall{ID: "call-b", Name: "edit", Input: `{"file_path":"/work/b.go"}`},
+			},
+		},
+		{
+			Role: message.Assistant,
+			Parts: []message.ContentPart{
+				message.ToolCall{ID: "call-2", Name: "write", Input: `{"file_path":"/work/a.go"}`},
+			},
+		},
+	}
+
+	grouped := groupFiles(files, msgs)
+	require.Len(t, grouped, 1, "files without changes are left out")
+	require.Equal(t, "/work/a.go", grouped[0].path)
+	require.Len(t, grouped[0].versions, 4)
+	require.Equal(t, []string{"", "call-1", "call-2", ""}, grouped[0].calls, "the calls of deleted messages are not shown")
+	require.Equal(t, 1, grouped[0].additions)
+	require.Equal(t, 1, grouped[0].removals)
+}
@@ -0,0 +1,74 @@
+package filechanges
+
+import (
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/uglyswap/push/internal/tui/keymap"
+)
+
+// KeyMap defines the keyboard bindings for the file changes dialog.
+type KeyMap struct {
+	Next,
+	Previous,
+	NextVersion,
+	PreviousVersion,
+	ScrollUp,
+	ScrollDown,
+	ToggleDiffMode,
+	GoToMessage,
+	Close key.Binding
+}
+
+func DefaultKeyMap() KeyMap {
+	return KeyMap{
+		Next:            keymap.Binding(keymap.FileChangesNext),
+		Previous:        keymap.Binding(keymap.FileChangesPrevious),
+		NextVersion:     keymap.Binding(keymap.FileChangesNextVersion),
+		PreviousVersion: keymap.Binding(keymap.FileChangesPreviousVersion),
+		ScrollUp:        keymap.Binding(keymap.FileChangesScrollUp),
+		ScrollDown:      keymap.Binding(keymap.FileChangesScrollDown),
+		ToggleDiffMode:  keymap.Binding(keymap.FileChangesToggleDiffMode),
+		GoToMessage:     keymap.Binding(keymap.FileChangesGoToMessage),
+		Close:           keymap.Binding(keymap.FileChangesClose),
+	}
+}
+
+// KeyBindings implements layout.KeyMapProvider
+func (k KeyMap) KeyBindings() []key.Binding {
+	return []key.Binding{
+		k.Next,
+		k.Previous,
+		k.NextVersion,
+		k.PreviousVersion,
+		k.ScrollUp,
+		k.ScrollDown,
+		k.ToggleDiffMode,
+		k.GoToMessage,
+		k.Close,
+	}
+}
+
+// FullHelp implements help.KeyMap.
+func (k KeyMap) FullHelp() [][]key.Binding {
+	m := [][]key.Binding{}
+	slice := k.KeyBindings()
+	for i := 0; i < len(slice); i += 4 {
+		end := min(i+4, len(slice))
+		m = append(m, slice[i:end])
+	}
+	return m
+}
+
+// ShortHelp implements help.KeyMap.
+func (k KeyMap) ShortHelp() []key.Binding {
+	return []key.Binding{
+		k.Next,
+		keymap.Combined("←→", "versions",
+			keymap.FileChangesPreviousVersion,
+			keymap.FileChangesNextVersion,
+		),
+		k.ScrollDown,
+		k.ToggleDiffMode,
+		k.GoToMessage,
+		k.Close,
+	}
+}
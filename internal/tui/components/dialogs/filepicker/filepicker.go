@@ -1,10 +1,12 @@
 package filepicker
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 
 	"github.com/charmbracelet/bubbles/filepicker"
@@ -12,6 +14,7 @@ import (
 	"github.com/charmbracelet/bubbles/key"
 	tea "github.com/uglyswap/push/internal/compat/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/uglyswap/push/internal/agent/tools/mcp"
 	compat_filepicker "github.com/uglyswap/push/internal/compat/bubbles/filepicker"
 	"github.com/uglyswap/push/internal/home"
 	"github.com/uglyswap/push/internal/message"
@@ -46,6 +49,17 @@ type model struct {
 	image           image.Model
 	keyMap          KeyMap
 	help            help.Model
+
+	// showResources switches the dialog to the resources published by the
+	// connected MCP servers.
+	showResources  bool
+	resources      []resourceItem
+	resourceCursor int
+}
+
+type resourceItem struct {
+	server   string
+	resource *mcp.Resource
 }
 
 var AllowedTypes = []string{".jpg", ".jpeg", ".png"}
@@ -106,6 +120,17 @@ func (m *model) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 		if key.Matches(msg, m.keyMap.Close) {
 			return m, util.CmdHandler(dialogs.CloseDialogMsg{})
 		}
+		if key.Matches(msg, m.keyMap.Resources) {
+			m.showResources = !m.showResources
+			if m.showResources {
+				m.resources = listResources()
+				m.resourceCursor = 0
+			}
+			return m, nil
+		}
+		if m.showResources {
+			return m, m.updateResources(msg)
+		}
 		if key.Matches(msg, m.filePicker.KeyMap.Back) {
 			// make sure we don't go back if we are at the home directory
 			if m.filePicker.CurrentDirectory == home.Dir() {
@@ -159,9 +184,98 @@ func (m *model) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 	return m, tea.Batch(cmds...)
 }
 
+// updateResources handles the keys of the MCP resources list.
+func (m *model) updateResources(msg tea.KeyMsg) tea.Cmd {
+	switch {
+	case key.Matches(msg, m.keyMap.Down):
+		if m.resourceCursor < len(m.resources)-1 {
+			m.resourceCursor++
+		}
+	case key.Matches(msg, m.keyMap.Up):
+		if m.resourceCursor > 0 {
+			m.resourceCursor--
+		}
+	case key.Matches(msg, m.keyMap.Select):
+		if len(m.resources) == 0 {
+			return nil
+		}
+		item := m.resources[m.resourceCursor]
+		return tea.Sequence(
+			util.CmdHandler(dialogs.CloseDialogMsg{}),
+			func() tea.Msg {
+				contents, err := mcp.ReadResource(context.Background(), item.server, item.resource.URI)
+				if err != nil {
+					return util.ReportError(fmt.Errorf("unable to read the resource: %w", err))
+				}
+				attachment, err := mcp.ResourceAttachment(item.resource.Name, contents)
+				if err != nil {
+					return util.ReportError(fmt.Errorf("unable to read the resource: %w", err))
+				}
+				if int64(len(attachment.Content)) > MaxAttachmentSize {
+					return util.ReportError(fmt.Errorf("resource too large, max 5MB"))
+				}
+				return FilePickedMsg{
+					Attachment: attachment,
+				}
+			},
+		)
+	}
+	return nil
+}
+
+func listResources() []resourceItem {
+	var items []resourceItem
+	for server, resources := range mcp.Resources() {
+		for _, resource := range resources {
+			items = append(items, resourceItem{server: server, resource: resource})
+		}
+	}
+	slices.SortFunc(items, func(a, b resourceItem) int {
+		if c := strings.Compare(a.server, b.server); c != 0 {
+			return c
+		}
+		return strings.Compare(a.resource.URI, b.resource.URI)
+	})
+	return items
+}
+
+func (m *model) resourcesView() string {
+	t := styles.CurrentTheme()
+	if len(m.resources) == 0 {
+		return t.S().Subtle.PaddingLeft(1).Height(fileSelectionHeight).Render("No MCP resources available")
+	}
+
+	start := max(0, m.resourceCursor-fileSelectionHeight+1)
+	end := min(len(m.resources), start+fileSelectionHeight)
+	lines := make([]string, 0, end-start)
+	for i, item := range m.resources[start:end] {
+		name := item.resource.Name
+		if name == "" {
+			name = item.resource.URI
+		}
+		line := fmt.Sprintf("%s %s", name, t.S().Subtle.Render(item.server))
+		if start+i == m.resourceCursor {
+			lines = append(lines, m.filePicker.Styles.Selected.Render(fmt.Sprintf("%s (%s)", name, item.server)))
+			continue
+		}
+		lines = append(lines, t.S().Base.PaddingLeft(1).Render(line))
+	}
+	return t.S().Base.Height(fileSelectionHeight).Render(strings.Join(lines, "\n"))
+}
+
 func (m *model) View() string {
 	t := styles.CurrentTheme()
 
+	if m.showResources {
+		content := lipgloss.JoinVertical(
+			lipgloss.Left,
+			t.S().Base.Padding(0, 1, 1, 1).Render(core.Title("Add MCP Resource", m.width-4)),
+			m.resourcesView(),
+			t.S().Base.Width(m.width-2).PaddingLeft(1).AlignHorizontal(lipgloss.Left).Render(m.help.View(m.keyMap)),
+		)
+		return m.style().Render(content)
+	}
+
 	strs := []string{
 		t.S().Base.Padding(0, 1, 1, 1).Render(core.Title("Add Image", m.width-4)),
 	}
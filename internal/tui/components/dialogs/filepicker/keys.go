@@ -2,6 +2,7 @@ package filepicker
 
 import (
 	"github.com/charmbracelet/bubbles/key"
+	"github.com/uglyswap/push/internal/tui/keymap"
 )
 
 // KeyMap defines keyboard bindings for dialog management.
@@ -11,36 +12,19 @@ type KeyMap struct {
 	Up,
 	Forward,
 	Backward,
+	Resources,
 	Close key.Binding
 }
 
 func DefaultKeyMap() KeyMap {
 	return KeyMap{
-		Select: key.NewBinding(
-			key.WithKeys("enter"),
-			key.WithHelp("enter", "accept"),
-		),
-		Down: key.NewBinding(
-			key.WithKeys("down", "j"),
-			key.WithHelp("down/j", "move down"),
-		),
-		Up: key.NewBinding(
-			key.WithKeys("up", "k"),
-			key.WithHelp("up/k", "move up"),
-		),
-		Forward: key.NewBinding(
-			key.WithKeys("right", "l"),
-			key.WithHelp("right/l", "move forward"),
-		),
-		Backward: key.NewBinding(
-			key.WithKeys("left", "h"),
-			key.WithHelp("left/h", "move backward"),
-		),
-
-		Close: key.NewBinding(
-			key.WithKeys("esc", "alt+esc"),
-			key.WithHelp("esc", "close/exit"),
-		),
+		Select:    keymap.Binding(keymap.FilePickerSelect),
+		Down:      keymap.Binding(keymap.FilePickerDown),
+		Up:        keymap.Binding(keymap.FilePickerUp),
+		Forward:   keymap.Binding(keymap.FilePickerForward),
+		Backward:  keymap.Binding(keymap.FilePickerBackward),
+		Resources: keymap.Binding(keymap.FilePickerResources),
+		Close:     keymap.Binding(keymap.FilePickerClose),
 	}
 }
 
@@ -52,6 +36,7 @@ func (k KeyMap) KeyBindings() []key.Binding {
 		k.Up,
 		k.Forward,
 		k.Backward,
+		k.Resources,
 		k.Close,
 	}
 }
@@ -70,11 +55,14 @@ func (k KeyMap) FullHelp() [][]key.Binding {
 // ShortHelp implements help.KeyMap.
 func (k KeyMap) ShortHelp() []key.Binding {
 	return []key.Binding{
-		key.NewBinding(
-			key.WithKeys("right", "l", "left", "h", "up", "k", "down", "j"),
-			key.WithHelp("↑↓←→", "navigate"),
+		keymap.Combined("↑↓←→", "navigate",
+			keymap.FilePickerUp,
+			keymap.FilePickerDown,
+			keymap.FilePickerBackward,
+			keymap.FilePickerForward,
 		),
 		k.Select,
+		k.Resources,
 		k.Close,
 	}
 }
@@ -0,0 +1,197 @@
+package keybindings
+
+import (
+	"fmt"
+
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/lipgloss"
+	tea "github.com/uglyswap/push/internal/compat/bubbletea"
+
+	"github.com/uglyswap/push/internal/tui/components/core"
+	"github.com/uglyswap/push/internal/tui/components/dialogs"
+	"github.com/uglyswap/push/internal/tui/exp/list"
+	"github.com/uglyswap/push/internal/tui/keymap"
+	"github.com/uglyswap/push/internal/tui/styles"
+	"github.com/uglyswap/push/internal/tui/util"
+)
+
+const (
+	KeybindingsDialogID dialogs.DialogID = "keybindings"
+
+	defaultWidth int = 70
+)
+
+type listModel = list.FilterableGroupList[list.CompletionItem[keymap.Info]]
+
+type KeybindingsDialog interface {
+	dialogs.DialogModel
+}
+
+// keybindingsDialogCmp lists the effective keybindings, by scope, with the
+// action names to use in the tui.keybindings configuration.
+type keybindingsDialogCmp struct {
+	width   int
+	wWidth  int // Width of the terminal window
+	wHeight int // Height of the terminal window
+
+	bindingList listModel
+	keyMap      KeybindingsDialogKeyMap
+	help        help.Model
+}
+
+type KeybindingsDialogKeyMap struct {
+	Next     key.Binding
+	Previous key.Binding
+	Close    key.Binding
+}
+
+func DefaultKeybindingsDialogKeyMap() KeybindingsDialogKeyMap {
+	return KeybindingsDialogKeyMap{
+		Next:     keymap.Binding(keymap.KeybindingsNext),
+		Previous: keymap.Binding(keymap.KeybindingsPrevious),
+		Close:    keymap.Binding(keymap.KeybindingsClose),
+	}
+}
+
+func (k KeybindingsDialogKeyMap) ShortHelp() []key.Binding {
+	return []key.Binding{k.Next, k.Previous, k.Close}
+}
+
+func (k KeybindingsDialogKeyMap) FullHelp() [][]key.Binding {
+	return [][]key.Binding{
+		{k.Next, k.Previous, k.Close},
+	}
+}
+
+func NewKeybindingsDialog() KeybindingsDialog {
+	keyMap := DefaultKeybindingsDialogKeyMap()
+	listKeyMap := list.DefaultKeyMap()
+	listKeyMap.Down.SetEnabled(false)
+	listKeyMap.Up.SetEnabled(false)
+	listKeyMap.DownOneItem = keyMap.Next
+	listKeyMap.UpOneItem = keyMap.Previous
+
+	t := styles.CurrentTheme()
+	inputStyle := t.S().Base.PaddingLeft(1).PaddingBottom(1)
+	bindingList := list.NewFilterableGroupedList(
+		[]list.Group[list.CompletionItem[keymap.Info]]{},
+		list.WithFilterInputStyle(inputStyle),
+		list.WithFilterPlaceholder("Type to filter keybindings"),
+		list.WithFilterListOptions(
+			list.WithKeyMap(listKeyMap),
+			list.WithWrapNavigation(),
+			list.WithResizeByList(),
+		),
+	)
+	help := help.New()
+	help.Styles = t.S().Help
+
+	return &keybindingsDialogCmp{
+		bindingList: bindingList,
+		width:       defaultWidth,
+		keyMap:      keyMap,
+		help:        help,
+	}
+}
+
+func (d *keybindingsDialogCmp) Init() tea.Cmd {
+	var groups []list.Group[list.CompletionItem[keymap.Info]]
+	infos := keymap.Actions()
+	for _, scope := range keymap.Scopes() {
+		group := list.Group[list.CompletionItem[keymap.Info]]{
+			Section: list.NewItemSection(scope.Title),
+		}
+		for _, info := range infos {
+			if info.Scope != scope.Name {
+				continue
+			}
+			shortcut := info.HelpKey
+			if len(info.Keys) == 0 {
+				shortcut = "disabled"
+			}
+			group.Items = append(group.Items, list.NewCompletionItem(
+				fmt.Sprintf("%s (%s)", info.Desc, info.Action),
+				info,
+				list.WithCompletionID(string(info.Action)),
+				list.WithCompletionShortcut(shortcut),
+			))
+		}
+		if len(group.Items) > 0 {
+			groups = append(groups, group)
+		}
+	}
+	return d.bindingList.SetGroups(groups)
+}
+
+func (d *keybindingsDialogCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		d.wWidth = msg.Width
+		d.wHeight = msg.Height
+		return d, d.bindingList.SetSize(d.listWidth(), d.listHeight())
+	case tea.KeyMsg:
+		switch {
+		case key.Matches(msg, d.keyMap.Close):
+			return d, util.CmdHandler(dialogs.CloseDialogMsg{})
+		default:
+			u, cmd := d.bindingList.Update(msg)
+			d.bindingList = u.(listModel)
+			return d, cmd
+		}
+	}
+	return d, nil
+}
+
+func (d *keybindingsDialogCmp) View() string {
+	t := styles.CurrentTheme()
+	header := t.S().Base.Padding(0, 1, 1, 1).Render(core.Title("Keybindings", d.width-4))
+	content := lipgloss.JoinVertical(
+		lipgloss.Left,
+		header,
+		d.bindingList.View(),
+		"",
+		t.S().Base.Width(d.width-2).PaddingLeft(1).AlignHorizontal(lipgloss.Left).Render(d.help.View(d.keyMap)),
+	)
+	return d.style().Render(content)
+}
+
+func (d *keybindingsDialogCmp) Cursor() *util.Cursor {
+	if cursorProvider, ok := d.bindingList.(util.CursorProvider); ok {
+		cursor := cursorProvider.Cursor()
+		if cursor != nil {
+			row, col := d.Position()
+			cursor.Y += row + 3
+			cursor.X += col + 2
+		}
+		return cursor
+	}
+	return nil
+}
+
+func (d *keybindingsDialogCmp) listWidth() int {
+	return d.width - 2
+}
+
+func (d *keybindingsDialogCmp) listHeight() int {
+	return d.wHeight / 2
+}
+
+func (d *keybindingsDialogCmp) style() lipgloss.Style {
+	t := styles.CurrentTheme()
+	return t.S().Base.
+		Width(d.width).
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(styles.TC(t.BorderFocus))
+}
+
+func (d *keybindingsDialogCmp) Position() (int, int) {
+	row := d.wHeight/4 - 2 // just a bit above the center
+	col := d.wWidth / 2
+	col -= d.width / 2
+	return row, col
+}
+
+func (d *keybindingsDialogCmp) ID() dialogs.DialogID {
+	return KeybindingsDialogID
+}
@@ -2,6 +2,7 @@ package dialogs
 
 import (
 	"github.com/charmbracelet/bubbles/key"
+	"github.com/uglyswap/push/internal/tui/keymap"
 )
 
 // KeyMap defines keyboard bindings for dialog management.
@@ -11,9 +12,7 @@ type KeyMap struct {
 
 func DefaultKeyMap() KeyMap {
 	return KeyMap{
-		Close: key.NewBinding(
-			key.WithKeys("esc", "alt+esc"),
-		),
+		Close: keymap.Binding(keymap.DialogClose),
 	}
 }
 
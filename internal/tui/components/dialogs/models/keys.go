@@ -2,6 +2,7 @@ package models
 
 import (
 	"github.com/charmbracelet/bubbles/key"
+	"github.com/uglyswap/push/internal/tui/keymap"
 )
 
 type KeyMap struct {
@@ -10,6 +11,7 @@ type KeyMap struct {
 	Previous,
 	Choose,
 	Tab,
+	Copy,
 	Close key.Binding
 
 	isAPIKeyHelp  bool
@@ -27,30 +29,13 @@ type KeyMap struct {
 
 func DefaultKeyMap() KeyMap {
 	return KeyMap{
-		Select: key.NewBinding(
-			key.WithKeys("enter", "ctrl+y"),
-			key.WithHelp("enter", "choose"),
-		),
-		Next: key.NewBinding(
-			key.WithKeys("down", "ctrl+n"),
-			key.WithHelp("↓", "next item"),
-		),
-		Previous: key.NewBinding(
-			key.WithKeys("up", "ctrl+p"),
-			key.WithHelp("↑", "previous item"),
-		),
-		Choose: key.NewBinding(
-			key.WithKeys("left", "right", "h", "l"),
-			key.WithHelp("←→", "choose"),
-		),
-		Tab: key.NewBinding(
-			key.WithKeys("tab"),
-			key.WithHelp("tab", "toggle type"),
-		),
-		Close: key.NewBinding(
-			key.WithKeys("esc", "alt+esc"),
-			key.WithHelp("esc", "exit"),
-		),
+		Select:   keymap.Binding(keymap.ModelsSelect),
+		Next:     keymap.Binding(keymap.ModelsNext),
+		Previous: keymap.Binding(keymap.ModelsPrevious),
+		Choose:   keymap.Binding(keymap.ModelsChoose),
+		Tab:      keymap.Binding(keymap.ModelsTab),
+		Copy:     keymap.Binding(keymap.ModelsCopy),
+		Close:    keymap.Binding(keymap.ModelsClose),
 	}
 }
 
@@ -80,49 +65,28 @@ func (k KeyMap) FullHelp() [][]key.Binding {
 func (k KeyMap) ShortHelp() []key.Binding {
 	if k.isHyperDeviceFlow || k.isCopilotDeviceFlow {
 		return []key.Binding{
-			key.NewBinding(
-				key.WithKeys("c"),
-				key.WithHelp("c", "copy code"),
-			),
-			key.NewBinding(
-				key.WithKeys("enter"),
-				key.WithHelp("enter", "copy & open"),
-			),
+			keymap.Help(keymap.ModelsCopy, "copy code"),
+			keymap.Help(keymap.ModelsSelect, "copy & open"),
 			k.Close,
 		}
 	}
 	if k.isCopilotUnavailable {
 		return []key.Binding{
-			key.NewBinding(
-				key.WithKeys("enter"),
-				key.WithHelp("enter", "open signup"),
-			),
+			keymap.Help(keymap.ModelsSelect, "open signup"),
 			k.Close,
 		}
 	}
 	if k.isClaudeAuthChoiceHelp {
 		return []key.Binding{
-			key.NewBinding(
-				key.WithKeys("left", "right", "h", "l"),
-				key.WithHelp("←→", "choose"),
-			),
-			key.NewBinding(
-				key.WithKeys("enter"),
-				key.WithHelp("enter", "accept"),
-			),
-			key.NewBinding(
-				key.WithKeys("esc"),
-				key.WithHelp("esc", "back"),
-			),
+			k.Choose,
+			keymap.Help(keymap.ModelsSelect, "accept"),
+			keymap.Help(keymap.ModelsClose, "back"),
 		}
 	}
 	if k.isClaudeOAuthHelp {
 		if k.isClaudeOAuthHelpComplete {
 			return []key.Binding{
-				key.NewBinding(
-					key.WithKeys("enter"),
-					key.WithHelp("enter", "close"),
-				),
+				keymap.Help(keymap.ModelsSelect, "close"),
 			}
 		}
 
@@ -132,32 +96,20 @@ func (k KeyMap) ShortHelp() []key.Binding {
 		}
 
 		bindings := []key.Binding{
-			key.NewBinding(
-				key.WithKeys("enter"),
-				key.WithHelp("enter", enterHelp),
-			),
+			keymap.Help(keymap.ModelsSelect, enterHelp),
 		}
 
 		if k.isClaudeOAuthURLState {
-			bindings = append(bindings, key.NewBinding(
-				key.WithKeys("c"),
-				key.WithHelp("c", "copy url"),
-			))
+			bindings = append(bindings, keymap.Help(keymap.ModelsCopy, "copy url"))
 		}
 
-		bindings = append(bindings, key.NewBinding(
-			key.WithKeys("esc"),
-			key.WithHelp("esc", "back"),
-		))
+		bindings = append(bindings, keymap.Help(keymap.ModelsClose, "back"))
 
 		return bindings
 	}
 	if k.isAPIKeyHelp && !k.isAPIKeyValid {
 		return []key.Binding{
-			key.NewBinding(
-				key.WithKeys("enter"),
-				key.WithHelp("enter", "submit"),
-			),
+			keymap.Help(keymap.ModelsSelect, "submit"),
 			k.Close,
 		}
 	} else if k.isAPIKeyValid {
@@ -166,10 +118,7 @@ func (k KeyMap) ShortHelp() []key.Binding {
 		}
 	}
 	return []key.Binding{
-		key.NewBinding(
-			key.WithKeys("down", "up"),
-			key.WithHelp("↑↓", "choose"),
-		),
+		keymap.Combined("↑↓", "choose", keymap.ModelsPrevious, keymap.ModelsNext),
 		k.Tab,
 		k.Select,
 		k.Close,
@@ -46,6 +46,9 @@ type ModelSelectedMsg struct {
 	ModelType config.SelectedModelType
 }
 
+// AgentModelUpdatedMsg is sent once the agent uses the selected model.
+type AgentModelUpdatedMsg struct{}
+
 // CloseModelDialogMsg is sent when a model is selected
 type CloseModelDialogMsg struct{}
 
@@ -176,11 +179,11 @@ func (m *modelDialogCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 	case tea.KeyMsg:
 		switch {
 		// Handle Hyper device flow keys
-		case key.Matches(msg, key.NewBinding(key.WithKeys("c", "C"))) && m.showHyperDeviceFlow:
+		case key.Matches(msg, m.keyMap.Copy) && m.showHyperDeviceFlow:
 			return m, m.hyperDeviceFlow.CopyCode()
-		case key.Matches(msg, key.NewBinding(key.WithKeys("c", "C"))) && m.showCopilotDeviceFlow:
+		case key.Matches(msg, m.keyMap.Copy) && m.showCopilotDeviceFlow:
 			return m, m.copilotDeviceFlow.CopyCode()
-		case key.Matches(msg, key.NewBinding(key.WithKeys("c", "C"))) && m.showClaudeOAuth2 && m.claudeOAuth2.State == claude.OAuthStateURL:
+		case key.Matches(msg, m.keyMap.Copy) && m.showClaudeOAuth2 && m.claudeOAuth2.State == claude.OAuthStateURL:
 			_ = clipboard.WriteAll(m.claudeOAuth2.URL)
 			return m, util.ReportInfo("URL copied to clipboard")
 		case key.Matches(msg, m.keyMap.Choose) && m.showClaudeAuthMethodChooser:
@@ -2,6 +2,7 @@ package permissions
 
 import (
 	"github.com/charmbracelet/bubbles/key"
+	"github.com/uglyswap/push/internal/tui/keymap"
 )
 
 type KeyMap struct {
@@ -17,58 +18,32 @@ type KeyMap struct {
 	ScrollUp key.Binding
 	ScrollLeft,
 	ScrollRight key.Binding
+	Review,
+	NextHunk,
+	PreviousHunk,
+	ToggleHunk,
+	EditHunk key.Binding
 }
 
 func DefaultKeyMap() KeyMap {
 	return KeyMap{
-		Left: key.NewBinding(
-			key.WithKeys("left", "h"),
-			key.WithHelp("←", "previous"),
-		),
-		Right: key.NewBinding(
-			key.WithKeys("right", "l"),
-			key.WithHelp("→", "next"),
-		),
-		Tab: key.NewBinding(
-			key.WithKeys("tab"),
-			key.WithHelp("tab", "switch"),
-		),
-		Allow: key.NewBinding(
-			key.WithKeys("a", "A", "ctrl+a"),
-			key.WithHelp("a", "allow"),
-		),
-		AllowSession: key.NewBinding(
-			key.WithKeys("s", "S", "ctrl+s"),
-			key.WithHelp("s", "allow session"),
-		),
-		Deny: key.NewBinding(
-			key.WithKeys("d", "D", "esc"),
-			key.WithHelp("d", "deny"),
-		),
-		Select: key.NewBinding(
-			key.WithKeys("enter", "ctrl+y"),
-			key.WithHelp("enter", "confirm"),
-		),
-		ToggleDiffMode: key.NewBinding(
-			key.WithKeys("t"),
-			key.WithHelp("t", "toggle diff mode"),
-		),
-		ScrollDown: key.NewBinding(
-			key.WithKeys("shift+down", "J"),
-			key.WithHelp("shift+↓", "scroll down"),
-		),
-		ScrollUp: key.NewBinding(
-			key.WithKeys("shift+up", "K"),
-			key.WithHelp("shift+↑", "scroll up"),
-		),
-		ScrollLeft: key.NewBinding(
-			key.WithKeys("shift+left", "H"),
-			key.WithHelp("shift+←", "scroll left"),
-		),
-		ScrollRight: key.NewBinding(
-			key.WithKeys("shift+right", "L"),
-			key.WithHelp("shift+→", "scroll right"),
-		),
+		Left:           keymap.Binding(keymap.PermissionsLeft),
+		Right:          keymap.Binding(keymap.PermissionsRight),
+		Tab:            keymap.Binding(keymap.PermissionsTab),
+		Allow:          keymap.Binding(keymap.PermissionsAllow),
+		AllowSession:   keymap.Binding(keymap.PermissionsAllowSession),
+		Deny:           keymap.Binding(keymap.PermissionsDeny),
+		Select:         keymap.Binding(keymap.PermissionsSelect),
+		ToggleDiffMode: keymap.Binding(keymap.PermissionsToggleDiffMode),
+		ScrollDown:     keymap.Binding(keymap.PermissionsScrollDown),
+		ScrollUp:       keymap.Binding(keymap.PermissionsScrollUp),
+		ScrollLeft:     keymap.Binding(keymap.PermissionsScrollLeft),
+		ScrollRight:    keymap.Binding(keymap.PermissionsScrollRight),
+		Review:         keymap.Binding(keymap.PermissionsReview),
+		NextHunk:       keymap.Binding(keymap.PermissionsNextHunk),
+		PreviousHunk:   keymap.Binding(keymap.PermissionsPreviousHunk),
+		ToggleHunk:     keymap.Binding(keymap.PermissionsToggleHunk),
+		EditHunk:       keymap.Binding(keymap.PermissionsEditHunk),
 	}
 }
 
@@ -87,6 +62,11 @@ func (k KeyMap) KeyBindings() []key.Binding {
 		k.ScrollUp,
 		k.ScrollLeft,
 		k.ScrollRight,
+		k.Review,
+		k.NextHunk,
+		k.PreviousHunk,
+		k.ToggleHunk,
+		k.EditHunk,
 	}
 }
 
@@ -104,10 +84,35 @@ func (k KeyMap) FullHelp() [][]key.Binding {
 // ShortHelp implements help.KeyMap.
 func (k KeyMap) ShortHelp() []key.Binding {
 	return []key.Binding{
+		k.Review,
 		k.ToggleDiffMode,
-		key.NewBinding(
-			key.WithKeys("shift+left", "shift+down", "shift+up", "shift+right"),
-			key.WithHelp("shift+←↓↑→", "scroll"),
+		keymap.Combined("shift+←↓↑→", "scroll",
+			keymap.PermissionsScrollLeft,
+			keymap.PermissionsScrollDown,
+			keymap.PermissionsScrollUp,
+			keymap.PermissionsScrollRight,
 		),
 	}
 }
+
+// reviewKeyMap is the help of the dialog while reviewing hunks.
+type reviewKeyMap KeyMap
+
+// FullHelp implements help.KeyMap.
+func (k reviewKeyMap) FullHelp() [][]key.Binding {
+	return [][]key.Binding{k.ShortHelp()}
+}
+
+// ShortHelp implements help.KeyMap.
+func (k reviewKeyMap) ShortHelp() []key.Binding {
+	return []key.Binding{
+		keymap.Combined("↑↓", "hunks",
+			keymap.PermissionsPreviousHunk,
+			keymap.PermissionsNextHunk,
+		),
+		k.ToggleHunk,
+		k.EditHunk,
+		k.ToggleDiffMode,
+		keymap.Help(keymap.PermissionsReview, "whole diff"),
+	}
+}
@@ -27,6 +27,9 @@ const (
 	PermissionAllow           PermissionAction = "allow"
 	PermissionAllowForSession PermissionAction = "allow_session"
 	PermissionDeny            PermissionAction = "deny"
+	// PermissionAllowReviewed allows the change the user reviewed hunk by
+	// hunk, given in the Review of the response.
+	PermissionAllowReviewed PermissionAction = "allow_reviewed"
 
 	PermissionsDialogID dialogs.DialogID = "permissions"
 )
@@ -35,6 +38,7 @@ const (
 type PermissionResponseMsg struct {
 	Permission permission.PermissionRequest
 	Action     PermissionAction
+	Review     *permission.Review
 }
 
 // PermissionDialogCmp interface for permission dialog component
@@ -58,6 +62,10 @@ type permissionDialogCmp struct {
 	diffXOffset          int   // horizontal scroll offset
 	diffYOffset          int   // vertical scroll offset
 
+	// Hunk review state
+	review    *hunkReview // nil until the review is first opened
+	reviewing bool
+
 	// Caching
 	cachedContent string
 	contentDirty  bool
@@ -77,7 +85,7 @@ func NewPermissionDialogCmp(permission permission.PermissionRequest, opts *Optio
 
 	// Create viewport for content
 	contentViewport := viewport.New(0, 0)
-	return &permissionDialogCmp{
+	p := &permissionDialogCmp{
 		contentViewPort: contentViewport,
 		selectedOption:  0, // Default to "Allow"
 		permission:      permission,
@@ -85,6 +93,8 @@ func NewPermissionDialogCmp(permission permission.PermissionRequest, opts *Optio
 		keyMap:          DefaultKeyMap(),
 		contentDirty:    true, // Mark as dirty initially
 	}
+	p.keyMap.Review.SetEnabled(p.supportsReview())
+	return p
 }
 
 func (p *permissionDialogCmp) Init() tea.Cmd {
@@ -92,7 +102,14 @@ func (p *permissionDialogCmp) Init() tea.Cmd {
 }
 
 func (p *permissionDialogCmp) supportsDiffView() bool {
-	return p.permission.ToolName == tools.EditToolName || p.permission.ToolName == tools.WriteToolName || p.permission.ToolName == tools.MultiEditToolName
+	if _, ok := p.permission.Params.(tools.RestoreSecretsPermissionsParams); ok {
+		return false
+	}
+	switch p.permission.ToolName {
+	case tools.EditToolName, tools.WriteToolName, tools.MultiEditToolName, tools.RenameToolName, tools.CodeActionsToolName, tools.ApplyPatchToolName:
+		return true
+	}
+	return false
 }
 
 func (p *permissionDialogCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
@@ -105,15 +122,36 @@ func (p *permissionDialogCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 		p.contentDirty = true // Mark content as dirty on window resize
 		cmd := p.SetSize()
 		cmds = append(cmds, cmd)
+	case hunkEditedMsg:
+		p.setHunkText(msg.index, msg.text)
+		return p, nil
 	case tea.KeyMsg:
 		switch {
 		case key.Matches(msg, p.keyMap.Right) || key.Matches(msg, p.keyMap.Tab):
-			p.selectedOption = (p.selectedOption + 1) % 3
+			p.moveSelection(1)
 			return p, nil
 		case key.Matches(msg, p.keyMap.Left):
-			p.selectedOption = (p.selectedOption + 2) % 3
+			p.moveSelection(-1)
 		case key.Matches(msg, p.keyMap.Select):
 			return p, p.selectCurrentOption()
+		case key.Matches(msg, p.keyMap.Review):
+			p.toggleReview()
+			return p, nil
+		case p.reviewing && key.Matches(msg, p.keyMap.NextHunk):
+			p.moveHunk(1)
+			return p, nil
+		case p.reviewing && key.Matches(msg, p.keyMap.PreviousHunk):
+			p.moveHunk(-1)
+			return p, nil
+		case p.reviewing && key.Matches(msg, p.keyMap.ToggleHunk):
+			p.toggleHunk()
+			return p, nil
+		case p.reviewing && key.Matches(msg, p.keyMap.EditHunk):
+			return p, p.editHunk()
+		case p.reviewing && key.Matches(msg, p.keyMap.Allow):
+			return p, p.confirmReview()
+		case p.reviewing && key.Matches(msg, p.keyMap.AllowSession):
+			return p, nil
 		case key.Matches(msg, p.keyMap.Allow):
 			return p, tea.Batch(
 				util.CmdHandler(dialogs.CloseDialogMsg{}),
@@ -215,7 +253,25 @@ func (p *permissionDialogCmp) isMouseOverDialog(x, y int) bool {
 	return x >= dialogX && x < dialogX+dialogWidth && y >= dialogY && y < dialogY+dialogHeight
 }
 
+// moveSelection selects the next or the previous button.
+func (p *permissionDialogCmp) moveSelection(delta int) {
+	if p.reviewing {
+		// Allowing for the session is not offered for a reviewed change.
+		if p.selectedOption == 0 {
+			p.selectedOption = 2
+		} else {
+			p.selectedOption = 0
+		}
+		return
+	}
+	p.selectedOption = (p.selectedOption + delta + 3) % 3
+}
+
 func (p *permissionDialogCmp) selectCurrentOption() tea.Cmd {
+	if p.reviewing && p.selectedOption == 0 {
+		return p.confirmReview()
+	}
+
 	var action PermissionAction
 
 	switch p.selectedOption {
@@ -255,6 +311,17 @@ func (p *permissionDialogCmp) renderButtons() string {
 		},
 	}
 
+	if p.reviewing {
+		buttons = []core.ButtonOpts{
+			{
+				Text:           "Apply Reviewed",
+				UnderlineIndex: 0, // "A"
+				Selected:       p.selectedOption == 0,
+			},
+			buttons[2],
+		}
+	}
+
 	content := core.SelectableButtons(buttons, "  ")
 	if lipgloss.Width(content) > p.width-4 {
 		content = core.SelectableButtonsVertical(buttons, 1)
@@ -377,6 +444,29 @@ func (p *permissionDialogCmp) renderHeader() string {
 			),
 			baseStyle.Render(strings.Repeat(" ", p.width)),
 		)
+	case tools.RenameToolName, tools.CodeActionsToolName, tools.ApplyPatchToolName:
+		params := p.permission.Params.(tools.MultiFilePermissionsParams)
+		descKey := t.S().Muted.Render("Desc")
+		descValue := t.S().Text.
+			Width(p.width - lipgloss.Width(descKey)).
+			Render(fmt.Sprintf(" %s", p.permission.Description))
+		filesKey := t.S().Muted.Render("Files")
+		filesValue := t.S().Text.
+			Width(p.width - lipgloss.Width(filesKey)).
+			Render(fmt.Sprintf(" %d", len(params.Changes)))
+		headerParts = append(headerParts,
+			lipgloss.JoinHorizontal(
+				lipgloss.Left,
+				descKey,
+				descValue,
+			),
+			lipgloss.JoinHorizontal(
+				lipgloss.Left,
+				filesKey,
+				filesValue,
+			),
+			baseStyle.Render(strings.Repeat(" ", p.width)),
+		)
 	case tools.FetchToolName:
 		headerParts = append(headerParts,
 			baseStyle.Render(strings.Repeat(" ", p.width)),
@@ -428,6 +518,19 @@ func (p *permissionDialogCmp) getOrGenerateContent() string {
 
 	// Generate new content
 	var content string
+	if _, ok := p.permission.Params.(tools.RestoreSecretsPermissionsParams); ok {
+		// Any tool writing files may ask to restore secrets.
+		content = p.generateRestoreSecretsContent()
+		p.cachedContent = content
+		p.contentDirty = false
+		return content
+	}
+	if p.reviewing {
+		content = p.generateReviewContent()
+		p.cachedContent = content
+		p.contentDirty = false
+		return content
+	}
 	switch p.permission.ToolName {
 	case tools.BashToolName:
 		content = p.generateBashContent()
@@ -439,6 +542,8 @@ func (p *permissionDialogCmp) getOrGenerateContent() string {
 		content = p.generateWriteContent()
 	case tools.MultiEditToolName:
 		content = p.generateMultiEditContent()
+	case tools.RenameToolName, tools.CodeActionsToolName, tools.ApplyPatchToolName:
+		content = p.generateMultiFileContent()
 	case tools.FetchToolName:
 		content = p.generateFetchContent()
 	case tools.AgenticFetchToolName:
@@ -584,6 +689,41 @@ func (p *permissionDialogCmp) generateMultiEditContent() string {
 	return ""
 }
 
+// generateMultiFileContent renders the diffs of every changed file one after
+// the other, scrolled as a whole.
+func (p *permissionDialogCmp) generateMultiFileContent() string {
+	pr, ok := p.permission.Params.(tools.MultiFilePermissionsParams)
+	if !ok {
+		return ""
+	}
+	var diffs []string
+	for _, change := range pr.Changes {
+		before, after := fsext.PrettyPath(change.Path), fsext.PrettyPath(change.Path)
+		if change.Created {
+			before = "/dev/null"
+		}
+		if change.Deleted {
+			after = "/dev/null"
+		}
+		formatter := core.DiffFormatter().
+			Before(before, change.OldContent).
+			After(after, change.NewContent).
+			Width(p.contentViewPort.Width).
+			XOffset(p.diffXOffset)
+		if p.useDiffSplitMode() {
+			formatter = formatter.Split()
+		} else {
+			formatter = formatter.Unified()
+		}
+		diffs = append(diffs, formatter.String())
+	}
+
+	lines := strings.Split(strings.Join(diffs, "\n"), "\n")
+	p.diffYOffset = max(0, min(p.diffYOffset, len(lines)-p.contentViewPort.Height))
+	end := min(len(lines), p.diffYOffset+p.contentViewPort.Height)
+	return strings.Join(lines[p.diffYOffset:end], "\n")
+}
+
 func (p *permissionDialogCmp) generateFetchContent() string {
 	t := styles.CurrentTheme()
 	baseStyle := t.S().Base.Background(styles.TC(t.BgSubtle))
@@ -655,6 +795,37 @@ func (p *permissionDialogCmp) generateLSContent() string {
 	return ""
 }
 
+// generateRestoreSecretsContent lists the placeholders of the secrets the
+// agent asks to write back to a file.
+func (p *permissionDialogCmp) generateRestoreSecretsContent() string {
+	t := styles.CurrentTheme()
+	baseStyle := t.S().Base.Background(styles.TC(t.BgSubtle))
+	pr, ok := p.permission.Params.(tools.RestoreSecretsPermissionsParams)
+	if !ok {
+		return ""
+	}
+
+	lines := []string{"", p.permission.Description, ""}
+	for _, placeholder := range pr.Placeholders {
+		lines = append(lines, "  "+placeholder)
+	}
+	lines = append(lines, "")
+
+	width := p.width - 4
+	var out []string
+	for _, ln := range lines {
+		out = append(out, t.S().Muted.
+			Width(width).
+			Padding(0, 1).
+			Foreground(styles.TC(t.FgBase)).
+			Background(styles.TC(t.BgSubtle)).
+			Render(ansi.Truncate(ln, width-2, "…")))
+	}
+	return baseStyle.
+		Width(p.contentViewPort.Width).
+		Render(strings.Join(out, "\n"))
+}
+
 func (p *permissionDialogCmp) generateDefaultContent() string {
 	t := styles.CurrentTheme()
 	baseStyle := t.S().Base.Background(styles.TC(t.BgSubtle))
@@ -760,7 +931,9 @@ func (p *permissionDialogCmp) render() string {
 	p.positionRow -= 3 // Move dialog slightly higher than middle
 
 	var contentHelp string
-	if p.supportsDiffView() {
+	if p.reviewing {
+		contentHelp = help.New().View(reviewKeyMap(p.keyMap))
+	} else if p.supportsDiffView() {
 		contentHelp = help.New().View(p.keyMap)
 	}
 
@@ -816,7 +989,7 @@ func (p *permissionDialogCmp) SetSize() tea.Cmd {
 	case tools.WriteToolName:
 		p.width = int(float64(p.wWidth) * 0.8)
 		p.height = int(float64(p.wHeight) * 0.8)
-	case tools.MultiEditToolName:
+	case tools.MultiEditToolName, tools.RenameToolName, tools.CodeActionsToolName, tools.ApplyPatchToolName:
 		p.width = int(float64(p.wWidth) * 0.8)
 		p.height = int(float64(p.wHeight) * 0.8)
 	case tools.FetchToolName:
@@ -0,0 +1,210 @@
+package permissions
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	tea "github.com/uglyswap/push/internal/compat/bubbletea"
+
+	"github.com/uglyswap/push/internal/agent/tools"
+	"github.com/uglyswap/push/internal/diff"
+	"github.com/uglyswap/push/internal/fsext"
+	"github.com/uglyswap/push/internal/permission"
+	"github.com/uglyswap/push/internal/tui/components/core"
+	"github.com/uglyswap/push/internal/tui/components/dialogs"
+	"github.com/uglyswap/push/internal/tui/styles"
+	"github.com/uglyswap/push/internal/tui/util"
+)
+
+// hunkReview is the state of the hunk by hunk review of a change to a file.
+type hunkReview struct {
+	path    string
+	old     string
+	hunks   []diff.Hunk
+	status  []permission.HunkStatus
+	current int
+	// proposed holds the new text of each hunk, as the agent proposed it.
+	proposed []string
+}
+
+// hunkEditedMsg is sent when the user is done editing a hunk in $EDITOR.
+type hunkEditedMsg struct {
+	index int
+	text  string
+}
+
+// reviewedChange returns the file and the change of the permission, when it
+// can be reviewed hunk by hunk.
+func (p *permissionDialogCmp) reviewedChange() (path, oldContent, newContent string, ok bool) {
+	switch pr := p.permission.Params.(type) {
+	case tools.EditPermissionsParams:
+		return pr.FilePath, pr.OldContent, pr.NewContent, true
+	case tools.WritePermissionsParams:
+		return pr.FilePath, pr.OldContent, pr.NewContent, true
+	case tools.MultiEditPermissionsParams:
+		return pr.FilePath, pr.OldContent, pr.NewContent, true
+	}
+	return "", "", "", false
+}
+
+func (p *permissionDialogCmp) supportsReview() bool {
+	_, _, _, ok := p.reviewedChange()
+	return ok
+}
+
+// toggleReview switches between the whole diff and the review of its hunks.
+func (p *permissionDialogCmp) toggleReview() {
+	if p.review == nil {
+		path, oldContent, newContent, ok := p.reviewedChange()
+		if !ok {
+			return
+		}
+		hunks := diff.Hunks(oldContent, newContent)
+		if len(hunks) == 0 {
+			return
+		}
+		r := &hunkReview{
+			path:     path,
+			old:      oldContent,
+			hunks:    hunks,
+			status:   make([]permission.HunkStatus, len(hunks)),
+			proposed: make([]string, len(hunks)),
+		}
+		for i, h := range hunks {
+			r.status[i] = permission.HunkApplied
+			r.proposed[i] = h.New
+		}
+		p.review = r
+	}
+	p.reviewing = !p.reviewing
+	if p.reviewing && p.selectedOption == 1 {
+		// Allowing for the session does not apply to a reviewed change.
+		p.selectedOption = 0
+	}
+	p.diffXOffset, p.diffYOffset = 0, 0
+	p.contentDirty = true
+}
+
+func (p *permissionDialogCmp) moveHunk(delta int) {
+	r := p.review
+	r.current = (r.current + delta + len(r.hunks)) % len(r.hunks)
+	p.diffXOffset, p.diffYOffset = 0, 0
+	p.contentDirty = true
+}
+
+func (p *permissionDialogCmp) toggleHunk() {
+	r := p.review
+	if r.status[r.current] == permission.HunkRejected {
+		r.status[r.current] = r.appliedStatus(r.current)
+	} else {
+		r.status[r.current] = permission.HunkRejected
+	}
+	p.contentDirty = true
+}
+
+// appliedStatus is the status of hunk i when it is applied.
+func (r *hunkReview) appliedStatus(i int) permission.HunkStatus {
+	if r.hunks[i].New != r.proposed[i] {
+		return permission.HunkEdited
+	}
+	return permission.HunkApplied
+}
+
+// editHunk opens the new text of the current hunk in $EDITOR.
+func (p *permissionDialogCmp) editHunk() tea.Cmd {
+	r := p.review
+	index := r.current
+	return util.OpenEditor("hunk_*"+filepath.Ext(r.path), r.hunks[index].New, func(text string) tea.Msg {
+		return hunkEditedMsg{index: index, text: text}
+	})
+}
+
+func (p *permissionDialogCmp) setHunkText(index int, text string) {
+	r := p.review
+	if r == nil || index >= len(r.hunks) {
+		return
+	}
+	// Editors may drop the final newline the hunk ends with.
+	if strings.HasSuffix(r.proposed[index], "\n") && text != "" && !strings.HasSuffix(text, "\n") {
+		text += "\n"
+	}
+	r.hunks[index].New = text
+	r.status[index] = r.appliedStatus(index)
+	p.contentDirty = true
+}
+
+// confirmReview answers the permission request with the reviewed change. A
+// change with every hunk rejected is denied, and one with every hunk applied
+// as proposed is allowed as is.
+func (p *permissionDialogCmp) confirmReview() tea.Cmd {
+	r := p.review
+	var (
+		applied  []diff.Hunk
+		reviewed []permission.ReviewedHunk
+		changed  bool
+	)
+	for i, h := range r.hunks {
+		reviewed = append(reviewed, permission.ReviewedHunk{
+			OldStart: h.OldStart,
+			OldLines: h.OldLines,
+			Status:   r.status[i],
+		})
+		if r.status[i] != permission.HunkRejected {
+			applied = append(applied, h)
+		}
+		if r.status[i] != permission.HunkApplied {
+			changed = true
+		}
+	}
+
+	msg := PermissionResponseMsg{Action: PermissionAllow, Permission: p.permission}
+	switch {
+	case len(applied) == 0:
+		msg.Action = PermissionDeny
+	case changed:
+		msg.Action = PermissionAllowReviewed
+		msg.Review = &permission.Review{
+			Content: diff.ApplyHunks(r.old, applied),
+			Hunks:   reviewed,
+		}
+	}
+	return tea.Batch(
+		util.CmdHandler(dialogs.CloseDialogMsg{}),
+		util.CmdHandler(msg),
+	)
+}
+
+// generateReviewContent renders the diff of the current hunk, under a line
+// telling where it is and whether it is applied.
+func (p *permissionDialogCmp) generateReviewContent() string {
+	t := styles.CurrentTheme()
+	r := p.review
+	h := r.hunks[r.current]
+
+	var status string
+	switch r.status[r.current] {
+	case permission.HunkApplied:
+		status = t.S().Base.Foreground(styles.TC(t.Success)).Render("apply")
+	case permission.HunkEdited:
+		status = t.S().Base.Foreground(styles.TC(t.Warning)).Render("apply edited")
+	case permission.HunkRejected:
+		status = t.S().Base.Foreground(styles.TC(t.Error)).Render("reject")
+	}
+	title := t.S().Muted.Render(fmt.Sprintf("Hunk %d/%d · lines %d-%d · ",
+		r.current+1, len(r.hunks), h.OldStart, h.OldStart+max(h.OldLines, 1)-1)) + status
+
+	formatter := core.DiffFormatter().
+		Before(fsext.PrettyPath(r.path), h.Old).
+		After(fsext.PrettyPath(r.path), h.New).
+		Height(p.contentViewPort.Height - 2).
+		Width(p.contentViewPort.Width).
+		XOffset(p.diffXOffset).
+		YOffset(p.diffYOffset)
+	if p.useDiffSplitMode() {
+		formatter = formatter.Split()
+	} else {
+		formatter = formatter.Unified()
+	}
+	return title + "\n\n" + formatter.String()
+}
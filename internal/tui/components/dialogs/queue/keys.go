@@ -0,0 +1,67 @@
+package queue
+
+import (
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/uglyswap/push/internal/tui/keymap"
+)
+
+// KeyMap defines the keyboard bindings for the queued prompts dialog.
+type KeyMap struct {
+	Next,
+	Previous,
+	MoveDown,
+	MoveUp,
+	Edit,
+	Drop,
+	SendNow,
+	Close key.Binding
+}
+
+func DefaultKeyMap() KeyMap {
+	return KeyMap{
+		Next:     keymap.Binding(keymap.QueueNext),
+		Previous: keymap.Binding(keymap.QueuePrevious),
+		MoveDown: keymap.Binding(keymap.QueueMoveDown),
+		MoveUp:   keymap.Binding(keymap.QueueMoveUp),
+		Edit:     keymap.Binding(keymap.QueueEdit),
+		Drop:     keymap.Binding(keymap.QueueDrop),
+		SendNow:  keymap.Binding(keymap.QueueSendNow),
+		Close:    keymap.Binding(keymap.QueueClose),
+	}
+}
+
+// KeyBindings implements layout.KeyMapProvider
+func (k KeyMap) KeyBindings() []key.Binding {
+	return []key.Binding{
+		k.Next,
+		k.Previous,
+		k.MoveDown,
+		k.MoveUp,
+		k.Edit,
+		k.Drop,
+		k.SendNow,
+		k.Close,
+	}
+}
+
+// FullHelp implements help.KeyMap.
+func (k KeyMap) FullHelp() [][]key.Binding {
+	m := [][]key.Binding{}
+	slice := k.KeyBindings()
+	for i := 0; i < len(slice); i += 4 {
+		end := min(i+4, len(slice))
+		m = append(m, slice[i:end])
+	}
+	return m
+}
+
+// ShortHelp implements help.KeyMap.
+func (k KeyMap) ShortHelp() []key.Binding {
+	return []key.Binding{
+		keymap.Combined("shift+↑↓", "move", keymap.QueueMoveUp, keymap.QueueMoveDown),
+		k.Edit,
+		k.Drop,
+		k.SendNow,
+		k.Close,
+	}
+}
@@ -0,0 +1,217 @@
+package queue
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+	tea "github.com/uglyswap/push/internal/compat/bubbletea"
+
+	"github.com/uglyswap/push/internal/tui/components/core"
+	"github.com/uglyswap/push/internal/tui/components/dialogs"
+	"github.com/uglyswap/push/internal/tui/styles"
+	"github.com/uglyswap/push/internal/tui/util"
+)
+
+const (
+	QueueDialogID dialogs.DialogID = "queue"
+
+	// maxListHeight is the number of prompts shown at once.
+	maxListHeight = 12
+)
+
+// Queue holds the prompts queued for the sessions while the agent is busy.
+type Queue interface {
+	QueuedPromptsList(sessionID string) []string
+	EditQueuedPrompt(sessionID string, index int, prompt string) error
+	MoveQueuedPrompt(sessionID string, index, to int) error
+	RemoveQueuedPrompt(sessionID string, index int) error
+}
+
+// SendNowMsg asks to cancel the current turn of a session and send its queued
+// prompt at Index next.
+type SendNowMsg struct {
+	SessionID string
+	Index     int
+}
+
+// promptEditedMsg is sent when the user is done editing a queued prompt in
+// $EDITOR.
+type promptEditedMsg struct {
+	original string
+	text     string
+}
+
+// QueueDialog lists the prompts queued for a session and lets the user edit,
+// reorder, drop them or send one right away.
+type QueueDialog interface {
+	dialogs.DialogModel
+}
+
+type queueDialogCmp struct {
+	wWidth  int
+	wHeight int
+	width   int
+
+	queue     Queue
+	sessionID string
+	prompts   []string
+	selected  int
+	keyMap    KeyMap
+}
+
+// NewQueueDialog creates a dialog managing the prompts queued for a session.
+func NewQueueDialog(queue Queue, sessionID string) QueueDialog {
+	c := &queueDialogCmp{
+		queue:     queue,
+		sessionID: sessionID,
+		keyMap:    DefaultKeyMap(),
+	}
+	c.refresh()
+	return c
+}
+
+func (c *queueDialogCmp) Init() tea.Cmd {
+	return nil
+}
+
+// refresh lists the queued prompts again, as the agent takes them while the
+// dialog is open.
+func (c *queueDialogCmp) refresh() {
+	c.prompts = c.queue.QueuedPromptsList(c.sessionID)
+	c.selected = max(0, min(c.selected, len(c.prompts)-1))
+}
+
+func (c *queueDialogCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
+	c.refresh()
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		c.wWidth = msg.Width
+		c.wHeight = msg.Height
+		c.width = min(int(float64(c.wWidth)*0.8), 100)
+	case promptEditedMsg:
+		return c, c.applyEdit(msg.original, msg.text)
+	case tea.KeyMsg:
+		if key.Matches(msg, c.keyMap.Close) {
+			return c, util.CmdHandler(dialogs.CloseDialogMsg{})
+		}
+		if len(c.prompts) == 0 {
+			return c, nil
+		}
+		switch {
+		case key.Matches(msg, c.keyMap.Next):
+			c.selected = min(c.selected+1, len(c.prompts)-1)
+		case key.Matches(msg, c.keyMap.Previous):
+			c.selected = max(c.selected-1, 0)
+		case key.Matches(msg, c.keyMap.MoveDown):
+			return c, c.move(1)
+		case key.Matches(msg, c.keyMap.MoveUp):
+			return c, c.move(-1)
+		case key.Matches(msg, c.keyMap.Edit):
+			original := c.prompts[c.selected]
+			return c, util.OpenEditor("queued_*.md", original, func(text string) tea.Msg {
+				return promptEditedMsg{original: original, text: text}
+			})
+		case key.Matches(msg, c.keyMap.Drop):
+			if err := c.queue.RemoveQueuedPrompt(c.sessionID, c.selected); err != nil {
+				return c, util.ReportError(err)
+			}
+			c.refresh()
+		case key.Matches(msg, c.keyMap.SendNow):
+			return c, tea.Sequence(
+				util.CmdHandler(dialogs.CloseDialogMsg{}),
+				util.CmdHandler(SendNowMsg{SessionID: c.sessionID, Index: c.selected}),
+			)
+		}
+	}
+	return c, nil
+}
+
+func (c *queueDialogCmp) move(delta int) tea.Cmd {
+	to := c.selected + delta
+	if to < 0 || to >= len(c.prompts) {
+		return nil
+	}
+	if err := c.queue.MoveQueuedPrompt(c.sessionID, c.selected, to); err != nil {
+		return util.ReportError(err)
+	}
+	c.selected = to
+	c.refresh()
+	return nil
+}
+
+// applyEdit replaces the queued prompt original with text. The prompt is
+// looked up again, as the queue may have changed while it was edited.
+func (c *queueDialogCmp) applyEdit(original, text string) tea.Cmd {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return util.ReportWarn("Prompt is empty, drop it instead")
+	}
+	i := slices.Index(c.prompts, original)
+	if i < 0 {
+		return util.ReportWarn("The prompt was sent while it was edited")
+	}
+	if err := c.queue.EditQueuedPrompt(c.sessionID, i, text); err != nil {
+		return util.ReportError(err)
+	}
+	c.selected = i
+	c.refresh()
+	return nil
+}
+
+func (c *queueDialogCmp) renderList() string {
+	t := styles.CurrentTheme()
+	if len(c.prompts) == 0 {
+		return t.S().Subtle.Render("No prompts queued for this session.")
+	}
+
+	height := min(len(c.prompts), maxListHeight)
+	// Keep the selected prompt in view.
+	start := max(0, c.selected-height+1)
+	rows := make([]string, 0, height)
+	for i, prompt := range c.prompts[start : start+height] {
+		// Show the first line of each prompt.
+		first, _, multiline := strings.Cut(prompt, "\n")
+		if multiline {
+			first += " …"
+		}
+		row := ansi.Truncate(fmt.Sprintf("%d. %s", start+i+1, first), c.width-6, "…")
+		if start+i == c.selected {
+			rows = append(rows, t.S().TextSelected.Width(c.width-4).Render(row))
+		} else {
+			rows = append(rows, t.S().Text.Render(row))
+		}
+	}
+	return strings.Join(rows, "\n")
+}
+
+func (c *queueDialogCmp) View() string {
+	t := styles.CurrentTheme()
+	return t.S().Base.
+		Padding(0, 1).
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(styles.TC(t.BorderFocus)).
+		Width(c.width).
+		Render(lipgloss.JoinVertical(
+			lipgloss.Top,
+			core.Title("Queued Prompts", c.width-4),
+			"",
+			c.renderList(),
+			"",
+			help.New().View(c.keyMap),
+		))
+}
+
+func (c *queueDialogCmp) Position() (int, int) {
+	row := c.wHeight/4 - 2 // just a bit above the center
+	col := c.wWidth/2 - c.width/2
+	return row, col
+}
+
+func (c *queueDialogCmp) ID() dialogs.DialogID {
+	return QueueDialogID
+}
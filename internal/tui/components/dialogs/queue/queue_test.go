@@ -0,0 +1,67 @@
+package queue
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type fakeQueue struct {
+	prompts []string
+}
+
+func (q *fakeQueue) QueuedPromptsList(string) []string {
+	return slices.Clone(q.prompts)
+}
+
+func (q *fakeQueue) EditQueuedPrompt(_ string, index int, prompt string) error {
+	q.prompts[index] = prompt
+	return nil
+}
+
+func (q *fakeQueue) MoveQueuedPrompt(_ string, index, to int) error {
+	prompt := q.prompts[index]
+	q.prompts = slices.Insert(slices.Delete(q.prompts, index, index+1), to, prompt)
+	return nil
+}
+
+func (q *fakeQueue) RemoveQueuedPrompt(_ string, index int) error {
+	q.prompts = slices.Delete(q.prompts, index, index+1)
+	return nil
+}
+
+func TestQueueDialog_ApplyEdit(t *testing.T) {
+	t.Parallel()
+
+	q := &fakeQueue{prompts: []string{"first", "second", "third"}}
+	c := NewQueueDialog(q, "session").(*queueDialogCmp)
+
+	// The agent takes the first prompt while the second one is edited.
+	q.prompts = q.prompts[1:]
+	c.refresh()
+	require.Nil(t, c.applyEdit("second", "second, edited\n"))
+	require.Equal(t, []string{"second, edited", "third"}, q.prompts)
+	require.Equal(t, 0, c.selected)
+
+	// A prompt sent while it was edited is not recreated.
+	q.prompts = q.prompts[1:]
+	c.refresh()
+	require.NotNil(t, c.applyEdit("second, edited", "again"))
+	require.Equal(t, []string{"third"}, q.prompts)
+}
+
+func TestQueueDialog_Move(t *testing.T) {
+	t.Parallel()
+
+	q := &fakeQueue{prompts: []string{"first", "second", "third"}}
+	c := NewQueueDialog(q, "session").(*queueDialogCmp)
+
+	require.Nil(t, c.move(1))
+	require.Equal(t, []string{"second", "first", "third"}, q.prompts)
+	require.Equal(t, 1, c.selected)
+
+	c.selected = 2
+	require.Nil(t, c.move(1), "the last prompt stays last")
+	require.Equal(t, []string{"second", "first", "third"}, q.prompts)
+}
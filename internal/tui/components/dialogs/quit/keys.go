@@ -2,6 +2,7 @@ package quit
 
 import (
 	"github.com/charmbracelet/bubbles/key"
+	"github.com/uglyswap/push/internal/tui/keymap"
 )
 
 // KeyMap defines the keyboard bindings for the quit dialog.
@@ -16,30 +17,12 @@ type KeyMap struct {
 
 func DefaultKeymap() KeyMap {
 	return KeyMap{
-		LeftRight: key.NewBinding(
-			key.WithKeys("left", "right"),
-			key.WithHelp("←/→", "switch options"),
-		),
-		EnterSpace: key.NewBinding(
-			key.WithKeys("enter", " "),
-			key.WithHelp("enter/space", "confirm"),
-		),
-		Yes: key.NewBinding(
-			key.WithKeys("y", "Y", "ctrl+c"),
-			key.WithHelp("y/Y/ctrl+c", "yes"),
-		),
-		No: key.NewBinding(
-			key.WithKeys("n", "N"),
-			key.WithHelp("n/N", "no"),
-		),
-		Tab: key.NewBinding(
-			key.WithKeys("tab"),
-			key.WithHelp("tab", "switch options"),
-		),
-		Close: key.NewBinding(
-			key.WithKeys("esc", "alt+esc"),
-			key.WithHelp("esc", "cancel"),
-		),
+		LeftRight:  keymap.Binding(keymap.QuitLeftRight),
+		EnterSpace: keymap.Binding(keymap.QuitConfirm),
+		Yes:        keymap.Binding(keymap.QuitYes),
+		No:         keymap.Binding(keymap.QuitNo),
+		Tab:        keymap.Binding(keymap.QuitTab),
+		Close:      keymap.Binding(keymap.QuitClose),
 	}
 }
 
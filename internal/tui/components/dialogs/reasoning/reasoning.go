@@ -12,6 +12,7 @@ import (
 	"github.com/uglyswap/push/internal/tui/components/core"
 	"github.com/uglyswap/push/internal/tui/components/dialogs"
 	"github.com/uglyswap/push/internal/tui/exp/list"
+	"github.com/uglyswap/push/internal/tui/keymap"
 	"github.com/uglyswap/push/internal/tui/styles"
 	"github.com/uglyswap/push/internal/tui/util"
 )
@@ -56,22 +57,10 @@ type ReasoningDialogKeyMap struct {
 
 func DefaultReasoningDialogKeyMap() ReasoningDialogKeyMap {
 	return ReasoningDialogKeyMap{
-		Next: key.NewBinding(
-			key.WithKeys("down", "j", "ctrl+n"),
-			key.WithHelp("↓/j/ctrl+n", "next"),
-		),
-		Previous: key.NewBinding(
-			key.WithKeys("up", "k", "ctrl+p"),
-			key.WithHelp("↑/k/ctrl+p", "previous"),
-		),
-		Select: key.NewBinding(
-			key.WithKeys("enter"),
-			key.WithHelp("enter", "select"),
-		),
-		Close: key.NewBinding(
-			key.WithKeys("esc", "ctrl+c"),
-			key.WithHelp("esc/ctrl+c", "close"),
-		),
+		Next: keymap.Binding(keymap.ReasoningNext),
+		Previous: keymap.Binding(keymap.ReasoningPrevious),
+		Select: keymap.Binding(keymap.ReasoningSelect),
+		Close: keymap.Binding(keymap.ReasoningClose),
 	}
 }
 
@@ -2,6 +2,7 @@ package sessions
 
 import (
 	"github.com/charmbracelet/bubbles/key"
+	"github.com/uglyswap/push/internal/tui/keymap"
 )
 
 type KeyMap struct {
@@ -13,22 +14,10 @@ type KeyMap struct {
 
 func DefaultKeyMap() KeyMap {
 	return KeyMap{
-		Select: key.NewBinding(
-			key.WithKeys("enter", "tab", "ctrl+y"),
-			key.WithHelp("enter", "choose"),
-		),
-		Next: key.NewBinding(
-			key.WithKeys("down", "ctrl+n"),
-			key.WithHelp("↓", "next item"),
-		),
-		Previous: key.NewBinding(
-			key.WithKeys("up", "ctrl+p"),
-			key.WithHelp("↑", "previous item"),
-		),
-		Close: key.NewBinding(
-			key.WithKeys("esc", "alt+esc"),
-			key.WithHelp("esc", "exit"),
-		),
+		Select:   keymap.Binding(keymap.SessionsSelect),
+		Next:     keymap.Binding(keymap.SessionsNext),
+		Previous: keymap.Binding(keymap.SessionsPrevious),
+		Close:    keymap.Binding(keymap.SessionsClose),
 	}
 }
 
@@ -56,11 +45,7 @@ func (k KeyMap) FullHelp() [][]key.Binding {
 // ShortHelp implements help.KeyMap.
 func (k KeyMap) ShortHelp() []key.Binding {
 	return []key.Binding{
-		key.NewBinding(
-
-			key.WithKeys("down", "up"),
-			key.WithHelp("↑↓", "choose"),
-		),
+		keymap.Combined("↑↓", "choose", keymap.SessionsPrevious, keymap.SessionsNext),
 		k.Select,
 		k.Close,
 	}
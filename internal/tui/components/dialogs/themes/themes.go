@@ -0,0 +1,203 @@
+package themes
+
+import (
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/lipgloss"
+	tea "github.com/uglyswap/push/internal/compat/bubbletea"
+
+	"github.com/uglyswap/push/internal/config"
+	"github.com/uglyswap/push/internal/tui/components/core"
+	"github.com/uglyswap/push/internal/tui/components/dialogs"
+	"github.com/uglyswap/push/internal/tui/exp/list"
+	"github.com/uglyswap/push/internal/tui/keymap"
+	"github.com/uglyswap/push/internal/tui/styles"
+	"github.com/uglyswap/push/internal/tui/util"
+)
+
+const (
+	ThemesDialogID dialogs.DialogID = "themes"
+
+	defaultWidth int = 50
+)
+
+type listModel = list.FilterableList[list.CompletionItem[string]]
+
+type ThemesDialog interface {
+	dialogs.DialogModel
+}
+
+type themesDialogCmp struct {
+	width   int
+	wWidth  int // Width of the terminal window
+	wHeight int // Height of the terminal window
+
+	themeList listModel
+	keyMap    ThemesDialogKeyMap
+	help      help.Model
+}
+
+// ThemeSelectedMsg is sent when a theme is picked in the dialog.
+type ThemeSelectedMsg struct {
+	Name string
+}
+
+type ThemesDialogKeyMap struct {
+	Next     key.Binding
+	Previous key.Binding
+	Select   key.Binding
+	Close    key.Binding
+}
+
+func DefaultThemesDialogKeyMap() ThemesDialogKeyMap {
+	return ThemesDialogKeyMap{
+		Next:     keymap.Binding(keymap.ThemesNext),
+		Previous: keymap.Binding(keymap.ThemesPrevious),
+		Select:   keymap.Binding(keymap.ThemesSelect),
+		Close:    keymap.Binding(keymap.ThemesClose),
+	}
+}
+
+func (k ThemesDialogKeyMap) ShortHelp() []key.Binding {
+	return []key.Binding{k.Select, k.Close}
+}
+
+func (k ThemesDialogKeyMap) FullHelp() [][]key.Binding {
+	return [][]key.Binding{
+		{k.Next, k.Previous},
+		{k.Select, k.Close},
+	}
+}
+
+func NewThemesDialog() ThemesDialog {
+	keyMap := DefaultThemesDialogKeyMap()
+	listKeyMap := list.DefaultKeyMap()
+	listKeyMap.Down.SetEnabled(false)
+	listKeyMap.Up.SetEnabled(false)
+	listKeyMap.DownOneItem = keyMap.Next
+	listKeyMap.UpOneItem = keyMap.Previous
+
+	t := styles.CurrentTheme()
+	inputStyle := t.S().Base.PaddingLeft(1).PaddingBottom(1)
+	themeList := list.NewFilterableList(
+		[]list.CompletionItem[string]{},
+		list.WithFilterInputStyle(inputStyle),
+		list.WithFilterListOptions(
+			list.WithKeyMap(listKeyMap),
+			list.WithWrapNavigation(),
+			list.WithResizeByList(),
+		),
+	)
+	help := help.New()
+	help.Styles = t.S().Help
+
+	return &themesDialogCmp{
+		themeList: themeList,
+		width:     defaultWidth,
+		keyMap:    keyMap,
+		help:      help,
+	}
+}
+
+func (d *themesDialogCmp) Init() tea.Cmd {
+	current := config.Get().Options.TUI.Theme
+	if current == "" {
+		current = styles.AutoTheme
+	}
+
+	names := append([]string{styles.AutoTheme}, styles.DefaultManager().List()...)
+	items := make([]list.CompletionItem[string], 0, len(names))
+	for _, name := range names {
+		opts := []list.CompletionItemOption{
+			list.WithCompletionID(name),
+		}
+		if name == current {
+			opts = append(opts, list.WithCompletionShortcut("current"))
+		}
+		items = append(items, list.NewCompletionItem(name, name, opts...))
+	}
+	return tea.Sequence(d.themeList.SetItems(items), d.themeList.SetSelected(current))
+}
+
+func (d *themesDialogCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		d.wWidth = msg.Width
+		d.wHeight = msg.Height
+		return d, d.themeList.SetSize(d.listWidth(), d.listHeight())
+	case tea.KeyMsg:
+		switch {
+		case key.Matches(msg, d.keyMap.Select):
+			selectedItem := d.themeList.SelectedItem()
+			if selectedItem == nil {
+				return d, nil
+			}
+			name := (*selectedItem).Value()
+			return d, tea.Sequence(
+				util.CmdHandler(dialogs.CloseDialogMsg{}),
+				util.CmdHandler(ThemeSelectedMsg{Name: name}),
+			)
+		case key.Matches(msg, d.keyMap.Close):
+			return d, util.CmdHandler(dialogs.CloseDialogMsg{})
+		default:
+			u, cmd := d.themeList.Update(msg)
+			d.themeList = u.(listModel)
+			return d, cmd
+		}
+	}
+	return d, nil
+}
+
+func (d *themesDialogCmp) View() string {
+	t := styles.CurrentTheme()
+	header := t.S().Base.Padding(0, 1, 1, 1).Render(core.Title("Switch Theme", d.width-4))
+	content := lipgloss.JoinVertical(
+		lipgloss.Left,
+		header,
+		d.themeList.View(),
+		"",
+		t.S().Base.Width(d.width-2).PaddingLeft(1).AlignHorizontal(lipgloss.Left).Render(d.help.View(d.keyMap)),
+	)
+	return d.style().Render(content)
+}
+
+func (d *themesDialogCmp) Cursor() *util.Cursor {
+	if cursorProvider, ok := d.themeList.(util.CursorProvider); ok {
+		cursor := cursorProvider.Cursor()
+		if cursor != nil {
+			row, col := d.Position()
+			cursor.Y += row + 3
+			cursor.X += col + 2
+		}
+		return cursor
+	}
+	return nil
+}
+
+func (d *themesDialogCmp) listWidth() int {
+	return d.width - 2
+}
+
+func (d *themesDialogCmp) listHeight() int {
+	listHeight := len(d.themeList.Items()) + 2 + 4 // height based on items + 2 for the input + 4 for the sections
+	return min(listHeight, d.wHeight/2)
+}
+
+func (d *themesDialogCmp) style() lipgloss.Style {
+	t := styles.CurrentTheme()
+	return t.S().Base.
+		Width(d.width).
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(styles.TC(t.BorderFocus))
+}
+
+func (d *themesDialogCmp) Position() (int, int) {
+	row := d.wHeight/4 - 2 // just a bit above the center
+	col := d.wWidth / 2
+	col -= d.width / 2
+	return row, col
+}
+
+func (d *themesDialogCmp) ID() dialogs.DialogID {
+	return ThemesDialogID
+}
@@ -0,0 +1,63 @@
+package worktree
+
+import (
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/uglyswap/push/internal/tui/keymap"
+)
+
+// KeyMap defines the keyboard bindings for the worktree dialog.
+type KeyMap struct {
+	ScrollUp,
+	ScrollDown,
+	PageUp,
+	PageDown,
+	Merge,
+	Discard,
+	Close key.Binding
+}
+
+func DefaultKeyMap() KeyMap {
+	return KeyMap{
+		ScrollUp:   keymap.Binding(keymap.WorktreeScrollUp),
+		ScrollDown: keymap.Binding(keymap.WorktreeScrollDown),
+		PageUp:     keymap.Binding(keymap.WorktreePageUp),
+		PageDown:   keymap.Binding(keymap.WorktreePageDown),
+		Merge:      keymap.Binding(keymap.WorktreeMerge),
+		Discard:    keymap.Binding(keymap.WorktreeDiscard),
+		Close:      keymap.Binding(keymap.WorktreeClose),
+	}
+}
+
+// KeyBindings implements layout.KeyMapProvider
+func (k KeyMap) KeyBindings() []key.Binding {
+	return []key.Binding{
+		k.ScrollUp,
+		k.ScrollDown,
+		k.PageUp,
+		k.PageDown,
+		k.Merge,
+		k.Discard,
+		k.Close,
+	}
+}
+
+// FullHelp implements help.KeyMap.
+func (k KeyMap) FullHelp() [][]key.Binding {
+	m := [][]key.Binding{}
+	slice := k.KeyBindings()
+	for i := 0; i < len(slice); i += 4 {
+		end := min(i+4, len(slice))
+		m = append(m, slice[i:end])
+	}
+	return m
+}
+
+// ShortHelp implements help.KeyMap.
+func (k KeyMap) ShortHelp() []key.Binding {
+	return []key.Binding{
+		k.ScrollDown,
+		k.Merge,
+		k.Discard,
+		k.Close,
+	}
+}
@@ -0,0 +1,191 @@
+package worktree
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/uglyswap/push/internal/compat/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/uglyswap/push/internal/tui/components/core"
+	"github.com/uglyswap/push/internal/tui/components/dialogs"
+	"github.com/uglyswap/push/internal/tui/styles"
+	"github.com/uglyswap/push/internal/tui/util"
+	"github.com/uglyswap/push/internal/worktree"
+)
+
+const WorktreeDialogID dialogs.DialogID = "worktree"
+
+// MergeWorktreeMsg asks to merge the worktree of a session into the
+// repository.
+type MergeWorktreeMsg struct {
+	SessionID string
+}
+
+// DiscardWorktreeMsg asks to drop the worktree of a session and its changes.
+type DiscardWorktreeMsg struct {
+	SessionID string
+}
+
+// WorktreeDialog shows the changes made in the worktree of a session and lets
+// the user merge or discard them.
+type WorktreeDialog interface {
+	dialogs.DialogModel
+}
+
+type worktreeDialogCmp struct {
+	wWidth  int
+	wHeight int
+	width   int
+	height  int
+
+	worktree worktree.Worktree
+	changes  []worktree.FileChange
+	lines    []string
+
+	yOffset        int
+	confirmDiscard bool
+	keyMap         KeyMap
+}
+
+// NewWorktreeDialog creates a dialog showing the given changes of a session
+// worktree.
+func NewWorktreeDialog(wt worktree.Worktree, changes []worktree.FileChange) WorktreeDialog {
+	return &worktreeDialogCmp{
+		worktree: wt,
+		changes:  changes,
+		keyMap:   DefaultKeyMap(),
+	}
+}
+
+func (w *worktreeDialogCmp) Init() tea.Cmd {
+	return nil
+}
+
+func (w *worktreeDialogCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		w.wWidth = msg.Width
+		w.wHeight = msg.Height
+		w.width = min(int(float64(w.wWidth)*0.8), 180)
+		w.height = int(float64(w.wHeight) * 0.8)
+		w.lines = nil
+	case tea.KeyMsg:
+		discard := key.Matches(msg, w.keyMap.Discard)
+		defer func() { w.confirmDiscard = discard && !w.confirmDiscard }()
+		switch {
+		case key.Matches(msg, w.keyMap.Close):
+			return w, util.CmdHandler(dialogs.CloseDialogMsg{})
+		case key.Matches(msg, w.keyMap.Merge):
+			return w, tea.Sequence(
+				util.CmdHandler(dialogs.CloseDialogMsg{}),
+				util.CmdHandler(MergeWorktreeMsg{SessionID: w.worktree.SessionID}),
+			)
+		case discard:
+			// Discarding drops the changes for good, so it takes a second
+			// press to confirm.
+			if !w.confirmDiscard {
+				return w, nil
+			}
+			return w, tea.Sequence(
+				util.CmdHandler(dialogs.CloseDialogMsg{}),
+				util.CmdHandler(DiscardWorktreeMsg{SessionID: w.worktree.SessionID}),
+			)
+		case key.Matches(msg, w.keyMap.ScrollUp):
+			w.scroll(-1)
+		case key.Matches(msg, w.keyMap.ScrollDown):
+			w.scroll(1)
+		case key.Matches(msg, w.keyMap.PageUp):
+			w.scroll(-w.contentHeight())
+		case key.Matches(msg, w.keyMap.PageDown):
+			w.scroll(w.contentHeight())
+		}
+	}
+	return w, nil
+}
+
+func (w *worktreeDialogCmp) scroll(delta int) {
+	w.yOffset = max(0, min(w.yOffset+delta, len(w.diffLines())-w.contentHeight()))
+}
+
+// contentHeight is the number of diff lines shown at once, the dialog height
+// minus the title, header, help and borders.
+func (w *worktreeDialogCmp) contentHeight() int {
+	return max(5, w.height-10)
+}
+
+// diffLines renders the diffs of every changed file one after the other.
+func (w *worktreeDialogCmp) diffLines() []string {
+	if w.lines != nil {
+		return w.lines
+	}
+	var diffs []string
+	for _, change := range w.changes {
+		before, after := change.Path, change.Path
+		if change.Created {
+			before = "/dev/null"
+		}
+		if change.Deleted {
+			after = "/dev/null"
+		}
+		formatter := core.DiffFormatter().
+			Before(before, change.OldContent).
+			After(after, change.NewContent).
+			Width(w.width - 4)
+		if w.width >= 140 {
+			formatter = formatter.Split()
+		} else {
+			formatter = formatter.Unified()
+		}
+		diffs = append(diffs, formatter.String())
+	}
+	w.lines = strings.Split(strings.Join(diffs, "\n"), "\n")
+	return w.lines
+}
+
+func (w *worktreeDialogCmp) View() string {
+	t := styles.CurrentTheme()
+	baseStyle := t.S().Base
+
+	header := t.S().Muted.Render(fmt.Sprintf("Branch %s, %d changed files", w.worktree.Branch, len(w.changes)))
+	var content string
+	if len(w.changes) == 0 {
+		content = t.S().Subtle.Render("No changes.")
+	} else {
+		lines := w.diffLines()
+		end := min(len(lines), w.yOffset+w.contentHeight())
+		content = strings.Join(lines[w.yOffset:end], "\n")
+	}
+
+	footer := help.New().View(w.keyMap)
+	if w.confirmDiscard {
+		footer = t.S().Error.Render("Press x again to discard all the changes of this session.")
+	}
+
+	return baseStyle.
+		Padding(0, 1).
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(styles.TC(t.BorderFocus)).
+		Width(w.width).
+		Render(lipgloss.JoinVertical(
+			lipgloss.Top,
+			core.Title("Session Worktree", w.width-4),
+			"",
+			header,
+			"",
+			content,
+			"",
+			footer,
+		))
+}
+
+func (w *worktreeDialogCmp) Position() (int, int) {
+	row := w.wHeight/2 - w.height/2
+	col := w.wWidth/2 - w.width/2
+	return row, col
+}
+
+func (w *worktreeDialogCmp) ID() dialogs.DialogID {
+	return WorktreeDialogID
+}
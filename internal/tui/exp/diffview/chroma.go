@@ -5,52 +5,67 @@ import (
 	"io"
 	"strings"
 
-	"github.com/charmbracelet/lipgloss"
 	"github.com/alecthomas/chroma/v2"
+	"github.com/charmbracelet/lipgloss"
 	"github.com/uglyswap/push/internal/ansiext"
 )
 
 var _ chroma.Formatter = chromaFormatter{}
 
 // chromaFormatter is a custom formatter for Chroma that uses Lip Gloss for
-// foreground styling, while keeping a forced background color.
+// foreground styling, while keeping a forced background color. The parts of
+// the line in spans get the emphasis style instead.
 type chromaFormatter struct {
-	bgColor lipgloss.TerminalColor
+	bgColor  lipgloss.TerminalColor
+	emphasis lipgloss.Style
+	spans    []span
 }
 
 // Format implements the chroma.Formatter interface.
 func (c chromaFormatter) Format(w io.Writer, style *chroma.Style, it chroma.Iterator) error {
+	offset := 0
 	for token := it(); token != chroma.EOF; token = it() {
+		start := offset
+		offset += len(token.Value)
 		value := strings.TrimRight(token.Value, "\n")
-		value = ansiext.Escape(value)
-
 		entry := style.Get(token.Type)
-		if entry.IsZero() {
-			if _, err := fmt.Fprint(w, value); err != nil {
+
+		for _, p := range splitSpans(value, start, c.spans) {
+			if err := c.formatPiece(w, entry, p); err != nil {
 				return err
 			}
-			continue
 		}
+	}
+	return nil
+}
 
-		s := lipgloss.NewStyle().
-			Background(c.bgColor)
+func (c chromaFormatter) formatPiece(w io.Writer, entry chroma.StyleEntry, p piece) error {
+	value := ansiext.Escape(p.text)
 
-		if entry.Bold == chroma.Yes {
-			s = s.Bold(true)
-		}
-		if entry.Underline == chroma.Yes {
-			s = s.Underline(true)
-		}
-		if entry.Italic == chroma.Yes {
-			s = s.Italic(true)
-		}
-		if entry.Colour.IsSet() {
-			s = s.Foreground(lipgloss.Color(entry.Colour.String()))
-		}
+	if entry.IsZero() && !p.emphasized {
+		_, err := fmt.Fprint(w, value)
+		return err
+	}
 
-		if _, err := fmt.Fprint(w, s.Render(value)); err != nil {
-			return err
-		}
+	s := lipgloss.NewStyle().
+		Background(c.bgColor)
+	if p.emphasized {
+		s = c.emphasis
 	}
-	return nil
+
+	if entry.Bold == chroma.Yes {
+		s = s.Bold(true)
+	}
+	if entry.Underline == chroma.Yes {
+		s = s.Underline(true)
+	}
+	if entry.Italic == chroma.Yes {
+		s = s.Italic(true)
+	}
+	if entry.Colour.IsSet() {
+		s = s.Foreground(lipgloss.Color(entry.Colour.String()))
+	}
+
+	_, err := fmt.Fprint(w, s.Render(value))
+	return err
 }
@@ -6,10 +6,10 @@ import (
 	"strconv"
 	"strings"
 
-	"github.com/charmbracelet/lipgloss"
 	"github.com/alecthomas/chroma/v2"
 	"github.com/alecthomas/chroma/v2/lexers"
 	"github.com/aymanbagabas/go-udiff"
+	"github.com/charmbracelet/lipgloss"
 	"github.com/charmbracelet/x/ansi"
 	"github.com/zeebo/xxh3"
 )
@@ -67,16 +67,24 @@ type DiffView struct {
 	// Cache highlighted lines to avoid re-highlighting the same content
 	// Key: hash of (content + background color), Value: highlighted string
 	syntaxCache map[string]string
+
+	// Changed parts of the deleted and inserted lines paired together
+	emphasis map[*udiff.Line][]span
+	// Cache the comparisons of paired lines
+	// Key: hash of (mode + both lines), Value: changed parts of each
+	emphasisCache map[string]emphasis
 }
 
 // New creates a new DiffView with default settings.
 func New() *DiffView {
 	dv := &DiffView{
-		layout:       layoutUnified,
-		contextLines: udiff.DefaultContextLines,
-		lineNumbers:  true,
-		tabWidth:     8,
-		syntaxCache:  make(map[string]string),
+		layout:        layoutUnified,
+		contextLines:  udiff.DefaultContextLines,
+		lineNumbers:   true,
+		tabWidth:      8,
+		syntaxCache:   make(map[string]string),
+		emphasis:      make(map[*udiff.Line][]span),
+		emphasisCache: make(map[string]emphasis),
 	}
 	dv.style = DefaultDarkStyle()
 	return dv
@@ -114,6 +122,7 @@ func (dv *DiffView) After(path, content string) *DiffView {
 func (dv *DiffView) clearCaches() {
 	dv.cachedLexer = nil
 	dv.clearSyntaxCache()
+	clear(dv.emphasisCache)
 	dv.isComputed = false
 }
 
@@ -199,6 +208,7 @@ func (dv *DiffView) String() string {
 		return err.Error()
 	}
 	dv.convertDiffToSplit()
+	dv.computeEmphasis()
 	dv.adjustStyles()
 	dv.detectNumDigits()
 	dv.detectTotalLines()
@@ -402,9 +412,13 @@ func (dv *DiffView) renderUnified() string {
 	printedLines := -dv.yOffset
 	shouldWrite := func() bool { return printedLines >= 0 }
 
-	getContent := func(in string, ls LineStyle) (content string, leadingEllipsis bool) {
-		content = strings.TrimSuffix(in, "\n")
-		content = dv.hightlightCode(content, ls.Code.GetBackground())
+	getContent := func(l *udiff.Line, ls LineStyle) (content string, leadingEllipsis bool) {
+		content = strings.TrimSuffix(l.Content, "\n")
+		if spans, ok := dv.emphasis[l]; ok && hasEmphasis(ls) {
+			content = dv.emphasizeCode(content, ls, spans)
+		} else {
+			content = dv.hightlightCode(content, ls.Code.GetBackground())
+		}
 		content = ansi.GraphemeWidth.Cut(content, dv.xOffset, len(content))
 		content = ansi.Truncate(content, dv.codeWidth, "…")
 		leadingEllipsis = dv.xOffset > 0 && strings.TrimSpace(content) != ""
@@ -428,7 +442,8 @@ outer:
 		beforeLine := h.FromLine
 		afterLine := h.ToLine
 
-		for j, l := range h.Lines {
+		for j := range h.Lines {
+			l := &h.Lines[j]
 			// print ellipis if we don't have enough space to print the rest of the diff
 			hasReachedHeight := dv.height > 0 && printedLines+1 == dv.height
 			isLastHunk := i+1 == len(dv.unified.Hunks)
@@ -452,7 +467,7 @@ outer:
 			case udiff.Equal:
 				if shouldWrite() {
 					ls := dv.style.EqualLine
-					content, leadingEllipsis := getContent(l.Content, ls)
+					content, leadingEllipsis := getContent(l, ls)
 					if dv.lineNumbers {
 						b.WriteString(ls.LineNumber.Render(pad(beforeLine, dv.beforeNumDigits)))
 						b.WriteString(ls.LineNumber.Render(pad(afterLine, dv.afterNumDigits)))
@@ -466,7 +481,7 @@ outer:
 			case udiff.Insert:
 				if shouldWrite() {
 					ls := dv.style.InsertLine
-					content, leadingEllipsis := getContent(l.Content, ls)
+					content, leadingEllipsis := getContent(l, ls)
 					if dv.lineNumbers {
 						b.WriteString(ls.LineNumber.Render(pad(" ", dv.beforeNumDigits)))
 						b.WriteString(ls.LineNumber.Render(pad(afterLine, dv.afterNumDigits)))
@@ -480,7 +495,7 @@ outer:
 			case udiff.Delete:
 				if shouldWrite() {
 					ls := dv.style.DeleteLine
-					content, leadingEllipsis := getContent(l.Content, ls)
+					content, leadingEllipsis := getContent(l, ls)
 					if dv.lineNumbers {
 						b.WriteString(ls.LineNumber.Render(pad(beforeLine, dv.beforeNumDigits)))
 						b.WriteString(ls.LineNumber.Render(pad(" ", dv.afterNumDigits)))
@@ -512,9 +527,13 @@ func (dv *DiffView) renderSplit() string {
 	printedLines := -dv.yOffset
 	shouldWrite := func() bool { return printedLines >= 0 }
 
-	getContent := func(in string, ls LineStyle) (content string, leadingEllipsis bool) {
-		content = strings.TrimSuffix(in, "\n")
-		content = dv.hightlightCode(content, ls.Code.GetBackground())
+	getContent := func(l *udiff.Line, ls LineStyle) (content string, leadingEllipsis bool) {
+		content = strings.TrimSuffix(l.Content, "\n")
+		if spans, ok := dv.emphasis[l]; ok && hasEmphasis(ls) {
+			content = dv.emphasizeCode(content, ls, spans)
+		} else {
+			content = dv.hightlightCode(content, ls.Code.GetBackground())
+		}
 		content = ansi.GraphemeWidth.Cut(content, dv.xOffset, len(content))
 		content = ansi.Truncate(content, dv.codeWidth, "…")
 		leadingEllipsis = dv.xOffset > 0 && strings.TrimSpace(content) != ""
@@ -587,7 +606,7 @@ outer:
 			case l.before.Kind == udiff.Equal:
 				if shouldWrite() {
 					ls := dv.style.EqualLine
-					content, leadingEllipsis := getContent(l.before.Content, ls)
+					content, leadingEllipsis := getContent(l.before, ls)
 					if dv.lineNumbers {
 						b.WriteString(ls.LineNumber.Render(pad(beforeLine, dv.beforeNumDigits)))
 					}
@@ -599,7 +618,7 @@ outer:
 			case l.before.Kind == udiff.Delete:
 				if shouldWrite() {
 					ls := dv.style.DeleteLine
-					content, leadingEllipsis := getContent(l.before.Content, ls)
+					content, leadingEllipsis := getContent(l.before, ls)
 					if dv.lineNumbers {
 						b.WriteString(ls.LineNumber.Render(pad(beforeLine, dv.beforeNumDigits)))
 					}
@@ -625,7 +644,7 @@ outer:
 			case l.after.Kind == udiff.Equal:
 				if shouldWrite() {
 					ls := dv.style.EqualLine
-					content, leadingEllipsis := getContent(l.after.Content, ls)
+					content, leadingEllipsis := getContent(l.after, ls)
 					if dv.lineNumbers {
 						b.WriteString(ls.LineNumber.Render(pad(afterLine, dv.afterNumDigits)))
 					}
@@ -637,7 +656,7 @@ outer:
 			case l.after.Kind == udiff.Insert:
 				if shouldWrite() {
 					ls := dv.style.InsertLine
-					content, leadingEllipsis := getContent(l.after.Content, ls)
+					content, leadingEllipsis := getContent(l.after, ls)
 					if dv.lineNumbers {
 						b.WriteString(ls.LineNumber.Render(pad(afterLine, dv.afterNumDigits)))
 					}
@@ -707,9 +726,14 @@ func (dv *DiffView) hightlightCode(source string, bgColor color.Color) string {
 	if dv.chromaStyle == nil {
 		return source
 	}
+	return dv.hightlightCodeWith(source, bgColor, lipgloss.NewStyle(), nil)
+}
 
-	// Create cache key from content and background color
-	cacheKey := dv.createSyntaxCacheKey(source, bgColor)
+// hightlightCodeWith highlights source, rendering the parts in spans with the
+// emphasis style.
+func (dv *DiffView) hightlightCodeWith(source string, bgColor color.Color, emphasis lipgloss.Style, spans []span) string {
+	// Create cache key from content, background color and emphasized parts
+	cacheKey := dv.createSyntaxCacheKey(source, bgColor, spans)
 
 	// Check if we already have this highlighted
 	if cached, exists := dv.syntaxCache[cacheKey]; exists {
@@ -717,7 +741,7 @@ func (dv *DiffView) hightlightCode(source string, bgColor color.Color) string {
 	}
 
 	l := dv.getChromaLexer()
-	f := dv.getChromaFormatter(bgColor)
+	f := dv.getChromaFormatter(bgColor, emphasis, spans)
 
 	it, err := l.Tokenise(nil, source)
 	if err != nil {
@@ -737,9 +761,10 @@ func (dv *DiffView) hightlightCode(source string, bgColor color.Color) string {
 	return result
 }
 
-// createSyntaxCacheKey creates a cache key from source content and background color.
-// We use a simple hash to keep memory usage reasonable.
-func (dv *DiffView) createSyntaxCacheKey(source string, bgColor color.Color) string {
+// createSyntaxCacheKey creates a cache key from source content, background
+// color and emphasized parts. We use a simple hash to keep memory usage
+// reasonable.
+func (dv *DiffView) createSyntaxCacheKey(source string, bgColor color.Color, spans []span) string {
 	// Convert color to string representation
 	r, g, b, a := bgColor.RGBA()
 	colorStr := fmt.Sprintf("%d,%d,%d,%d", r, g, b, a)
@@ -748,6 +773,9 @@ func (dv *DiffView) createSyntaxCacheKey(source string, bgColor color.Color) str
 	h := xxh3.New()
 	h.Write([]byte(source))
 	h.Write([]byte(colorStr))
+	for _, sp := range spans {
+		fmt.Fprintf(h, ",%d-%d", sp.start, sp.end)
+	}
 	return fmt.Sprintf("%x", h.Sum(nil))
 }
 
@@ -767,9 +795,11 @@ func (dv *DiffView) getChromaLexer() chroma.Lexer {
 	return dv.cachedLexer
 }
 
-func (dv *DiffView) getChromaFormatter(bgColor color.Color) chroma.Formatter {
+func (dv *DiffView) getChromaFormatter(bgColor color.Color, emphasis lipgloss.Style, spans []span) chroma.Formatter {
 	return chromaFormatter{
-		bgColor: colorToTerminalColor(bgColor),
+		bgColor:  colorToTerminalColor(bgColor),
+		emphasis: emphasis,
+		spans:    spans,
 	}
 }
 
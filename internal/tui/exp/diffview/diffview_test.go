@@ -3,13 +3,16 @@ package diffview_test
 import (
 	_ "embed"
 	"fmt"
+	"io"
 	"strings"
 	"testing"
 
 	"github.com/alecthomas/chroma/v2/styles"
-	"github.com/uglyswap/push/internal/tui/exp/diffview"
+	"github.com/charmbracelet/lipgloss"
 	"github.com/charmbracelet/x/ansi"
 	"github.com/charmbracelet/x/exp/golden"
+	"github.com/muesli/termenv"
+	"github.com/uglyswap/push/internal/tui/exp/diffview"
 )
 
 //go:embed testdata/TestDefault.before
@@ -42,6 +45,12 @@ var TestLineBreakIssueBefore string
 //go:embed testdata/TestLineBreakIssue.after
 var TestLineBreakIssueAfter string
 
+//go:embed testdata/TestIntraLine.before
+var TestIntraLineBefore string
+
+//go:embed testdata/TestIntraLine.after
+var TestIntraLineAfter string
+
 type (
 	TestFunc  func(dv *diffview.DiffView) *diffview.DiffView
 	TestFuncs map[string]TestFunc
@@ -203,6 +212,56 @@ func TestDiffViewLineBreakIssue(t *testing.T) {
 	}
 }
 
+func TestDiffViewIntraLine(t *testing.T) {
+	t.Parallel()
+
+	modes := map[string]diffview.IntraLine{
+		"Words": diffview.IntraLineWords,
+		"Chars": diffview.IntraLineChars,
+		"None":  diffview.IntraLineNone,
+	}
+	highlights := TestFuncs{
+		"NoSyntaxHighlight": func(dv *diffview.DiffView) *diffview.DiffView {
+			return dv.ChromaStyle(nil)
+		},
+		"SyntaxHighlight": func(dv *diffview.DiffView) *diffview.DiffView {
+			return dv.ChromaStyle(styles.Get("catppuccin-latte"))
+		},
+	}
+
+	for layoutName, layoutFunc := range LayoutFuncs {
+		t.Run(layoutName, func(t *testing.T) {
+			t.Parallel()
+
+			for modeName, mode := range modes {
+				for highlightName, highlightFunc := range highlights {
+					for _, xOffset := range []int{0, 8, 26} {
+						t.Run(fmt.Sprintf("%s/%s/XOffsetOf%02d", modeName, highlightName, xOffset), func(t *testing.T) {
+							t.Parallel()
+
+							style := trueColorStyle(diffview.DefaultLightStyle())
+							style.IntraLine = mode
+							dv := diffview.New().
+								Before("main.go", TestIntraLineBefore).
+								After("main.go", TestIntraLineAfter).
+								Style(style).
+								Width(60).
+								XOffset(xOffset)
+							dv = layoutFunc(dv)
+							dv = highlightFunc(dv)
+
+							output := dv.String()
+							golden.RequireEqual(t, []byte(output))
+
+							assertLineWidth(t, 60, output)
+						})
+					}
+				}
+			}
+		})
+	}
+}
+
 func TestDiffViewWidth(t *testing.T) {
 	for layoutName, layoutFunc := range LayoutFuncs {
 		t.Run(layoutName, func(t *testing.T) {
@@ -330,6 +389,26 @@ func TestDiffViewYOffsetInfinite(t *testing.T) {
 	}
 }
 
+// trueColorStyle renders a style in true colors whatever the terminal, for
+// the golden files to show the emphasis.
+func trueColorStyle(style diffview.Style) diffview.Style {
+	r := lipgloss.NewRenderer(io.Discard)
+	r.SetColorProfile(termenv.TrueColor)
+	for _, ls := range []*diffview.LineStyle{
+		&style.DividerLine,
+		&style.MissingLine,
+		&style.EqualLine,
+		&style.InsertLine,
+		&style.DeleteLine,
+	} {
+		ls.LineNumber = ls.LineNumber.Renderer(r)
+		ls.Symbol = ls.Symbol.Renderer(r)
+		ls.Code = ls.Code.Renderer(r)
+		ls.Emphasis = ls.Emphasis.Renderer(r)
+	}
+	return style
+}
+
 func assertLineWidth(t *testing.T, expected int, output string) {
 	var lineWidth int
 	for line := range strings.SplitSeq(output, "\n") {
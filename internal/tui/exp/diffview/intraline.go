@@ -0,0 +1,271 @@
+package diffview
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/aymanbagabas/go-udiff"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/rivo/uniseg"
+	"github.com/uglyswap/push/internal/ansiext"
+	"github.com/zeebo/xxh3"
+)
+
+// IntraLine defines how the changes within a deleted line and the inserted
+// line replacing it are emphasized.
+type IntraLine int
+
+const (
+	// IntraLineWords emphasizes the changed words.
+	IntraLineWords IntraLine = iota
+	// IntraLineChars emphasizes the changed characters.
+	IntraLineChars
+	// IntraLineNone emphasizes nothing within the lines.
+	IntraLineNone
+)
+
+const (
+	// maxIntraLineCells bounds the work of comparing two lines, as the product
+	// of their number of tokens. Longer lines are not emphasized.
+	maxIntraLineCells = 256 * 256
+	// minIntraLineEqualRatio is the part of the longest of two lines that must
+	// be left unchanged for their changes to be emphasized. Emphasizing lines
+	// with little in common only adds noise.
+	minIntraLineEqualRatio = 0.4
+)
+
+// span is a byte range of a line.
+type span struct {
+	start, end int
+}
+
+// emphasis holds the changed parts of a pair of lines.
+type emphasis struct {
+	before, after []span
+}
+
+// computeEmphasis pairs the deleted lines with the inserted lines replacing
+// them, the way they are shown side by side in the split layout, and finds
+// the changes within each pair.
+func (dv *DiffView) computeEmphasis() {
+	clear(dv.emphasis)
+	if dv.style.IntraLine == IntraLineNone {
+		return
+	}
+
+	switch dv.layout {
+	case layoutUnified:
+		for _, h := range dv.unified.Hunks {
+			for i := 0; i < len(h.Lines); {
+				// A run of deleted lines followed by a run of inserted lines.
+				deletes := i
+				for i < len(h.Lines) && h.Lines[i].Kind == udiff.Delete {
+					i++
+				}
+				inserts := i
+				for i < len(h.Lines) && h.Lines[i].Kind == udiff.Insert {
+					i++
+				}
+				if deletes == i {
+					i++
+					continue
+				}
+				for j := 0; deletes+j < inserts && inserts+j < i; j++ {
+					dv.emphasize(&h.Lines[deletes+j], &h.Lines[inserts+j])
+				}
+			}
+		}
+	case layoutSplit:
+		for _, h := range dv.splitHunks {
+			for _, l := range h.lines {
+				if l.before != nil && l.after != nil && l.before.Kind == udiff.Delete {
+					dv.emphasize(l.before, l.after)
+				}
+			}
+		}
+	}
+}
+
+// emphasize finds the changes between a deleted line and the inserted line
+// replacing it.
+func (dv *DiffView) emphasize(before, after *udiff.Line) {
+	beforeContent := strings.TrimSuffix(before.Content, "\n")
+	afterContent := strings.TrimSuffix(after.Content, "\n")
+
+	h := xxh3.New()
+	fmt.Fprintf(h, "%d\x00%s\x00%s", dv.style.IntraLine, beforeContent, afterContent)
+	key := fmt.Sprintf("%x", h.Sum(nil))
+	e, ok := dv.emphasisCache[key]
+	if !ok {
+		e = intraLineDiff(beforeContent, afterContent, dv.style.IntraLine)
+		dv.emphasisCache[key] = e
+	}
+	if len(e.before) > 0 {
+		dv.emphasis[before] = e.before
+	}
+	if len(e.after) > 0 {
+		dv.emphasis[after] = e.after
+	}
+}
+
+// intraLineDiff finds the parts of before and after not in common, comparing
+// words or characters depending on mode.
+func intraLineDiff(before, after string, mode IntraLine) emphasis {
+	beforeTokens := tokenize(before, mode)
+	afterTokens := tokenize(after, mode)
+	if len(beforeTokens)*len(afterTokens) > maxIntraLineCells {
+		return emphasis{}
+	}
+
+	// Longest common subsequence of the tokens, lengths[i][j] being the one
+	// of beforeTokens[i:] and afterTokens[j:].
+	lengths := make([][]int, len(beforeTokens)+1)
+	for i := range lengths {
+		lengths[i] = make([]int, len(afterTokens)+1)
+	}
+	for i := len(beforeTokens) - 1; i >= 0; i-- {
+		for j := len(afterTokens) - 1; j >= 0; j-- {
+			if beforeTokens[i] == afterTokens[j] {
+				lengths[i][j] = lengths[i+1][j+1] + 1
+			} else {
+				lengths[i][j] = max(lengths[i+1][j], lengths[i][j+1])
+			}
+		}
+	}
+
+	var e emphasis
+	var equal, beforeOffset, afterOffset int
+	i, j := 0, 0
+	for i < len(beforeTokens) || j < len(afterTokens) {
+		switch {
+		case i < len(beforeTokens) && j < len(afterTokens) && beforeTokens[i] == afterTokens[j]:
+			equal += len(beforeTokens[i])
+			beforeOffset += len(beforeTokens[i])
+			afterOffset += len(afterTokens[j])
+			i++
+			j++
+		case j == len(afterTokens) || (i < len(beforeTokens) && lengths[i+1][j] >= lengths[i][j+1]):
+			e.before = appendSpan(e.before, beforeOffset, beforeOffset+len(beforeTokens[i]))
+			beforeOffset += len(beforeTokens[i])
+			i++
+		default:
+			e.after = appendSpan(e.after, afterOffset, afterOffset+len(afterTokens[j]))
+			afterOffset += len(afterTokens[j])
+			j++
+		}
+	}
+
+	if float64(equal) < minIntraLineEqualRatio*float64(max(len(before), len(after))) {
+		return emphasis{}
+	}
+	return e
+}
+
+// appendSpan adds a span to spans, merging it with the last one when they
+// touch.
+func appendSpan(spans []span, start, end int) []span {
+	if n := len(spans); n > 0 && spans[n-1].end == start {
+		spans[n-1].end = end
+		return spans
+	}
+	return append(spans, span{start: start, end: end})
+}
+
+// tokenize splits a line into the tokens compared: words, runs of spaces and
+// single other characters for IntraLineWords, or characters for
+// IntraLineChars. Characters are grapheme clusters, so wide and combined
+// runes are never split.
+func tokenize(s string, mode IntraLine) []string {
+	var tokens []string
+	state := -1
+	for len(s) > 0 {
+		var cluster string
+		cluster, s, _, state = uniseg.FirstGraphemeClusterInString(s, state)
+		if mode == IntraLineWords && len(tokens) > 0 {
+			last := tokens[len(tokens)-1]
+			if class := classOf(cluster); class != classOther && class == classOf(last) {
+				tokens[len(tokens)-1] = last + cluster
+				continue
+			}
+		}
+		tokens = append(tokens, cluster)
+	}
+	return tokens
+}
+
+// tokenClass tells what a token is made of.
+type tokenClass int
+
+const (
+	classOther tokenClass = iota
+	classWord
+	classSpace
+)
+
+// classOf returns the class of a token from its first rune.
+func classOf(s string) tokenClass {
+	r, _ := utf8.DecodeRuneInString(s)
+	switch {
+	case r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r):
+		return classWord
+	case unicode.IsSpace(r):
+		return classSpace
+	}
+	return classOther
+}
+
+// splitSpans splits the part of a line starting at offset into the pieces in
+// and out of spans.
+func splitSpans(s string, offset int, spans []span) []piece {
+	var pieces []piece
+	end := offset + len(s)
+	for _, sp := range spans {
+		if sp.end <= offset || sp.start >= end {
+			continue
+		}
+		start := max(sp.start, offset)
+		if start > offset {
+			pieces = append(pieces, piece{text: s[:start-offset]})
+		}
+		stop := min(sp.end, end)
+		pieces = append(pieces, piece{text: s[start-offset : stop-offset], emphasized: true})
+		s = s[stop-offset:]
+		offset = stop
+	}
+	if s != "" {
+		pieces = append(pieces, piece{text: s})
+	}
+	return pieces
+}
+
+// piece is a part of a line, emphasized or not.
+type piece struct {
+	text       string
+	emphasized bool
+}
+
+// emphasizeCode renders a line with its changed parts emphasized, syntax
+// highlighted when a chroma style is set.
+func (dv *DiffView) emphasizeCode(source string, ls LineStyle, spans []span) string {
+	if dv.chromaStyle != nil {
+		return dv.hightlightCodeWith(source, ls.Code.GetBackground(), ls.Emphasis, spans)
+	}
+
+	var b strings.Builder
+	for _, p := range splitSpans(source, 0, spans) {
+		style := ls.Code
+		if p.emphasized {
+			style = ls.Emphasis
+		}
+		b.WriteString(style.Render(ansiext.Escape(p.text)))
+	}
+	return b.String()
+}
+
+// hasEmphasis tells whether a line style emphasizes changes within lines.
+func hasEmphasis(ls LineStyle) bool {
+	_, unset := ls.Emphasis.GetBackground().(lipgloss.NoColor)
+	return !unset
+}
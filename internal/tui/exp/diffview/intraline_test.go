@@ -0,0 +1,72 @@
+package diffview
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestIntraLineDiff(t *testing.T) {
+	tests := []struct {
+		name          string
+		before, after string
+		mode          IntraLine
+		expected      emphasis
+	}{
+		{
+			name:     "changed word",
+			before:   "total := count * 2",
+			after:    "total := amount * 2",
+			mode:     IntraLineWords,
+			expected: emphasis{before: []span{{9, 14}}, after: []span{{9, 15}}},
+		},
+		{
+			name:     "changed character",
+			before:   "total := count * 2",
+			after:    "total := counts * 2",
+			mode:     IntraLineChars,
+			expected: emphasis{after: []span{{14, 15}}},
+		},
+		{
+			name:     "inserted words",
+			before:   "greet(name)",
+			after:    "greet(name, loud)",
+			mode:     IntraLineWords,
+			expected: emphasis{after: []span{{10, 16}}},
+		},
+		{
+			name:     "wide runes",
+			before:   `"日本語"`,
+			after:    `"日本人"`,
+			mode:     IntraLineChars,
+			expected: emphasis{before: []span{{7, 10}}, after: []span{{7, 10}}},
+		},
+		{
+			name:   "little in common",
+			before: "return nil",
+			after:  "panic(err)",
+			mode:   IntraLineWords,
+		},
+	}
+
+	for _, tt := range tests {
+		result := intraLineDiff(tt.before, tt.after, tt.mode)
+		if !slices.Equal(result.before, tt.expected.before) || !slices.Equal(result.after, tt.expected.after) {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.expected, result)
+		}
+	}
+}
+
+func TestSplitSpans(t *testing.T) {
+	spans := []span{{0, 3}, {5, 6}, {8, 12}}
+	// A token of the line, from byte 2 to byte 10.
+	expected := []piece{
+		{text: "c", emphasized: true},
+		{text: "de"},
+		{text: "f", emphasized: true},
+		{text: "gh"},
+		{text: "ij", emphasized: true},
+	}
+	if result := splitSpans("cdefghij", 2, spans); !slices.Equal(result, expected) {
+		t.Errorf("expected %v, got %v", expected, result)
+	}
+}
@@ -10,6 +10,9 @@ type LineStyle struct {
 	LineNumber lipgloss.Style
 	Symbol     lipgloss.Style
 	Code       lipgloss.Style
+	// Emphasis is the style of the changed parts of the deleted and inserted
+	// lines replacing each other. Nothing is emphasized without a background.
+	Emphasis lipgloss.Style
 }
 
 // Style defines the overall style for the diff view, including styles for
@@ -21,6 +24,9 @@ type Style struct {
 	EqualLine   LineStyle
 	InsertLine  LineStyle
 	DeleteLine  LineStyle
+	// IntraLine sets how the changes within lines are emphasized, words by
+	// default.
+	IntraLine IntraLine
 }
 
 // DefaultLightStyle provides a default light theme style for the diff view.
@@ -58,6 +64,9 @@ func DefaultLightStyle() Style {
 			Code: lipgloss.NewStyle().
 				Foreground(charmtone.Pepper.Lipgloss()).
 				Background(lipgloss.Color("#e8f5e9")),
+			Emphasis: lipgloss.NewStyle().
+				Foreground(charmtone.Pepper.Lipgloss()).
+				Background(lipgloss.Color("#a5d6a7")),
 		},
 		DeleteLine: LineStyle{
 			LineNumber: lipgloss.NewStyle().
@@ -69,6 +78,9 @@ func DefaultLightStyle() Style {
 			Code: lipgloss.NewStyle().
 				Foreground(charmtone.Pepper.Lipgloss()).
 				Background(lipgloss.Color("#ffebee")),
+			Emphasis: lipgloss.NewStyle().
+				Foreground(charmtone.Pepper.Lipgloss()).
+				Background(lipgloss.Color("#ef9a9a")),
 		},
 	}
 }
@@ -108,6 +120,9 @@ func DefaultDarkStyle() Style {
 			Code: lipgloss.NewStyle().
 				Foreground(charmtone.Salt.Lipgloss()).
 				Background(lipgloss.Color("#303a30")),
+			Emphasis: lipgloss.NewStyle().
+				Foreground(charmtone.Salt.Lipgloss()).
+				Background(lipgloss.Color("#3f5a3f")),
 		},
 		DeleteLine: LineStyle{
 			LineNumber: lipgloss.NewStyle().
@@ -119,6 +134,9 @@ func DefaultDarkStyle() Style {
 			Code: lipgloss.NewStyle().
 				Foreground(charmtone.Salt.Lipgloss()).
 				Background(lipgloss.Color("#3a3030")),
+			Emphasis: lipgloss.NewStyle().
+				Foreground(charmtone.Salt.Lipgloss()).
+				Background(lipgloss.Color("#5c3a3a")),
 		},
 	}
 }
@@ -2,6 +2,7 @@ package list
 
 import (
 	"github.com/charmbracelet/bubbles/key"
+	"github.com/uglyswap/push/internal/tui/keymap"
 )
 
 type KeyMap struct {
@@ -19,46 +20,16 @@ type KeyMap struct {
 
 func DefaultKeyMap() KeyMap {
 	return KeyMap{
-		Down: key.NewBinding(
-			key.WithKeys("down", "ctrl+j", "ctrl+n", "j"),
-			key.WithHelp("↓", "down"),
-		),
-		Up: key.NewBinding(
-			key.WithKeys("up", "ctrl+k", "ctrl+p", "k"),
-			key.WithHelp("↑", "up"),
-		),
-		UpOneItem: key.NewBinding(
-			key.WithKeys("shift+up", "K"),
-			key.WithHelp("shift+↑", "up one item"),
-		),
-		DownOneItem: key.NewBinding(
-			key.WithKeys("shift+down", "J"),
-			key.WithHelp("shift+↓", "down one item"),
-		),
-		HalfPageDown: key.NewBinding(
-			key.WithKeys("d"),
-			key.WithHelp("d", "half page down"),
-		),
-		PageDown: key.NewBinding(
-			key.WithKeys("pgdown", " ", "f"),
-			key.WithHelp("f/pgdn", "page down"),
-		),
-		PageUp: key.NewBinding(
-			key.WithKeys("pgup", "b"),
-			key.WithHelp("b/pgup", "page up"),
-		),
-		HalfPageUp: key.NewBinding(
-			key.WithKeys("u"),
-			key.WithHelp("u", "half page up"),
-		),
-		Home: key.NewBinding(
-			key.WithKeys("g", "home"),
-			key.WithHelp("g", "home"),
-		),
-		End: key.NewBinding(
-			key.WithKeys("G", "end"),
-			key.WithHelp("G", "end"),
-		),
+		Down:         keymap.Binding(keymap.ListDown),
+		Up:           keymap.Binding(keymap.ListUp),
+		UpOneItem:    keymap.Binding(keymap.ListUpOneItem),
+		DownOneItem:  keymap.Binding(keymap.ListDownOneItem),
+		HalfPageDown: keymap.Binding(keymap.ListHalfPageDown),
+		PageDown:     keymap.Binding(keymap.ListPageDown),
+		PageUp:       keymap.Binding(keymap.ListPageUp),
+		HalfPageUp:   keymap.Binding(keymap.ListHalfPageUp),
+		Home:         keymap.Binding(keymap.ListHome),
+		End:          keymap.Binding(keymap.ListEnd),
 	}
 }
 
@@ -1,6 +1,7 @@
 package list
 
 import (
+	"slices"
 	"strings"
 	"sync"
 
@@ -74,6 +75,7 @@ type List[T Item] interface {
 	SelectParagraph(col, line int)
 	GetSelectedText(paddingLeft int) string
 	HasSelection() bool
+	SetHighlight(text string)
 }
 
 type direction int
@@ -133,6 +135,8 @@ type list[T Item] struct {
 	selectionEndLine    int
 
 	selectionActive bool
+
+	highlight string // text marked wherever it shows, ignoring case
 }
 
 type ListOption func(*confOptions)
@@ -489,6 +493,46 @@ func (l *list[T]) selectionView(view string, textOnly bool) string {
 	return scr.Render()
 }
 
+// highlightView marks the occurrences of the highlighted text on each line
+// of view, ignoring case. Only the lines shown are searched.
+func (l *list[T]) highlightView(view string) string {
+	t := styles.CurrentTheme()
+	area := uv.Rect(0, 0, l.width, l.height)
+	scr := uv.NewScreenBuffer(area.Dx(), area.Dy())
+	uv.NewStyledString(view).Draw(scr, area)
+
+	highlight := []rune(strings.ToLower(l.highlight))
+	for y := range scr.Height() {
+		// The lowercased runes of the line, and the column of each.
+		var line []rune
+		var cols []int
+		for x := range scr.Width() {
+			cell := scr.CellAt(x, y)
+			if cell == nil {
+				continue
+			}
+			for _, r := range strings.ToLower(cell.String()) {
+				line = append(line, r)
+				cols = append(cols, x)
+			}
+		}
+
+		for i := 0; i+len(highlight) <= len(line); i++ {
+			if !slices.Equal(line[i:i+len(highlight)], highlight) {
+				continue
+			}
+			for _, x := range cols[i : i+len(highlight)] {
+				cell := scr.CellAt(x, y).Clone()
+				cell.Style.Bg = t.Warning
+				cell.Style.Fg = t.BgBase
+				scr.SetCell(x, y, cell)
+			}
+			i += len(highlight) - 1
+		}
+	}
+	return scr.Render()
+}
+
 func (l *list[T]) View() string {
 	if l.height <= 0 || l.width <= 0 {
 		return ""
@@ -519,6 +563,10 @@ func (l *list[T]) View() string {
 		Width(l.width).
 		Render(view)
 
+	if l.highlight != "" {
+		view = l.highlightView(view)
+	}
+
 	if !l.hasSelection() {
 		l.cachedView = view
 		l.cachedViewOffset = l.offset
@@ -1570,6 +1618,13 @@ func (l *list[T]) SelectionClear() {
 	l.selectionActive = false
 }
 
+// SetHighlight marks text wherever it shows in the list, ignoring case. An
+// empty text marks nothing.
+func (l *list[T]) SetHighlight(text string) {
+	l.highlight = text
+	l.cachedViewDirty = true
+}
+
 func (l *list[T]) findWordBoundaries(col, line int) (startCol, endCol int) {
 	numLines := l.lineCount()
 
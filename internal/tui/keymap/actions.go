@@ -0,0 +1,422 @@
+package keymap
+
+// Action names a bindable action of the TUI, as "<scope>.<action>". The
+// names are the keys of the tui.keybindings configuration.
+type Action string
+
+// Scope groups the actions active in the same part of the interface.
+type Scope struct {
+	Name  string
+	Title string
+	// With lists the other scopes whose actions are active at the same time,
+	// and so must not share keys with this one.
+	With []string
+}
+
+type action struct {
+	name    Action
+	keys    []string
+	helpKey string
+	desc    string
+}
+
+const (
+	AppQuit     Action = "app.quit"
+	AppHelp     Action = "app.help"
+	AppCommands Action = "app.commands"
+	AppSuspend  Action = "app.suspend"
+	AppModels   Action = "app.models"
+	AppSessions Action = "app.sessions"
+	AppNewTab   Action = "app.new_tab"
+	AppCloseTab Action = "app.close_tab"
+	AppNextTab  Action = "app.next_tab"
+	AppPrevTab  Action = "app.previous_tab"
+	AppGoToTab  Action = "app.go_to_tab"
+
+	ChatNewSession    Action = "chat.new_session"
+	ChatAddAttachment Action = "chat.add_attachment"
+	ChatCancel        Action = "chat.cancel"
+	ChatTab           Action = "chat.tab"
+	ChatDetails       Action = "chat.details"
+	ChatTogglePills   Action = "chat.toggle_pills"
+	ChatPillLeft      Action = "chat.pill_left"
+	ChatPillRight     Action = "chat.pill_right"
+
+	EditorAddFile          Action = "editor.add_file"
+	EditorSend             Action = "editor.send"
+	EditorOpenEditor       Action = "editor.open_editor"
+	EditorNewline          Action = "editor.newline"
+	EditorDeleteAttachment Action = "editor.delete_attachment"
+
+	AttachmentsDeleteAll Action = "attachments.delete_all"
+	AttachmentsCancel    Action = "attachments.cancel"
+
+	MessagesCopy           Action = "messages.copy"
+	MessagesClearSelection Action = "messages.clear_selection"
+	MessagesEdit           Action = "messages.edit"
+	MessagesBranch         Action = "messages.branch"
+	MessagesRetry          Action = "messages.retry"
+	MessagesRetryWithModel Action = "messages.retry_with_model"
+	MessagesDelete         Action = "messages.delete"
+	MessagesSearch         Action = "messages.search"
+	MessagesSearchNext     Action = "messages.search_next"
+	MessagesSearchPrevious Action = "messages.search_previous"
+	MessagesNextToolCall   Action = "messages.next_tool_call"
+	MessagesPrevToolCall   Action = "messages.previous_tool_call"
+	MessagesLastError      Action = "messages.last_error"
+
+	SearchConfirm  Action = "search.confirm"
+	SearchNext     Action = "search.next"
+	SearchPrevious Action = "search.previous"
+	SearchCancel   Action = "search.cancel"
+
+	ListDown         Action = "list.down"
+	ListUp           Action = "list.up"
+	ListDownOneItem  Action = "list.down_one_item"
+	ListUpOneItem    Action = "list.up_one_item"
+	ListHalfPageDown Action = "list.half_page_down"
+	ListHalfPageUp   Action = "list.half_page_up"
+	ListPageDown     Action = "list.page_down"
+	ListPageUp       Action = "list.page_up"
+	ListHome         Action = "list.home"
+	ListEnd          Action = "list.end"
+
+	CompletionsDown           Action = "completions.down"
+	CompletionsUp             Action = "completions.up"
+	CompletionsSelect         Action = "completions.select"
+	CompletionsCancel         Action = "completions.cancel"
+	CompletionsInsertNext     Action = "completions.insert_next"
+	CompletionsInsertPrevious Action = "completions.insert_previous"
+
+	SplashSelect    Action = "splash.select"
+	SplashNext      Action = "splash.next"
+	SplashPrevious  Action = "splash.previous"
+	SplashYes       Action = "splash.yes"
+	SplashNo        Action = "splash.no"
+	SplashTab       Action = "splash.tab"
+	SplashLeftRight Action = "splash.left_right"
+	SplashBack      Action = "splash.back"
+	SplashCopy      Action = "splash.copy"
+
+	DialogClose Action = "dialog.close"
+
+	CommandsSelect   Action = "commands.select"
+	CommandsNext     Action = "commands.next"
+	CommandsPrevious Action = "commands.previous"
+	CommandsTab      Action = "commands.tab"
+	CommandsClose    Action = "commands.close"
+
+	ArgumentsConfirm  Action = "arguments.confirm"
+	ArgumentsNext     Action = "arguments.next"
+	ArgumentsPrevious Action = "arguments.previous"
+	ArgumentsClose    Action = "arguments.close"
+
+	SessionsSelect   Action = "sessions.select"
+	SessionsNext     Action = "sessions.next"
+	SessionsPrevious Action = "sessions.previous"
+	SessionsClose    Action = "sessions.close"
+
+	ModelsSelect   Action = "models.select"
+	ModelsNext     Action = "models.next"
+	ModelsPrevious Action = "models.previous"
+	ModelsChoose   Action = "models.choose"
+	ModelsTab      Action = "models.tab"
+	ModelsCopy     Action = "models.copy"
+	ModelsClose    Action = "models.close"
+
+	PermissionsLeft           Action = "permissions.left"
+	PermissionsRight          Action = "permissions.right"
+	PermissionsTab            Action = "permissions.tab"
+	PermissionsAllow          Action = "permissions.allow"
+	PermissionsAllowSession   Action = "permissions.allow_session"
+	PermissionsDeny           Action = "permissions.deny"
+	PermissionsSelect         Action = "permissions.select"
+	PermissionsToggleDiffMode Action = "permissions.toggle_diff_mode"
+	PermissionsScrollDown     Action = "permissions.scroll_down"
+	PermissionsScrollUp       Action = "permissions.scroll_up"
+	PermissionsScrollLeft     Action = "permissions.scroll_left"
+	PermissionsScrollRight    Action = "permissions.scroll_right"
+	PermissionsReview         Action = "permissions.review"
+	PermissionsNextHunk       Action = "permissions.next_hunk"
+	PermissionsPreviousHunk   Action = "permissions.previous_hunk"
+	PermissionsToggleHunk     Action = "permissions.toggle_hunk"
+	PermissionsEditHunk       Action = "permissions.edit_hunk"
+
+	QuitLeftRight Action = "quit.left_right"
+	QuitConfirm   Action = "quit.confirm"
+	QuitYes       Action = "quit.yes"
+	QuitNo        Action = "quit.no"
+	QuitTab       Action = "quit.tab"
+	QuitClose     Action = "quit.close"
+
+	CheckpointsNext       Action = "checkpoints.next"
+	CheckpointsPrevious   Action = "checkpoints.previous"
+	CheckpointsScrollUp   Action = "checkpoints.scroll_up"
+	CheckpointsScrollDown Action = "checkpoints.scroll_down"
+	CheckpointsRestore    Action = "checkpoints.restore"
+	CheckpointsClose      Action = "checkpoints.close"
+
+	FileChangesNext            Action = "filechanges.next"
+	FileChangesPrevious        Action = "filechanges.previous"
+	FileChangesNextVersion     Action = "filechanges.next_version"
+	FileChangesPreviousVersion Action = "filechanges.previous_version"
+	FileChangesScrollUp        Action = "filechanges.scroll_up"
+	FileChangesScrollDown      Action = "filechanges.scroll_down"
+	FileChangesToggleDiffMode  Action = "filechanges.toggle_diff_mode"
+	FileChangesGoToMessage     Action = "filechanges.go_to_message"
+	FileChangesClose           Action = "filechanges.close"
+
+	QueueNext     Action = "queue.next"
+	QueuePrevious Action = "queue.previous"
+	QueueMoveDown Action = "queue.move_down"
+	QueueMoveUp   Action = "queue.move_up"
+	QueueEdit     Action = "queue.edit"
+	QueueDrop     Action = "queue.drop"
+	QueueSendNow  Action = "queue.send_now"
+	QueueClose    Action = "queue.close"
+
+	WorktreeScrollUp   Action = "worktree.scroll_up"
+	WorktreeScrollDown Action = "worktree.scroll_down"
+	WorktreePageUp     Action = "worktree.page_up"
+	WorktreePageDown   Action = "worktree.page_down"
+	WorktreeMerge      Action = "worktree.merge"
+	WorktreeDiscard    Action = "worktree.discard"
+	WorktreeClose      Action = "worktree.close"
+
+	FilePickerSelect    Action = "filepicker.select"
+	FilePickerDown      Action = "filepicker.down"
+	FilePickerUp        Action = "filepicker.up"
+	FilePickerForward   Action = "filepicker.forward"
+	FilePickerBackward  Action = "filepicker.backward"
+	FilePickerResources Action = "filepicker.resources"
+	FilePickerClose     Action = "filepicker.close"
+
+	ReasoningNext     Action = "reasoning.next"
+	ReasoningPrevious Action = "reasoning.previous"
+	ReasoningSelect   Action = "reasoning.select"
+	ReasoningClose    Action = "reasoning.close"
+
+	ThemesNext     Action = "themes.next"
+	ThemesPrevious Action = "themes.previous"
+	ThemesSelect   Action = "themes.select"
+	ThemesClose    Action = "themes.close"
+
+	KeybindingsNext     Action = "keybindings.next"
+	KeybindingsPrevious Action = "keybindings.previous"
+	KeybindingsClose    Action = "keybindings.close"
+)
+
+var scopes = []Scope{
+	{Name: "app", Title: "Global"},
+	{Name: "chat", Title: "Chat", With: []string{"app"}},
+	{Name: "editor", Title: "Editor", With: []string{"app", "chat"}},
+	{Name: "attachments", Title: "Attachment deletion"},
+	{Name: "messages", Title: "Messages", With: []string{"list"}},
+	{Name: "search", Title: "Message search"},
+	{Name: "list", Title: "Lists"},
+	{Name: "completions", Title: "Completions"},
+	{Name: "splash", Title: "Onboarding"},
+	{Name: "dialog", Title: "Dialogs"},
+	{Name: "commands", Title: "Commands dialog"},
+	{Name: "arguments", Title: "Command arguments dialog"},
+	{Name: "sessions", Title: "Sessions dialog"},
+	{Name: "models", Title: "Models dialog"},
+	{Name: "permissions", Title: "Permissions dialog"},
+	{Name: "quit", Title: "Quit dialog"},
+	{Name: "checkpoints", Title: "Checkpoints dialog"},
+	{Name: "filechanges", Title: "File changes dialog"},
+	{Name: "queue", Title: "Queued prompts dialog"},
+	{Name: "worktree", Title: "Worktree dialog"},
+	{Name: "filepicker", Title: "File picker"},
+	{Name: "reasoning", Title: "Reasoning dialog"},
+	{Name: "themes", Title: "Themes dialog"},
+	{Name: "keybindings", Title: "Keybindings dialog"},
+}
+
+var actions = []action{
+	{AppQuit, []string{"ctrl+c"}, "ctrl+c", "quit"},
+	{AppHelp, []string{"ctrl+g"}, "ctrl+g", "more"},
+	{AppCommands, []string{"ctrl+p"}, "ctrl+p", "commands"},
+	{AppSuspend, []string{"ctrl+z"}, "ctrl+z", "suspend"},
+	{AppModels, []string{"ctrl+l", "ctrl+m"}, "ctrl+l", "models"},
+	{AppSessions, []string{"ctrl+s"}, "ctrl+s", "sessions"},
+	{AppNewTab, []string{"ctrl+t"}, "ctrl+t", "new tab"},
+	{AppCloseTab, []string{"alt+w"}, "alt+w", "close tab"},
+	{AppNextTab, []string{"alt+n", "ctrl+pgdown"}, "alt+n", "next tab"},
+	{AppPrevTab, []string{"alt+p", "ctrl+pgup"}, "alt+p", "previous tab"},
+	{AppGoToTab, []string{"alt+1", "alt+2", "alt+3", "alt+4", "alt+5", "alt+6", "alt+7", "alt+8", "alt+9"}, "alt+1-9", "go to tab"},
+
+	{ChatNewSession, []string{"ctrl+n"}, "ctrl+n", "new session"},
+	{ChatAddAttachment, []string{"ctrl+f"}, "ctrl+f", "add attachment"},
+	{ChatCancel, []string{"esc", "alt+esc"}, "esc", "cancel"},
+	{ChatTab, []string{"tab"}, "tab", "change focus"},
+	{ChatDetails, []string{"ctrl+d"}, "ctrl+d", "toggle details"},
+	{ChatTogglePills, []string{"ctrl+space"}, "ctrl+space", "toggle tasks"},
+	{ChatPillLeft, []string{"left"}, "←/→", "switch section"},
+	{ChatPillRight, []string{"right"}, "←/→", "switch section"},
+
+	{EditorAddFile, []string{"/"}, "/", "add file"},
+	{EditorSend, []string{"enter"}, "enter", "send"},
+	{EditorOpenEditor, []string{"ctrl+o"}, "ctrl+o", "open editor"},
+	// "ctrl+j" is a common keybinding for newline in many editors. If the
+	// terminal supports "shift+enter", the help text is substituted to
+	// reflect that.
+	{EditorNewline, []string{"shift+enter", "ctrl+j"}, "ctrl+j", "newline"},
+	{EditorDeleteAttachment, []string{"ctrl+r"}, "ctrl+r", "delete attachment at index i"},
+
+	{AttachmentsDeleteAll, []string{"r"}, "r", "delete all attachments"},
+	{AttachmentsCancel, []string{"esc", "alt+esc"}, "esc", "cancel delete mode"},
+
+	{MessagesCopy, []string{"c", "y", "C", "Y"}, "c/y", "copy"},
+	{MessagesClearSelection, []string{"esc", "alt+esc"}, "esc", "clear selection"},
+	{MessagesEdit, []string{"e"}, "e", "edit prompt"},
+	{MessagesBranch, []string{"E"}, "E", "edit prompt in a branch"},
+	{MessagesRetry, []string{"r"}, "r", "retry"},
+	{MessagesRetryWithModel, []string{"R"}, "R", "retry with model"},
+	{MessagesDelete, []string{"x"}, "x", "delete prompt"},
+	{MessagesSearch, []string{"/"}, "/", "search"},
+	{MessagesSearchNext, []string{"n"}, "n", "next match"},
+	{MessagesSearchPrevious, []string{"N"}, "N", "previous match"},
+	{MessagesNextToolCall, []string{"]"}, "]", "next tool call"},
+	{MessagesPrevToolCall, []string{"["}, "[", "previous tool call"},
+	{MessagesLastError, []string{"!"}, "!", "last error"},
+
+	{SearchConfirm, []string{"enter"}, "enter", "done"},
+	{SearchNext, []string{"down"}, "↓", "next match"},
+	{SearchPrevious, []string{"up"}, "↑", "previous match"},
+	{SearchCancel, []string{"esc", "alt+esc"}, "esc", "cancel"},
+
+	{ListDown, []string{"down", "ctrl+j", "ctrl+n", "j"}, "↓", "down"},
+	{ListUp, []string{"up", "ctrl+k", "ctrl+p", "k"}, "↑", "up"},
+	{ListDownOneItem, []string{"shift+down", "J"}, "shift+↓", "down one item"},
+	{ListUpOneItem, []string{"shift+up", "K"}, "shift+↑", "up one item"},
+	{ListHalfPageDown, []string{"d"}, "d", "half page down"},
+	{ListHalfPageUp, []string{"u"}, "u", "half page up"},
+	{ListPageDown, []string{"pgdown", " ", "f"}, "f/pgdn", "page down"},
+	{ListPageUp, []string{"pgup", "b"}, "b/pgup", "page up"},
+	{ListHome, []string{"g", "home"}, "g", "home"},
+	{ListEnd, []string{"G", "end"}, "G", "end"},
+
+	{CompletionsDown, []string{"down"}, "down", "move down"},
+	{CompletionsUp, []string{"up"}, "up", "move up"},
+	{CompletionsSelect, []string{"enter", "tab", "ctrl+y"}, "enter", "select"},
+	{CompletionsCancel, []string{"esc", "alt+esc"}, "esc", "cancel"},
+	{CompletionsInsertNext, []string{"ctrl+n"}, "ctrl+n", "insert next"},
+	{CompletionsInsertPrevious, []string{"ctrl+p"}, "ctrl+p", "insert previous"},
+
+	{SplashSelect, []string{"enter", "ctrl+y"}, "enter", "confirm"},
+	{SplashNext, []string{"down", "ctrl+n"}, "↓", "next item"},
+	{SplashPrevious, []string{"up", "ctrl+p"}, "↑", "previous item"},
+	{SplashYes, []string{"y", "Y"}, "y", "yes"},
+	{SplashNo, []string{"n", "N"}, "n", "no"},
+	{SplashTab, []string{"tab"}, "tab", "switch"},
+	{SplashLeftRight, []string{"left", "right"}, "←/→", "switch"},
+	{SplashBack, []string{"esc", "alt+esc"}, "esc", "back"},
+	{SplashCopy, []string{"c"}, "c", "copy url"},
+
+	{DialogClose, []string{"esc", "alt+esc"}, "esc", "close dialog"},
+
+	{CommandsSelect, []string{"enter", "ctrl+y"}, "enter", "confirm"},
+	{CommandsNext, []string{"down", "ctrl+n"}, "↓", "next item"},
+	{CommandsPrevious, []string{"up", "ctrl+p"}, "↑", "previous item"},
+	{CommandsTab, []string{"tab"}, "tab", "switch selection"},
+	{CommandsClose, []string{"esc", "alt+esc"}, "esc", "cancel"},
+
+	{ArgumentsConfirm, []string{"enter"}, "enter", "confirm"},
+	{ArgumentsNext, []string{"tab", "down"}, "tab/↓", "next"},
+	{ArgumentsPrevious, []string{"shift+tab", "up"}, "shift+tab/↑", "previous"},
+	{ArgumentsClose, []string{"esc", "alt+esc"}, "esc", "cancel"},
+
+	{SessionsSelect, []string{"enter", "tab", "ctrl+y"}, "enter", "choose"},
+	{SessionsNext, []string{"down", "ctrl+n"}, "↓", "next item"},
+	{SessionsPrevious, []string{"up", "ctrl+p"}, "↑", "previous item"},
+	{SessionsClose, []string{"esc", "alt+esc"}, "esc", "exit"},
+
+	{ModelsSelect, []string{"enter", "ctrl+y"}, "enter", "choose"},
+	{ModelsNext, []string{"down", "ctrl+n"}, "↓", "next item"},
+	{ModelsPrevious, []string{"up", "ctrl+p"}, "↑", "previous item"},
+	{ModelsChoose, []string{"left", "right", "h", "l"}, "←→", "choose"},
+	{ModelsTab, []string{"tab"}, "tab", "toggle type"},
+	{ModelsCopy, []string{"c", "C"}, "c", "copy"},
+	{ModelsClose, []string{"esc", "alt+esc"}, "esc", "exit"},
+
+	{PermissionsLeft, []string{"left", "h"}, "←", "previous"},
+	{PermissionsRight, []string{"right", "l"}, "→", "next"},
+	{PermissionsTab, []string{"tab"}, "tab", "switch"},
+	{PermissionsAllow, []string{"a", "A", "ctrl+a"}, "a", "allow"},
+	{PermissionsAllowSession, []string{"s", "S", "ctrl+s"}, "s", "allow session"},
+	{PermissionsDeny, []string{"d", "D", "esc"}, "d", "deny"},
+	{PermissionsSelect, []string{"enter", "ctrl+y"}, "enter", "confirm"},
+	{PermissionsToggleDiffMode, []string{"t"}, "t", "toggle diff mode"},
+	{PermissionsScrollDown, []string{"shift+down", "J"}, "shift+↓", "scroll down"},
+	{PermissionsScrollUp, []string{"shift+up", "K"}, "shift+↑", "scroll up"},
+	{PermissionsScrollLeft, []string{"shift+left", "H"}, "shift+←", "scroll left"},
+	{PermissionsScrollRight, []string{"shift+right", "L"}, "shift+→", "scroll right"},
+	{PermissionsReview, []string{"r", "R"}, "r", "review hunks"},
+	{PermissionsNextHunk, []string{"down", "j"}, "↓", "next hunk"},
+	{PermissionsPreviousHunk, []string{"up", "k"}, "↑", "previous hunk"},
+	{PermissionsToggleHunk, []string{" "}, "space", "apply/reject hunk"},
+	{PermissionsEditHunk, []string{"e", "E"}, "e", "edit hunk"},
+
+	{QuitLeftRight, []string{"left", "right"}, "←/→", "switch options"},
+	{QuitConfirm, []string{"enter", " "}, "enter/space", "confirm"},
+	{QuitYes, []string{"y", "Y", "ctrl+c"}, "y/Y/ctrl+c", "yes"},
+	{QuitNo, []string{"n", "N"}, "n/N", "no"},
+	{QuitTab, []string{"tab"}, "tab", "switch options"},
+	{QuitClose, []string{"esc", "alt+esc"}, "esc", "cancel"},
+
+	{CheckpointsNext, []string{"down", "j"}, "↓/j", "next"},
+	{CheckpointsPrevious, []string{"up", "k"}, "↑/k", "previous"},
+	{CheckpointsScrollUp, []string{"pgup", "b"}, "pgup", "scroll diff up"},
+	{CheckpointsScrollDown, []string{"pgdown", " ", "f"}, "pgdown", "scroll diff down"},
+	{CheckpointsRestore, []string{"r"}, "r", "restore"},
+	{CheckpointsClose, []string{"esc", "alt+esc", "q"}, "esc", "close"},
+	{FileChangesNext, []string{"down", "j"}, "↓/j", "next file"},
+	{FileChangesPrevious, []string{"up", "k"}, "↑/k", "previous file"},
+	{FileChangesNextVersion, []string{"right", "l"}, "→/l", "next version"},
+	{FileChangesPreviousVersion, []string{"left", "h"}, "←/h", "previous version"},
+	{FileChangesScrollUp, []string{"pgup", "b"}, "pgup", "scroll diff up"},
+	{FileChangesScrollDown, []string{"pgdown", " ", "f"}, "pgdown", "scroll diff down"},
+	{FileChangesToggleDiffMode, []string{"t"}, "t", "toggle diff mode"},
+	{FileChangesGoToMessage, []string{"enter"}, "enter", "go to message"},
+	{FileChangesClose, []string{"esc", "alt+esc", "q"}, "esc", "close"},
+
+	{QueueNext, []string{"down", "j"}, "↓/j", "next"},
+	{QueuePrevious, []string{"up", "k"}, "↑/k", "previous"},
+	{QueueMoveDown, []string{"shift+down", "J"}, "shift+↓", "move down"},
+	{QueueMoveUp, []string{"shift+up", "K"}, "shift+↑", "move up"},
+	{QueueEdit, []string{"e"}, "e", "edit"},
+	{QueueDrop, []string{"x", "delete"}, "x", "drop"},
+	{QueueSendNow, []string{"enter"}, "enter", "send now"},
+	{QueueClose, []string{"esc", "alt+esc", "q"}, "esc", "close"},
+
+	{WorktreeScrollUp, []string{"up", "k"}, "↑/k", "scroll up"},
+	{WorktreeScrollDown, []string{"down", "j"}, "↓/j", "scroll down"},
+	{WorktreePageUp, []string{"pgup", "b"}, "pgup", "page up"},
+	{WorktreePageDown, []string{"pgdown", " ", "f"}, "pgdown", "page down"},
+	{WorktreeMerge, []string{"m"}, "m", "merge"},
+	{WorktreeDiscard, []string{"x"}, "x", "discard"},
+	{WorktreeClose, []string{"esc", "alt+esc", "q"}, "esc", "close"},
+
+	{FilePickerSelect, []string{"enter"}, "enter", "accept"},
+	{FilePickerDown, []string{"down", "j"}, "down/j", "move down"},
+	{FilePickerUp, []string{"up", "k"}, "up/k", "move up"},
+	{FilePickerForward, []string{"right", "l"}, "right/l", "move forward"},
+	{FilePickerBackward, []string{"left", "h"}, "left/h", "move backward"},
+	{FilePickerResources, []string{"tab"}, "tab", "files/mcp resources"},
+	{FilePickerClose, []string{"esc", "alt+esc"}, "esc", "close/exit"},
+
+	{ReasoningNext, []string{"down", "j", "ctrl+n"}, "↓/j/ctrl+n", "next"},
+	{ReasoningPrevious, []string{"up", "k", "ctrl+p"}, "↑/k/ctrl+p", "previous"},
+	{ReasoningSelect, []string{"enter"}, "enter", "select"},
+	{ReasoningClose, []string{"esc", "ctrl+c"}, "esc/ctrl+c", "close"},
+
+	{ThemesNext, []string{"down", "ctrl+n"}, "↓/ctrl+n", "next"},
+	{ThemesPrevious, []string{"up", "ctrl+p"}, "↑/ctrl+p", "previous"},
+	{ThemesSelect, []string{"enter"}, "enter", "select"},
+	{ThemesClose, []string{"esc", "ctrl+c"}, "esc/ctrl+c", "close"},
+
+	{KeybindingsNext, []string{"down", "ctrl+n"}, "↓/ctrl+n", "next"},
+	{KeybindingsPrevious, []string{"up", "ctrl+p"}, "↑/ctrl+p", "previous"},
+	{KeybindingsClose, []string{"esc", "alt+esc"}, "esc", "close"},
+}
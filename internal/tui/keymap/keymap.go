@@ -0,0 +1,261 @@
+// Package keymap is the registry of the TUI keybindings. Every bindable
+// action has a name and default keys, which the tui.keybindings
+// configuration can override.
+package keymap
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+)
+
+var (
+	index = func() map[Action]*action {
+		m := make(map[Action]*action, len(actions))
+		for i := range actions {
+			m[actions[i].name] = &actions[i]
+		}
+		return m
+	}()
+
+	// overrides holds the keys configured for actions, replacing their
+	// defaults. Load sets it before the interface is built.
+	overrides = map[Action][]string{}
+)
+
+// Info describes the effective binding of an action.
+type Info struct {
+	Action     Action
+	Scope      string
+	Keys       []string
+	HelpKey    string
+	Desc       string
+	Overridden bool
+}
+
+// Scopes returns the scopes of the actions, in display order.
+func Scopes() []Scope {
+	return slices.Clone(scopes)
+}
+
+// Actions returns the effective binding of every action, grouped by scope.
+func Actions() []Info {
+	infos := make([]Info, 0, len(actions))
+	for _, s := range scopes {
+		for _, a := range actions {
+			if a.name.Scope() != s.Name {
+				continue
+			}
+			_, overridden := overrides[a.name]
+			infos = append(infos, Info{
+				Action:     a.name,
+				Scope:      s.Name,
+				Keys:       Keys(a.name),
+				HelpKey:    HelpKey(a.name),
+				Desc:       a.desc,
+				Overridden: overridden,
+			})
+		}
+	}
+	return infos
+}
+
+// Scope returns the scope of the action.
+func (a Action) Scope() string {
+	scope, _, _ := strings.Cut(string(a), ".")
+	return scope
+}
+
+// Keys returns the effective keys of an action. An action disabled in the
+// configuration has none.
+func Keys(a Action) []string {
+	if keys, ok := overrides[a]; ok {
+		return keys
+	}
+	return mustGet(a).keys
+}
+
+// HelpKey returns how the keys of an action are shown in help views.
+func HelpKey(a Action) string {
+	keys, ok := overrides[a]
+	if !ok {
+		return mustGet(a).helpKey
+	}
+	names := make([]string, len(keys))
+	for i, k := range keys {
+		names[i] = displayKey(k)
+	}
+	return strings.Join(names, "/")
+}
+
+// Overridden tells whether the configuration changed the keys of an action.
+func Overridden(a Action) bool {
+	_, ok := overrides[a]
+	return ok
+}
+
+// Binding returns the effective binding of an action.
+func Binding(a Action) key.Binding {
+	return Help(a, mustGet(a).desc)
+}
+
+// Help returns the effective binding of an action, described as desc in help
+// views.
+func Help(a Action, desc string) key.Binding {
+	keys := Keys(a)
+	if len(keys) == 0 {
+		return key.NewBinding(key.WithDisabled())
+	}
+	return key.NewBinding(
+		key.WithKeys(keys...),
+		key.WithHelp(HelpKey(a), desc),
+	)
+}
+
+// Combined returns a binding matching the keys of several actions, for help
+// views showing them as one entry. It is shown as helpKey, unless the
+// configuration changed the keys of some of the actions.
+func Combined(helpKey, desc string, as ...Action) key.Binding {
+	var keys, helpKeys []string
+	for _, a := range as {
+		if Overridden(a) {
+			helpKey = ""
+		}
+		if k := Keys(a); len(k) > 0 {
+			keys = append(keys, k...)
+			if h := HelpKey(a); !slices.Contains(helpKeys, h) {
+				helpKeys = append(helpKeys, h)
+			}
+		}
+	}
+	if len(keys) == 0 {
+		return key.NewBinding(key.WithDisabled())
+	}
+	if helpKey == "" {
+		helpKey = strings.Join(helpKeys, "/")
+	}
+	return key.NewBinding(
+		key.WithKeys(keys...),
+		key.WithHelp(helpKey, desc),
+	)
+}
+
+// Load replaces the keys of the actions named in config, the
+// tui.keybindings configuration. An empty list of keys disables an action.
+// Unknown actions and overrides that would make two actions active at the
+// same time share a key are reported in the returned error and ignored. It
+// must run before the interface is built.
+func Load(config map[string][]string) error {
+	var errs []error
+	next := make(map[Action][]string, len(config))
+	for name, keys := range config {
+		a := Action(name)
+		if _, ok := index[a]; !ok {
+			errs = append(errs, fmt.Errorf("unknown keybinding action %s", name))
+			continue
+		}
+		normalized := make([]string, 0, len(keys))
+		for _, k := range keys {
+			if k = normalizeKey(k); k != "" && !slices.Contains(normalized, k) {
+				normalized = append(normalized, k)
+			}
+		}
+		next[a] = normalized
+	}
+	slices.SortFunc(errs, func(a, b error) int { return strings.Compare(a.Error(), b.Error()) })
+
+	// The defaults do not conflict, so dropping the conflicting overrides
+	// eventually settles.
+	for {
+		found := conflicts(next)
+		if len(found) == 0 {
+			break
+		}
+		for _, c := range found {
+			errs = append(errs, c)
+			delete(next, c.First)
+			delete(next, c.Second)
+		}
+	}
+	overrides = next
+	return errors.Join(errs...)
+}
+
+// Conflict is reported when two actions active at the same time share a key.
+type Conflict struct {
+	Key           string
+	First, Second Action
+}
+
+func (c Conflict) Error() string {
+	return fmt.Sprintf("keybinding %s is bound to both %s and %s", displayKey(c.Key), c.First, c.Second)
+}
+
+// conflicts returns the keys shared by actions active at the same time, with
+// the keys of with replacing the defaults.
+func conflicts(with map[Action][]string) []Conflict {
+	keysOf := func(a Action) []string {
+		if keys, ok := with[a]; ok {
+			return keys
+		}
+		return index[a].keys
+	}
+	var found []Conflict
+	for _, s := range scopes {
+		active := append([]string{s.Name}, s.With...)
+		owners := map[string]Action{}
+		for _, a := range actions {
+			if !slices.Contains(active, a.name.Scope()) {
+				continue
+			}
+			for _, k := range keysOf(a.name) {
+				owner, ok := owners[k]
+				if !ok {
+					owners[k] = a.name
+					continue
+				}
+				// Only report the conflicts of the scope itself, the
+				// others are reported with their own scope.
+				if owner.Scope() != s.Name && a.name.Scope() != s.Name {
+					continue
+				}
+				c := Conflict{Key: k, First: owner, Second: a.name}
+				if !slices.Contains(found, c) {
+					found = append(found, c)
+				}
+			}
+		}
+	}
+	return found
+}
+
+func mustGet(a Action) *action {
+	act, ok := index[a]
+	if !ok {
+		panic("keymap: unknown action " + string(a))
+	}
+	return act
+}
+
+// normalizeKey turns the key names of the configuration into the ones of key
+// messages.
+func normalizeKey(k string) string {
+	if k == " " {
+		return k
+	}
+	k = strings.TrimSpace(k)
+	if strings.EqualFold(k, "space") {
+		return " "
+	}
+	return k
+}
+
+func displayKey(k string) string {
+	if k == " " {
+		return "space"
+	}
+	return k
+}
@@ -0,0 +1,79 @@
+package keymap
+
+import (
+	"testing"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefaultsDoNotConflict(t *testing.T) {
+	require.Empty(t, conflicts(nil))
+	for _, a := range actions {
+		found := false
+		for _, s := range scopes {
+			found = found || s.Name == a.name.Scope()
+		}
+		require.True(t, found, "action %s has no scope", a.name)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	t.Cleanup(func() { require.NoError(t, Load(nil)) })
+
+	require.NoError(t, Load(map[string][]string{
+		string(AppHelp):      {"ctrl+h", "f1"},
+		string(AppCommands):  {"ctrl+k"},
+		string(AppSessions):  {},
+		string(ListPageDown): {"space"},
+	}))
+
+	help := Binding(AppHelp)
+	require.Equal(t, []string{"ctrl+h", "f1"}, help.Keys())
+	require.Equal(t, "ctrl+h/f1", help.Help().Key)
+	require.Equal(t, "more", help.Help().Desc)
+	require.True(t, Overridden(AppHelp))
+
+	require.False(t, Binding(AppSessions).Enabled())
+	require.Equal(t, []string{" "}, Keys(ListPageDown))
+	require.Equal(t, "space", HelpKey(ListPageDown))
+
+	// Untouched actions keep their defaults.
+	quit := Binding(AppQuit)
+	require.Equal(t, []string{"ctrl+c"}, quit.Keys())
+	require.False(t, Overridden(AppQuit))
+
+	combined := Combined("↑↓", "choose", SessionsPrevious, SessionsNext)
+	require.Equal(t, "↑↓", combined.Help().Key)
+	require.ElementsMatch(t, []string{"up", "ctrl+p", "down", "ctrl+n"}, combined.Keys())
+	combined = Combined("↑↓", "navigate", ListUp, ListPageDown)
+	require.Equal(t, "↑/space", combined.Help().Key)
+}
+
+func TestLoadErrors(t *testing.T) {
+	t.Cleanup(func() { require.NoError(t, Load(nil)) })
+
+	err := Load(map[string][]string{
+		"app.fly":                  {"f"},
+		string(ChatNewSession):     {"ctrl+g"},
+		string(EditorOpenEditor):   {"ctrl+e"},
+		string(ChatDetails):        {"ctrl+x"},
+		string(PermissionsAllow):   {"ctrl+x"},
+		string(CheckpointsRestore): {"q"},
+	})
+	require.ErrorContains(t, err, "unknown keybinding action app.fly")
+	require.ErrorContains(t, err, "keybinding ctrl+g is bound to both app.help and chat.new_session")
+	require.ErrorContains(t, err, "keybinding q is bound to both checkpoints.restore and checkpoints.close")
+
+	// Conflicting overrides are dropped, the others apply.
+	require.Equal(t, []string{"ctrl+n"}, Keys(ChatNewSession))
+	require.Equal(t, []string{"r"}, Keys(CheckpointsRestore))
+	require.Equal(t, []string{"ctrl+e"}, Keys(EditorOpenEditor))
+	// Scopes that are never active together can share keys.
+	require.True(t, key.Matches(keyMsg("ctrl+x"), Binding(ChatDetails)))
+	require.True(t, key.Matches(keyMsg("ctrl+x"), Binding(PermissionsAllow)))
+}
+
+type keyMsg string
+
+func (k keyMsg) String() string { return string(k) }
@@ -2,6 +2,7 @@ package tui
 
 import (
 	"github.com/charmbracelet/bubbles/key"
+	"github.com/uglyswap/push/internal/tui/keymap"
 )
 
 type KeyMap struct {
@@ -11,35 +12,27 @@ type KeyMap struct {
 	Suspend  key.Binding
 	Models   key.Binding
 	Sessions key.Binding
+	NewTab   key.Binding
+	CloseTab key.Binding
+	NextTab  key.Binding
+	PrevTab  key.Binding
+	GoToTab  key.Binding
 
 	pageBindings []key.Binding
 }
 
 func DefaultKeyMap() KeyMap {
 	return KeyMap{
-		Quit: key.NewBinding(
-			key.WithKeys("ctrl+c"),
-			key.WithHelp("ctrl+c", "quit"),
-		),
-		Help: key.NewBinding(
-			key.WithKeys("ctrl+g"),
-			key.WithHelp("ctrl+g", "more"),
-		),
-		Commands: key.NewBinding(
-			key.WithKeys("ctrl+p"),
-			key.WithHelp("ctrl+p", "commands"),
-		),
-		Suspend: key.NewBinding(
-			key.WithKeys("ctrl+z"),
-			key.WithHelp("ctrl+z", "suspend"),
-		),
-		Models: key.NewBinding(
-			key.WithKeys("ctrl+l", "ctrl+m"),
-			key.WithHelp("ctrl+l", "models"),
-		),
-		Sessions: key.NewBinding(
-			key.WithKeys("ctrl+s"),
-			key.WithHelp("ctrl+s", "sessions"),
-		),
+		Quit:     keymap.Binding(keymap.AppQuit),
+		Help:     keymap.Binding(keymap.AppHelp),
+		Commands: keymap.Binding(keymap.AppCommands),
+		Suspend:  keymap.Binding(keymap.AppSuspend),
+		Models:   keymap.Binding(keymap.AppModels),
+		Sessions: keymap.Binding(keymap.AppSessions),
+		NewTab:   keymap.Binding(keymap.AppNewTab),
+		CloseTab: keymap.Binding(keymap.AppCloseTab),
+		NextTab:  keymap.Binding(keymap.AppNextTab),
+		PrevTab:  keymap.Binding(keymap.AppPrevTab),
+		GoToTab:  keymap.Binding(keymap.AppGoToTab),
 	}
 }
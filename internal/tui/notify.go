@@ -0,0 +1,114 @@
+package tui
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/charmbracelet/x/ansi"
+	tea "github.com/uglyswap/push/internal/compat/bubbletea"
+	"mvdan.cc/sh/v3/shell"
+
+	"github.com/uglyswap/push/internal/config"
+)
+
+const (
+	// notificationTitle is the title of the notifications.
+	notificationTitle = "Push"
+	// notificationCommandTimeout bounds the run of the notification command.
+	notificationCommandTimeout = 10 * time.Second
+)
+
+// terminalOutput is the output of the program. The renderer writes whole
+// frames at once, and the lock keeps the notifications from being written in
+// the middle of one.
+type terminalOutput struct {
+	*os.File
+	mu sync.Mutex
+}
+
+func (o *terminalOutput) Write(p []byte) (int, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.File.Write(p)
+}
+
+func (o *terminalOutput) WriteString(s string) (int, error) {
+	return o.Write([]byte(s))
+}
+
+// Output returns the writer the program must render to, so that the
+// notifications are sent through it.
+func (a *appModel) Output() io.Writer {
+	return a.output
+}
+
+// notify tells that the agent needs attention, through the terminal and the
+// notification command, unless the terminal reported having the focus.
+func (a *appModel) notify(event config.NotificationEvent, body string) tea.Cmd {
+	if a.focused {
+		return nil
+	}
+	opts := config.Get().Options.TUI.Notifications
+	if !opts.Notifies(event) {
+		return nil
+	}
+
+	var seq string
+	switch opts.Method() {
+	case config.NotificationMethodOSC9:
+		seq = ansi.Notify(notificationTitle + ": " + sanitizeNotification(body))
+	case config.NotificationMethodOSC777:
+		seq = "\x1b]777;notify;" + notificationTitle + ";" + sanitizeNotification(body) + "\a"
+	}
+	if opts.Bell {
+		seq += "\a"
+	}
+	command := opts.Command
+	output := a.output
+
+	return func() tea.Msg {
+		if seq != "" {
+			if _, err := io.WriteString(output, seq); err != nil {
+				slog.Debug("Failed to send notification to the terminal", "error", err)
+			}
+		}
+		if command != "" {
+			runNotificationCommand(command, body)
+		}
+		return nil
+	}
+}
+
+// runNotificationCommand runs the notification command with the title and
+// body of the notification as its last arguments, as notify-send takes them.
+func runNotificationCommand(command, body string) {
+	fields, err := shell.Fields(command, nil)
+	if err != nil || len(fields) == 0 {
+		slog.Warn("Invalid notification command", "command", command, "error", err)
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), notificationCommandTimeout)
+	defer cancel()
+	args := append(fields[1:], notificationTitle, body)
+	if out, err := exec.CommandContext(ctx, fields[0], args...).CombinedOutput(); err != nil {
+		slog.Warn("Notification command failed", "command", command, "error", err, "output", string(out))
+	}
+}
+
+// sanitizeNotification removes from the body of a notification what would
+// end the escape sequence it is sent in, or split its fields.
+func sanitizeNotification(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r == ';' || unicode.IsControl(r) {
+			return ' '
+		}
+		return r
+	}, ansi.Strip(s))
+}
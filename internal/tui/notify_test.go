@@ -0,0 +1,28 @@
+package tui
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSanitizeNotification(t *testing.T) {
+	t.Parallel()
+
+	for _, tt := range []struct {
+		name string
+		in   string
+		want string
+	}{
+		{name: "plain", in: "Agent finished", want: "Agent finished"},
+		{name: "styled", in: "\x1b[1mAgent\x1b[0m finished", want: "Agent finished"},
+		{name: "field separator", in: "a;b", want: "a b"},
+		{name: "terminators", in: "a\ab\x1b\\c", want: "a bc"},
+		{name: "newlines", in: "first\nsecond\r\n", want: "first second  "},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			require.Equal(t, tt.want, sanitizeNotification(tt.in))
+		})
+	}
+}
@@ -35,7 +35,9 @@ import (
 	"github.com/uglyswap/push/internal/tui/components/dialogs/filepicker"
 	"github.com/uglyswap/push/internal/tui/components/dialogs/hyper"
 	"github.com/uglyswap/push/internal/tui/components/dialogs/models"
+	"github.com/uglyswap/push/internal/tui/components/dialogs/queue"
 	"github.com/uglyswap/push/internal/tui/components/dialogs/reasoning"
+	"github.com/uglyswap/push/internal/tui/keymap"
 	"github.com/uglyswap/push/internal/tui/page"
 	"github.com/uglyswap/push/internal/tui/styles"
 	"github.com/uglyswap/push/internal/tui/util"
@@ -90,6 +92,8 @@ type ChatPage interface {
 	util.Model
 	layout.Help
 	IsChatFocused() bool
+	// Session returns the session the page shows.
+	Session() session.Session
 }
 
 // cancelTimerCmd creates a command that expires the cancel timer
@@ -128,6 +132,8 @@ type chatPage struct {
 	isOnboarding     bool
 	isProjectInit    bool
 	promptQueue      int
+	// pendingRetry is the message to retry once another model is selected.
+	pendingRetry string
 
 	// Pills state
 	pillsExpanded      bool
@@ -352,7 +358,7 @@ func (p *chatPage) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 		anim.StepMsg,
 		spinner.TickMsg:
 		// Update todo spinner if agent is busy and we have in-progress todos
-		agentBusy := p.app.AgentCoordinator != nil && p.app.AgentCoordinator.IsBusy()
+		agentBusy := p.isBusy()
 		if _, ok := msg.(spinner.TickMsg); ok && p.hasInProgressTodo() && agentBusy {
 			var cmd tea.Cmd
 			p.todoSpinner, cmd = p.todoSpinner.Update(msg)
@@ -373,6 +379,52 @@ func (p *chatPage) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 		}
 
 		return p, tea.Batch(cmds...)
+	case chat.GoToMessageMsg:
+		if p.session.ID == "" {
+			return p, nil
+		}
+		// The selection only shows while the list has the focus.
+		p.focusedPane = PanelTypeChat
+		cmds = append(cmds, p.chat.Focus(), p.editor.Blur())
+		u, cmd := p.chat.Update(msg)
+		p.chat = u.(chat.MessageListCmp)
+		cmds = append(cmds, cmd)
+		return p, tea.Batch(cmds...)
+	case chat.ThemeChangedMsg:
+		u, cmd := p.chat.Update(msg)
+		p.chat = u.(chat.MessageListCmp)
+		cmds = append(cmds, cmd)
+		u, cmd = p.editor.Update(msg)
+		p.editor = u.(editor.Editor)
+		cmds = append(cmds, cmd)
+		return p, tea.Batch(cmds...)
+	case chat.EditPromptMsg:
+		return p, p.editPrompt(msg.MessageID, msg.Branch)
+	case promptEditedMsg:
+		return p, p.replacePrompt(msg.messageID, msg.branch, msg.text)
+	case chat.RetryPromptMsg:
+		if msg.ChooseModel {
+			p.pendingRetry = msg.MessageID
+			return p, util.CmdHandler(commands.SwitchModelMsg{})
+		}
+		return p, p.replacePrompt(msg.MessageID, false, "")
+	case models.AgentModelUpdatedMsg:
+		if p.pendingRetry == "" {
+			return p, nil
+		}
+		id := p.pendingRetry
+		p.pendingRetry = ""
+		return p, p.replacePrompt(id, false, "")
+	case promptReplacedMsg:
+		return p, p.sendReplacedPrompt(msg)
+	case queue.SendNowMsg:
+		return p, p.sendQueuedPromptNow(msg.SessionID, msg.Index)
+	case chat.DeleteTurnMsg:
+		return p, p.deleteTurn(msg.MessageID)
+	case turnDeletedMsg:
+		u, cmd := p.chat.Update(chat.MessagesChangedMsg{})
+		p.chat = u.(chat.MessageListCmp)
+		return p, tea.Batch(cmd, util.ReportInfo("Prompt deleted"))
 	case commands.ToggleYoloModeMsg:
 		// update the editor style
 		u, cmd := p.editor.Update(msg)
@@ -390,7 +442,7 @@ func (p *chatPage) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 		return p, tea.Batch(cmds...)
 
 	case commands.CommandRunCustomMsg:
-		if p.app.AgentCoordinator.IsBusy() {
+		if p.isBusy() {
 			return p, util.ReportWarn("Agent is busy, please wait before executing a command...")
 		}
 
@@ -414,18 +466,25 @@ func (p *chatPage) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 		p.focusedPane = PanelTypeEditor
 		return p, p.SetSize(p.width, p.height)
 	case commands.NewSessionsMsg:
-		if p.app.AgentCoordinator.IsBusy() {
+		if p.isBusy() {
 			return p, util.ReportWarn("Agent is busy, please wait before starting a new session...")
 		}
 		return p, p.newSession()
 	case tea.KeyPressMsg:
+		p.pendingRetry = ""
+		if p.focusedPane == PanelTypeChat && p.chat.Searching() {
+			// The search takes all the keys while typed.
+			u, cmd := p.chat.Update(msg)
+			p.chat = u.(chat.MessageListCmp)
+			return p, cmd
+		}
 		switch {
 		case key.Matches(msg, p.keyMap.NewSession):
 			// if we have no agent do nothing
 			if p.app.AgentCoordinator == nil {
 				return p, nil
 			}
-			if p.app.AgentCoordinator.IsBusy() {
+			if p.isBusy() {
 				return p, util.ReportWarn("Agent is busy, please wait before starting a new session...")
 			}
 			return p, p.newSession()
@@ -454,7 +513,10 @@ func (p *chatPage) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 			}
 			return p, p.changeFocus()
 		case key.Matches(msg, p.keyMap.Cancel):
-			if p.session.ID != "" && p.app.AgentCoordinator.IsBusy() {
+			if p.focusedPane == PanelTypeChat && p.chat.ClearSearch() {
+				return p, nil
+			}
+			if p.isBusy() {
 				return p, p.cancel()
 			}
 		case key.Matches(msg, p.keyMap.Details):
@@ -552,7 +614,7 @@ func (p *chatPage) View() string {
 		queueFocused := p.pillsExpanded && p.focusedPillSection == PillSectionQueue
 
 		// Use spinner when agent is busy, otherwise show static icon
-		agentBusy := p.app.AgentCoordinator != nil && p.app.AgentCoordinator.IsBusy()
+		agentBusy := p.isBusy()
 		inProgressIcon := t.S().Base.Foreground(styles.TC(t.GreenDark)).Render(styles.CenterSpinnerIcon)
 		if agentBusy {
 			inProgressIcon = p.todoSpinner.View()
@@ -844,6 +906,16 @@ func (p *chatPage) newSession() tea.Cmd {
 	)
 }
 
+func (p *chatPage) Session() session.Session {
+	return p.session
+}
+
+// isBusy tells whether the agent is working on the session of the page.
+// Other sessions may run at the same time.
+func (p *chatPage) isBusy() bool {
+	return p.session.ID != "" && p.app.AgentCoordinator != nil && p.app.AgentCoordinator.IsSessionBusy(p.session.ID)
+}
+
 func (p *chatPage) setSession(sess session.Session) tea.Cmd {
 	if p.session.ID == sess.ID {
 		return nil
@@ -960,26 +1032,52 @@ func (p *chatPage) sendMessage(text string, attachments []message.Attachment) te
 		session = newSession
 		cmds = append(cmds, util.CmdHandler(chat.SessionSelectedMsg(session)))
 	}
+	cmds = append(cmds, p.chat.GoToBottom())
+	cmds = append(cmds, p.runPrompt(session.ID, text, attachments))
+	return tea.Batch(cmds...)
+}
+
+// runPrompt sends a prompt to the agent of a session.
+func (p *chatPage) runPrompt(sessionID, text string, attachments []message.Attachment) tea.Cmd {
+	if p.app.AgentCoordinator == nil {
+		return util.ReportError(fmt.Errorf("coder agent is not initialized"))
+	}
+	return func() tea.Msg {
+		_, err := p.app.AgentCoordinator.Run(context.Background(), sessionID, text, attachments...)
+		return runErrorMsg(err)
+	}
+}
+
+// sendQueuedPromptNow cancels the current turn of a session to send its
+// queued prompt at index next.
+func (p *chatPage) sendQueuedPromptNow(sessionID string, index int) tea.Cmd {
 	if p.app.AgentCoordinator == nil {
 		return util.ReportError(fmt.Errorf("coder agent is not initialized"))
 	}
-	cmds = append(cmds, p.chat.GoToBottom())
-	cmds = append(cmds, func() tea.Msg {
-		_, err := p.app.AgentCoordinator.Run(context.Background(), session.ID, text, attachments...)
-		if err != nil {
-			isCancelErr := errors.Is(err, context.Canceled)
-			isPermissionErr := errors.Is(err, permission.ErrorPermissionDenied)
-			if isCancelErr || isPermissionErr {
-				return nil
-			}
-			return util.InfoMsg{
-				Type: util.InfoTypeError,
-				Msg:  err.Error(),
-			}
-		}
+	return tea.Batch(
+		util.ReportInfo("Sending the queued prompt now"),
+		func() tea.Msg {
+			_, err := p.app.AgentCoordinator.SendQueuedPromptNow(context.Background(), sessionID, index)
+			return runErrorMsg(err)
+		},
+	)
+}
+
+// runErrorMsg reports the error a turn of the agent ended with, unless the
+// user caused it.
+func runErrorMsg(err error) tea.Msg {
+	if err == nil {
 		return nil
-	})
-	return tea.Batch(cmds...)
+	}
+	isCancelErr := errors.Is(err, context.Canceled)
+	isPermissionErr := errors.Is(err, permission.ErrorPermissionDenied)
+	if isCancelErr || isPermissionErr {
+		return nil
+	}
+	return util.InfoMsg{
+		Type: util.InfoTypeError,
+		Msg:  err.Error(),
+	}
 }
 
 func (p *chatPage) Bindings() []key.Binding {
@@ -987,13 +1085,10 @@ func (p *chatPage) Bindings() []key.Binding {
 		p.keyMap.NewSession,
 		p.keyMap.AddAttachment,
 	}
-	if p.app.AgentCoordinator != nil && p.app.AgentCoordinator.IsBusy() {
+	if p.isBusy() {
 		cancelBinding := p.keyMap.Cancel
 		if p.isCanceling {
-			cancelBinding = key.NewBinding(
-				key.WithKeys("esc", "alt+esc"),
-				key.WithHelp("esc", "press again to cancel"),
-			)
+			cancelBinding = keymap.Help(keymap.ChatCancel, "press again to cancel")
 		}
 		bindings = append([]key.Binding{cancelBinding}, bindings...)
 	}
@@ -1001,18 +1096,12 @@ func (p *chatPage) Bindings() []key.Binding {
 	switch p.focusedPane {
 	case PanelTypeChat:
 		bindings = append([]key.Binding{
-			key.NewBinding(
-				key.WithKeys("tab"),
-				key.WithHelp("tab", "focus editor"),
-			),
+			keymap.Help(keymap.ChatTab, "focus editor"),
 		}, bindings...)
 		bindings = append(bindings, p.chat.Bindings()...)
 	case PanelTypeEditor:
 		bindings = append([]key.Binding{
-			key.NewBinding(
-				key.WithKeys("tab"),
-				key.WithHelp("tab", "focus chat"),
-			),
+			keymap.Help(keymap.ChatTab, "focus chat"),
 		}, bindings...)
 		bindings = append(bindings, p.editor.Bindings()...)
 	case PanelTypeSplash:
@@ -1029,25 +1118,13 @@ func (p *chatPage) Help() help.KeyMap {
 	case p.isOnboarding && p.splash.IsShowingClaudeAuthMethodChooser():
 		shortList = append(shortList,
 			// Choose auth method
-			key.NewBinding(
-				key.WithKeys("left", "right", "tab"),
-				key.WithHelp("←→/tab", "choose"),
-			),
+			keymap.Combined("←→/tab", "choose", keymap.SplashLeftRight, keymap.SplashTab),
 			// Accept selection
-			key.NewBinding(
-				key.WithKeys("enter"),
-				key.WithHelp("enter", "accept"),
-			),
+			keymap.Help(keymap.SplashSelect, "accept"),
 			// Go back
-			key.NewBinding(
-				key.WithKeys("esc", "alt+esc"),
-				key.WithHelp("esc", "back"),
-			),
+			keymap.Binding(keymap.SplashBack),
 			// Quit
-			key.NewBinding(
-				key.WithKeys("ctrl+c"),
-				key.WithHelp("ctrl+c", "quit"),
-			),
+			keymap.Binding(keymap.AppQuit),
 		)
 		// keep them the same
 		for _, v := range shortList {
@@ -1057,47 +1134,26 @@ func (p *chatPage) Help() help.KeyMap {
 		switch {
 		case p.splash.IsClaudeOAuthURLState():
 			shortList = append(shortList,
-				key.NewBinding(
-					key.WithKeys("enter"),
-					key.WithHelp("enter", "open"),
-				),
-				key.NewBinding(
-					key.WithKeys("c"),
-					key.WithHelp("c", "copy url"),
-				),
+				keymap.Help(keymap.SplashSelect, "open"),
+				keymap.Binding(keymap.SplashCopy),
 			)
 		case p.splash.IsClaudeOAuthComplete():
 			shortList = append(shortList,
-				key.NewBinding(
-					key.WithKeys("enter"),
-					key.WithHelp("enter", "continue"),
-				),
+				keymap.Help(keymap.SplashSelect, "continue"),
 			)
 		case p.splash.IsShowingHyperOAuth2() || p.splash.IsShowingCopilotOAuth2():
 			shortList = append(shortList,
-				key.NewBinding(
-					key.WithKeys("enter"),
-					key.WithHelp("enter", "copy url & open signup"),
-				),
-				key.NewBinding(
-					key.WithKeys("c"),
-					key.WithHelp("c", "copy url"),
-				),
+				keymap.Help(keymap.SplashSelect, "copy url & open signup"),
+				keymap.Binding(keymap.SplashCopy),
 			)
 		default:
 			shortList = append(shortList,
-				key.NewBinding(
-					key.WithKeys("enter"),
-					key.WithHelp("enter", "submit"),
-				),
+				keymap.Help(keymap.SplashSelect, "submit"),
 			)
 		}
 		shortList = append(shortList,
 			// Quit
-			key.NewBinding(
-				key.WithKeys("ctrl+c"),
-				key.WithHelp("ctrl+c", "quit"),
-			),
+			keymap.Binding(keymap.AppQuit),
 		)
 		// keep them the same
 		for _, v := range shortList {
@@ -1106,20 +1162,11 @@ func (p *chatPage) Help() help.KeyMap {
 	case p.isOnboarding && !p.splash.IsShowingAPIKey():
 		shortList = append(shortList,
 			// Choose model
-			key.NewBinding(
-				key.WithKeys("up", "down"),
-				key.WithHelp("↑/↓", "choose"),
-			),
+			keymap.Combined("↑/↓", "choose", keymap.SplashPrevious, keymap.SplashNext),
 			// Accept selection
-			key.NewBinding(
-				key.WithKeys("enter", "ctrl+y"),
-				key.WithHelp("enter", "accept"),
-			),
+			keymap.Help(keymap.SplashSelect, "accept"),
 			// Quit
-			key.NewBinding(
-				key.WithKeys("ctrl+c"),
-				key.WithHelp("ctrl+c", "quit"),
-			),
+			keymap.Binding(keymap.AppQuit),
 		)
 		// keep them the same
 		for _, v := range shortList {
@@ -1128,26 +1175,17 @@ func (p *chatPage) Help() help.KeyMap {
 	case p.isOnboarding && p.splash.IsShowingAPIKey():
 		if p.splash.IsAPIKeyValid() {
 			shortList = append(shortList,
-				key.NewBinding(
-					key.WithKeys("enter"),
-					key.WithHelp("enter", "continue"),
-				),
+				keymap.Help(keymap.SplashSelect, "continue"),
 			)
 		} else {
 			shortList = append(shortList,
 				// Go back
-				key.NewBinding(
-					key.WithKeys("esc", "alt+esc"),
-					key.WithHelp("esc", "back"),
-				),
+				keymap.Binding(keymap.SplashBack),
 			)
 		}
 		shortList = append(shortList,
 			// Quit
-			key.NewBinding(
-				key.WithKeys("ctrl+c"),
-				key.WithHelp("ctrl+c", "quit"),
-			),
+			keymap.Binding(keymap.AppQuit),
 		)
 		// keep them the same
 		for _, v := range shortList {
@@ -1155,10 +1193,7 @@ func (p *chatPage) Help() help.KeyMap {
 		}
 	case p.isProjectInit:
 		shortList = append(shortList,
-			key.NewBinding(
-				key.WithKeys("ctrl+c"),
-				key.WithHelp("ctrl+c", "quit"),
-			),
+			keymap.Binding(keymap.AppQuit),
 		)
 		// keep them the same
 		for _, v := range shortList {
@@ -1167,40 +1202,22 @@ func (p *chatPage) Help() help.KeyMap {
 	default:
 		if p.editor.IsCompletionsOpen() {
 			shortList = append(shortList,
-				key.NewBinding(
-					key.WithKeys("tab", "enter"),
-					key.WithHelp("tab/enter", "complete"),
-				),
-				key.NewBinding(
-					key.WithKeys("esc", "alt+esc"),
-					key.WithHelp("esc", "cancel"),
-				),
-				key.NewBinding(
-					key.WithKeys("up", "down"),
-					key.WithHelp("↑/↓", "choose"),
-				),
+				keymap.Combined("tab/enter", "complete", keymap.CompletionsSelect),
+				keymap.Binding(keymap.CompletionsCancel),
+				keymap.Combined("↑/↓", "choose", keymap.CompletionsUp, keymap.CompletionsDown),
 			)
 			for _, v := range shortList {
 				fullList = append(fullList, []key.Binding{v})
 			}
 			return core.NewSimpleHelp(shortList, fullList)
 		}
-		if p.app.AgentCoordinator != nil && p.app.AgentCoordinator.IsBusy() {
-			cancelBinding := key.NewBinding(
-				key.WithKeys("esc", "alt+esc"),
-				key.WithHelp("esc", "cancel"),
-			)
+		if p.isBusy() {
+			cancelBinding := p.keyMap.Cancel
 			if p.isCanceling {
-				cancelBinding = key.NewBinding(
-					key.WithKeys("esc", "alt+esc"),
-					key.WithHelp("esc", "press again to cancel"),
-				)
+				cancelBinding = keymap.Help(keymap.ChatCancel, "press again to cancel")
 			}
 			if p.app.AgentCoordinator != nil && p.app.AgentCoordinator.QueuedPrompts(p.session.ID) > 0 {
-				cancelBinding = key.NewBinding(
-					key.WithKeys("esc", "alt+esc"),
-					key.WithHelp("esc", "clear queue"),
-				)
+				cancelBinding = keymap.Help(keymap.ChatCancel, "clear queue")
 			}
 			shortList = append(shortList, cancelBinding)
 			fullList = append(fullList,
@@ -1212,23 +1229,9 @@ func (p *chatPage) Help() help.KeyMap {
 		globalBindings := []key.Binding{}
 		// we are in a session
 		if p.session.ID != "" {
-			var tabKey key.Binding
-			switch p.focusedPane {
-			case PanelTypeEditor:
-				tabKey = key.NewBinding(
-					key.WithKeys("tab"),
-					key.WithHelp("tab", "focus chat"),
-				)
-			case PanelTypeChat:
-				tabKey = key.NewBinding(
-					key.WithKeys("tab"),
-					key.WithHelp("tab", "focus editor"),
-				)
-			default:
-				tabKey = key.NewBinding(
-					key.WithKeys("tab"),
-					key.WithHelp("tab", "focus chat"),
-				)
+			tabKey := keymap.Help(keymap.ChatTab, "focus chat")
+			if p.focusedPane == PanelTypeChat {
+				tabKey = keymap.Help(keymap.ChatTab, "focus editor")
 			}
 			shortList = append(shortList, tabKey)
 			globalBindings = append(globalBindings, tabKey)
@@ -1241,38 +1244,26 @@ func (p *chatPage) Help() help.KeyMap {
 				globalBindings = append(globalBindings, p.keyMap.PillLeft)
 			}
 		}
-		commandsBinding := key.NewBinding(
-			key.WithKeys("ctrl+p"),
-			key.WithHelp("ctrl+p", "commands"),
-		)
+		commandsBinding := keymap.Binding(keymap.AppCommands)
 		if p.focusedPane == PanelTypeEditor && p.editor.IsEmpty() {
-			commandsBinding.SetHelp("/ or ctrl+p", "commands")
+			commandsBinding.SetHelp("/ or "+keymap.HelpKey(keymap.AppCommands), "commands")
 		}
-		modelsBinding := key.NewBinding(
-			key.WithKeys("ctrl+m", "ctrl+l"),
-			key.WithHelp("ctrl+l", "models"),
-		)
-		if p.keyboardEnhancementsFlags > 0 {
+		modelsBinding := keymap.Binding(keymap.AppModels)
+		if p.keyboardEnhancementsFlags > 0 && !keymap.Overridden(keymap.AppModels) {
 			// non-zero flags mean we have at least key disambiguation
 			modelsBinding.SetHelp("ctrl+m", "models")
 		}
-		helpBinding := key.NewBinding(
-			key.WithKeys("ctrl+g"),
-			key.WithHelp("ctrl+g", "more"),
-		)
+		helpBinding := keymap.Binding(keymap.AppHelp)
 		globalBindings = append(globalBindings, commandsBinding, modelsBinding)
 		globalBindings = append(globalBindings,
-			key.NewBinding(
-				key.WithKeys("ctrl+s"),
-				key.WithHelp("ctrl+s", "sessions"),
-			),
+			keymap.Binding(keymap.AppSessions),
+			keymap.Binding(keymap.AppNewTab),
+			keymap.Combined("alt+n/p", "switch tab", keymap.AppNextTab, keymap.AppPrevTab),
 		)
 		if p.session.ID != "" {
 			globalBindings = append(globalBindings,
-				key.NewBinding(
-					key.WithKeys("ctrl+n"),
-					key.WithHelp("ctrl+n", "new sessions"),
-				))
+				keymap.Binding(keymap.ChatNewSession),
+			)
 		}
 		shortList = append(shortList,
 			// Commands
@@ -1283,64 +1274,45 @@ func (p *chatPage) Help() help.KeyMap {
 
 		switch p.focusedPane {
 		case PanelTypeChat:
+			scrollBinding := keymap.Combined("↑↓", "scroll", keymap.ListUp, keymap.ListDown)
 			shortList = append(shortList,
-				key.NewBinding(
-					key.WithKeys("up", "down"),
-					key.WithHelp("↑↓", "scroll"),
-				),
-				messages.CopyKey,
+				scrollBinding,
+				messages.CopyKey(),
 			)
 			fullList = append(fullList,
 				[]key.Binding{
-					key.NewBinding(
-						key.WithKeys("up", "down"),
-						key.WithHelp("↑↓", "scroll"),
-					),
-					key.NewBinding(
-						key.WithKeys("shift+up", "shift+down"),
-						key.WithHelp("shift+↑↓", "next/prev item"),
-					),
-					key.NewBinding(
-						key.WithKeys("pgup", "b"),
-						key.WithHelp("b/pgup", "page up"),
-					),
-					key.NewBinding(
-						key.WithKeys("pgdown", " ", "f"),
-						key.WithHelp("f/pgdn", "page down"),
-					),
+					scrollBinding,
+					keymap.Combined("shift+↑↓", "next/prev item", keymap.ListUpOneItem, keymap.ListDownOneItem),
+					keymap.Binding(keymap.ListPageUp),
+					keymap.Binding(keymap.ListPageDown),
 				},
 				[]key.Binding{
-					key.NewBinding(
-						key.WithKeys("u"),
-						key.WithHelp("u", "half page up"),
-					),
-					key.NewBinding(
-						key.WithKeys("d"),
-						key.WithHelp("d", "half page down"),
-					),
-					key.NewBinding(
-						key.WithKeys("g", "home"),
-						key.WithHelp("g", "home"),
-					),
-					key.NewBinding(
-						key.WithKeys("G", "end"),
-						key.WithHelp("G", "end"),
-					),
+					keymap.Binding(keymap.ListHalfPageUp),
+					keymap.Binding(keymap.ListHalfPageDown),
+					keymap.Binding(keymap.ListHome),
+					keymap.Binding(keymap.ListEnd),
+				},
+				[]key.Binding{
+					messages.CopyKey(),
+					messages.ClearSelectionKey(),
+				},
+				[]key.Binding{
+					keymap.Binding(keymap.MessagesSearch),
+					keymap.Combined("n/N", "next/prev match", keymap.MessagesSearchNext, keymap.MessagesSearchPrevious),
+					keymap.Combined("[/]", "prev/next tool call", keymap.MessagesPrevToolCall, keymap.MessagesNextToolCall),
+					keymap.Binding(keymap.MessagesLastError),
 				},
 				[]key.Binding{
-					messages.CopyKey,
-					messages.ClearSelectionKey,
+					keymap.Binding(keymap.MessagesEdit),
+					keymap.Binding(keymap.MessagesBranch),
+					keymap.Binding(keymap.MessagesRetry),
+					keymap.Binding(keymap.MessagesRetryWithModel),
+					keymap.Binding(keymap.MessagesDelete),
 				},
 			)
 		case PanelTypeEditor:
-			newLineBinding := key.NewBinding(
-				key.WithKeys("shift+enter", "ctrl+j"),
-				// "ctrl+j" is a common keybinding for newline in many editors. If
-				// the terminal supports "shift+enter", we substitute the help text
-				// to reflect that.
-				key.WithHelp("ctrl+j", "newline"),
-			)
-			if p.keyboardEnhancementsFlags > 0 {
+			newLineBinding := keymap.Binding(keymap.EditorNewline)
+			if p.keyboardEnhancementsFlags > 0 && !keymap.Overridden(keymap.EditorNewline) {
 				// Non-zero flags mean we have at least key disambiguation.
 				newLineBinding.SetHelp("shift+enter", newLineBinding.Help().Desc)
 			}
@@ -1348,51 +1320,31 @@ func (p *chatPage) Help() help.KeyMap {
 			fullList = append(fullList,
 				[]key.Binding{
 					newLineBinding,
-					key.NewBinding(
-						key.WithKeys("ctrl+f"),
-						key.WithHelp("ctrl+f", "add image"),
-					),
+					keymap.Help(keymap.ChatAddAttachment, "add image"),
 					key.NewBinding(
 						key.WithKeys("@"),
 						key.WithHelp("@", "mention file"),
 					),
-					key.NewBinding(
-						key.WithKeys("ctrl+o"),
-						key.WithHelp("ctrl+o", "open editor"),
-					),
+					keymap.Binding(keymap.EditorOpenEditor),
 				})
 
 			if p.editor.HasAttachments() {
+				attachments := editor.DefaultAttachmentsKeyMap()
 				fullList = append(fullList, []key.Binding{
-					key.NewBinding(
-						key.WithKeys("ctrl+r"),
-						key.WithHelp("ctrl+r+{i}", "delete attachment at index i"),
-					),
-					key.NewBinding(
-						key.WithKeys("ctrl+r", "r"),
-						key.WithHelp("ctrl+r+r", "delete all attachments"),
-					),
-					key.NewBinding(
-						key.WithKeys("esc", "alt+esc"),
-						key.WithHelp("esc", "cancel delete mode"),
-					),
+					attachments.AttachmentDeleteMode,
+					attachments.DeleteAllAttachments,
+					attachments.Escape,
 				})
 			}
 		}
 		shortList = append(shortList,
 			// Quit
-			key.NewBinding(
-				key.WithKeys("ctrl+c"),
-				key.WithHelp("ctrl+c", "quit"),
-			),
+			keymap.Binding(keymap.AppQuit),
 			// Help
 			helpBinding,
 		)
 		fullList = append(fullList, []key.Binding{
-			key.NewBinding(
-				key.WithKeys("ctrl+g"),
-				key.WithHelp("ctrl+g", "less"),
-			),
+			keymap.Help(keymap.AppHelp, "less"),
 		})
 	}
 
@@ -2,6 +2,7 @@ package chat
 
 import (
 	"github.com/charmbracelet/bubbles/key"
+	"github.com/uglyswap/push/internal/tui/keymap"
 )
 
 type KeyMap struct {
@@ -17,37 +18,13 @@ type KeyMap struct {
 
 func DefaultKeyMap() KeyMap {
 	return KeyMap{
-		NewSession: key.NewBinding(
-			key.WithKeys("ctrl+n"),
-			key.WithHelp("ctrl+n", "new session"),
-		),
-		AddAttachment: key.NewBinding(
-			key.WithKeys("ctrl+f"),
-			key.WithHelp("ctrl+f", "add attachment"),
-		),
-		Cancel: key.NewBinding(
-			key.WithKeys("esc", "alt+esc"),
-			key.WithHelp("esc", "cancel"),
-		),
-		Tab: key.NewBinding(
-			key.WithKeys("tab"),
-			key.WithHelp("tab", "change focus"),
-		),
-		Details: key.NewBinding(
-			key.WithKeys("ctrl+d"),
-			key.WithHelp("ctrl+d", "toggle details"),
-		),
-		TogglePills: key.NewBinding(
-			key.WithKeys("ctrl+space"),
-			key.WithHelp("ctrl+space", "toggle tasks"),
-		),
-		PillLeft: key.NewBinding(
-			key.WithKeys("left"),
-			key.WithHelp("←/→", "switch section"),
-		),
-		PillRight: key.NewBinding(
-			key.WithKeys("right"),
-			key.WithHelp("←/→", "switch section"),
-		),
+		NewSession:    keymap.Binding(keymap.ChatNewSession),
+		AddAttachment: keymap.Binding(keymap.ChatAddAttachment),
+		Cancel:        keymap.Binding(keymap.ChatCancel),
+		Tab:           keymap.Binding(keymap.ChatTab),
+		Details:       keymap.Binding(keymap.ChatDetails),
+		TogglePills:   keymap.Binding(keymap.ChatTogglePills),
+		PillLeft:      keymap.Binding(keymap.ChatPillLeft),
+		PillRight:     keymap.Binding(keymap.ChatPillRight),
 	}
 }
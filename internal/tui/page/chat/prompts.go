@@ -0,0 +1,102 @@
+package chat
+
+import (
+	"context"
+	"strings"
+
+	tea "github.com/uglyswap/push/internal/compat/bubbletea"
+
+	"github.com/uglyswap/push/internal/app"
+	"github.com/uglyswap/push/internal/session"
+	"github.com/uglyswap/push/internal/tui/components/chat"
+	"github.com/uglyswap/push/internal/tui/util"
+)
+
+// promptEditedMsg carries a prompt edited in $EDITOR, to send in place of
+// the prompt of the turn of messageID.
+type promptEditedMsg struct {
+	messageID string
+	branch    bool
+	text      string
+}
+
+// promptReplacedMsg is sent once the turn a prompt is sent again in place of
+// is deleted, or the messages before it are copied to a new session.
+type promptReplacedMsg struct {
+	session  session.Session
+	branched bool
+	prompt   app.Prompt
+}
+
+// turnDeletedMsg is sent once a turn is deleted.
+type turnDeletedMsg struct{}
+
+// editPrompt opens the prompt of the turn of messageID in $EDITOR.
+func (p *chatPage) editPrompt(messageID string, branch bool) tea.Cmd {
+	prompt, err := p.app.TurnPrompt(context.Background(), p.session.ID, messageID)
+	if err != nil {
+		return util.ReportError(err)
+	}
+	return util.OpenEditor("msg_*.md", prompt.Text, func(text string) tea.Msg {
+		text = strings.TrimSpace(text)
+		if text == "" {
+			return util.InfoMsg{Type: util.InfoTypeWarn, Msg: "Message is empty"}
+		}
+		return promptEditedMsg{messageID: messageID, branch: branch, text: text}
+	})
+}
+
+// replacePrompt deletes the turn of messageID and every later message, or
+// copies the messages before it to a new session when branch is set, for its
+// prompt to be sent again, with text in place of the original one unless it
+// is empty.
+func (p *chatPage) replacePrompt(messageID string, branch bool, text string) tea.Cmd {
+	sess := p.session
+	return func() tea.Msg {
+		var (
+			prompt app.Prompt
+			err    error
+		)
+		if branch {
+			sess, prompt, err = p.app.BranchAtPrompt(context.Background(), sess.ID, messageID)
+		} else {
+			prompt, err = p.app.TruncateAtPrompt(context.Background(), sess.ID, messageID)
+		}
+		if err != nil {
+			return util.InfoMsg{Type: util.InfoTypeError, Msg: err.Error()}
+		}
+		if text != "" {
+			prompt.Text = text
+		}
+		return promptReplacedMsg{session: sess, branched: branch, prompt: prompt}
+	}
+}
+
+// sendReplacedPrompt shows the session the prompt is sent again in and sends
+// it.
+func (p *chatPage) sendReplacedPrompt(msg promptReplacedMsg) tea.Cmd {
+	var show tea.Cmd
+	if msg.branched {
+		show = util.CmdHandler(chat.SessionSelectedMsg(msg.session))
+	} else {
+		u, cmd := p.chat.Update(chat.MessagesChangedMsg{})
+		p.chat = u.(chat.MessageListCmp)
+		show = cmd
+	}
+	return tea.Sequence(
+		show,
+		p.chat.GoToBottom(),
+		p.runPrompt(msg.session.ID, msg.prompt.Text, msg.prompt.Attachments),
+	)
+}
+
+// deleteTurn deletes the turn of messageID, leaving the later ones.
+func (p *chatPage) deleteTurn(messageID string) tea.Cmd {
+	sessionID := p.session.ID
+	return func() tea.Msg {
+		if err := p.app.DeleteTurn(context.Background(), sessionID, messageID); err != nil {
+			return util.InfoMsg{Type: util.InfoTypeError, Msg: err.Error()}
+		}
+		return turnDeletedMsg{}
+	}
+}
@@ -1,13 +1,19 @@
 package styles
 
 import (
-	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/glamour/styles"
 	"github.com/uglyswap/push/internal/charmtone"
 )
 
+// Names of the built-in themes.
+const (
+	CharmtoneTheme      = "charmtone"
+	CharmtoneLightTheme = "charmtone-light"
+)
+
 func NewCharmtoneTheme() *Theme {
 	t := &Theme{
-		Name:   "charmtone",
+		Name:   CharmtoneTheme,
 		IsDark: true,
 
 		Primary:   charmtone.Charple,
@@ -58,26 +64,70 @@ func NewCharmtoneTheme() *Theme {
 		Cherry:   charmtone.Cherry,
 	}
 
-	// Text selection.
-	t.TextSelection = lipgloss.NewStyle().Foreground(charmtone.Salt.Lipgloss()).Background(charmtone.Charple.Lipgloss())
-
-	// LSP and MCP status.
-	t.ItemOfflineIcon = lipgloss.NewStyle().Foreground(charmtone.Squid.Lipgloss()).SetString("●")
-	t.ItemBusyIcon = t.ItemOfflineIcon.Foreground(charmtone.Citron.Lipgloss())
-	t.ItemErrorIcon = t.ItemOfflineIcon.Foreground(charmtone.Coral.Lipgloss())
-	t.ItemOnlineIcon = t.ItemOfflineIcon.Foreground(charmtone.Guac.Lipgloss())
-
-	// Editor: Yolo Mode.
-	t.YoloIconFocused = lipgloss.NewStyle().Foreground(charmtone.Oyster.Lipgloss()).Background(charmtone.Citron.Lipgloss()).Bold(true).SetString(" ! ")
-	t.YoloIconBlurred = t.YoloIconFocused.Foreground(charmtone.Pepper.Lipgloss()).Background(charmtone.Squid.Lipgloss())
-	t.YoloDotsFocused = lipgloss.NewStyle().Foreground(charmtone.Zest.Lipgloss()).SetString(":::")
-	t.YoloDotsBlurred = t.YoloDotsFocused.Foreground(charmtone.Squid.Lipgloss())
-
-	// oAuth Chooser.
-	t.AuthBorderSelected = lipgloss.NewStyle().BorderForeground(charmtone.Guac.Lipgloss())
-	t.AuthTextSelected = lipgloss.NewStyle().Foreground(charmtone.Julep.Lipgloss())
-	t.AuthBorderUnselected = lipgloss.NewStyle().BorderForeground(charmtone.Iron.Lipgloss())
-	t.AuthTextUnselected = lipgloss.NewStyle().Foreground(charmtone.Squid.Lipgloss())
+	t.setComponentStyles()
+
+	return t
+}
+
+// NewCharmtoneLightTheme returns the charmtone palette adapted to terminals
+// with a light background.
+func NewCharmtoneLightTheme() *Theme {
+	t := &Theme{
+		Name:   CharmtoneLightTheme,
+		IsDark: false,
+
+		Primary:   charmtone.Charple,
+		Secondary: charmtone.Damson,
+		Tertiary:  charmtone.NewColor("#1E8449"),
+		Accent:    charmtone.NewColor("#C0392B"),
+
+		// Backgrounds
+		BgBase:        charmtone.Salt,
+		BgBaseLighter: charmtone.NewColor("#F5F5F5"),
+		BgSubtle:      charmtone.NewColor("#EBEBEB"),
+		BgOverlay:     charmtone.NewColor("#E0E0E0"),
+
+		// Foregrounds
+		FgBase:      charmtone.Thunder,
+		FgMuted:     charmtone.NewColor("#6B6B6B"),
+		FgHalfMuted: charmtone.Anchovy,
+		FgSubtle:    charmtone.NewColor("#8A8A8A"),
+		FgSelected:  charmtone.Salt,
+
+		// Borders
+		Border:      charmtone.Silver,
+		BorderFocus: charmtone.Charple,
+
+		// Status
+		Success: charmtone.NewColor("#1E8449"),
+		Error:   charmtone.NewColor("#C0392B"),
+		Warning: charmtone.NewColor("#B7791F"),
+		Info:    charmtone.NewColor("#1F6FB2"),
+
+		// Colors
+		White: charmtone.Thunder,
+
+		BlueLight: charmtone.NewColor("#2E86C1"),
+		BlueDark:  charmtone.Damson,
+		Blue:      charmtone.NewColor("#1F6FB2"),
+
+		Yellow: charmtone.NewColor("#B7791F"),
+		Citron: charmtone.NewColor("#B7950B"),
+
+		Green:      charmtone.NewColor("#239B56"),
+		GreenDark:  charmtone.NewColor("#1E8449"),
+		GreenLight: charmtone.NewColor("#28B463"),
+
+		Red:      charmtone.NewColor("#C0392B"),
+		RedDark:  charmtone.NewColor("#922B21"),
+		RedLight: charmtone.NewColor("#E74C3C"),
+		Cherry:   charmtone.Cherry,
+
+		Markdown:    &styles.LightStyleConfig,
+		ChromaStyle: "github",
+	}
+
+	t.setComponentStyles()
 
 	return t
 }
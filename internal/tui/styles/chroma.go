@@ -3,6 +3,7 @@ package styles
 import (
 	"github.com/charmbracelet/glamour/ansi"
 	"github.com/alecthomas/chroma/v2"
+	chromastyles "github.com/alecthomas/chroma/v2/styles"
 )
 
 func chromaStyle(style ansi.StylePrimitive) string {
@@ -39,9 +40,25 @@ func chromaStyle(style ansi.StylePrimitive) string {
 	return s
 }
 
+// ChromaStyle returns the chroma style of the current theme: the one it
+// names, or else the one derived from its markdown style.
+func ChromaStyle() *chroma.Style {
+	t := CurrentTheme()
+	if t.ChromaStyle != "" {
+		return chromastyles.Get(t.ChromaStyle)
+	}
+	if t.S().Markdown.CodeBlock.Chroma == nil {
+		return chromastyles.Fallback
+	}
+	return chroma.MustNewStyle("crush", GetChromaTheme())
+}
+
 func GetChromaTheme() chroma.StyleEntries {
 	t := CurrentTheme()
 	rules := t.S().Markdown.CodeBlock
+	if rules.Chroma == nil {
+		return nil
+	}
 
 	return chroma.StyleEntries{
 		chroma.Text:                chromaStyle(rules.Chroma.Text),
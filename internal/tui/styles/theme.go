@@ -3,6 +3,8 @@ package styles
 import (
 	"fmt"
 	"image/color"
+	"maps"
+	"slices"
 	"strings"
 
 	"github.com/charmbracelet/bubbles/filepicker"
@@ -117,6 +119,12 @@ type Theme struct {
 	AuthBorderUnselected lipgloss.Style
 	AuthTextUnselected   lipgloss.Style
 
+	// Markdown, when set, replaces the default markdown style.
+	Markdown *ansi.StyleConfig
+	// ChromaStyle, when set, names the chroma style highlighting code in
+	// markdown, diffs and file views.
+	ChromaStyle string
+
 	styles *Styles
 }
 
@@ -160,10 +168,56 @@ func (t *Theme) S() *Styles {
 	return t.styles
 }
 
+// setComponentStyles derives the styles of the status indicators, the yolo
+// mode prompt and the oAuth chooser from the palette.
+func (t *Theme) setComponentStyles() {
+	// Text selection.
+	t.TextSelection = lipgloss.NewStyle().Foreground(tc(t.FgSelected)).Background(tc(t.Primary))
+
+	// LSP and MCP status.
+	t.ItemOfflineIcon = lipgloss.NewStyle().Foreground(tc(t.FgMuted)).SetString("●")
+	t.ItemBusyIcon = t.ItemOfflineIcon.Foreground(tc(t.Citron))
+	t.ItemErrorIcon = t.ItemOfflineIcon.Foreground(tc(t.Red))
+	t.ItemOnlineIcon = t.ItemOfflineIcon.Foreground(tc(t.GreenDark))
+
+	// Editor: Yolo Mode.
+	t.YoloIconFocused = lipgloss.NewStyle().Foreground(tc(t.FgSubtle)).Background(tc(t.Citron)).Bold(true).SetString(" ! ")
+	t.YoloIconBlurred = t.YoloIconFocused.Foreground(tc(t.BgBase)).Background(tc(t.FgMuted))
+	t.YoloDotsFocused = lipgloss.NewStyle().Foreground(tc(t.Accent)).SetString(":::")
+	t.YoloDotsBlurred = t.YoloDotsFocused.Foreground(tc(t.FgMuted))
+
+	// oAuth Chooser.
+	t.AuthBorderSelected = lipgloss.NewStyle().BorderForeground(tc(t.GreenDark))
+	t.AuthTextSelected = lipgloss.NewStyle().Foreground(tc(t.Green))
+	t.AuthBorderUnselected = lipgloss.NewStyle().BorderForeground(tc(t.BgOverlay))
+	t.AuthTextUnselected = lipgloss.NewStyle().Foreground(tc(t.FgMuted))
+}
+
+// diffColors are the colors of inserted and deleted lines in diffs.
+type diffColors struct {
+	insertFg, insertNumberBg, insertBg, insertEmphasisBg lipgloss.Color
+	deleteFg, deleteNumberBg, deleteBg, deleteEmphasisBg lipgloss.Color
+}
+
+var (
+	darkDiffColors = diffColors{
+		insertFg: "#629657", insertNumberBg: "#2b322a", insertBg: "#323931", insertEmphasisBg: "#3f5a3c",
+		deleteFg: "#a45c59", deleteNumberBg: "#312929", deleteBg: "#383030", deleteEmphasisBg: "#5c3a38",
+	}
+	lightDiffColors = diffColors{
+		insertFg: "#1a7f37", insertNumberBg: "#ccffd8", insertBg: "#e6ffec", insertEmphasisBg: "#abf2bc",
+		deleteFg: "#cf222e", deleteNumberBg: "#ffd7d5", deleteBg: "#ffebe9", deleteEmphasisBg: "#ffc1bc",
+	}
+)
+
 func (t *Theme) buildStyles() *Styles {
 	base := lipgloss.NewStyle().
 		Foreground(tc(t.FgBase))
-	return &Styles{
+	diff := darkDiffColors
+	if !t.IsDark {
+		diff = lightDiffColors
+	}
+	s := &Styles{
 		Base: base,
 
 		SelectedBase: base.Background(tc(t.Primary)),
@@ -480,23 +534,27 @@ func (t *Theme) buildStyles() *Styles {
 			},
 			InsertLine: diffview.LineStyle{
 				LineNumber: lipgloss.NewStyle().
-					Foreground(lipgloss.Color("#629657")).
-					Background(lipgloss.Color("#2b322a")),
+					Foreground(diff.insertFg).
+					Background(diff.insertNumberBg),
 				Symbol: lipgloss.NewStyle().
-					Foreground(lipgloss.Color("#629657")).
-					Background(lipgloss.Color("#323931")),
+					Foreground(diff.insertFg).
+					Background(diff.insertBg),
 				Code: lipgloss.NewStyle().
-					Background(lipgloss.Color("#323931")),
+					Background(diff.insertBg),
+				Emphasis: lipgloss.NewStyle().
+					Background(diff.insertEmphasisBg),
 			},
 			DeleteLine: diffview.LineStyle{
 				LineNumber: lipgloss.NewStyle().
-					Foreground(lipgloss.Color("#a45c59")).
-					Background(lipgloss.Color("#312929")),
+					Foreground(diff.deleteFg).
+					Background(diff.deleteNumberBg),
 				Symbol: lipgloss.NewStyle().
-					Foreground(lipgloss.Color("#a45c59")).
-					Background(lipgloss.Color("#383030")),
+					Foreground(diff.deleteFg).
+					Background(diff.deleteBg),
 				Code: lipgloss.NewStyle().
-					Background(lipgloss.Color("#383030")),
+					Background(diff.deleteBg),
+				Emphasis: lipgloss.NewStyle().
+					Background(diff.deleteEmphasisBg),
 			},
 		},
 		FilePicker: filepicker.Styles{
@@ -513,6 +571,16 @@ func (t *Theme) buildStyles() *Styles {
 			EmptyDirectory:   base.Foreground(tc(t.FgMuted)).PaddingLeft(2).SetString("Empty directory"),
 		},
 	}
+
+	if t.Markdown != nil {
+		s.Markdown = *t.Markdown
+	}
+	if t.ChromaStyle != "" {
+		// Let glamour highlight code blocks with the same style.
+		s.Markdown.CodeBlock.Theme = t.ChromaStyle
+		s.Markdown.CodeBlock.Chroma = nil
+	}
+	return s
 }
 
 type Manager struct {
@@ -547,6 +615,7 @@ func NewManager() *Manager {
 
 	t := NewCharmtoneTheme() // default theme
 	m.Register(t)
+	m.Register(NewCharmtoneLightTheme())
 	m.current = m.themes[t.Name]
 
 	return m
@@ -560,6 +629,12 @@ func (m *Manager) Current() *Theme {
 	return m.current
 }
 
+// Theme returns the registered theme with the given name.
+func (m *Manager) Theme(name string) (*Theme, bool) {
+	theme, ok := m.themes[name]
+	return theme, ok
+}
+
 func (m *Manager) SetTheme(name string) error {
 	if theme, ok := m.themes[name]; ok {
 		m.current = theme
@@ -568,12 +643,18 @@ func (m *Manager) SetTheme(name string) error {
 	return fmt.Errorf("theme %s not found", name)
 }
 
+// List returns the names of the registered themes, sorted.
 func (m *Manager) List() []string {
-	names := make([]string, 0, len(m.themes))
-	for name := range m.themes {
-		names = append(names, name)
+	return slices.Sorted(maps.Keys(m.themes))
+}
+
+// DefaultTheme returns the name of the built-in theme for a dark or a light
+// terminal background.
+func DefaultTheme(dark bool) string {
+	if dark {
+		return CharmtoneTheme
 	}
-	return names
+	return CharmtoneLightTheme
 }
 
 // ParseHex converts hex string to color
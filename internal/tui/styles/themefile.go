@@ -0,0 +1,237 @@
+package styles
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image/color"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	chromastyles "github.com/alecthomas/chroma/v2/styles"
+	"github.com/charmbracelet/glamour/ansi"
+	glamourstyles "github.com/charmbracelet/glamour/styles"
+	"github.com/uglyswap/push/internal/charmtone"
+)
+
+// AutoTheme picks the built-in light or dark theme from the background of
+// the terminal.
+const AutoTheme = "auto"
+
+var hexColor = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+// ThemeFile is a theme defined in a JSON or TOML file. The colors it leaves
+// out come from the theme it extends.
+type ThemeFile struct {
+	// Name defaults to the file name without its extension.
+	Name string `json:"name,omitempty"`
+	// Extends names the theme the file starts from. It defaults to the
+	// built-in theme matching Dark.
+	Extends string `json:"extends,omitempty"`
+	// Dark tells whether the theme is meant for dark backgrounds.
+	Dark *bool `json:"dark,omitempty"`
+	// Colors maps palette colors, such as primary or bg_base, to hex values.
+	Colors map[string]string `json:"colors,omitempty"`
+	// Markdown is the name of a glamour style, such as "light" or
+	// "dracula", or a full glamour style definition.
+	Markdown json.RawMessage `json:"markdown,omitempty"`
+	// Chroma names the chroma style highlighting code, such as "github".
+	Chroma string `json:"chroma,omitempty"`
+}
+
+// base returns the name of the theme the file extends.
+func (f ThemeFile) base() string {
+	if f.Extends != "" {
+		return f.Extends
+	}
+	return DefaultTheme(f.Dark == nil || *f.Dark)
+}
+
+// palette returns the colors of a theme by their name in theme files.
+func (t *Theme) palette() map[string]*color.Color {
+	return map[string]*color.Color{
+		"primary":         &t.Primary,
+		"secondary":       &t.Secondary,
+		"tertiary":        &t.Tertiary,
+		"accent":          &t.Accent,
+		"bg_base":         &t.BgBase,
+		"bg_base_lighter": &t.BgBaseLighter,
+		"bg_subtle":       &t.BgSubtle,
+		"bg_overlay":      &t.BgOverlay,
+		"fg_base":         &t.FgBase,
+		"fg_muted":        &t.FgMuted,
+		"fg_half_muted":   &t.FgHalfMuted,
+		"fg_subtle":       &t.FgSubtle,
+		"fg_selected":     &t.FgSelected,
+		"border":          &t.Border,
+		"border_focus":    &t.BorderFocus,
+		"success":         &t.Success,
+		"error":           &t.Error,
+		"warning":         &t.Warning,
+		"info":            &t.Info,
+		"white":           &t.White,
+		"blue_light":      &t.BlueLight,
+		"blue_dark":       &t.BlueDark,
+		"blue":            &t.Blue,
+		"yellow":          &t.Yellow,
+		"citron":          &t.Citron,
+		"green":           &t.Green,
+		"green_dark":      &t.GreenDark,
+		"green_light":     &t.GreenLight,
+		"red":             &t.Red,
+		"red_dark":        &t.RedDark,
+		"red_light":       &t.RedLight,
+		"cherry":          &t.Cherry,
+	}
+}
+
+// LoadThemes registers the themes defined by the .json and .toml files of
+// dirs. Themes of later directories replace the ones of earlier directories
+// with the same name. Missing directories are skipped, and invalid files are
+// reported in the returned error without stopping the others from loading.
+func (m *Manager) LoadThemes(dirs ...string) error {
+	var errs []error
+	for _, dir := range dirs {
+		entries, err := os.ReadDir(dir)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to read themes: %w", err))
+			continue
+		}
+		var files []ThemeFile
+		for _, entry := range entries {
+			ext := filepath.Ext(entry.Name())
+			if entry.IsDir() || (ext != ".json" && ext != ".toml") {
+				continue
+			}
+			file, err := ReadThemeFile(filepath.Join(dir, entry.Name()))
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			files = append(files, file)
+		}
+		// Themes can extend each other: build them once their base is.
+		for len(files) > 0 {
+			var pending []ThemeFile
+			for _, file := range files {
+				if _, ok := m.Theme(file.base()); !ok {
+					pending = append(pending, file)
+					continue
+				}
+				theme, err := m.NewTheme(file)
+				if err != nil {
+					errs = append(errs, fmt.Errorf("theme %s: %w", file.Name, err))
+					continue
+				}
+				m.Register(theme)
+			}
+			if len(pending) == len(files) {
+				for _, file := range pending {
+					errs = append(errs, fmt.Errorf("theme %s extends unknown theme %s", file.Name, file.base()))
+				}
+				break
+			}
+			files = pending
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// ReadThemeFile reads a theme file, in JSON or TOML depending on its
+// extension.
+func ReadThemeFile(path string) (ThemeFile, error) {
+	var file ThemeFile
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return file, fmt.Errorf("failed to read theme: %w", err)
+	}
+	if filepath.Ext(path) == ".toml" {
+		// Go through JSON so that both formats share the same keys, and
+		// the markdown style can be given as a table.
+		var raw map[string]any
+		if _, err := toml.Decode(string(data), &raw); err != nil {
+			return file, fmt.Errorf("failed to parse theme %s: %w", path, err)
+		}
+		if data, err = json.Marshal(raw); err != nil {
+			return file, fmt.Errorf("failed to parse theme %s: %w", path, err)
+		}
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return file, fmt.Errorf("failed to parse theme %s: %w", path, err)
+	}
+	if file.Name == "" {
+		file.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	return file, nil
+}
+
+// NewTheme builds the theme a file defines on top of the registered theme it
+// extends.
+func (m *Manager) NewTheme(file ThemeFile) (*Theme, error) {
+	if file.Name == AutoTheme {
+		return nil, fmt.Errorf("%s is a reserved theme name", AutoTheme)
+	}
+	base, ok := m.Theme(file.base())
+	if !ok {
+		return nil, fmt.Errorf("theme %s extends unknown theme %s", file.Name, file.base())
+	}
+
+	t := *base
+	t.Name = file.Name
+	t.styles = nil
+	if file.Dark != nil {
+		t.IsDark = *file.Dark
+	}
+
+	palette := t.palette()
+	for name, value := range file.Colors {
+		c, ok := palette[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown color %s", name)
+		}
+		if !hexColor.MatchString(value) {
+			return nil, fmt.Errorf("color %s: %q is not a #rrggbb hex color", name, value)
+		}
+		*c = charmtone.NewColor(value)
+	}
+	t.setComponentStyles()
+
+	if len(file.Markdown) > 0 {
+		markdown, err := parseMarkdownStyle(file.Markdown)
+		if err != nil {
+			return nil, err
+		}
+		t.Markdown = markdown
+	}
+
+	if file.Chroma != "" {
+		if _, ok := chromastyles.Registry[file.Chroma]; !ok {
+			return nil, fmt.Errorf("unknown chroma style %s", file.Chroma)
+		}
+		t.ChromaStyle = file.Chroma
+	}
+	return &t, nil
+}
+
+func parseMarkdownStyle(data json.RawMessage) (*ansi.StyleConfig, error) {
+	var name string
+	if err := json.Unmarshal(data, &name); err == nil {
+		style, ok := glamourstyles.DefaultStyles[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown markdown style %s", name)
+		}
+		return style, nil
+	}
+	var style ansi.StyleConfig
+	if err := json.Unmarshal(data, &style); err != nil {
+		return nil, fmt.Errorf("invalid markdown style: %w", err)
+	}
+	return &style, nil
+}
@@ -0,0 +1,90 @@
+package styles
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func writeTheme(t *testing.T, dir, name, content string) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644))
+}
+
+func TestLoadThemes(t *testing.T) {
+	t.Parallel()
+
+	global, project := t.TempDir(), t.TempDir()
+	writeTheme(t, global, "solar.json", `{
+		"dark": false,
+		"colors": {"primary": "#268bd2", "bg_base": "#fdf6e3"},
+		"markdown": "light",
+		"chroma": "solarized-light"
+	}`)
+	writeTheme(t, global, "solar-warm.toml", `
+extends = "solar"
+
+[colors]
+accent = "#cb4b16"
+`)
+	writeTheme(t, global, "README.md", "not a theme")
+	writeTheme(t, project, "solar.json", `{"colors": {"primary": "#6c71c4"}}`)
+
+	m := NewManager()
+	require.NoError(t, m.LoadThemes(global, filepath.Join(global, "missing"), project))
+	require.Equal(t, []string{CharmtoneTheme, CharmtoneLightTheme, "solar", "solar-warm"}, m.List())
+
+	warm, ok := m.Theme("solar-warm")
+	require.True(t, ok)
+	require.False(t, warm.IsDark)
+	require.Equal(t, "#268bd2", lipglossColorToHex(warm.Primary))
+	require.Equal(t, "#cb4b16", lipglossColorToHex(warm.Accent))
+	require.Equal(t, "solarized-light", warm.ChromaStyle)
+	require.NotNil(t, warm.S().Markdown.Document.Color)
+	require.Equal(t, "solarized-light", warm.S().Markdown.CodeBlock.Theme)
+
+	// The project theme replaces the global one of the same name, starting
+	// over from the default dark theme.
+	solar, ok := m.Theme("solar")
+	require.True(t, ok)
+	require.True(t, solar.IsDark)
+	require.Equal(t, "#6c71c4", lipglossColorToHex(solar.Primary))
+
+	base, _ := m.Theme(CharmtoneTheme)
+	require.NotEqual(t, "#6c71c4", lipglossColorToHex(base.Primary))
+}
+
+func TestLoadThemesErrors(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	writeTheme(t, dir, "bad-color.json", `{"colors": {"primary": "blue"}}`)
+	writeTheme(t, dir, "bad-key.json", `{"colors": {"purple": "#800080"}}`)
+	writeTheme(t, dir, "orphan.json", `{"extends": "nowhere"}`)
+	writeTheme(t, dir, "broken.toml", `colors = [`)
+	writeTheme(t, dir, "good.json", `{"markdown": "dracula"}`)
+
+	m := NewManager()
+	err := m.LoadThemes(dir)
+	require.ErrorContains(t, err, "not a #rrggbb hex color")
+	require.ErrorContains(t, err, "unknown color purple")
+	require.ErrorContains(t, err, "extends unknown theme nowhere")
+	require.ErrorContains(t, err, "broken.toml")
+
+	// The valid themes load anyway.
+	_, ok := m.Theme("good")
+	require.True(t, ok)
+}
+
+func TestDefaultTheme(t *testing.T) {
+	t.Parallel()
+
+	m := NewManager()
+	for _, dark := range []bool{true, false} {
+		theme, ok := m.Theme(DefaultTheme(dark))
+		require.True(t, ok)
+		require.Equal(t, dark, theme.IsDark)
+	}
+}
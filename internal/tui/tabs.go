@@ -0,0 +1,313 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/spinner"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+	tea "github.com/uglyswap/push/internal/compat/bubbletea"
+
+	"github.com/uglyswap/push/internal/config"
+	"github.com/uglyswap/push/internal/history"
+	"github.com/uglyswap/push/internal/message"
+	"github.com/uglyswap/push/internal/permission"
+	"github.com/uglyswap/push/internal/pubsub"
+	"github.com/uglyswap/push/internal/session"
+	"github.com/uglyswap/push/internal/tui/components/anim"
+	cmpChat "github.com/uglyswap/push/internal/tui/components/chat"
+	"github.com/uglyswap/push/internal/tui/components/chat/sidebar"
+	"github.com/uglyswap/push/internal/tui/page/chat"
+	"github.com/uglyswap/push/internal/tui/styles"
+	"github.com/uglyswap/push/internal/tui/util"
+)
+
+const (
+	// maxTabs is the number of tabs, each reachable with alt and its number.
+	maxTabs = 9
+	// maxTabTitleLength is the width the title of a session takes in the
+	// tab strip.
+	maxTabTitleLength = 24
+)
+
+// tab is a chat page of its own, so several sessions can run and be followed
+// at the same time.
+type tab struct {
+	// page is the chat page of the tab. The page of the active tab lives in
+	// the pages of the app instead, see tabPage.
+	page chat.ChatPage
+	// busy tells whether the agent was working on the session of the tab
+	// when last checked.
+	busy bool
+	// finished is set when the session of a background tab finishes, until
+	// the tab is shown.
+	finished bool
+}
+
+// tabsTickMsg checks the busy sessions of the tabs again.
+type tabsTickMsg struct{}
+
+// tabPage returns the chat page of tab i.
+func (a *appModel) tabPage(i int) chat.ChatPage {
+	if i == a.activeTab {
+		return a.pages[chat.ChatPageID].(chat.ChatPage)
+	}
+	return a.tabs[i].page
+}
+
+// tabsHeight is the height of the tab strip, only shown with several tabs.
+func (a *appModel) tabsHeight() int {
+	if len(a.tabs) > 1 {
+		return 1
+	}
+	return 0
+}
+
+// tabOf returns the tab showing a session, or -1.
+func (a *appModel) tabOf(sessionID string) int {
+	for i := range a.tabs {
+		if a.tabPage(i).Session().ID == sessionID {
+			return i
+		}
+	}
+	return -1
+}
+
+// newTab opens a tab with a chat page of its own and shows it.
+func (a *appModel) newTab() tea.Cmd {
+	if len(a.tabs) >= maxTabs {
+		return util.ReportWarn(fmt.Sprintf("You can open up to %d tabs", maxTabs))
+	}
+	page := chat.New(a.app)
+	a.tabs = append(a.tabs, &tab{page: page})
+	a.activateTab(len(a.tabs) - 1)
+	return tea.Batch(page.Init(), a.handleWindowResize(a.wWidth, a.wHeight))
+}
+
+// closeTab closes the active tab. The agent keeps working on its session.
+func (a *appModel) closeTab() tea.Cmd {
+	if len(a.tabs) == 1 {
+		return util.ReportWarn("This is the last tab")
+	}
+	closing := a.activeTab
+	next := closing + 1
+	if next == len(a.tabs) {
+		next = closing - 1
+	}
+	a.activateTab(next)
+	a.tabs = slices.Delete(a.tabs, closing, closing+1)
+	if a.activeTab > closing {
+		a.activeTab--
+	}
+	return a.handleWindowResize(a.wWidth, a.wHeight)
+}
+
+// switchTab shows tab i.
+func (a *appModel) switchTab(i int) tea.Cmd {
+	if i < 0 || i >= len(a.tabs) || i == a.activeTab {
+		return nil
+	}
+	a.activateTab(i)
+	return a.handleWindowResize(a.wWidth, a.wHeight)
+}
+
+func (a *appModel) activateTab(i int) {
+	a.tabs[a.activeTab].page = a.pages[chat.ChatPageID].(chat.ChatPage)
+	a.activeTab = i
+	a.tabs[i].finished = false
+	a.pages[chat.ChatPageID] = a.tabs[i].page
+	a.selectedSessionID = a.tabs[i].page.Session().ID
+}
+
+// updateBackgroundTabs forwards to the pages of the background tabs the
+// messages keeping their sessions up to date, so they stream while hidden.
+func (a *appModel) updateBackgroundTabs(msg tea.Msg) tea.Cmd {
+	switch msg.(type) {
+	case pubsub.Event[message.Message],
+		pubsub.Event[session.Session],
+		pubsub.Event[history.File],
+		pubsub.Event[permission.PermissionNotification],
+		sidebar.SessionFilesMsg,
+		anim.StepMsg,
+		spinner.TickMsg,
+		cmpChat.ThemeChangedMsg:
+	default:
+		return nil
+	}
+	var cmds []tea.Cmd
+	for i, t := range a.tabs {
+		if i == a.activeTab {
+			continue
+		}
+		u, cmd := t.page.Update(msg)
+		t.page = u.(chat.ChatPage)
+		cmds = append(cmds, cmd)
+	}
+	return tea.Batch(cmds...)
+}
+
+// checkTabs notes which sessions of the tabs are busy, notifies when one
+// finishes, and tells which when it is the one of a background tab.
+func (a *appModel) checkTabs() tea.Cmd {
+	if a.app.AgentCoordinator == nil {
+		return nil
+	}
+	var cmds []tea.Cmd
+	for i, t := range a.tabs {
+		sess := a.tabPage(i).Session()
+		busy := sess.ID != "" && a.app.AgentCoordinator.IsSessionBusy(sess.ID)
+		if t.busy && !busy {
+			finished := fmt.Sprintf("%q finished", sessionTitle(sess))
+			cmds = append(cmds, a.notify(config.NotificationTurnFinished, finished))
+			if i != a.activeTab {
+				t.finished = true
+				cmds = append(cmds, util.ReportInfo(finished))
+			}
+		}
+		t.busy = busy
+		if busy && !a.tabsTicking {
+			// Sessions may finish without anything else to update.
+			a.tabsTicking = true
+			cmds = append(cmds, tea.Tick(time.Second, func(time.Time) tea.Msg {
+				return tabsTickMsg{}
+			}))
+		}
+	}
+	return tea.Batch(cmds...)
+}
+
+// permissionSessionMsg tells the session a permission request is made for,
+// once resolved.
+type permissionSessionMsg struct {
+	request   permission.PermissionRequest
+	sessionID string
+}
+
+// rootSessionID returns the session a permission request is made for, the
+// one of the tab, when it comes from a sub-agent. It reads the database, so
+// it is run from a command.
+func (a *appModel) rootSessionID(sessionID string) string {
+	messageID, _, ok := a.app.Sessions.ParseAgentToolSessionID(sessionID)
+	if !ok {
+		return sessionID
+	}
+	msg, err := a.app.Messages.Get(context.Background(), messageID)
+	if err != nil {
+		return sessionID
+	}
+	return msg.SessionID
+}
+
+// permissionRequested resolves the session a permission request is made
+// for, right away unless it comes from a sub-agent.
+func (a *appModel) permissionRequested(req permission.PermissionRequest) tea.Cmd {
+	if !a.app.Sessions.IsAgentToolSession(req.SessionID) {
+		return a.permissionWaiting(req, req.SessionID)
+	}
+	return func() tea.Msg {
+		return permissionSessionMsg{request: req, sessionID: a.rootSessionID(req.SessionID)}
+	}
+}
+
+// permissionWaiting notes that a session waits on a permission, notifies
+// about it, and tells which session when it is not the shown one.
+func (a *appModel) permissionWaiting(req permission.PermissionRequest, sessionID string) tea.Cmd {
+	if _, answered := a.permissionSessions[req.ID]; answered {
+		delete(a.permissionSessions, req.ID)
+		return nil
+	}
+	a.permissionSessions[req.ID] = sessionID
+	a.waiting[sessionID]++
+	title := "A background session"
+	if i := a.tabOf(sessionID); i >= 0 {
+		title = fmt.Sprintf("%q", sessionTitle(a.tabPage(i).Session()))
+	}
+	notify := a.notify(config.NotificationPermission, fmt.Sprintf("%s asks for permission to use %s", title, req.ToolName))
+	if len(a.tabs) == 1 || sessionID == a.selectedSessionID {
+		return notify
+	}
+	return tea.Batch(notify, util.ReportWarn(title+" asks for permission"))
+}
+
+// permissionAnswered notes that a permission request of a session got its
+// answer.
+func (a *appModel) permissionAnswered(req permission.PermissionRequest) {
+	sessionID, ok := a.permissionSessions[req.ID]
+	if !ok {
+		// Answered before its session was resolved, the empty entry tells
+		// permissionWaiting not to count it.
+		a.permissionSessions[req.ID] = ""
+		return
+	}
+	delete(a.permissionSessions, req.ID)
+	if a.waiting[sessionID] <= 1 {
+		delete(a.waiting, sessionID)
+		return
+	}
+	a.waiting[sessionID]--
+}
+
+func sessionTitle(sess session.Session) string {
+	switch {
+	case sess.ID == "":
+		return "New Session"
+	case sess.Title == "":
+		return "Untitled"
+	}
+	return sess.Title
+}
+
+// tabLabels renders the label of each tab, marking the sessions that wait on
+// a permission, are busy, or finished in the background.
+func (a *appModel) tabLabels() []string {
+	t := styles.CurrentTheme()
+	labels := make([]string, len(a.tabs))
+	for i, tb := range a.tabs {
+		sess := a.tabPage(i).Session()
+		label := fmt.Sprintf("%d %s", i+1, ansi.Truncate(sessionTitle(sess), maxTabTitleLength, "…"))
+		switch {
+		case a.waiting[sess.ID] > 0:
+			label += " " + styles.WarningIcon
+		case tb.busy:
+			label += " " + styles.ToolPending
+		case tb.finished:
+			label += " " + styles.CheckIcon
+		}
+
+		style := t.S().Base.Padding(0, 1)
+		switch {
+		case i == a.activeTab:
+			style = style.Background(styles.TC(t.Primary)).Foreground(styles.TC(t.FgSelected))
+		case a.waiting[sess.ID] > 0:
+			style = style.Foreground(styles.TC(t.Warning))
+		case tb.finished:
+			style = style.Foreground(styles.TC(t.Success))
+		default:
+			style = style.Foreground(styles.TC(t.FgMuted))
+		}
+		labels[i] = style.Render(label)
+	}
+	return labels
+}
+
+// tabsView renders the tab strip.
+func (a *appModel) tabsView() string {
+	return ansi.Truncate(strings.Join(a.tabLabels(), " "), a.wWidth, "…")
+}
+
+// tabAt returns the tab whose label is at column x of the tab strip, or -1.
+func (a *appModel) tabAt(x int) int {
+	start := 0
+	for i, label := range a.tabLabels() {
+		end := start + lipgloss.Width(label)
+		if x >= start && x < end {
+			return i
+		}
+		start = end + 1
+	}
+	return -1
+}
@@ -0,0 +1,173 @@
+package tui
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	tea "github.com/uglyswap/push/internal/compat/bubbletea"
+
+	"github.com/uglyswap/push/internal/agent"
+	"github.com/uglyswap/push/internal/app"
+	"github.com/uglyswap/push/internal/db"
+	"github.com/uglyswap/push/internal/message"
+	"github.com/uglyswap/push/internal/permission"
+	"github.com/uglyswap/push/internal/session"
+	"github.com/uglyswap/push/internal/tui/components/core/status"
+	"github.com/uglyswap/push/internal/tui/components/dialogs"
+	"github.com/uglyswap/push/internal/tui/page"
+	"github.com/uglyswap/push/internal/tui/page/chat"
+	"github.com/uglyswap/push/internal/tui/util"
+)
+
+// fakePage is a chat page showing a session.
+type fakePage struct {
+	chat.ChatPage
+	session session.Session
+}
+
+func (p *fakePage) Init() tea.Cmd                        { return nil }
+func (p *fakePage) Update(tea.Msg) (util.Model, tea.Cmd) { return p, nil }
+func (p *fakePage) View() string                         { return "" }
+func (p *fakePage) Session() session.Session             { return p.session }
+
+// fakeCoordinator reports the sessions in busy as busy.
+type fakeCoordinator struct {
+	agent.Coordinator
+	busy map[string]bool
+}
+
+func (c *fakeCoordinator) IsSessionBusy(sessionID string) bool {
+	return c.busy[sessionID]
+}
+
+// testTabs returns an app model with a tab for each session, the first one
+// shown.
+func testTabs(t *testing.T, sessions ...session.Session) (*appModel, *fakeCoordinator) {
+	t.Helper()
+	conn, err := db.Connect(t.Context(), t.TempDir())
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	q := db.New(conn)
+
+	coordinator := &fakeCoordinator{busy: make(map[string]bool)}
+	a := &appModel{
+		app: &app.App{
+			Sessions:         session.NewService(q),
+			Messages:         message.NewService(q),
+			AgentCoordinator: coordinator,
+		},
+		status:             status.NewStatusCmp(),
+		dialog:             dialogs.NewDialogCmp(),
+		pages:              make(map[page.PageID]util.Model),
+		waiting:            make(map[string]int),
+		permissionSessions: make(map[string]string),
+		// Notifications are only sent without the focus.
+		focused: true,
+	}
+	for _, sess := range sessions {
+		a.tabs = append(a.tabs, &tab{page: &fakePage{session: sess}})
+	}
+	a.pages[chat.ChatPageID] = a.tabs[0].page
+	a.selectedSessionID = sessions[0].ID
+	return a, coordinator
+}
+
+func tabSessions(a *appModel) []string {
+	ids := make([]string, len(a.tabs))
+	for i := range a.tabs {
+		ids[i] = a.tabPage(i).Session().ID
+	}
+	return ids
+}
+
+func TestCloseTab(t *testing.T) {
+	t.Parallel()
+
+	a, _ := testTabs(t, session.Session{ID: "a"}, session.Session{ID: "b"}, session.Session{ID: "c"})
+
+	a.switchTab(1)
+	a.closeTab()
+	require.Equal(t, []string{"a", "c"}, tabSessions(a))
+	require.Equal(t, 1, a.activeTab, "the next tab is shown")
+	require.Equal(t, "c", a.selectedSessionID)
+	require.Equal(t, "c", a.pages[chat.ChatPageID].(chat.ChatPage).Session().ID)
+
+	a.closeTab()
+	require.Equal(t, []string{"a"}, tabSessions(a))
+	require.Zero(t, a.activeTab, "the previous tab is shown after closing the last one")
+	require.Equal(t, "a", a.selectedSessionID)
+
+	require.NotNil(t, a.closeTab(), "the last tab warns")
+	require.Equal(t, []string{"a"}, tabSessions(a))
+
+	a, _ = testTabs(t, session.Session{ID: "a"}, session.Session{ID: "b"}, session.Session{ID: "c"})
+	a.closeTab()
+	require.Equal(t, []string{"b", "c"}, tabSessions(a))
+	require.Zero(t, a.activeTab)
+	require.Equal(t, "b", a.selectedSessionID)
+}
+
+func TestCheckTabs(t *testing.T) {
+	t.Parallel()
+
+	a, coordinator := testTabs(t, session.Session{ID: "a"}, session.Session{ID: "b"})
+	coordinator.busy["a"] = true
+	coordinator.busy["b"] = true
+
+	require.NotNil(t, a.checkTabs())
+	require.True(t, a.tabs[0].busy)
+	require.True(t, a.tabs[1].busy)
+	require.True(t, a.tabsTicking, "busy sessions are checked again")
+
+	coordinator.busy["a"] = false
+	coordinator.busy["b"] = false
+	a.checkTabs()
+	require.False(t, a.tabs[0].busy)
+	require.False(t, a.tabs[1].busy)
+	require.False(t, a.tabs[0].finished, "the shown tab is not marked")
+	require.True(t, a.tabs[1].finished)
+
+	a.checkTabs()
+	require.True(t, a.tabs[1].finished, "finished until shown")
+	a.switchTab(1)
+	require.False(t, a.tabs[1].finished)
+}
+
+func TestPermissionWaiting(t *testing.T) {
+	t.Parallel()
+
+	a, _ := testTabs(t, session.Session{ID: "a"})
+	sess, err := a.app.Sessions.Create(t.Context(), "Session")
+	require.NoError(t, err)
+
+	first := permission.PermissionRequest{ID: "1", SessionID: "a"}
+	second := permission.PermissionRequest{ID: "2", SessionID: "a"}
+	a.permissionRequested(first)
+	a.permissionRequested(second)
+	require.Equal(t, 2, a.waiting["a"])
+	a.permissionAnswered(first)
+	require.Equal(t, 1, a.waiting["a"])
+	a.permissionAnswered(second)
+	require.NotContains(t, a.waiting, "a")
+
+	// Sub-agents ask for the session of the message running them.
+	msg, err := a.app.Messages.Create(t.Context(), sess.ID, message.CreateMessageParams{Role: message.Assistant})
+	require.NoError(t, err)
+	subAgent := permission.PermissionRequest{ID: "3", SessionID: a.app.Sessions.CreateAgentToolSessionID(msg.ID, "call")}
+	cmd := a.permissionRequested(subAgent)
+	require.Empty(t, a.waiting, "the session is resolved by a command")
+	resolved := cmd().(permissionSessionMsg)
+	require.Equal(t, sess.ID, resolved.sessionID)
+	a.permissionWaiting(resolved.request, resolved.sessionID)
+	require.Equal(t, 1, a.waiting[sess.ID])
+	a.permissionAnswered(subAgent)
+	require.Empty(t, a.waiting)
+
+	// Answered before its session is resolved.
+	late := permission.PermissionRequest{ID: "4", SessionID: subAgent.SessionID}
+	resolved = a.permissionRequested(late)().(permissionSessionMsg)
+	a.permissionAnswered(late)
+	a.permissionWaiting(resolved.request, resolved.sessionID)
+	require.Empty(t, a.waiting)
+	require.Empty(t, a.permissionSessions)
+}
@@ -0,0 +1,62 @@
+package tui
+
+import (
+	"path/filepath"
+
+	tea "github.com/uglyswap/push/internal/compat/bubbletea"
+	"github.com/uglyswap/push/internal/config"
+	cmpChat "github.com/uglyswap/push/internal/tui/components/chat"
+	"github.com/uglyswap/push/internal/tui/styles"
+	"github.com/uglyswap/push/internal/tui/util"
+)
+
+const themesDir = "themes"
+
+// themeDirs returns the directories holding theme files, from the lowest to
+// the highest priority.
+func themeDirs(cfg *config.Config) []string {
+	return []string{
+		filepath.Join(filepath.Dir(config.GlobalConfig()), themesDir),
+		filepath.Join(filepath.Dir(config.GlobalConfigData()), themesDir),
+		filepath.Join(cfg.Options.DataDirectory, themesDir),
+	}
+}
+
+// setupTheme loads the user themes and applies the configured one. It must
+// run before the components are built, as some keep the styles of the theme
+// they were built with.
+func (a *appModel) setupTheme(cfg *config.Config) error {
+	manager := styles.DefaultManager()
+	loadErr := manager.LoadThemes(themeDirs(cfg)...)
+	if err := a.applyTheme(cfg.Options.TUI.Theme); err != nil {
+		// Fall back to the theme matching the terminal.
+		_ = a.applyTheme(styles.AutoTheme)
+		return err
+	}
+	return loadErr
+}
+
+// applyTheme makes a theme current, resolving the auto theme from the
+// terminal background.
+func (a *appModel) applyTheme(name string) error {
+	if name == "" || name == styles.AutoTheme {
+		name = styles.DefaultTheme(a.darkBackground)
+	}
+	return styles.DefaultManager().SetTheme(name)
+}
+
+// switchTheme applies a theme selected in the themes dialog, saves it in the
+// configuration and redraws the interface with it.
+func (a *appModel) switchTheme(name string) tea.Cmd {
+	if err := a.applyTheme(name); err != nil {
+		return util.ReportError(err)
+	}
+	if err := config.Get().SetTheme(name); err != nil {
+		return util.ReportError(err)
+	}
+	return tea.Batch(
+		a.handleWindowResize(a.wWidth, a.wHeight),
+		util.CmdHandler(cmpChat.ThemeChangedMsg{}),
+		util.ReportInfo("Theme set to "+name),
+	)
+}
@@ -2,7 +2,10 @@ package tui
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"os"
 	"strings"
 	"time"
 
@@ -22,12 +25,19 @@ import (
 	"github.com/uglyswap/push/internal/tui/components/core/layout"
 	"github.com/uglyswap/push/internal/tui/components/core/status"
 	"github.com/uglyswap/push/internal/tui/components/dialogs"
+	"github.com/uglyswap/push/internal/tui/components/dialogs/checkpoints"
+	"github.com/uglyswap/push/internal/tui/components/dialogs/filechanges"
+	"github.com/uglyswap/push/internal/tui/components/dialogs/queue"
 	"github.com/uglyswap/push/internal/tui/components/dialogs/commands"
 	"github.com/uglyswap/push/internal/tui/components/dialogs/filepicker"
+	"github.com/uglyswap/push/internal/tui/components/dialogs/keybindings"
 	"github.com/uglyswap/push/internal/tui/components/dialogs/models"
 	"github.com/uglyswap/push/internal/tui/components/dialogs/permissions"
 	"github.com/uglyswap/push/internal/tui/components/dialogs/quit"
 	"github.com/uglyswap/push/internal/tui/components/dialogs/sessions"
+	"github.com/uglyswap/push/internal/tui/components/dialogs/themes"
+	"github.com/uglyswap/push/internal/tui/components/dialogs/worktree"
+	"github.com/uglyswap/push/internal/tui/keymap"
 	"github.com/uglyswap/push/internal/tui/page"
 	"github.com/uglyswap/push/internal/tui/page/chat"
 	"github.com/uglyswap/push/internal/tui/styles"
@@ -78,6 +88,23 @@ type appModel struct {
 	// Chat Page Specific
 	selectedSessionID string // The ID of the currently selected session
 
+	// tabs hold a chat page each, activeTab is the shown one.
+	tabs      []*tab
+	activeTab int
+	// tabsTicking is set while a tick checks the busy sessions of the tabs.
+	tabsTicking bool
+	// waiting counts the pending permission requests of each session.
+	waiting map[string]int
+	// permissionSessions maps the ID of each pending permission request to
+	// the session it is counted for in waiting.
+	permissionSessions map[string]string
+	// focused is set while the terminal reports having the focus, which
+	// silences the notifications.
+	focused bool
+	// output is the output of the program, where the notifications are
+	// written.
+	output io.Writer
+
 	// sendProgressBar instructs the TUI to send progress bar updates to the
 	// terminal.
 	sendProgressBar bool
@@ -85,6 +112,13 @@ type appModel struct {
 	// QueryVersion instructs the TUI to query for the terminal version when it
 	// starts.
 	QueryVersion bool
+
+	// darkBackground tells whether the terminal has a dark background, for
+	// the auto theme.
+	darkBackground bool
+	// setupErr is the error setting up the themes and the keybindings,
+	// reported on start.
+	setupErr error
 }
 
 // Init initializes the application model and returns initial commands.
@@ -101,6 +135,9 @@ func (a appModel) Init() tea.Cmd {
 
 	cmd = a.status.Init()
 	cmds = append(cmds, cmd)
+	if a.setupErr != nil {
+		cmds = append(cmds, util.ReportWarn(a.setupErr.Error()))
+	}
 	// In v1, tea.RequestTerminalVersion doesn't exist, skip version querying
 
 	return tea.Batch(cmds...)
@@ -108,6 +145,14 @@ func (a appModel) Init() tea.Cmd {
 
 // Update handles incoming messages and updates the application state.
 func (a *appModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if _, ok := msg.(tabsTickMsg); ok {
+		a.tabsTicking = false
+	}
+	m, cmd := a.update(msg)
+	return m, tea.Batch(cmd, a.checkTabs())
+}
+
+func (a *appModel) update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmds []tea.Cmd
 	var cmd tea.Cmd
 	a.isConfigured = config.HasInitialDataConfig()
@@ -115,6 +160,12 @@ func (a *appModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	// In v1, tea.EnvMsg, tea.TerminalVersionMsg, tea.KeyboardEnhancementsMsg don't exist
 	// Progress bar and keyboard enhancements features are v2-only
+	case tea.FocusMsg:
+		a.focused = true
+		return a, nil
+	case tea.BlurMsg:
+		a.focused = false
+		return a, nil
 	case tea.WindowSizeMsg:
 		a.wWidth, a.wHeight = msg.Width, msg.Height
 		a.completions.Update(msg)
@@ -124,10 +175,6 @@ func (a *appModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		switch msg.Payload.Type {
 		case mcp.EventStateChanged:
 			return a, a.handleStateChanged(context.Background())
-		case mcp.EventPromptsListChanged:
-			return a, handleMCPPromptsEvent(context.Background(), msg.Payload.Name)
-		case mcp.EventToolsListChanged:
-			return a, handleMCPToolsEvent(context.Background(), msg.Payload.Name)
 		}
 
 	// Completions messages
@@ -195,10 +242,17 @@ func (a *appModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		s, statusCmd := a.status.Update(msg)
 		a.status = s.(status.StatusCmp)
 		cmds = append(cmds, statusCmd)
+		if info, ok := msg.(util.InfoMsg); ok && info.Type == util.InfoTypeError {
+			cmds = append(cmds, a.notify(config.NotificationError, info.Msg))
+		}
 		return a, tea.Batch(cmds...)
 
 	// Session
 	case cmpChat.SessionSelectedMsg:
+		// Show the session where it is already open.
+		if i := a.tabOf(msg.ID); i >= 0 && i != a.activeTab {
+			return a, a.switchTab(i)
+		}
 		a.selectedSessionID = msg.ID
 	case cmpChat.SessionClearedMsg:
 		a.selectedSessionID = ""
@@ -226,6 +280,83 @@ func (a *appModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 			return nil
 		}
+	case commands.OpenWorktreeMsg:
+		return a, func() tea.Msg {
+			wt, err := a.app.SessionWorktree(msg.SessionID)
+			if err != nil {
+				return util.ReportError(err)()
+			}
+			changes, err := a.app.Worktrees.Changes(context.Background(), wt)
+			if err != nil {
+				return util.ReportError(err)()
+			}
+			return dialogs.OpenDialogMsg{
+				Model: worktree.NewWorktreeDialog(wt, changes),
+			}
+		}
+	case worktree.MergeWorktreeMsg:
+		return a, func() tea.Msg {
+			if err := a.app.MergeSessionWorktree(context.Background(), msg.SessionID); err != nil {
+				return util.ReportError(err)()
+			}
+			return util.ReportInfo("Session changes merged")()
+		}
+	case worktree.DiscardWorktreeMsg:
+		return a, func() tea.Msg {
+			if err := a.app.DiscardSessionWorktree(context.Background(), msg.SessionID); err != nil {
+				return util.ReportError(err)()
+			}
+			return util.ReportInfo("Session changes discarded")()
+		}
+	case commands.OpenCheckpointsMsg:
+		return a, func() tea.Msg {
+			manager := a.app.SessionCheckpoints(msg.SessionID)
+			list, err := manager.List(context.Background(), msg.SessionID)
+			if err != nil {
+				return util.ReportError(err)()
+			}
+			return dialogs.OpenDialogMsg{
+				Model: checkpoints.NewCheckpointsDialog(msg.SessionID, manager, list),
+			}
+		}
+	case commands.OpenFileChangesMsg:
+		return a, func() tea.Msg {
+			files, err := a.app.History.ListBySession(context.Background(), msg.SessionID)
+			if err != nil {
+				return util.ReportError(err)()
+			}
+			msgs, err := a.app.Messages.List(context.Background(), msg.SessionID)
+			if err != nil {
+				return util.ReportError(err)()
+			}
+			return dialogs.OpenDialogMsg{
+				Model: filechanges.NewFileChangesDialog(files, msgs),
+			}
+		}
+	case commands.OpenQueueMsg:
+		if a.app.AgentCoordinator == nil {
+			return a, util.ReportWarn("Agent is not initialized")
+		}
+		return a, util.CmdHandler(dialogs.OpenDialogMsg{
+			Model: queue.NewQueueDialog(a.app.AgentCoordinator, msg.SessionID),
+		})
+	case checkpoints.RestoreCheckpointMsg:
+		return a, func() tea.Msg {
+			if err := a.app.RestoreCheckpoint(context.Background(), msg.SessionID, msg.Hash); err != nil {
+				return util.ReportError(err)()
+			}
+			return util.ReportInfo("Restored checkpoint " + msg.Hash[:7])()
+		}
+	case commands.OpenThemesMsg:
+		return a, util.CmdHandler(dialogs.OpenDialogMsg{
+			Model: themes.NewThemesDialog(),
+		})
+	case commands.OpenKeybindingsMsg:
+		return a, util.CmdHandler(dialogs.OpenDialogMsg{
+			Model: keybindings.NewKeybindingsDialog(),
+		})
+	case themes.ThemeSelectedMsg:
+		return a, a.switchTheme(msg.Name)
 	case commands.QuitMsg:
 		return a, util.CmdHandler(dialogs.OpenDialogMsg{
 			Model: quit.NewQuitDialog(),
@@ -247,13 +378,21 @@ func (a *appModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return a, util.ReportError(err)
 		}
 
-		go a.app.UpdateAgentModel(context.TODO())
+		updateAgent := func() tea.Msg {
+			if err := a.app.UpdateAgentModel(context.TODO()); err != nil {
+				return util.InfoMsg{Type: util.InfoTypeError, Msg: err.Error()}
+			}
+			return models.AgentModelUpdatedMsg{}
+		}
 
 		modelTypeName := "large"
 		if msg.ModelType == config.SelectedModelTypeSmall {
 			modelTypeName = "small"
 		}
-		return a, util.ReportInfo(fmt.Sprintf("%s model changed to %s", modelTypeName, msg.Model.Model))
+		return a, tea.Batch(
+			updateAgent,
+			util.ReportInfo(fmt.Sprintf("%s model changed to %s", modelTypeName, msg.Model.Model)),
+		)
 
 	// File Picker
 	case commands.OpenFilePickerMsg:
@@ -277,19 +416,27 @@ func (a *appModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		updated, itemCmd := item.Update(msg)
 		a.pages[a.currentPage] = updated
 
-		return a, itemCmd
+		return a, tea.Batch(itemCmd, a.updateBackgroundTabs(msg))
 	case pubsub.Event[permission.PermissionRequest]:
-		return a, util.CmdHandler(dialogs.OpenDialogMsg{
-			Model: permissions.NewPermissionDialogCmp(msg.Payload, &permissions.Options{
-				DiffMode: config.Get().Options.TUI.DiffMode,
+		return a, tea.Batch(
+			a.permissionRequested(msg.Payload),
+			util.CmdHandler(dialogs.OpenDialogMsg{
+				Model: permissions.NewPermissionDialogCmp(msg.Payload, &permissions.Options{
+					DiffMode: config.Get().Options.TUI.DiffMode,
+				}),
 			}),
-		})
+		)
+	case permissionSessionMsg:
+		return a, a.permissionWaiting(msg.request, msg.sessionID)
 	case permissions.PermissionResponseMsg:
+		a.permissionAnswered(msg.Permission)
 		switch msg.Action {
 		case permissions.PermissionAllow:
 			a.app.Permissions.Grant(msg.Permission)
 		case permissions.PermissionAllowForSession:
 			a.app.Permissions.GrantPersistent(msg.Permission)
+		case permissions.PermissionAllowReviewed:
+			a.app.Permissions.GrantReviewed(msg.Permission, *msg.Review)
 		case permissions.PermissionDeny:
 			a.app.Permissions.Deny(msg.Permission)
 		}
@@ -316,6 +463,16 @@ func (a *appModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			a.dialog = u.(dialogs.DialogCmp)
 			cmds = append(cmds, dialogCmd)
 		} else {
+			if h := a.tabsHeight(); h > 0 {
+				if msg.Y < h {
+					if msg.Type == tea.MouseLeft {
+						return a, a.switchTab(a.tabAt(msg.X))
+					}
+					return a, nil
+				}
+				// The page is below the tab strip.
+				msg.Y -= h
+			}
 			item, ok := a.pages[a.currentPage]
 			if !ok {
 				return a, nil
@@ -372,6 +529,7 @@ func (a *appModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	updated, cmd := item.Update(msg)
 	a.pages[a.currentPage] = updated
+	cmds = append(cmds, a.updateBackgroundTabs(msg))
 
 	if a.dialog.HasDialogs() {
 		u, dialogCmd := a.dialog.Update(msg)
@@ -395,6 +553,7 @@ func (a *appModel) handleWindowResize(width, height int) tea.Cmd {
 	} else {
 		height -= 2
 	}
+	height -= a.tabsHeight()
 
 	a.width, a.height = width, height
 	// Update status bar
@@ -411,6 +570,14 @@ func (a *appModel) handleWindowResize(width, height int) tea.Cmd {
 
 		cmds = append(cmds, pageCmd)
 	}
+	for i, t := range a.tabs {
+		if i == a.activeTab {
+			continue
+		}
+		updated, pageCmd := t.page.Update(tea.WindowSizeMsg{Width: width, Height: height})
+		t.page = updated.(chat.ChatPage)
+		cmds = append(cmds, pageCmd)
+	}
 
 	// Update the dialogs
 	dialog, cmd := a.dialog.Update(tea.WindowSizeMsg{Width: width, Height: height})
@@ -508,6 +675,22 @@ func (a *appModel) handleKeyPressMsg(msg tea.KeyPressMsg) tea.Cmd {
 			},
 		)
 		return tea.Sequence(cmds...)
+	// tabs
+	case key.Matches(msg, a.keyMap.NewTab):
+		if !a.isConfigured {
+			return nil
+		}
+		return a.newTab()
+	case key.Matches(msg, a.keyMap.CloseTab):
+		return a.closeTab()
+	case key.Matches(msg, a.keyMap.NextTab):
+		return a.switchTab((a.activeTab + 1) % len(a.tabs))
+	case key.Matches(msg, a.keyMap.PrevTab):
+		return a.switchTab((a.activeTab + len(a.tabs) - 1) % len(a.tabs))
+	case key.Matches(msg, a.keyMap.GoToTab):
+		// The keys end with the number of the tab.
+		keyStr := msg.String()
+		return a.switchTab(int(keyStr[len(keyStr)-1] - '1'))
 	case key.Matches(msg, a.keyMap.Suspend):
 		if a.app.AgentCoordinator != nil && a.app.AgentCoordinator.IsBusy() {
 			return util.ReportWarn("Agent is busy, please wait...")
@@ -570,9 +753,11 @@ func (a *appModel) View() string {
 		a.status.SetKeyMap(withHelp.Help())
 	}
 	pageView := page.View()
-	components := []string{
-		pageView,
+	var components []string
+	if a.tabsHeight() > 0 {
+		components = append(components, a.tabsView())
 	}
+	components = append(components, pageView)
 	components = append(components, a.status.View())
 
 	appView := lipgloss.JoinVertical(lipgloss.Top, components...)
@@ -702,40 +887,33 @@ func (a *appModel) handleStateChanged(ctx context.Context) tea.Cmd {
 	}
 }
 
-func handleMCPPromptsEvent(ctx context.Context, name string) tea.Cmd {
-	return func() tea.Msg {
-		mcp.RefreshPrompts(ctx, name)
-		return nil
-	}
-}
-
-func handleMCPToolsEvent(ctx context.Context, name string) tea.Cmd {
-	return func() tea.Msg {
-		mcp.RefreshTools(ctx, name)
-		return nil
-	}
-}
-
 // New creates and initializes a new TUI application model.
 func New(app *app.App) *appModel {
-	chatPage := chat.New(app)
-	keyMap := DefaultKeyMap()
-	keyMap.pageBindings = chatPage.Bindings()
-
 	model := &appModel{
-		currentPage: chat.ChatPageID,
-		app:         app,
-		status:      status.NewStatusCmp(),
-		loadedPages: make(map[page.PageID]bool),
-		keyMap:      keyMap,
-
-		pages: map[page.PageID]util.Model{
-			chat.ChatPageID: chatPage,
-		},
+		currentPage:    chat.ChatPageID,
+		app:            app,
+		loadedPages:    make(map[page.PageID]bool),
+		darkBackground: lipgloss.HasDarkBackground(),
+		output:         &terminalOutput{File: os.Stdout},
+	}
+	// The components read the theme and the keybindings when built.
+	model.setupErr = errors.Join(
+		model.setupTheme(app.Config()),
+		keymap.Load(app.Config().Options.TUI.Keybindings),
+	)
 
-		dialog:      dialogs.NewDialogCmp(),
-		completions: completions.New(),
-	}
+	chatPage := chat.New(app)
+	model.keyMap = DefaultKeyMap()
+	model.keyMap.pageBindings = chatPage.Bindings()
+	model.status = status.NewStatusCmp()
+	model.pages = map[page.PageID]util.Model{
+		chat.ChatPageID: chatPage,
+	}
+	model.tabs = []*tab{{page: chatPage}}
+	model.waiting = make(map[string]int)
+	model.permissionSessions = make(map[string]string)
+	model.dialog = dialogs.NewDialogCmp()
+	model.completions = completions.New()
 
 	return model
 }
@@ -0,0 +1,45 @@
+package util
+
+import (
+	"context"
+	"os"
+	"runtime"
+
+	tea "github.com/uglyswap/push/internal/compat/bubbletea"
+)
+
+// OpenEditor opens content in $EDITOR, in a temporary file named after
+// pattern as os.CreateTemp does. Once the editor exits, done receives the
+// edited content and returns the message to send.
+func OpenEditor(pattern, content string, done func(edited string) tea.Msg) tea.Cmd {
+	editor := os.Getenv("EDITOR")
+	if editor == "" {
+		// Use platform-appropriate default editor
+		if runtime.GOOS == "windows" {
+			editor = "notepad"
+		} else {
+			editor = "nvim"
+		}
+	}
+
+	tmpfile, err := os.CreateTemp("", pattern)
+	if err != nil {
+		return ReportError(err)
+	}
+	defer tmpfile.Close() //nolint:errcheck
+	if _, err := tmpfile.WriteString(content); err != nil {
+		return ReportError(err)
+	}
+	cmdStr := editor + " " + tmpfile.Name()
+	return ExecShell(context.TODO(), cmdStr, func(err error) tea.Msg {
+		defer os.Remove(tmpfile.Name()) //nolint:errcheck
+		if err != nil {
+			return InfoMsg{Type: InfoTypeError, Msg: err.Error()}
+		}
+		edited, err := os.ReadFile(tmpfile.Name())
+		if err != nil {
+			return InfoMsg{Type: InfoTypeError, Msg: err.Error()}
+		}
+		return done(string(edited))
+	})
+}
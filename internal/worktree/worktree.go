@@ -0,0 +1,209 @@
+// Package worktree isolates sessions in their own git worktree, on a branch
+// of their own, so that agent edits do not land in the user's checkout until
+// they are merged back.
+package worktree
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/uglyswap/push/internal/gitutil"
+)
+
+// DirName is the name of the directory holding the worktrees inside the data
+// directory.
+const DirName = "worktrees"
+
+// BranchPrefix prefixes the name of the branch of every session worktree.
+const BranchPrefix = "push/session-"
+
+// ErrNotFound is returned when a session has no worktree.
+var ErrNotFound = errors.New("session has no worktree")
+
+// Worktree is the worktree of a session.
+type Worktree struct {
+	SessionID string `json:"session_id"`
+	// Path is the directory of the worktree.
+	Path string `json:"path"`
+	// Branch is the branch checked out in the worktree.
+	Branch string `json:"branch"`
+	// Base is the commit the branch was created from.
+	Base string `json:"base"`
+}
+
+// FileChange is a file that differs between the base commit and the
+// worktree.
+type FileChange = gitutil.FileChange
+
+// Manager creates and tracks the worktrees of the sessions of a repository.
+type Manager struct {
+	repoDir string
+	dir     string
+
+	mu sync.Mutex
+}
+
+// New returns a manager creating worktrees of the repository at repoDir
+// inside the given data directory.
+func New(dataDir, repoDir string) *Manager {
+	return &Manager{
+		repoDir: repoDir,
+		dir:     filepath.Join(dataDir, DirName),
+	}
+}
+
+// Get returns the worktree of a session.
+func (m *Manager) Get(sessionID string) (Worktree, error) {
+	data, err := os.ReadFile(m.metadataPath(sessionID))
+	if errors.Is(err, os.ErrNotExist) {
+		return Worktree{}, ErrNotFound
+	} else if err != nil {
+		return Worktree{}, fmt.Errorf("failed to read worktree metadata: %w", err)
+	}
+	var wt Worktree
+	if err := json.Unmarshal(data, &wt); err != nil {
+		return Worktree{}, fmt.Errorf("failed to parse worktree metadata: %w", err)
+	}
+	return wt, nil
+}
+
+// Ensure returns the worktree of a session, creating it on a new branch from
+// the current HEAD of the repository if needed.
+func (m *Manager) Ensure(ctx context.Context, sessionID string) (Worktree, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if wt, err := m.Get(sessionID); !errors.Is(err, ErrNotFound) {
+		return wt, err
+	}
+
+	base, err := gitutil.Run(ctx, m.repoDir, "rev-parse", "HEAD")
+	if err != nil {
+		return Worktree{}, fmt.Errorf("failed to resolve HEAD: %w", err)
+	}
+	if err := m.ensureDir(); err != nil {
+		return Worktree{}, err
+	}
+
+	wt := Worktree{
+		SessionID: sessionID,
+		Path:      filepath.Join(m.dir, sessionID),
+		Branch:    BranchPrefix + shortID(sessionID),
+		Base:      base,
+	}
+	if _, err := gitutil.Run(ctx, m.repoDir, "worktree", "add", "-b", wt.Branch, wt.Path, base); err != nil {
+		return Worktree{}, fmt.Errorf("failed to create worktree: %w", err)
+	}
+
+	data, err := json.MarshalIndent(wt, "", "  ")
+	if err != nil {
+		return Worktree{}, err
+	}
+	if err := os.WriteFile(m.metadataPath(sessionID), data, 0o644); err != nil {
+		_ = m.remove(ctx, wt)
+		return Worktree{}, fmt.Errorf("failed to write worktree metadata: %w", err)
+	}
+	return wt, nil
+}
+
+// Changes returns the files changed in the worktree since its base commit,
+// whether committed or not. The index of the worktree is left alone.
+func (m *Manager) Changes(ctx context.Context, wt Worktree) ([]FileChange, error) {
+	tree, err := gitutil.WriteTree(ctx, wt.Path)
+	if err != nil {
+		return nil, err
+	}
+	out, err := gitutil.Raw(ctx, wt.Path, "diff-tree", "-r", "--name-status", "--no-renames", "-z", wt.Base, tree)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list changes: %w", err)
+	}
+
+	changes := gitutil.ParseNameStatus(out)
+	for i, change := range changes {
+		if !change.Created {
+			if changes[i].OldContent, err = gitutil.Raw(ctx, wt.Path, "show", wt.Base+":"+change.Path); err != nil {
+				return nil, fmt.Errorf("failed to read %s at base: %w", change.Path, err)
+			}
+		}
+		if !change.Deleted {
+			content, err := os.ReadFile(filepath.Join(wt.Path, change.Path))
+			if err != nil {
+				return nil, fmt.Errorf("failed to read %s: %w", change.Path, err)
+			}
+			changes[i].NewContent = string(content)
+		}
+	}
+	return changes, nil
+}
+
+// Merge commits the pending changes of the worktree and merges its branch
+// into the branch checked out in the repository, then removes the worktree.
+// The merge is aborted if it conflicts, keeping the worktree.
+func (m *Manager) Merge(ctx context.Context, wt Worktree, message string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, err := gitutil.Run(ctx, wt.Path, "add", "--all"); err != nil {
+		return fmt.Errorf("failed to stage changes: %w", err)
+	}
+	if _, err := gitutil.Run(ctx, wt.Path, "diff", "--cached", "--quiet"); err != nil {
+		if _, err := gitutil.Run(ctx, wt.Path, "commit", "-m", message); err != nil {
+			return fmt.Errorf("failed to commit changes: %w", err)
+		}
+	}
+	if _, err := gitutil.Run(ctx, m.repoDir, "merge", "--no-ff", "-m", message, wt.Branch); err != nil {
+		_, _ = gitutil.Run(ctx, m.repoDir, "merge", "--abort")
+		return fmt.Errorf("failed to merge %s: %w", wt.Branch, err)
+	}
+	return m.remove(ctx, wt)
+}
+
+// Discard removes the worktree and its branch, dropping its changes.
+func (m *Manager) Discard(ctx context.Context, wt Worktree) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.remove(ctx, wt)
+}
+
+func (m *Manager) remove(ctx context.Context, wt Worktree) error {
+	if _, err := gitutil.Run(ctx, m.repoDir, "worktree", "remove", "--force", wt.Path); err != nil {
+		return fmt.Errorf("failed to remove worktree: %w", err)
+	}
+	if _, err := gitutil.Run(ctx, m.repoDir, "branch", "-D", wt.Branch); err != nil {
+		return fmt.Errorf("failed to delete branch %s: %w", wt.Branch, err)
+	}
+	if err := os.Remove(m.metadataPath(wt.SessionID)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove worktree metadata: %w", err)
+	}
+	return nil
+}
+
+// ensureDir creates the worktrees directory, ignored by git so that the
+// worktrees do not show up in the repository.
+func (m *Manager) ensureDir() error {
+	if err := os.MkdirAll(m.dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create worktrees directory: %w", err)
+	}
+	gitignore := filepath.Join(m.dir, ".gitignore")
+	if _, err := os.Stat(gitignore); errors.Is(err, os.ErrNotExist) {
+		if err := os.WriteFile(gitignore, []byte("*\n"), 0o644); err != nil {
+			return fmt.Errorf("failed to write worktrees .gitignore: %w", err)
+		}
+	}
+	return nil
+}
+
+func (m *Manager) metadataPath(sessionID string) string {
+	return filepath.Join(m.dir, sessionID+".json")
+}
+
+func shortID(sessionID string) string {
+	id := strings.ReplaceAll(sessionID, "-", "")
+	return id[:min(len(id), 12)]
+}
@@ -0,0 +1,105 @@
+package worktree
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/uglyswap/push/internal/gitutil"
+)
+
+func newTestRepo(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	dir := t.TempDir()
+	for _, args := range [][]string{
+		{"init", "-q", "-b", "main"},
+		{"config", "user.name", "test"},
+		{"config", "user.email", "test@example.com"},
+	} {
+		_, err := gitutil.Run(t.Context(), dir, args...)
+		require.NoError(t, err)
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "main.go"), []byte("package main\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "old.go"), []byte("package old\n"), 0o644))
+	_, err := gitutil.Run(t.Context(), dir, "add", "--all")
+	require.NoError(t, err)
+	_, err = gitutil.Run(t.Context(), dir, "commit", "-q", "-m", "initial")
+	require.NoError(t, err)
+	return dir
+}
+
+func TestManagerMerge(t *testing.T) {
+	t.Parallel()
+
+	repo := newTestRepo(t)
+	m := New(t.TempDir(), repo)
+
+	_, err := m.Get("session")
+	require.ErrorIs(t, err, ErrNotFound)
+
+	wt, err := m.Ensure(t.Context(), "session")
+	require.NoError(t, err)
+	require.Equal(t, BranchPrefix+"session", wt.Branch)
+	require.FileExists(t, filepath.Join(wt.Path, "main.go"))
+
+	again, err := m.Ensure(t.Context(), "session")
+	require.NoError(t, err)
+	require.Equal(t, wt, again)
+
+	require.NoError(t, os.WriteFile(filepath.Join(wt.Path, "main.go"), []byte("package main\n\nfunc main() {}\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(wt.Path, "new.go"), []byte("package main\n"), 0o644))
+	require.NoError(t, os.Remove(filepath.Join(wt.Path, "old.go")))
+
+	changes, err := m.Changes(t.Context(), wt)
+	require.NoError(t, err)
+	require.Equal(t, []FileChange{
+		{Path: "main.go", OldContent: "package main\n", NewContent: "package main\n\nfunc main() {}\n"},
+		{Path: "new.go", NewContent: "package main\n", Created: true},
+		{Path: "old.go", OldContent: "package old\n", Deleted: true},
+	}, changes)
+	staged, err := gitutil.Run(t.Context(), wt.Path, "diff", "--cached", "--name-only")
+	require.NoError(t, err)
+	require.Empty(t, staged, "listing the changes must not stage them")
+
+	// The user's checkout is untouched until the merge.
+	content, err := os.ReadFile(filepath.Join(repo, "main.go"))
+	require.NoError(t, err)
+	require.Equal(t, "package main\n", string(content))
+
+	require.NoError(t, m.Merge(t.Context(), wt, "Merge session"))
+	content, err = os.ReadFile(filepath.Join(repo, "main.go"))
+	require.NoError(t, err)
+	require.Equal(t, "package main\n\nfunc main() {}\n", string(content))
+	require.FileExists(t, filepath.Join(repo, "new.go"))
+	require.NoFileExists(t, filepath.Join(repo, "old.go"))
+	require.NoDirExists(t, wt.Path)
+
+	_, err = m.Get("session")
+	require.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestManagerDiscard(t *testing.T) {
+	t.Parallel()
+
+	repo := newTestRepo(t)
+	m := New(t.TempDir(), repo)
+
+	wt, err := m.Ensure(t.Context(), "session")
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(wt.Path, "main.go"), []byte("changed\n"), 0o644))
+
+	require.NoError(t, m.Discard(t.Context(), wt))
+	require.NoDirExists(t, wt.Path)
+	content, err := os.ReadFile(filepath.Join(repo, "main.go"))
+	require.NoError(t, err)
+	require.Equal(t, "package main\n", string(content))
+
+	_, err = gitutil.Run(t.Context(), repo, "rev-parse", "--verify", wt.Branch)
+	require.Error(t, err)
+}
@@ -4,6 +4,8 @@ package fantasy
 
 import (
 	"context"
+	"fmt"
+	"slices"
 	"time"
 )
 
@@ -364,7 +366,9 @@ func NewAgent(model LanguageModel, opts ...AgentOption) *Agent {
 	return a
 }
 
-// Stream executes the agent with streaming responses.
+// Stream executes the agent with streaming responses. Each step sends the
+// conversation to the model and runs the tools it calls, until the model
+// stops calling tools or a stop condition is met.
 func (a *Agent) Stream(ctx context.Context, call AgentStreamCall) (*AgentResult, error) {
 	messages := call.Messages
 
@@ -379,17 +383,6 @@ func (a *Agent) Stream(ctx context.Context, call AgentStreamCall) (*AgentResult,
 		messages = append(messages, userMsg)
 	}
 
-	// Call prepare step if provided
-	if call.PrepareStep != nil {
-		var err error
-		var prepared PrepareStepResult
-		ctx, prepared, err = call.PrepareStep(ctx, PrepareStepFunctionOptions{Messages: messages})
-		if err != nil {
-			return nil, err
-		}
-		messages = prepared.Messages
-	}
-
 	maxTokens := call.MaxOutputTokens
 	if maxTokens == nil {
 		maxTokens = &a.maxTokens
@@ -408,20 +401,64 @@ func (a *Agent) Stream(ctx context.Context, call AgentStreamCall) (*AgentResult,
 
 	callbacks := StreamCallbacks{
 		OnTextDelta:      call.OnTextDelta,
+		OnToolCall:       call.OnToolCall,
 		OnReasoningDelta: call.OnReasoningDelta,
 		OnReasoningEnd:   call.OnReasoningEnd,
 	}
 
 	var steps []StepResult
 	var totalUsage Usage
+	var resp *Response
 
 	// Agent loop - continue until stop condition or no more tool calls
 	for {
-		resp, err := a.model.Stream(ctx, messages, opts, callbacks)
+		// The prepared messages are only sent for this step, the next one
+		// starts again from the conversation.
+		stepCtx := ctx
+		stepMessages := slices.Clone(messages)
+		if call.PrepareStep != nil {
+			var err error
+			var prepared PrepareStepResult
+			stepCtx, prepared, err = call.PrepareStep(ctx, PrepareStepFunctionOptions{Messages: stepMessages})
+			if err != nil {
+				return nil, err
+			}
+			stepMessages = prepared.Messages
+		}
+
+		var err error
+		resp, err = a.model.Stream(stepCtx, stepMessages, opts, callbacks)
 		if err != nil {
 			return nil, err
 		}
 
+		var toolCalls []ToolCallPart
+		for _, part := range resp.Content.Parts {
+			if tc, ok := part.(ToolCallPart); ok {
+				toolCalls = append(toolCalls, tc)
+			}
+		}
+		messages = append(messages, Message{Role: MessageRoleAssistant, Content: resp.Content.Parts})
+
+		for _, tc := range toolCalls {
+			result, err := a.executeTool(stepCtx, tc)
+			if err != nil {
+				return nil, err
+			}
+			if call.OnToolResult != nil {
+				if err := call.OnToolResult(result); err != nil {
+					return nil, err
+				}
+			}
+			messages = append(messages, Message{
+				Role: MessageRoleTool,
+				Content: []MessagePart{ToolResultPart{
+					ToolCallID: result.ToolCallID,
+					Output:     result.Result,
+				}},
+			})
+		}
+
 		step := StepResult{
 			FinishReason:     resp.FinishReason,
 			Usage:            resp.Usage,
@@ -453,27 +490,48 @@ func (a *Agent) Stream(ctx context.Context, call AgentStreamCall) (*AgentResult,
 			break
 		}
 
-		// Check if we need to execute tools
-		if resp.FinishReason != FinishReasonToolCalls {
+		// The model answers the tool results in the next step.
+		if len(toolCalls) == 0 {
 			break
 		}
-
-		// Execute tools and continue
-		// TODO: Implement tool execution loop
-		break
 	}
 
 	return &AgentResult{
 		Response: Response{
-			Content:      ResponseContent{Parts: []MessagePart{TextPart{Text: ""}}},
-			FinishReason: FinishReasonStop,
-			Usage:        totalUsage,
+			Content:          resp.Content,
+			FinishReason:     resp.FinishReason,
+			Usage:            totalUsage,
+			ProviderMetadata: resp.ProviderMetadata,
 		},
 		Steps:      steps,
 		TotalUsage: totalUsage,
 	}, nil
 }
 
+// executeTool runs the tool called by the model. A call of an unknown tool
+// or with a malformed input is answered with an error for the model, an
+// error returned by the tool stops the agent.
+func (a *Agent) executeTool(ctx context.Context, tc ToolCallPart) (ToolResultContent, error) {
+	result := ToolResultContent{
+		ToolCallID: tc.ToolCallID,
+		ToolName:   tc.ToolName,
+	}
+	i := slices.IndexFunc(a.tools, func(t AgentTool) bool { return t.Name() == tc.ToolName })
+	if i < 0 {
+		result.Result = ToolResultOutputContentError{Error: fmt.Errorf("tool %q not found", tc.ToolName)}
+		return result, nil
+	}
+
+	run := &toolRun{call: ToolCall{ID: tc.ToolCallID, Name: tc.ToolName, Input: tc.Input}}
+	output, err := a.tools[i].Execute(context.WithValue(ctx, toolRunKey{}, run), tc.Input)
+	if err != nil {
+		return result, err
+	}
+	result.Result = output
+	result.ClientMetadata = run.metadata
+	return result, nil
+}
+
 // ToolCall represents a tool call request from the model.
 type ToolCall struct {
 	ID    string
@@ -532,17 +590,11 @@ func (t *TypedAgentTool[T]) Description() string {
 }
 
 func (t *TypedAgentTool[T]) Parameters() map[string]interface{} {
-	// This would use reflection in a full implementation to generate JSON schema
-	return map[string]interface{}{
-		"type":       "object",
-		"properties": map[string]interface{}{},
-	}
+	return parametersSchema[T]()
 }
 
 func (t *TypedAgentTool[T]) Execute(ctx context.Context, input string) (ToolResultOutput, error) {
-	// Parse input JSON and call handler
-	// This is a simplified implementation
-	return ToolResultOutputContentText{Text: "executed"}, nil
+	return executeTyped(ctx, t.name, input, t.handler)
 }
 
 func (t *TypedAgentTool[T]) SetProviderOptions(opts ProviderOptions) {
@@ -606,15 +658,11 @@ func (t *ParallelAgentTool[T]) Description() string {
 }
 
 func (t *ParallelAgentTool[T]) Parameters() map[string]interface{} {
-	// This would use reflection in a full implementation to generate JSON schema
-	return map[string]interface{}{
-		"type":       "object",
-		"properties": map[string]interface{}{},
-	}
+	return parametersSchema[T]()
 }
 
 func (t *ParallelAgentTool[T]) Execute(ctx context.Context, input string) (ToolResultOutput, error) {
-	return ToolResultOutputContentText{Text: "executed"}, nil
+	return executeTyped(ctx, t.name, input, t.handler)
 }
 
 func (t *ParallelAgentTool[T]) SetProviderOptions(opts ProviderOptions) {
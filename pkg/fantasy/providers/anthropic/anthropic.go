@@ -2,12 +2,15 @@
 package anthropic
 
 import (
+	"bufio"
 	"bytes"
+	"cmp"
 	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/uglyswap/push/pkg/fantasy"
@@ -207,11 +210,13 @@ type messageResponse struct {
 }
 
 type contentBlock struct {
-	Type  string `json:"type"`
-	Text  string `json:"text,omitempty"`
-	ID    string `json:"id,omitempty"`
-	Name  string `json:"name,omitempty"`
-	Input json.RawMessage `json:"input,omitempty"`
+	Type      string          `json:"type"`
+	Text      string          `json:"text,omitempty"`
+	Thinking  string          `json:"thinking,omitempty"`
+	Signature string          `json:"signature,omitempty"`
+	ID        string          `json:"id,omitempty"`
+	Name      string          `json:"name,omitempty"`
+	Input     json.RawMessage `json:"input,omitempty"`
 }
 
 type usageInfo struct {
@@ -304,68 +309,145 @@ func (c *Client) Stream(ctx context.Context, messages []fantasy.Message, opts fa
 		}
 	}
 
-	// Process SSE stream
-	var fullText string
-	var usage fantasy.Usage
-	var finishReason fantasy.FinishReason = fantasy.FinishReasonStop
-
-	decoder := json.NewDecoder(resp.Body)
-	for {
-		// Read SSE event
-		var line string
-		if _, err := fmt.Fscanln(resp.Body, &line); err != nil {
-			if err == io.EOF {
-				break
-			}
-			// Try to continue on non-fatal errors
+	return c.parseSSE(resp.Body, callbacks)
+}
+
+// streamEvent is an event of the message stream.
+type streamEvent struct {
+	Type    string `json:"type"`
+	Index   int    `json:"index"`
+	Message struct {
+		ID    string    `json:"id"`
+		Model string    `json:"model"`
+		Usage usageInfo `json:"usage"`
+	} `json:"message"`
+	ContentBlock contentBlock `json:"content_block"`
+	Delta        struct {
+		Type        string `json:"type"`
+		Text        string `json:"text"`
+		Thinking    string `json:"thinking"`
+		Signature   string `json:"signature"`
+		PartialJSON string `json:"partial_json"`
+		StopReason  string `json:"stop_reason"`
+	} `json:"delta"`
+	Usage usageInfo `json:"usage"`
+	Error struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+func (c *Client) parseSSE(body io.Reader, callbacks fantasy.StreamCallbacks) (*fantasy.Response, error) {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 1024*1024), 1024*1024) // 1MB buffer
+
+	var msgResp messageResponse
+	// Blocks being streamed, by index.
+	blocks := make(map[int]*contentBlock)
+	inputs := make(map[int]*strings.Builder)
+
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data: ")
+		if !ok {
 			continue
 		}
 
-		// Parse event data
-		if len(line) > 6 && line[:6] == "data: " {
-			data := line[6:]
-			if data == "[DONE]" {
-				break
-			}
+		var event streamEvent
+		if err := json.Unmarshal([]byte(data), &event); err != nil {
+			continue
+		}
 
-			var event struct {
-				Type  string `json:"type"`
-				Delta struct {
-					Type string `json:"type"`
-					Text string `json:"text"`
-				} `json:"delta"`
-				Usage usageInfo `json:"usage"`
+		switch event.Type {
+		case "message_start":
+			msgResp.ID = event.Message.ID
+			msgResp.Model = event.Message.Model
+			msgResp.Usage = event.Message.Usage
+		case "content_block_start":
+			block := event.ContentBlock
+			block.Input = nil
+			blocks[event.Index] = &block
+			inputs[event.Index] = &strings.Builder{}
+		case "content_block_delta":
+			block, ok := blocks[event.Index]
+			if !ok {
+				continue
+			}
+			switch event.Delta.Type {
+			case "text_delta":
+				block.Text += event.Delta.Text
+				if callbacks.OnTextDelta != nil {
+					if err := callbacks.OnTextDelta(msgResp.ID, event.Delta.Text); err != nil {
+						return nil, err
+					}
+				}
+			case "thinking_delta":
+				block.Thinking += event.Delta.Thinking
+				if callbacks.OnReasoningDelta != nil {
+					if err := callbacks.OnReasoningDelta(msgResp.ID, event.Delta.Thinking); err != nil {
+						return nil, err
+					}
+				}
+			case "signature_delta":
+				block.Signature += event.Delta.Signature
+			case "input_json_delta":
+				inputs[event.Index].WriteString(event.Delta.PartialJSON)
 			}
-			if err := decoder.Decode(&event); err != nil {
+		case "content_block_stop":
+			block, ok := blocks[event.Index]
+			if !ok {
 				continue
 			}
-
-			switch event.Type {
-			case "content_block_delta":
-				if event.Delta.Type == "text_delta" {
-					fullText += event.Delta.Text
-					if callbacks.OnTextDelta != nil {
-						if err := callbacks.OnTextDelta("", event.Delta.Text); err != nil {
-							return nil, err
-						}
+			switch block.Type {
+			case "thinking":
+				if callbacks.OnReasoningEnd != nil {
+					if err := callbacks.OnReasoningEnd(msgResp.ID, fantasy.ReasoningContent{
+						Text: block.Thinking,
+						ProviderMetadata: fantasy.ProviderMetadata{
+							Name: &ReasoningOptionMetadata{Signature: block.Signature},
+						},
+					}); err != nil {
+						return nil, err
+					}
+				}
+			case "tool_use":
+				input := cmp.Or(inputs[event.Index].String(), "{}")
+				block.Input = json.RawMessage(input)
+				if callbacks.OnToolCall != nil {
+					if err := callbacks.OnToolCall(fantasy.ToolCallContent{
+						ToolCallID: block.ID,
+						ToolName:   block.Name,
+						Input:      input,
+					}); err != nil {
+						return nil, err
 					}
 				}
-			case "message_delta":
-				usage.InputTokens = event.Usage.InputTokens
-				usage.OutputTokens = event.Usage.OutputTokens
-				usage.CacheCreationTokens = event.Usage.CacheCreationInputTokens
-				usage.CacheReadTokens = event.Usage.CacheReadInputTokens
+			}
+			msgResp.Content = append(msgResp.Content, *block)
+			delete(blocks, event.Index)
+		case "message_delta":
+			msgResp.StopReason = event.Delta.StopReason
+			msgResp.Usage.OutputTokens = event.Usage.OutputTokens
+			if event.Usage.InputTokens > 0 {
+				msgResp.Usage.InputTokens = event.Usage.InputTokens
+			}
+		case "error":
+			return nil, &fantasy.ProviderError{
+				Title:    event.Error.Type,
+				Message:  event.Error.Message,
+				Provider: Name,
 			}
 		}
 	}
 
-	return &fantasy.Response{
-		Content: fantasy.ResponseContent{
-			Parts: []fantasy.MessagePart{fantasy.TextPart{Text: fullText}},
-		},
-		FinishReason: finishReason,
-		Usage:        usage,
-	}, nil
+	if err := scanner.Err(); err != nil {
+		return nil, &fantasy.ProviderError{
+			Title:    "Stream Read Error",
+			Message:  err.Error(),
+			Provider: Name,
+		}
+	}
+
+	return c.convertResponse(&msgResp, ""), nil
 }
 
 func (c *Client) buildRequest(messages []fantasy.Message, opts fantasy.GenerateOptions, stream bool) (*messageRequest, string) {
@@ -402,12 +484,30 @@ func (c *Client) buildRequest(messages []fantasy.Message, opts fantasy.GenerateO
 						"data":       string(p.Data),
 					},
 				})
+			case fantasy.ToolCallPart:
+				input := json.RawMessage(p.Input)
+				if !json.Valid(input) {
+					input = json.RawMessage("{}")
+				}
+				content = append(content, map[string]interface{}{
+					"type":  "tool_use",
+					"id":    p.ToolCallID,
+					"name":  p.ToolName,
+					"input": input,
+				})
+			case fantasy.ToolResultPart:
+				content = append(content, toolResultBlock(p))
 			}
 		}
 
+		// Tool results are sent back by the user.
+		role := string(msg.Role)
+		if msg.Role == fantasy.MessageRoleTool {
+			role = string(fantasy.MessageRoleUser)
+		}
 		if len(content) > 0 {
 			msgPayloads = append(msgPayloads, messagePayload{
-				Role:    string(msg.Role),
+				Role:    role,
 				Content: content,
 			})
 		}
@@ -441,12 +541,41 @@ func (c *Client) buildRequest(messages []fantasy.Message, opts fantasy.GenerateO
 	return req, systemPrompt
 }
 
+// toolResultBlock returns the content block answering a tool call.
+func toolResultBlock(p fantasy.ToolResultPart) map[string]interface{} {
+	block := map[string]interface{}{
+		"type":        "tool_result",
+		"tool_use_id": p.ToolCallID,
+	}
+	switch output := p.Output.(type) {
+	case fantasy.ToolResultOutputContentText:
+		block["content"] = output.Text
+	case fantasy.ToolResultOutputContentError:
+		block["content"] = output.Error.Error()
+		block["is_error"] = true
+	case fantasy.ToolResultOutputContentMedia:
+		block["content"] = []interface{}{
+			map[string]interface{}{
+				"type": "image",
+				"source": map[string]interface{}{
+					"type":       "base64",
+					"media_type": output.MediaType,
+					"data":       output.Data,
+				},
+			},
+		}
+	}
+	return block
+}
+
 func (c *Client) convertResponse(resp *messageResponse, systemPrompt string) *fantasy.Response {
 	var parts []fantasy.MessagePart
 	for _, block := range resp.Content {
 		switch block.Type {
 		case "text":
 			parts = append(parts, fantasy.TextPart{Text: block.Text})
+		case "thinking":
+			parts = append(parts, fantasy.ReasoningPart{Text: block.Thinking})
 		case "tool_use":
 			parts = append(parts, fantasy.ToolCallPart{
 				ToolCallID: block.ID,
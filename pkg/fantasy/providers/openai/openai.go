@@ -8,7 +8,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"maps"
 	"net/http"
+	"slices"
 	"strings"
 	"time"
 
@@ -194,6 +196,18 @@ func (c *Client) WithBaseURL(baseURL string) *Client {
 	return c
 }
 
+// WithHeaders sets custom headers.
+func (c *Client) WithHeaders(headers map[string]string) *Client {
+	c.provider.headers = headers
+	return c
+}
+
+// WithHTTPClient sets a custom HTTP client.
+func (c *Client) WithHTTPClient(client *http.Client) *Client {
+	c.provider.httpClient = client
+	return c
+}
+
 // Model returns the model name.
 func (c *Client) Model() string {
 	return c.model
@@ -720,7 +734,9 @@ func (c *Client) parseSSE(body io.Reader, callbacks fantasy.StreamCallbacks) (*f
 	}
 
 	// Convert tool call deltas to parts
-	for _, tc := range toolCallDeltas {
+	// The calls are reported in the order the model made them.
+	for _, index := range slices.Sorted(maps.Keys(toolCallDeltas)) {
+		tc := toolCallDeltas[index]
 		toolCallPart := fantasy.ToolCallPart{
 			ToolCallID: tc.ID,
 			ToolName:   tc.Function.Name,
@@ -9,7 +9,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"maps"
 	"net/http"
+	"slices"
 	"strings"
 	"time"
 
@@ -689,7 +691,9 @@ func (c *Client) parseSSE(body io.Reader, callbacks fantasy.StreamCallbacks) (*f
 		}
 	}
 
-	for _, tc := range toolCallDeltas {
+	// The calls are reported in the order the model made them.
+	for _, index := range slices.Sorted(maps.Keys(toolCallDeltas)) {
+		tc := toolCallDeltas[index]
 		toolCallPart := fantasy.ToolCallPart{
 			ToolCallID: tc.ID,
 			ToolName:   tc.Function.Name,
@@ -97,8 +97,10 @@ func New(opts ...Option) (fantasy.Provider, error) {
 // LanguageModel returns a language model for the given model ID.
 func (p *provider) LanguageModel(ctx context.Context, modelID string) (fantasy.LanguageModel, error) {
 	// Create an OpenAI client configured for OpenRouter
-	client := openai.NewClient(p.apiKey, modelID)
-	client.WithBaseURL(baseURL)
+	client := openai.NewClient(p.apiKey, modelID).
+		WithBaseURL(baseURL).
+		WithHeaders(p.headers).
+		WithHTTPClient(p.httpClient)
 	return &Client{
 		openaiClient: client,
 		model:        modelID,
@@ -124,6 +126,7 @@ func NewClient(apiKey string, model string) *Client {
 
 // WithHTTPClient sets a custom HTTP client.
 func (c *Client) WithHTTPClient(httpClient *http.Client) *Client {
+	c.openaiClient.WithHTTPClient(httpClient)
 	return c
 }
 
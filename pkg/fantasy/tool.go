@@ -0,0 +1,145 @@
+package fantasy
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// toolRun is the tool call being executed by the agent. The typed tools read
+// the call from it and leave the metadata of their response in it.
+type toolRun struct {
+	call     ToolCall
+	metadata map[string]interface{}
+}
+
+type toolRunKey struct{}
+
+// ToolCallFromContext returns the tool call executed with the context.
+func ToolCallFromContext(ctx context.Context) (ToolCall, bool) {
+	run, ok := ctx.Value(toolRunKey{}).(*toolRun)
+	if !ok {
+		return ToolCall{}, false
+	}
+	return run.call, true
+}
+
+// executeTyped decodes the input of a tool call into the parameters of the
+// handler and converts its response into a tool result.
+func executeTyped[T any](ctx context.Context, name, input string, handler func(context.Context, T, ToolCall) (ToolResponse, error)) (ToolResultOutput, error) {
+	run, ok := ctx.Value(toolRunKey{}).(*toolRun)
+	if !ok {
+		run = &toolRun{call: ToolCall{Name: name, Input: input}}
+	}
+
+	var params T
+	if strings.TrimSpace(input) != "" {
+		if err := json.Unmarshal([]byte(input), &params); err != nil {
+			return ToolResultOutputContentError{Error: fmt.Errorf("invalid parameters for %s: %w", name, err)}, nil
+		}
+	}
+
+	resp, err := handler(ctx, params, run.call)
+	if err != nil {
+		return nil, err
+	}
+	if data, ok := resp.Metadata["data"]; ok {
+		run.metadata = metadataMap(data)
+	}
+	return resp.output(), nil
+}
+
+// output converts the response into the result sent to the model.
+func (r ToolResponse) output() ToolResultOutput {
+	if r.IsError {
+		return ToolResultOutputContentError{Error: errors.New(r.Content)}
+	}
+	switch r.Metadata["type"] {
+	case "image", "media":
+		mediaType, _ := r.Metadata["mediaType"].(string)
+		return ToolResultOutputContentMedia{Data: r.Content, MediaType: mediaType}
+	}
+	return ToolResultOutputContentText{Text: r.Content}
+}
+
+// metadataMap returns the fields of the metadata attached to a response, as
+// they are stored with the tool result.
+func metadataMap(metadata interface{}) map[string]interface{} {
+	data, err := json.Marshal(metadata)
+	if err != nil {
+		return nil
+	}
+	var m map[string]interface{}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil
+	}
+	return m
+}
+
+// parametersSchema returns the JSON schema of the parameters of a typed
+// tool. Fields are named by their json tag, described by their description
+// tag and required unless they are omitempty.
+func parametersSchema[T any]() map[string]interface{} {
+	schema := typeSchema(reflect.TypeFor[T]())
+	if schema["type"] != "object" {
+		return map[string]interface{}{
+			"type":       "object",
+			"properties": map[string]interface{}{},
+		}
+	}
+	return schema
+}
+
+func typeSchema(t reflect.Type) map[string]interface{} {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": typeSchema(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": typeSchema(t.Elem())}
+	case reflect.Struct:
+		properties := map[string]interface{}{}
+		required := []string{}
+		for i := range t.NumField() {
+			f := t.Field(i)
+			if !f.IsExported() {
+				continue
+			}
+			name, opts, _ := strings.Cut(f.Tag.Get("json"), ",")
+			if name == "-" {
+				continue
+			}
+			if name == "" {
+				name = f.Name
+			}
+			property := typeSchema(f.Type)
+			if description := f.Tag.Get("description"); description != "" {
+				property["description"] = description
+			}
+			properties[name] = property
+			if !strings.Contains(opts, "omitempty") {
+				required = append(required, name)
+			}
+		}
+		return map[string]interface{}{
+			"type":       "object",
+			"properties": properties,
+			"required":   required,
+		}
+	}
+	return map[string]interface{}{}
+}